@@ -665,3 +665,63 @@ mod get_namespaces_for_pipeline {
         }]"#
     );
 }
+
+mod schema_for_documents {
+    use crate::schema_for_document;
+    use mongosql::{json_schema, schema::Schema};
+    use serde_json::Value;
+
+    // The cases are shared with the Go port of schema derivation in go/mongosql/catalog, which
+    // checks that it derives the same schemas. Running this test with UPDATE_GOLDEN set rewrites
+    // the expected schemas of the cases with the ones derived here.
+    const CASES_FILE: &str = concat!(
+        env!("CARGO_MANIFEST_DIR"),
+        "/../../testdata/schema_for_documents/cases.json"
+    );
+
+    // derive_schema derives the schema of a collection holding documents in the same way as the
+    // schema builder does.
+    fn derive_schema(documents: &Value) -> json_schema::Schema {
+        let schema = documents
+            .as_array()
+            .unwrap()
+            .iter()
+            .map(
+                |document| match bson::Bson::try_from(document.clone()).unwrap() {
+                    bson::Bson::Document(document) => schema_for_document(&document),
+                    value => panic!("expected a document, got {value}"),
+                },
+            )
+            .fold(Schema::Unsat, |schema, document_schema| {
+                schema.union(&document_schema)
+            });
+        json_schema::Schema::try_from(Schema::simplify(&schema)).unwrap()
+    }
+
+    #[test]
+    fn derived_schemas_match_cases() {
+        let mut golden: Value =
+            serde_json::from_str(&std::fs::read_to_string(CASES_FILE).unwrap()).unwrap();
+        let cases = golden["cases"].as_array_mut().unwrap();
+
+        if std::env::var_os("UPDATE_GOLDEN").is_some() {
+            for case in cases.iter_mut() {
+                case["schema"] = serde_json::to_value(derive_schema(&case["documents"])).unwrap();
+            }
+            let data = serde_json::to_string_pretty(&golden).unwrap();
+            std::fs::write(CASES_FILE, data + "\n").unwrap();
+            return;
+        }
+
+        for case in cases.iter() {
+            let expected: json_schema::Schema =
+                serde_json::from_value(case["schema"].clone()).unwrap();
+            assert_eq!(
+                expected,
+                derive_schema(&case["documents"]),
+                "{}",
+                case["description"]
+            );
+        }
+    }
+}
@@ -0,0 +1,679 @@
+// Package ast contains a Go mirror of the syntax tree produced by the
+// translation library's sql parser.
+//
+// Every struct in this package corresponds to a struct or enum of the
+// same name in the library. Enums with data are mirrored as structs
+// with one field per variant, of which exactly one is set: unit
+// variants are bool fields, and the other variants are pointer or
+// slice fields. Enums without data are mirrored as string types whose
+// values are the variant names.
+//
+// Queries are obtained with mongosql.Parse, inspected and modified
+// with Walk and Inspect, and turned back into sql with mongosql.Render.
+package ast
+
+import "github.com/mongodb/mongosql/go/mongosql/internal/translation"
+
+// Query is a sql query.
+type Query struct {
+	Select *SelectQuery
+	Set    *SetQuery
+	With   *WithQuery
+}
+
+// WithQuery is a query with a WITH clause.
+type WithQuery struct {
+	Queries []NamedQuery `bson:"queries"`
+	Body    *Query       `bson:"body"`
+}
+
+// NamedQuery is a query named by a WITH clause.
+type NamedQuery struct {
+	Name  string `bson:"name"`
+	Query Query  `bson:"query"`
+}
+
+// SelectQuery is a SELECT query. Its optional clauses are nil when
+// absent.
+type SelectQuery struct {
+	SelectClause  SelectClause   `bson:"select_clause"`
+	FromClause    *Datasource    `bson:"from_clause"`
+	WhereClause   *Expression    `bson:"where_clause"`
+	GroupByClause *GroupByClause `bson:"group_by_clause"`
+	HavingClause  *Expression    `bson:"having_clause"`
+	OrderByClause *OrderByClause `bson:"order_by_clause"`
+	Limit         *uint32        `bson:"limit"`
+	Offset        *uint32        `bson:"offset"`
+}
+
+// SetQuery combines the results of two queries.
+type SetQuery struct {
+	Left  *Query      `bson:"left"`
+	Op    SetOperator `bson:"op"`
+	Right *Query      `bson:"right"`
+}
+
+// SetOperator is the operator of a SetQuery.
+type SetOperator string
+
+// The values of SetOperator.
+const (
+	SetOperatorUnion    SetOperator = "Union"
+	SetOperatorUnionAll SetOperator = "UnionAll"
+)
+
+// SelectClause is the SELECT clause of a SelectQuery.
+type SelectClause struct {
+	SetQuantifier SetQuantifier `bson:"set_quantifier"`
+	Body          SelectBody    `bson:"body"`
+}
+
+// SetQuantifier is the quantifier of a SELECT clause or of an
+// aggregation function call.
+type SetQuantifier string
+
+// The values of SetQuantifier.
+const (
+	SetQuantifierAll      SetQuantifier = "All"
+	SetQuantifierDistinct SetQuantifier = "Distinct"
+)
+
+// SelectBody is the body of a SELECT clause: either a standard
+// SELECT list or a SELECT VALUES list.
+type SelectBody struct {
+	Standard []SelectExpression
+	Values   []SelectValuesExpression
+}
+
+// SelectValuesExpression is an element of a SELECT VALUES list.
+type SelectValuesExpression struct {
+	Expression *Expression
+	Substar    *SubstarExpr
+}
+
+// SelectExpression is an element of a standard SELECT list.
+type SelectExpression struct {
+	Star       bool
+	Substar    *SubstarExpr
+	Expression *OptionallyAliasedExpr
+}
+
+// SubstarExpr selects every field of a datasource, as in "foo.*".
+type SubstarExpr struct {
+	Datasource string `bson:"datasource"`
+}
+
+// Datasource is a datasource of a FROM clause.
+type Datasource struct {
+	Array          *ArraySource
+	Collection     *CollectionSource
+	Derived        *DerivedSource
+	Join           *JoinSource
+	Flatten        *FlattenSource
+	Unwind         *UnwindSource
+	ExtendedUnwind *ExtendedUnwindSource
+}
+
+// ArraySource is a literal array of documents used as a datasource.
+type ArraySource struct {
+	Array []Expression `bson:"array"`
+	Alias string       `bson:"alias"`
+}
+
+// CollectionSource is a collection used as a datasource. Database is
+// nil when the collection is in the current database. Span is the
+// location of the datasource in the statement it was parsed from, and
+// is nil for datasources that were not parsed.
+type CollectionSource struct {
+	Database   *string `bson:"database"`
+	Collection string  `bson:"collection"`
+	Alias      *string `bson:"alias"`
+	Span       *Span   `bson:"span"`
+}
+
+// Span is the location of a node in the statement it was parsed from.
+// It is not itself a node, and is not visited by Walk.
+type Span struct {
+	// Start is the position of the first character of the node
+	Start translation.Position `bson:"start"`
+	// End is the position just past the last character of the node
+	End translation.Position `bson:"end"`
+}
+
+// DerivedSource is a subquery used as a datasource.
+type DerivedSource struct {
+	Query *Query `bson:"query"`
+	Alias string `bson:"alias"`
+}
+
+// AliasedExpr is an expression with an alias.
+type AliasedExpr struct {
+	Expr  Expression `bson:"expr"`
+	Alias string     `bson:"alias"`
+}
+
+// OptionallyAliasedExpr is an expression that may have an alias.
+type OptionallyAliasedExpr struct {
+	Aliased   *AliasedExpr
+	Unaliased *Expression
+}
+
+// JoinSource joins two datasources.
+type JoinSource struct {
+	JoinType  JoinType    `bson:"join_type"`
+	Left      *Datasource `bson:"left"`
+	Right     *Datasource `bson:"right"`
+	Condition *Expression `bson:"condition"`
+}
+
+// JoinType is the type of a JoinSource.
+type JoinType string
+
+// The values of JoinType.
+const (
+	JoinTypeLeft  JoinType = "Left"
+	JoinTypeRight JoinType = "Right"
+	JoinTypeCross JoinType = "Cross"
+	JoinTypeInner JoinType = "Inner"
+)
+
+// FlattenSource flattens the documents of a datasource.
+type FlattenSource struct {
+	Datasource *Datasource     `bson:"datasource"`
+	Options    []FlattenOption `bson:"options"`
+}
+
+// FlattenOption is an option of a FlattenSource.
+type FlattenOption struct {
+	Separator *string
+	Depth     *uint32
+}
+
+// UnwindSource unwinds an array field of a datasource.
+type UnwindSource struct {
+	Datasource *Datasource    `bson:"datasource"`
+	Options    []UnwindOption `bson:"options"`
+}
+
+// ExtendedUnwindSource unwinds one or more array paths of a
+// datasource.
+type ExtendedUnwindSource struct {
+	Datasource *Datasource            `bson:"datasource"`
+	Options    []ExtendedUnwindOption `bson:"options"`
+}
+
+// UnwindOption is an option of an UnwindSource.
+type UnwindOption struct {
+	Path  *Expression
+	Index *string
+	Outer *bool
+}
+
+// ExtendedUnwindOption is an option of an ExtendedUnwindSource.
+type ExtendedUnwindOption struct {
+	Paths [][]UnwindPathPart
+	Index *string
+	Outer *bool
+}
+
+// UnwindPathPartOption is an option of an UnwindPathPart.
+type UnwindPathPartOption struct {
+	Index *string
+	Outer *bool
+}
+
+// UnwindPathPart is a field of a path unwound by an
+// ExtendedUnwindSource.
+type UnwindPathPart struct {
+	Field   string                   `bson:"field"`
+	Options [][]UnwindPathPartOption `bson:"options"`
+}
+
+// Expression is a sql expression.
+type Expression struct {
+	Binary              *BinaryExpr
+	Unary               *UnaryExpr
+	Between             *BetweenExpr
+	Case                *CaseExpr
+	Function            *FunctionExpr
+	Trim                *TrimExpr
+	DateFunction        *DateFunctionExpr
+	Extract             *ExtractExpr
+	Cast                *CastExpr
+	Array               []Expression
+	Subquery            *Query
+	Exists              *Query
+	SubqueryComparison  *SubqueryComparisonExpr
+	Document            []DocumentPair
+	Access              *AccessExpr
+	Subpath             *SubpathExpr
+	Identifier          *string
+	Is                  *IsExpr
+	Like                *LikeExpr
+	Literal             *Literal
+	StringConstructor   *string
+	Tuple               []Expression
+	TypeAssertion       *TypeAssertionExpr
+	HigherOrderFunction *HigherOrderFunctionExpr
+}
+
+// DocumentPair is a key and value of a document literal.
+type DocumentPair struct {
+	Key   string     `bson:"key"`
+	Value Expression `bson:"value"`
+}
+
+// CastExpr is a CAST expression.
+type CastExpr struct {
+	Expr    *Expression `bson:"expr"`
+	To      Type        `bson:"to"`
+	OnNull  *Expression `bson:"on_null"`
+	OnError *Expression `bson:"on_error"`
+}
+
+// BinaryExpr is an expression with a binary operator.
+type BinaryExpr struct {
+	Left  *Expression `bson:"left"`
+	Op    BinaryOp    `bson:"op"`
+	Right *Expression `bson:"right"`
+}
+
+// UnaryExpr is an expression with a unary operator.
+type UnaryExpr struct {
+	Op   UnaryOp     `bson:"op"`
+	Expr *Expression `bson:"expr"`
+}
+
+// BetweenExpr is a BETWEEN expression.
+type BetweenExpr struct {
+	Arg *Expression `bson:"arg"`
+	Min *Expression `bson:"min"`
+	Max *Expression `bson:"max"`
+}
+
+// CaseExpr is a CASE expression. Expr is nil for a searched CASE
+// expression.
+type CaseExpr struct {
+	Expr       *Expression  `bson:"expr"`
+	WhenBranch []WhenBranch `bson:"when_branch"`
+	ElseBranch *Expression  `bson:"else_branch"`
+}
+
+// WhenBranch is a WHEN branch of a CaseExpr.
+type WhenBranch struct {
+	When *Expression `bson:"when"`
+	Then *Expression `bson:"then"`
+}
+
+// SubqueryQuantifier is the quantifier of a SubqueryComparisonExpr.
+type SubqueryQuantifier string
+
+// The values of SubqueryQuantifier.
+const (
+	SubqueryQuantifierAll SubqueryQuantifier = "All"
+	SubqueryQuantifierAny SubqueryQuantifier = "Any"
+)
+
+// SubqueryComparisonExpr compares an expression to the results of a
+// subquery, as in "a > ANY (SELECT ...)".
+type SubqueryComparisonExpr struct {
+	Expr       *Expression        `bson:"expr"`
+	Op         ComparisonOp       `bson:"op"`
+	Quantifier SubqueryQuantifier `bson:"quantifier"`
+	Subquery   *Query             `bson:"subquery"`
+}
+
+// FunctionExpr is a function call.
+type FunctionExpr struct {
+	Function      FunctionName      `bson:"function"`
+	Args          FunctionArguments `bson:"args"`
+	SetQuantifier *SetQuantifier    `bson:"set_quantifier"`
+}
+
+// DateFunctionName is the function of a DateFunctionExpr.
+type DateFunctionName string
+
+// The values of DateFunctionName.
+const (
+	DateFunctionNameAdd   DateFunctionName = "Add"
+	DateFunctionNameDiff  DateFunctionName = "Diff"
+	DateFunctionNameTrunc DateFunctionName = "Trunc"
+)
+
+// DatePart is a part of a date, as used by date functions and
+// EXTRACT.
+type DatePart string
+
+// The values of DatePart.
+const (
+	DatePartYear        DatePart = "Year"
+	DatePartQuarter     DatePart = "Quarter"
+	DatePartMonth       DatePart = "Month"
+	DatePartWeek        DatePart = "Week"
+	DatePartDay         DatePart = "Day"
+	DatePartHour        DatePart = "Hour"
+	DatePartMinute      DatePart = "Minute"
+	DatePartSecond      DatePart = "Second"
+	DatePartMillisecond DatePart = "Millisecond"
+	DatePartDayOfYear   DatePart = "DayOfYear"
+	DatePartDayOfWeek   DatePart = "DayOfWeek"
+	DatePartIsoWeek     DatePart = "IsoWeek"
+	DatePartIsoWeekday  DatePart = "IsoWeekday"
+)
+
+// DateFunctionExpr is a DATEADD, DATEDIFF, or DATETRUNC call.
+type DateFunctionExpr struct {
+	Function DateFunctionName `bson:"function"`
+	DatePart DatePart         `bson:"date_part"`
+	Args     []Expression     `bson:"args"`
+}
+
+// ExtractExpr is an EXTRACT expression.
+type ExtractExpr struct {
+	ExtractSpec DatePart    `bson:"extract_spec"`
+	Arg         *Expression `bson:"arg"`
+}
+
+// TrimExpr is a TRIM expression.
+type TrimExpr struct {
+	TrimSpec  TrimSpec    `bson:"trim_spec"`
+	TrimChars *Expression `bson:"trim_chars"`
+	Arg       *Expression `bson:"arg"`
+}
+
+// FunctionName is the function of a FunctionExpr.
+type FunctionName string
+
+// The values of FunctionName.
+const (
+	// Aggregation functions
+	FunctionNameAddToArray     FunctionName = "AddToArray"
+	FunctionNameAddToSet       FunctionName = "AddToSet"
+	FunctionNameAvg            FunctionName = "Avg"
+	FunctionNameCount          FunctionName = "Count"
+	FunctionNameFirst          FunctionName = "First"
+	FunctionNameLast           FunctionName = "Last"
+	FunctionNameMax            FunctionName = "Max"
+	FunctionNameMergeDocuments FunctionName = "MergeDocuments"
+	FunctionNameMin            FunctionName = "Min"
+	FunctionNameStddevPop      FunctionName = "StddevPop"
+	FunctionNameStddevSamp     FunctionName = "StddevSamp"
+	FunctionNameSum            FunctionName = "Sum"
+
+	// Scalar functions
+	FunctionNameAbs              FunctionName = "Abs"
+	FunctionNameBitLength        FunctionName = "BitLength"
+	FunctionNameCeil             FunctionName = "Ceil"
+	FunctionNameCharLength       FunctionName = "CharLength"
+	FunctionNameCoalesce         FunctionName = "Coalesce"
+	FunctionNameCos              FunctionName = "Cos"
+	FunctionNameCurrentTimestamp FunctionName = "CurrentTimestamp"
+	FunctionNameDegrees          FunctionName = "Degrees"
+	FunctionNameFloor            FunctionName = "Floor"
+	FunctionNameLog              FunctionName = "Log"
+	FunctionNameLog10            FunctionName = "Log10"
+	FunctionNameLower            FunctionName = "Lower"
+	FunctionNameLTrim            FunctionName = "LTrim"
+	FunctionNameMod              FunctionName = "Mod"
+	FunctionNameNullIf           FunctionName = "NullIf"
+	FunctionNameOctetLength      FunctionName = "OctetLength"
+	FunctionNamePosition         FunctionName = "Position"
+	FunctionNamePow              FunctionName = "Pow"
+	FunctionNameRadians          FunctionName = "Radians"
+	FunctionNameReplace          FunctionName = "Replace"
+	FunctionNameRound            FunctionName = "Round"
+	FunctionNameRTrim            FunctionName = "RTrim"
+	FunctionNameSin              FunctionName = "Sin"
+	FunctionNameSize             FunctionName = "Size"
+	FunctionNameSlice            FunctionName = "Slice"
+	FunctionNameSplit            FunctionName = "Split"
+	FunctionNameSqrt             FunctionName = "Sqrt"
+	FunctionNameSubstring        FunctionName = "Substring"
+	FunctionNameTan              FunctionName = "Tan"
+	FunctionNameUpper            FunctionName = "Upper"
+
+	// Date functions
+	FunctionNameDateAdd     FunctionName = "DateAdd"
+	FunctionNameDateDiff    FunctionName = "DateDiff"
+	FunctionNameDateTrunc   FunctionName = "DateTrunc"
+	FunctionNameYear        FunctionName = "Year"
+	FunctionNameMonth       FunctionName = "Month"
+	FunctionNameWeek        FunctionName = "Week"
+	FunctionNameDayOfWeek   FunctionName = "DayOfWeek"
+	FunctionNameDayOfMonth  FunctionName = "DayOfMonth"
+	FunctionNameDayOfYear   FunctionName = "DayOfYear"
+	FunctionNameHour        FunctionName = "Hour"
+	FunctionNameMinute      FunctionName = "Minute"
+	FunctionNameSecond      FunctionName = "Second"
+	FunctionNameMillisecond FunctionName = "Millisecond"
+)
+
+// FunctionArguments are the arguments of a FunctionExpr. Star is set
+// for calls like COUNT(*).
+type FunctionArguments struct {
+	Star bool
+	Args []Expression
+}
+
+// TrimSpec is the side of a string from which a TrimExpr removes
+// characters.
+type TrimSpec string
+
+// The values of TrimSpec.
+const (
+	TrimSpecLeading  TrimSpec = "Leading"
+	TrimSpecTrailing TrimSpec = "Trailing"
+	TrimSpecBoth     TrimSpec = "Both"
+)
+
+// AccessExpr accesses a field or element of an expression, as in
+// "a['b']".
+type AccessExpr struct {
+	Expr     *Expression `bson:"expr"`
+	Subfield *Expression `bson:"subfield"`
+}
+
+// SubpathExpr accesses a field of an expression, as in "a.b".
+type SubpathExpr struct {
+	Expr    *Expression `bson:"expr"`
+	Subpath string      `bson:"subpath"`
+}
+
+// TypeOrMissing is the target of an IsExpr.
+type TypeOrMissing struct {
+	Type    *Type
+	Number  bool
+	Missing bool
+}
+
+// IsExpr is an IS expression, as in "a IS NULL".
+type IsExpr struct {
+	Expr       *Expression   `bson:"expr"`
+	TargetType TypeOrMissing `bson:"target_type"`
+}
+
+// LikeExpr is a LIKE expression. Escape holds a single character, or
+// is nil when there is no ESCAPE clause.
+type LikeExpr struct {
+	Expr    *Expression `bson:"expr"`
+	Pattern *Expression `bson:"pattern"`
+	Escape  *string     `bson:"escape"`
+}
+
+// TypeAssertionExpr asserts the type of an expression, as in
+// "a::!INT".
+type TypeAssertionExpr struct {
+	Expr       *Expression `bson:"expr"`
+	TargetType Type        `bson:"target_type"`
+}
+
+// UnaryOp is the operator of a UnaryExpr.
+type UnaryOp string
+
+// The values of UnaryOp.
+const (
+	UnaryOpPos UnaryOp = "Pos"
+	UnaryOpNeg UnaryOp = "Neg"
+	UnaryOpNot UnaryOp = "Not"
+)
+
+// BinaryOp is the operator of a BinaryExpr. The comparison operators
+// have the same values as the corresponding ComparisonOp.
+type BinaryOp string
+
+// The values of BinaryOp.
+const (
+	BinaryOpAdd    BinaryOp = "Add"
+	BinaryOpAnd    BinaryOp = "And"
+	BinaryOpConcat BinaryOp = "Concat"
+	BinaryOpDiv    BinaryOp = "Div"
+	BinaryOpIn     BinaryOp = "In"
+	BinaryOpMul    BinaryOp = "Mul"
+	BinaryOpNotIn  BinaryOp = "NotIn"
+	BinaryOpOr     BinaryOp = "Or"
+	BinaryOpSub    BinaryOp = "Sub"
+	BinaryOpEq     BinaryOp = BinaryOp(ComparisonOpEq)
+	BinaryOpGt     BinaryOp = BinaryOp(ComparisonOpGt)
+	BinaryOpGte    BinaryOp = BinaryOp(ComparisonOpGte)
+	BinaryOpLt     BinaryOp = BinaryOp(ComparisonOpLt)
+	BinaryOpLte    BinaryOp = BinaryOp(ComparisonOpLte)
+	BinaryOpNeq    BinaryOp = BinaryOp(ComparisonOpNeq)
+)
+
+// Comparison returns the ComparisonOp of a comparison operator, and
+// false for any other operator.
+func (op BinaryOp) Comparison() (ComparisonOp, bool) {
+	switch c := ComparisonOp(op); c {
+	case ComparisonOpEq, ComparisonOpGt, ComparisonOpGte, ComparisonOpLt, ComparisonOpLte, ComparisonOpNeq:
+		return c, true
+	}
+	return "", false
+}
+
+// ComparisonOp is a comparison operator.
+type ComparisonOp string
+
+// The values of ComparisonOp.
+const (
+	ComparisonOpEq  ComparisonOp = "Eq"
+	ComparisonOpGt  ComparisonOp = "Gt"
+	ComparisonOpGte ComparisonOp = "Gte"
+	ComparisonOpLt  ComparisonOp = "Lt"
+	ComparisonOpLte ComparisonOp = "Lte"
+	ComparisonOpNeq ComparisonOp = "Neq"
+)
+
+// GroupByClause is the GROUP BY clause of a SelectQuery.
+type GroupByClause struct {
+	Keys         []OptionallyAliasedExpr `bson:"keys"`
+	Aggregations []AliasedExpr           `bson:"aggregations"`
+}
+
+// OrderByClause is the ORDER BY clause of a SelectQuery.
+type OrderByClause struct {
+	SortSpecs []SortSpec `bson:"sort_specs"`
+}
+
+// SortSpec is an element of an ORDER BY clause.
+type SortSpec struct {
+	Key       SortKey       `bson:"key"`
+	Direction SortDirection `bson:"direction"`
+}
+
+// SortKey is the key of a SortSpec: an expression, or the 1-based
+// position of a SELECT list element.
+type SortKey struct {
+	Simple     *Expression
+	Positional *uint32
+}
+
+// SortDirection is the direction of a SortSpec.
+type SortDirection string
+
+// The values of SortDirection.
+const (
+	SortDirectionAsc  SortDirection = "Asc"
+	SortDirectionDesc SortDirection = "Desc"
+)
+
+// Literal is a literal value.
+type Literal struct {
+	Null    bool
+	Boolean *bool
+	Integer *int32
+	Long    *int64
+	Double  *float64
+}
+
+// Type is a BSON type, as used by CAST, IS, and type assertions.
+type Type string
+
+// The values of Type.
+const (
+	TypeArray               Type = "Array"
+	TypeBinData             Type = "BinData"
+	TypeBoolean             Type = "Boolean"
+	TypeDate                Type = "Date"
+	TypeDatetime            Type = "Datetime"
+	TypeDbPointer           Type = "DbPointer"
+	TypeDecimal128          Type = "Decimal128"
+	TypeDocument            Type = "Document"
+	TypeDouble              Type = "Double"
+	TypeInt32               Type = "Int32"
+	TypeInt64               Type = "Int64"
+	TypeJavascript          Type = "Javascript"
+	TypeJavascriptWithScope Type = "JavascriptWithScope"
+	TypeMaxKey              Type = "MaxKey"
+	TypeMinKey              Type = "MinKey"
+	TypeNull                Type = "Null"
+	TypeObjectId            Type = "ObjectId"
+	TypeRegularExpression   Type = "RegularExpression"
+	TypeString              Type = "String"
+	TypeSymbol              Type = "Symbol"
+	TypeTime                Type = "Time"
+	TypeTimestamp           Type = "Timestamp"
+	TypeUndefined           Type = "Undefined"
+)
+
+// HigherOrderFunctionExpr is a call of a function that takes a
+// function as an argument.
+type HigherOrderFunctionExpr struct {
+	Map    *MapExpr
+	Filter *FilterExpr
+	Reduce *ReduceExpr
+}
+
+// MapExpr applies F to every element of Array.
+type MapExpr struct {
+	Array *Expression       `bson:"array"`
+	F     *FunctionArgument `bson:"f"`
+}
+
+// FilterExpr keeps the elements of Array for which F is true.
+type FilterExpr struct {
+	Array *Expression       `bson:"array"`
+	F     *FunctionArgument `bson:"f"`
+}
+
+// ReduceExpr combines the elements of Array with F, starting from
+// InitValue.
+type ReduceExpr struct {
+	Array     *Expression       `bson:"array"`
+	InitValue *Expression       `bson:"init_value"`
+	F         *FunctionArgument `bson:"f"`
+}
+
+// FunctionArgument is the function argument of a
+// HigherOrderFunctionExpr.
+type FunctionArgument struct {
+	Expr          *Expression
+	NamedFunction *NamedFunction
+}
+
+// NamedFunction is an operator or function passed as a
+// FunctionArgument.
+type NamedFunction struct {
+	UnaryOp  *UnaryOp
+	BinaryOp *BinaryOp
+	Function *FunctionName
+}
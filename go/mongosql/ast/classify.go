@@ -0,0 +1,141 @@
+package ast
+
+// StatementKind is the kind of a sql statement.
+type StatementKind string
+
+// The values of StatementKind.
+const (
+	StatementKindSelect StatementKind = "Select"
+	StatementKindSet    StatementKind = "Set"
+	StatementKindWith   StatementKind = "With"
+)
+
+// Features are the sql features used by a statement. A feature is
+// reported if it is used anywhere in the statement, including in its
+// subqueries.
+type Features struct {
+	// Joins is true if a FROM clause joins datasources, including
+	// with a comma
+	Joins bool
+	// Subqueries is true if the statement has a subquery expression,
+	// an EXISTS expression, a subquery comparison, or a derived table
+	Subqueries bool
+	// Flatten is true if a FROM clause uses FLATTEN
+	Flatten bool
+	// Unwind is true if a FROM clause uses UNWIND
+	Unwind bool
+	// Aggregates is true if the statement calls an aggregation
+	// function, such as COUNT or SUM
+	Aggregates bool
+	// Distinct is true if a SELECT clause or an aggregation function
+	// call uses DISTINCT
+	Distinct bool
+	// Limit is true if a query has a LIMIT or FETCH FIRST clause
+	Limit bool
+}
+
+// StatementInfo describes the kind and features of a sql statement.
+type StatementInfo struct {
+	// Kind is the kind of the statement
+	Kind StatementKind
+	// SetOperator is the operator of a set operation statement, and is
+	// empty for the other kinds of statements
+	SetOperator SetOperator
+	Features
+	// Depth is the nesting depth of the statement's queries: 1 for a
+	// statement without subqueries, and one more for each level of
+	// subqueries. The operands of a set operation and the queries of
+	// a WITH statement are at the same level as the statement.
+	Depth int
+}
+
+// aggregationFunctions are the functions that aggregate the rows of a
+// group.
+var aggregationFunctions = map[FunctionName]bool{
+	FunctionNameAddToArray:     true,
+	FunctionNameAddToSet:       true,
+	FunctionNameAvg:            true,
+	FunctionNameCount:          true,
+	FunctionNameFirst:          true,
+	FunctionNameLast:           true,
+	FunctionNameMax:            true,
+	FunctionNameMergeDocuments: true,
+	FunctionNameMin:            true,
+	FunctionNameStddevPop:      true,
+	FunctionNameStddevSamp:     true,
+	FunctionNameSum:            true,
+}
+
+// Classify returns the StatementInfo of a parsed statement.
+func Classify(query *Query) StatementInfo {
+	info := StatementInfo{}
+	switch {
+	case query.Select != nil:
+		info.Kind = StatementKindSelect
+	case query.Set != nil:
+		info.Kind = StatementKindSet
+		info.SetOperator = query.Set.Op
+	case query.With != nil:
+		info.Kind = StatementKindWith
+	}
+	Walk(classifier{info: &info, depth: 1}, query)
+	return info
+}
+
+// classifier records the features of the nodes it visits in info. The
+// nodes it visits are nested in depth levels of queries.
+type classifier struct {
+	info  *StatementInfo
+	depth int
+}
+
+func (c classifier) deeper() classifier {
+	return classifier{info: c.info, depth: c.depth + 1}
+}
+
+func (c classifier) Visit(node Node) Visitor {
+	switch n := node.(type) {
+	case *SelectQuery:
+		if c.depth > c.info.Depth {
+			c.info.Depth = c.depth
+		}
+		if n.SelectClause.SetQuantifier == SetQuantifierDistinct {
+			c.info.Distinct = true
+		}
+		if n.Limit != nil {
+			c.info.Limit = true
+		}
+	case *JoinSource:
+		c.info.Joins = true
+	case *FlattenSource:
+		c.info.Flatten = true
+	case *UnwindSource, *ExtendedUnwindSource:
+		c.info.Unwind = true
+	case *FunctionExpr:
+		if aggregationFunctions[n.Function] {
+			c.info.Aggregates = true
+			if n.SetQuantifier != nil && *n.SetQuantifier == SetQuantifierDistinct {
+				c.info.Distinct = true
+			}
+		}
+	case *DerivedSource:
+		c.info.Subqueries = true
+		return c.deeper()
+	case *Expression:
+		if n.Subquery != nil || n.Exists != nil {
+			c.info.Subqueries = true
+			return c.deeper()
+		}
+	case *SubqueryComparisonExpr:
+		// only the subquery is nested, not the expression compared to it
+		c.info.Subqueries = true
+		if n.Expr != nil {
+			Walk(c, n.Expr)
+		}
+		if n.Subquery != nil {
+			Walk(c.deeper(), n.Subquery)
+		}
+		return nil
+	}
+	return c
+}
@@ -0,0 +1,146 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mongodb/mongosql/go/mongosql/ast"
+)
+
+// selectFrom returns the query "SELECT * FROM <from>".
+func selectFrom(from ast.Datasource) *ast.SelectQuery {
+	return &ast.SelectQuery{
+		SelectClause: ast.SelectClause{
+			SetQuantifier: ast.SetQuantifierAll,
+			Body:          ast.SelectBody{Standard: []ast.SelectExpression{{Star: true}}},
+		},
+		FromClause: &from,
+	}
+}
+
+func collection(name string) ast.Datasource {
+	return ast.Datasource{Collection: &ast.CollectionSource{Collection: name}}
+}
+
+func TestClassify(t *testing.T) {
+	limit := uint32(10)
+	distinct := ast.SetQuantifierDistinct
+
+	distinctWithLimit := selectFrom(collection("bar"))
+	distinctWithLimit.SelectClause.SetQuantifier = ast.SetQuantifierDistinct
+	distinctWithLimit.Limit = &limit
+
+	// SELECT * FROM foo WHERE EXISTS(SELECT * FROM (SELECT * FROM bar) AS d)
+	nestedSubqueries := selectFrom(collection("foo"))
+	nestedSubqueries.WhereClause = &ast.Expression{Exists: &ast.Query{Select: selectFrom(ast.Datasource{
+		Derived: &ast.DerivedSource{Query: &ast.Query{Select: selectFrom(collection("bar"))}, Alias: "d"},
+	})}}
+
+	// SELECT * FROM foo WHERE a > ANY(SELECT * FROM bar)
+	subqueryComparison := selectFrom(collection("foo"))
+	subqueryComparison.WhereClause = &ast.Expression{SubqueryComparison: &ast.SubqueryComparisonExpr{
+		Expr:       &ast.Expression{Identifier: str("a")},
+		Op:         ast.ComparisonOpGt,
+		Quantifier: ast.SubqueryQuantifierAny,
+		Subquery:   &ast.Query{Select: selectFrom(collection("bar"))},
+	}}
+
+	// SELECT VALUE {'c': COUNT(DISTINCT a)}
+	// FROM FLATTEN(UNWIND(foo JOIN bar WITH PATH => a))
+	features := selectFrom(ast.Datasource{Flatten: &ast.FlattenSource{
+		Datasource: &ast.Datasource{Unwind: &ast.UnwindSource{
+			Datasource: &ast.Datasource{Join: &ast.JoinSource{
+				JoinType: ast.JoinTypeInner,
+				Left:     &ast.Datasource{Collection: &ast.CollectionSource{Collection: "foo"}},
+				Right:    &ast.Datasource{Collection: &ast.CollectionSource{Collection: "bar"}},
+			}},
+			Options: []ast.UnwindOption{{Path: &ast.Expression{Identifier: str("a")}}},
+		}},
+	}})
+	features.SelectClause.Body = ast.SelectBody{Values: []ast.SelectValuesExpression{{
+		Expression: &ast.Expression{Document: []ast.DocumentPair{{
+			Key: "c",
+			Value: ast.Expression{Function: &ast.FunctionExpr{
+				Function:      ast.FunctionNameCount,
+				Args:          ast.FunctionArguments{Args: []ast.Expression{{Identifier: str("a")}}},
+				SetQuantifier: &distinct,
+			}},
+		}}},
+	}}}
+
+	tests := []struct {
+		name     string
+		query    ast.Query
+		expected ast.StatementInfo
+	}{
+		{
+			name:     "select",
+			query:    selectWhere,
+			expected: ast.StatementInfo{Kind: ast.StatementKindSelect, Depth: 1},
+		},
+		{
+			name: "set operation",
+			query: ast.Query{Set: &ast.SetQuery{
+				Left:  &selectWhere,
+				Op:    ast.SetOperatorUnion,
+				Right: &ast.Query{Select: distinctWithLimit},
+			}},
+			expected: ast.StatementInfo{
+				Kind:        ast.StatementKindSet,
+				SetOperator: ast.SetOperatorUnion,
+				Features:    ast.Features{Distinct: true, Limit: true},
+				Depth:       1,
+			},
+		},
+		{
+			name: "with",
+			query: ast.Query{With: &ast.WithQuery{
+				Queries: []ast.NamedQuery{{Name: "q", Query: ast.Query{Select: selectFrom(collection("foo"))}}},
+				Body:    &ast.Query{Select: selectFrom(collection("q"))},
+			}},
+			expected: ast.StatementInfo{Kind: ast.StatementKindWith, Depth: 1},
+		},
+		{
+			name:  "nested subqueries",
+			query: ast.Query{Select: nestedSubqueries},
+			expected: ast.StatementInfo{
+				Kind:     ast.StatementKindSelect,
+				Features: ast.Features{Subqueries: true},
+				Depth:    3,
+			},
+		},
+		{
+			name:  "subquery comparison",
+			query: ast.Query{Select: subqueryComparison},
+			expected: ast.StatementInfo{
+				Kind:     ast.StatementKindSelect,
+				Features: ast.Features{Subqueries: true},
+				Depth:    2,
+			},
+		},
+		{
+			name:  "datasource and aggregation features",
+			query: ast.Query{Select: features},
+			expected: ast.StatementInfo{
+				Kind: ast.StatementKindSelect,
+				Features: ast.Features{
+					Joins:      true,
+					Flatten:    true,
+					Unwind:     true,
+					Aggregates: true,
+					Distinct:   true,
+				},
+				Depth: 1,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			query := test.query
+			if diff := cmp.Diff(test.expected, ast.Classify(&query)); diff != "" {
+				t.Fatalf("unexpected statement info (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,297 @@
+package ast
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// The syntax tree is exchanged with the translation library as BSON in
+// the library's serialization format: a struct is a document of its
+// fields, a unit variant is a string holding the variant name, and any
+// other variant is a document whose only key is the variant name.
+
+// enumTypes are the structs that mirror enums with data.
+var enumTypes = map[reflect.Type]bool{
+	reflect.TypeOf(Query{}):                   true,
+	reflect.TypeOf(SelectBody{}):              true,
+	reflect.TypeOf(SelectValuesExpression{}):  true,
+	reflect.TypeOf(SelectExpression{}):        true,
+	reflect.TypeOf(Datasource{}):              true,
+	reflect.TypeOf(OptionallyAliasedExpr{}):   true,
+	reflect.TypeOf(FlattenOption{}):           true,
+	reflect.TypeOf(UnwindOption{}):            true,
+	reflect.TypeOf(ExtendedUnwindOption{}):    true,
+	reflect.TypeOf(UnwindPathPartOption{}):    true,
+	reflect.TypeOf(Expression{}):              true,
+	reflect.TypeOf(FunctionArguments{}):       true,
+	reflect.TypeOf(TypeOrMissing{}):           true,
+	reflect.TypeOf(SortKey{}):                 true,
+	reflect.TypeOf(Literal{}):                 true,
+	reflect.TypeOf(HigherOrderFunctionExpr{}): true,
+	reflect.TypeOf(FunctionArgument{}):        true,
+	reflect.TypeOf(NamedFunction{}):           true,
+}
+
+var binaryOpType = reflect.TypeOf(BinaryOp(""))
+
+// MarshalBSON implements bson.Marshaler. It returns the BSON
+// representation of the query expected by the translation library, or
+// an error if an enum in the query does not have exactly one field set.
+func (q *Query) MarshalBSON() ([]byte, error) {
+	doc, err := encode(reflect.ValueOf(q).Elem())
+	if err != nil {
+		return nil, err
+	}
+	return bson.Marshal(doc)
+}
+
+// UnmarshalBSON implements bson.Unmarshaler. It decodes a query from the
+// BSON representation produced by the translation library.
+func (q *Query) UnmarshalBSON(data []byte) error {
+	*q = Query{}
+	return decode(bson.RawValue{Type: bsontype.EmbeddedDocument, Value: data}, reflect.ValueOf(q).Elem())
+}
+
+// fieldName returns the name of a struct field in the BSON
+// representation: its bson tag if it has one, and otherwise its Go name,
+// which is the name of the variant for enum fields.
+func fieldName(f reflect.StructField) string {
+	if tag, ok := f.Tag.Lookup("bson"); ok {
+		return tag
+	}
+	return f.Name
+}
+
+// isSet returns whether a field of an enum struct holds its variant.
+func isSet(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Ptr, reflect.Slice:
+		return !v.IsNil()
+	}
+	return false
+}
+
+// encode returns the BSON representation of v as a value that can be
+// marshaled by the bson package.
+func encode(v reflect.Value) (interface{}, error) {
+	if v.Type() == binaryOpType {
+		op := BinaryOp(v.String())
+		if c, ok := op.Comparison(); ok {
+			return bson.D{{Key: "Comparison", Value: string(c)}}, nil
+		}
+		return string(op), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return encode(v.Elem())
+	case reflect.Struct:
+		if enumTypes[v.Type()] {
+			return encodeEnum(v)
+		}
+		doc := bson.D{}
+		for i := 0; i < v.NumField(); i++ {
+			value, err := encode(v.Field(i))
+			if err != nil {
+				return nil, err
+			}
+			doc = append(doc, bson.E{Key: fieldName(v.Type().Field(i)), Value: value})
+		}
+		return doc, nil
+	case reflect.Slice:
+		arr := bson.A{}
+		for i := 0; i < v.Len(); i++ {
+			value, err := encode(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, value)
+		}
+		return arr, nil
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int32:
+		return int32(v.Int()), nil
+	case reflect.Int, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint32:
+		return int64(v.Uint()), nil
+	case reflect.Float64:
+		return v.Float(), nil
+	}
+	return nil, fmt.Errorf("ast: cannot encode value of type %s", v.Type())
+}
+
+func encodeEnum(v reflect.Value) (interface{}, error) {
+	set := -1
+	for i := 0; i < v.NumField(); i++ {
+		if !isSet(v.Field(i)) {
+			continue
+		}
+		if set >= 0 {
+			return nil, fmt.Errorf("ast: %s has both %s and %s set", v.Type().Name(), v.Type().Field(set).Name, v.Type().Field(i).Name)
+		}
+		set = i
+	}
+	if set < 0 {
+		return nil, fmt.Errorf("ast: %s has no field set", v.Type().Name())
+	}
+
+	name := fieldName(v.Type().Field(set))
+	field := v.Field(set)
+	if field.Kind() == reflect.Bool {
+		return name, nil
+	}
+	value, err := encode(field)
+	if err != nil {
+		return nil, err
+	}
+	return bson.D{{Key: name, Value: value}}, nil
+}
+
+// decode sets v, which must be settable, to the value represented by
+// rv.
+func decode(rv bson.RawValue, v reflect.Value) error {
+	if v.Type() == binaryOpType {
+		if doc, ok := rv.DocumentOK(); ok {
+			c, ok := doc.Lookup("Comparison").StringValueOK()
+			if !ok {
+				return fmt.Errorf("ast: invalid BinaryOp %s", rv)
+			}
+			v.SetString(c)
+			return nil
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if rv.Type == bsontype.Null {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		elem := reflect.New(v.Type().Elem())
+		if err := decode(rv, elem.Elem()); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	case reflect.Struct:
+		if enumTypes[v.Type()] {
+			return decodeEnum(rv, v)
+		}
+		doc, ok := rv.DocumentOK()
+		if !ok {
+			return fmt.Errorf("ast: expected a document for %s, got %s", v.Type().Name(), rv.Type)
+		}
+		for i := 0; i < v.NumField(); i++ {
+			value, err := doc.LookupErr(fieldName(v.Type().Field(i)))
+			if err != nil {
+				continue
+			}
+			if err := decode(value, v.Field(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Slice:
+		arr, ok := rv.ArrayOK()
+		if !ok {
+			return fmt.Errorf("ast: expected an array for %s, got %s", v.Type(), rv.Type)
+		}
+		values, err := arr.Values()
+		if err != nil {
+			return err
+		}
+		slice := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := decode(value, slice.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	case reflect.String:
+		s, ok := rv.StringValueOK()
+		if !ok {
+			return fmt.Errorf("ast: expected a string for %s, got %s", v.Type(), rv.Type)
+		}
+		v.SetString(s)
+		return nil
+	case reflect.Bool:
+		b, ok := rv.BooleanOK()
+		if !ok {
+			return fmt.Errorf("ast: expected a boolean, got %s", rv.Type)
+		}
+		v.SetBool(b)
+		return nil
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		i, ok := rv.AsInt64OK()
+		if !ok || v.OverflowInt(i) {
+			return fmt.Errorf("ast: invalid %s %s", v.Type(), rv)
+		}
+		v.SetInt(i)
+		return nil
+	case reflect.Uint32:
+		i, ok := rv.AsInt64OK()
+		if !ok || i < 0 || i > math.MaxUint32 {
+			return fmt.Errorf("ast: invalid %s %s", v.Type(), rv)
+		}
+		v.SetUint(uint64(i))
+		return nil
+	case reflect.Float64:
+		f, ok := rv.DoubleOK()
+		if !ok {
+			return fmt.Errorf("ast: expected a double, got %s", rv.Type)
+		}
+		v.SetFloat(f)
+		return nil
+	}
+	return fmt.Errorf("ast: cannot decode value of type %s", v.Type())
+}
+
+func decodeEnum(rv bson.RawValue, v reflect.Value) error {
+	var name string
+	var value bson.RawValue
+	unit := false
+	if s, ok := rv.StringValueOK(); ok {
+		name, unit = s, true
+	} else if doc, ok := rv.DocumentOK(); ok {
+		elements, err := doc.Elements()
+		if err != nil {
+			return err
+		}
+		if len(elements) != 1 {
+			return fmt.Errorf("ast: expected a single variant for %s, got %s", v.Type().Name(), doc)
+		}
+		name, value = elements[0].Key(), elements[0].Value()
+	} else {
+		return fmt.Errorf("ast: expected a string or document for %s, got %s", v.Type().Name(), rv.Type)
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		if fieldName(v.Type().Field(i)) != name {
+			continue
+		}
+		field := v.Field(i)
+		if unit != (field.Kind() == reflect.Bool) {
+			return fmt.Errorf("ast: invalid value for %s variant %s", v.Type().Name(), name)
+		}
+		if unit {
+			field.SetBool(true)
+			return nil
+		}
+		return decode(value, field)
+	}
+	return fmt.Errorf("ast: unknown %s variant %s", v.Type().Name(), name)
+}
@@ -0,0 +1,134 @@
+package ast_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mongodb/mongosql/go/mongosql/ast"
+	"github.com/mongodb/mongosql/go/mongosql/internal/translation"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// selectWhereBSON is the library's representation of
+// "SELECT * FROM foo WHERE a > 1".
+var selectWhereBSON = bson.D{{Key: "Select", Value: bson.D{
+	{Key: "select_clause", Value: bson.D{
+		{Key: "set_quantifier", Value: "All"},
+		{Key: "body", Value: bson.D{{Key: "Standard", Value: bson.A{"Star"}}}},
+	}},
+	{Key: "from_clause", Value: bson.D{{Key: "Collection", Value: bson.D{
+		{Key: "database", Value: nil},
+		{Key: "collection", Value: "foo"},
+		{Key: "alias", Value: nil},
+		{Key: "span", Value: bson.D{
+			{Key: "start", Value: bson.D{{Key: "offset", Value: int64(14)}, {Key: "line", Value: int64(1)}, {Key: "column", Value: int64(15)}}},
+			{Key: "end", Value: bson.D{{Key: "offset", Value: int64(17)}, {Key: "line", Value: int64(1)}, {Key: "column", Value: int64(18)}}},
+		}},
+	}}}},
+	{Key: "where_clause", Value: bson.D{{Key: "Binary", Value: bson.D{
+		{Key: "left", Value: bson.D{{Key: "Identifier", Value: "a"}}},
+		{Key: "op", Value: bson.D{{Key: "Comparison", Value: "Gt"}}},
+		{Key: "right", Value: bson.D{{Key: "Literal", Value: bson.D{{Key: "Integer", Value: int32(1)}}}}},
+	}}}},
+	{Key: "group_by_clause", Value: nil},
+	{Key: "having_clause", Value: nil},
+	{Key: "order_by_clause", Value: nil},
+	{Key: "limit", Value: nil},
+	{Key: "offset", Value: nil},
+}}}
+
+func str(s string) *string { return &s }
+
+func int32p(i int32) *int32 { return &i }
+
+var selectWhere = ast.Query{Select: &ast.SelectQuery{
+	SelectClause: ast.SelectClause{
+		SetQuantifier: ast.SetQuantifierAll,
+		Body:          ast.SelectBody{Standard: []ast.SelectExpression{{Star: true}}},
+	},
+	FromClause: &ast.Datasource{Collection: &ast.CollectionSource{
+		Collection: "foo",
+		Span: &ast.Span{
+			Start: translation.Position{Offset: 14, Line: 1, Column: 15},
+			End:   translation.Position{Offset: 17, Line: 1, Column: 18},
+		},
+	}},
+	WhereClause: &ast.Expression{Binary: &ast.BinaryExpr{
+		Left:  &ast.Expression{Identifier: str("a")},
+		Op:    ast.BinaryOpGt,
+		Right: &ast.Expression{Literal: &ast.Literal{Integer: int32p(1)}},
+	}},
+}}
+
+func TestUnmarshalQuery(t *testing.T) {
+	data, err := bson.Marshal(selectWhereBSON)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+
+	var query ast.Query
+	if err := query.UnmarshalBSON(data); err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	if diff := cmp.Diff(selectWhere, query); diff != "" {
+		t.Fatalf("unexpected query (-want +got):\n%s", diff)
+	}
+}
+
+func TestMarshalQuery(t *testing.T) {
+	query := selectWhere
+	data, err := query.MarshalBSON()
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	expected, err := bson.Marshal(selectWhereBSON)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+
+	if !bson.Raw(data).Lookup("Select").Equal(bson.Raw(expected).Lookup("Select")) {
+		t.Fatalf("expected %s, got %s", bson.Raw(expected), bson.Raw(data))
+	}
+}
+
+func TestMarshalQueryRequiresOneVariant(t *testing.T) {
+	query := ast.Query{Select: &ast.SelectQuery{
+		SelectClause: ast.SelectClause{
+			SetQuantifier: ast.SetQuantifierAll,
+			Body:          ast.SelectBody{Standard: []ast.SelectExpression{{Star: true}}},
+		},
+		WhereClause: &ast.Expression{Identifier: str("a"), StringConstructor: str("b")},
+	}}
+
+	_, err := query.MarshalBSON()
+	if err == nil {
+		t.Fatalf("expected error to be non-nil, but it was nil")
+	}
+	if !strings.Contains(err.Error(), "Expression has both Identifier and StringConstructor set") {
+		t.Fatalf("error message did not contain expected text: %q", err.Error())
+	}
+
+	_, err = (&ast.Query{}).MarshalBSON()
+	if err == nil {
+		t.Fatalf("expected error to be non-nil, but it was nil")
+	}
+}
+
+func TestUnmarshalUnknownVariant(t *testing.T) {
+	data, err := bson.Marshal(bson.D{{Key: "Insert", Value: bson.D{}}})
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+
+	var query ast.Query
+	err = query.UnmarshalBSON(data)
+	if err == nil {
+		t.Fatalf("expected error to be non-nil, but it was nil")
+	}
+	if !strings.Contains(err.Error(), "unknown Query variant Insert") {
+		t.Fatalf("error message did not contain expected text: %q", err.Error())
+	}
+}
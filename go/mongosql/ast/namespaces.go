@@ -0,0 +1,197 @@
+package ast
+
+import "github.com/mongodb/mongosql/go/mongosql/internal/translation"
+
+// NamespaceRole is the role of a collection in the FROM clause it
+// appears in.
+type NamespaceRole string
+
+// The values of NamespaceRole.
+const (
+	// NamespaceRoleFrom is the role of the only datasource of a FROM
+	// clause
+	NamespaceRoleFrom NamespaceRole = "From"
+	// NamespaceRoleJoinLeft is the role of the left side of a join
+	NamespaceRoleJoinLeft NamespaceRole = "JoinLeft"
+	// NamespaceRoleJoinRight is the role of the right side of a join
+	NamespaceRoleJoinRight NamespaceRole = "JoinRight"
+)
+
+// NestingKind is a construct in which a query can be nested.
+type NestingKind string
+
+// The values of NestingKind.
+const (
+	// NestingSubquery is a subquery expression, an EXISTS expression,
+	// or a subquery comparison
+	NestingSubquery NestingKind = "Subquery"
+	// NestingDerivedTable is a derived table in a FROM clause
+	NestingDerivedTable NestingKind = "DerivedTable"
+	// NestingWith is a query defined by a WITH statement
+	NestingWith NestingKind = "With"
+	// NestingUnionArm is an operand of a set operation
+	NestingUnionArm NestingKind = "UnionArm"
+)
+
+// NamespaceUse is a reference to a namespace in the FROM clause of a
+// sql statement. Unlike GetNamespaces, which returns each namespace
+// once, a statement that reads a namespace several times has a
+// NamespaceUse for each of them.
+type NamespaceUse struct {
+	// Database is the database component of the namespace
+	Database string
+	// Collection is the collection component of the namespace
+	Collection string
+	// Alias is the name by which the query refers to the collection:
+	// its alias, or its collection name when it has no alias
+	Alias string
+	// Position is the location of the reference in the statement,
+	// starting at its database name if it has one. It is nil if the
+	// statement was not parsed, as for a syntax tree built by hand.
+	Position *translation.Position
+	// Role is the role of the collection in its FROM clause. A
+	// collection inside FLATTEN or UNWIND has the role of the FLATTEN
+	// or UNWIND datasource.
+	Role NamespaceRole
+	// Nesting lists the constructs that the query of the FROM clause
+	// is nested in, from the outermost to the innermost. It is empty
+	// for the FROM clause of the statement itself.
+	Nesting []NestingKind
+}
+
+// Primary returns true if u is a read of the main datasource of the
+// statement: the only datasource or the left side of a join in the
+// FROM clause of the statement, or of one of the operands of a
+// statement that is a set operation.
+func (u NamespaceUse) Primary() bool {
+	for _, nesting := range u.Nesting {
+		if nesting != NestingUnionArm {
+			return false
+		}
+	}
+	return u.Role == NamespaceRoleFrom || u.Role == NamespaceRoleJoinLeft
+}
+
+// NamespaceUses returns a NamespaceUse for each collection in the FROM
+// clauses of a parsed statement, in the order they appear in the
+// statement. Collections without a database are in currentDB.
+// References to the queries of a WITH statement are not namespaces,
+// and are skipped.
+func NamespaceUses(query *Query, currentDB string) []NamespaceUse {
+	c := &namespaceUseCollector{currentDB: currentDB}
+	c.query(query, nil, nil)
+	return c.uses
+}
+
+type namespaceUseCollector struct {
+	currentDB string
+	// uses are the uses found in the tree, in the order they appear in
+	// the statement
+	uses []NamespaceUse
+}
+
+// query collects the uses in q, which is nested in the constructs
+// listed in nesting, and can reference the WITH queries in with.
+func (c *namespaceUseCollector) query(q *Query, nesting []NestingKind, with map[string]bool) {
+	switch {
+	case q.Select != nil:
+		c.selectQuery(q.Select, nesting, with)
+	case q.Set != nil:
+		arm := appendNesting(nesting, NestingUnionArm)
+		c.query(q.Set.Left, arm, with)
+		c.query(q.Set.Right, arm, with)
+	case q.With != nil:
+		visible := map[string]bool{}
+		for name := range with {
+			visible[name] = true
+		}
+		for i := range q.With.Queries {
+			c.query(&q.With.Queries[i].Query, appendNesting(nesting, NestingWith), visible)
+			visible[q.With.Queries[i].Name] = true
+		}
+		c.query(q.With.Body, nesting, visible)
+	}
+}
+
+func (c *namespaceUseCollector) selectQuery(q *SelectQuery, nesting []NestingKind, with map[string]bool) {
+	// the SELECT list comes before the FROM clause in the statement
+	c.subqueries(&q.SelectClause, nesting, with)
+	if q.FromClause != nil {
+		c.datasource(q.FromClause, NamespaceRoleFrom, nesting, with)
+	}
+	if q.WhereClause != nil {
+		c.subqueries(q.WhereClause, nesting, with)
+	}
+	if q.GroupByClause != nil {
+		c.subqueries(q.GroupByClause, nesting, with)
+	}
+	if q.HavingClause != nil {
+		c.subqueries(q.HavingClause, nesting, with)
+	}
+	if q.OrderByClause != nil {
+		c.subqueries(q.OrderByClause, nesting, with)
+	}
+}
+
+// subqueries collects the uses in the subqueries nested in node.
+func (c *namespaceUseCollector) subqueries(node Node, nesting []NestingKind, with map[string]bool) {
+	Inspect(node, func(n Node) bool {
+		if q, ok := n.(*Query); ok {
+			c.query(q, appendNesting(nesting, NestingSubquery), with)
+			return false
+		}
+		return true
+	})
+}
+
+func (c *namespaceUseCollector) datasource(ds *Datasource, role NamespaceRole, nesting []NestingKind, with map[string]bool) {
+	switch {
+	case ds.Collection != nil:
+		if ds.Collection.Database == nil && with[ds.Collection.Collection] {
+			return
+		}
+		use := NamespaceUse{
+			Database:   c.currentDB,
+			Collection: ds.Collection.Collection,
+			Alias:      ds.Collection.Collection,
+			Role:       role,
+			Nesting:    nesting,
+		}
+		if ds.Collection.Database != nil {
+			use.Database = *ds.Collection.Database
+		}
+		if ds.Collection.Alias != nil {
+			use.Alias = *ds.Collection.Alias
+		}
+		if ds.Collection.Span != nil {
+			position := ds.Collection.Span.Start
+			use.Position = &position
+		}
+		c.uses = append(c.uses, use)
+	case ds.Array != nil:
+		c.subqueries(ds.Array, nesting, with)
+	case ds.Derived != nil:
+		c.query(ds.Derived.Query, appendNesting(nesting, NestingDerivedTable), with)
+	case ds.Join != nil:
+		c.datasource(ds.Join.Left, NamespaceRoleJoinLeft, nesting, with)
+		c.datasource(ds.Join.Right, NamespaceRoleJoinRight, nesting, with)
+		if ds.Join.Condition != nil {
+			c.subqueries(ds.Join.Condition, nesting, with)
+		}
+	case ds.Flatten != nil:
+		c.datasource(ds.Flatten.Datasource, role, nesting, with)
+	case ds.Unwind != nil:
+		c.datasource(ds.Unwind.Datasource, role, nesting, with)
+		for i := range ds.Unwind.Options {
+			c.subqueries(&ds.Unwind.Options[i], nesting, with)
+		}
+	case ds.ExtendedUnwind != nil:
+		c.datasource(ds.ExtendedUnwind.Datasource, role, nesting, with)
+	}
+}
+
+// appendNesting returns a new slice holding nesting followed by kind,
+// so that the slices of sibling queries do not share elements.
+func appendNesting(nesting []NestingKind, kind NestingKind) []NestingKind {
+	return append(append([]NestingKind(nil), nesting...), kind)
+}
@@ -0,0 +1,170 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mongodb/mongosql/go/mongosql/ast"
+	"github.com/mongodb/mongosql/go/mongosql/internal/translation"
+)
+
+func position(offset, line, column int) *translation.Position {
+	return &translation.Position{Offset: offset, Line: line, Column: column}
+}
+
+// parsedCollection returns a collection datasource whose span starts at
+// the provided position, as the parser records it.
+func parsedCollection(c ast.CollectionSource, offset, line, column int) *ast.Datasource {
+	c.Span = &ast.Span{Start: *position(offset, line, column)}
+	return &ast.Datasource{Collection: &c}
+}
+
+func TestNamespaceUses(t *testing.T) {
+	// SELECT * FROM foo AS f JOIN db2.bar ON f.a = bar.b
+	join := selectFrom(ast.Datasource{Join: &ast.JoinSource{
+		JoinType:  ast.JoinTypeInner,
+		Left:      parsedCollection(ast.CollectionSource{Collection: "foo", Alias: str("f")}, 14, 1, 15),
+		Right:     parsedCollection(ast.CollectionSource{Database: str("db2"), Collection: "bar"}, 28, 1, 29),
+		Condition: eq(subpath(ident("f"), "a"), subpath(ident("bar"), "b")),
+	}})
+
+	// SELECT EXTRACT(YEAR FROM a) AS p FROM foo
+	// WHERE EXISTS(SELECT * FROM (SELECT * FROM bar) AS d)
+	nested := selectExprs(*parsedCollection(ast.CollectionSource{Collection: "foo"}, 38, 1, 39), &ast.Expression{Extract: &ast.ExtractExpr{
+		ExtractSpec: ast.DatePartYear,
+		Arg:         ident("a"),
+	}})
+	nested.WhereClause = &ast.Expression{Exists: &ast.Query{Select: selectFrom(ast.Datasource{
+		Derived: &ast.DerivedSource{
+			Query: &ast.Query{Select: selectFrom(*parsedCollection(ast.CollectionSource{Collection: "bar"}, 84, 2, 43))},
+			Alias: "d",
+		},
+	})}}
+
+	// SELECT * FROM foo
+	// UNION ALL
+	// SELECT * FROM FLATTEN(UNWIND(bar WITH PATH => arr, INDEX => i))
+	union := ast.Query{Set: &ast.SetQuery{
+		Left: &ast.Query{Select: selectFrom(*parsedCollection(ast.CollectionSource{Collection: "foo"}, 14, 1, 15))},
+		Op:   ast.SetOperatorUnionAll,
+		Right: &ast.Query{Select: selectFrom(ast.Datasource{Flatten: &ast.FlattenSource{
+			Datasource: &ast.Datasource{Unwind: &ast.UnwindSource{
+				Datasource: parsedCollection(ast.CollectionSource{Collection: "bar"}, 57, 3, 30),
+				Options:    []ast.UnwindOption{{Path: ident("arr")}, {Index: str("i")}},
+			}},
+		}})},
+	}}
+
+	// WITH q AS (SELECT * FROM foo) SELECT * FROM q, bar
+	with := ast.Query{With: &ast.WithQuery{
+		Queries: []ast.NamedQuery{{
+			Name:  "q",
+			Query: ast.Query{Select: selectFrom(*parsedCollection(ast.CollectionSource{Collection: "foo"}, 25, 1, 26))},
+		}},
+		Body: &ast.Query{Select: selectFrom(ast.Datasource{Join: &ast.JoinSource{
+			JoinType: ast.JoinTypeCross,
+			Left:     parsedCollection(ast.CollectionSource{Collection: "q"}, 44, 1, 45),
+			Right:    parsedCollection(ast.CollectionSource{Collection: "bar"}, 47, 1, 48),
+		}})},
+	}}
+
+	tests := []struct {
+		name     string
+		query    ast.Query
+		expected []ast.NamespaceUse
+	}{
+		{
+			name:  "join",
+			query: ast.Query{Select: join},
+			expected: []ast.NamespaceUse{
+				{Database: "test", Collection: "foo", Alias: "f", Position: position(14, 1, 15), Role: ast.NamespaceRoleJoinLeft},
+				{Database: "db2", Collection: "bar", Alias: "bar", Position: position(28, 1, 29), Role: ast.NamespaceRoleJoinRight},
+			},
+		},
+		{
+			name:  "subquery and derived table",
+			query: ast.Query{Select: nested},
+			expected: []ast.NamespaceUse{
+				{Database: "test", Collection: "foo", Alias: "foo", Position: position(38, 1, 39), Role: ast.NamespaceRoleFrom},
+				{
+					Database:   "test",
+					Collection: "bar",
+					Alias:      "bar",
+					Position:   position(84, 2, 43),
+					Role:       ast.NamespaceRoleFrom,
+					Nesting:    []ast.NestingKind{ast.NestingSubquery, ast.NestingDerivedTable},
+				},
+			},
+		},
+		{
+			name:  "union with flatten and unwind",
+			query: union,
+			expected: []ast.NamespaceUse{
+				{
+					Database:   "test",
+					Collection: "foo",
+					Alias:      "foo",
+					Position:   position(14, 1, 15),
+					Role:       ast.NamespaceRoleFrom,
+					Nesting:    []ast.NestingKind{ast.NestingUnionArm},
+				},
+				{
+					Database:   "test",
+					Collection: "bar",
+					Alias:      "bar",
+					Position:   position(57, 3, 30),
+					Role:       ast.NamespaceRoleFrom,
+					Nesting:    []ast.NestingKind{ast.NestingUnionArm},
+				},
+			},
+		},
+		{
+			name:  "with query",
+			query: with,
+			expected: []ast.NamespaceUse{
+				{
+					Database:   "test",
+					Collection: "foo",
+					Alias:      "foo",
+					Position:   position(25, 1, 26),
+					Role:       ast.NamespaceRoleFrom,
+					Nesting:    []ast.NestingKind{ast.NestingWith},
+				},
+				{Database: "test", Collection: "bar", Alias: "bar", Position: position(47, 1, 48), Role: ast.NamespaceRoleJoinRight},
+			},
+		},
+		{
+			name:  "unparsed tree has no positions",
+			query: ast.Query{Select: selectFrom(collection("foo"))},
+			expected: []ast.NamespaceUse{
+				{Database: "test", Collection: "foo", Alias: "foo", Role: ast.NamespaceRoleFrom},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			query := test.query
+			if diff := cmp.Diff(test.expected, ast.NamespaceUses(&query, "test")); diff != "" {
+				t.Fatalf("unexpected namespace uses (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNamespaceUsePrimary(t *testing.T) {
+	tests := []struct {
+		use      ast.NamespaceUse
+		expected bool
+	}{
+		{ast.NamespaceUse{Role: ast.NamespaceRoleFrom}, true},
+		{ast.NamespaceUse{Role: ast.NamespaceRoleJoinLeft, Nesting: []ast.NestingKind{ast.NestingUnionArm}}, true},
+		{ast.NamespaceUse{Role: ast.NamespaceRoleJoinRight}, false},
+		{ast.NamespaceUse{Role: ast.NamespaceRoleFrom, Nesting: []ast.NestingKind{ast.NestingSubquery}}, false},
+	}
+	for _, test := range tests {
+		if got := test.use.Primary(); got != test.expected {
+			t.Errorf("expected Primary() of %+v to be %t, got %t", test.use, test.expected, got)
+		}
+	}
+}
@@ -0,0 +1,436 @@
+package ast
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// NamespaceReference describes how a sql statement references a
+// namespace.
+type NamespaceReference struct {
+	// Database is the database component of the namespace
+	Database string
+	// Collection is the collection component of the namespace
+	Collection string
+	// Aliases are the names by which the statement refers to the
+	// namespace: the alias of each FROM clause entry for it, or its
+	// collection name when the entry has no alias
+	Aliases []string
+	// Fields are the dotted paths of the fields of the namespace that
+	// the statement references, including the paths unwound by UNWIND
+	Fields []string
+	// WholeDocument is true if the statement reads whole documents of
+	// the namespace, as with SELECT *, SELECT foo.*, or a reference to
+	// the datasource itself
+	WholeDocument bool
+}
+
+// References returns how a parsed statement references each namespace
+// it reads, sorted by namespace. Collections without a database are in
+// currentDB.
+//
+// References only looks at the syntax of the statement, so field
+// references are resolved without schemas:
+//   - a field qualified by a datasource name belongs to that datasource,
+//     and an unqualified field belongs to every datasource of its query
+//     and of the queries it is nested in, since telling them apart
+//     requires their schemas;
+//   - fields referenced through a derived table or a WITH query are not
+//     attributed to a namespace, but the references made by the query
+//     that defines it are;
+//   - a field referenced through FLATTEN is recorded as the path found by
+//     splitting its flattened name on the FLATTEN separator.
+//
+// Names given to expressions by GROUP BY, AGGREGATE and SELECT aliases
+// are not fields, and are skipped in the clauses that can reference
+// them.
+func References(query *Query, currentDB string) []NamespaceReference {
+	c := &referenceCollector{currentDB: currentDB, namespaces: map[[2]string]*namespaceRefs{}}
+	c.query(query, nil)
+
+	refs := make([]NamespaceReference, 0, len(c.namespaces))
+	for key, ns := range c.namespaces {
+		refs = append(refs, NamespaceReference{
+			Database:      key[0],
+			Collection:    key[1],
+			Aliases:       sortedKeys(ns.aliases),
+			Fields:        sortedKeys(ns.fields),
+			WholeDocument: ns.wholeDocument,
+		})
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Database != refs[j].Database {
+			return refs[i].Database < refs[j].Database
+		}
+		return refs[i].Collection < refs[j].Collection
+	})
+	return refs
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+type namespaceRefs struct {
+	aliases       map[string]bool
+	fields        map[string]bool
+	wholeDocument bool
+}
+
+// datasource is a datasource in the FROM clause of a query.
+type datasource struct {
+	name string
+	// ns is nil for datasources that are not collections, such as
+	// derived tables
+	ns *namespaceRefs
+	// flattenSeparator is set if the datasource is flattened
+	flattenSeparator *string
+	// indexes are the fields added by UNWIND to hold array indexes
+	indexes map[string]bool
+}
+
+func (d *datasource) field(path []string) {
+	if d.ns == nil || (len(path) == 1 && d.indexes[path[0]]) {
+		return
+	}
+	if d.flattenSeparator != nil {
+		var unflattened []string
+		for _, part := range path {
+			unflattened = append(unflattened, strings.Split(part, *d.flattenSeparator)...)
+		}
+		path = unflattened
+	}
+	d.ns.fields[strings.Join(path, ".")] = true
+}
+
+func (d *datasource) wholeDocument() {
+	if d.ns != nil {
+		d.ns.wholeDocument = true
+	}
+}
+
+// scope holds the names visible in a query. Queries nested in a query
+// have their own scopes, whose parent is the scope of the enclosing
+// query.
+type scope struct {
+	parent      *scope
+	datasources []*datasource
+	// withQueries are the names of the WITH queries visible in the
+	// query
+	withQueries map[string]bool
+	// computed are the names given to expressions that the clause
+	// being resolved can reference
+	computed map[string]bool
+}
+
+func newScope(parent *scope) *scope {
+	s := &scope{parent: parent, withQueries: map[string]bool{}, computed: map[string]bool{}}
+	if parent != nil {
+		for name := range parent.withQueries {
+			s.withQueries[name] = true
+		}
+	}
+	return s
+}
+
+func (s *scope) datasource(name string) *datasource {
+	for ; s != nil; s = s.parent {
+		for _, d := range s.datasources {
+			if d.name == name {
+				return d
+			}
+		}
+	}
+	return nil
+}
+
+type referenceCollector struct {
+	currentDB  string
+	namespaces map[[2]string]*namespaceRefs
+}
+
+func (c *referenceCollector) namespace(db, collection string) *namespaceRefs {
+	key := [2]string{db, collection}
+	ns, ok := c.namespaces[key]
+	if !ok {
+		ns = &namespaceRefs{aliases: map[string]bool{}, fields: map[string]bool{}}
+		c.namespaces[key] = ns
+	}
+	return ns
+}
+
+// query collects the references of q, which is nested in the query
+// whose scope is outer.
+func (c *referenceCollector) query(q *Query, outer *scope) {
+	switch {
+	case q.Select != nil:
+		c.selectQuery(q.Select, outer)
+	case q.Set != nil:
+		c.query(q.Set.Left, outer)
+		c.query(q.Set.Right, outer)
+	case q.With != nil:
+		s := newScope(outer)
+		for i := range q.With.Queries {
+			c.query(&q.With.Queries[i].Query, s)
+			s.withQueries[q.With.Queries[i].Name] = true
+		}
+		c.query(q.With.Body, s)
+	}
+}
+
+func (c *referenceCollector) selectQuery(q *SelectQuery, outer *scope) {
+	s := newScope(outer)
+	if q.FromClause != nil {
+		s.datasources = c.datasource(q.FromClause, s)
+	}
+	if q.WhereClause != nil {
+		c.expr(q.WhereClause, s)
+	}
+	if q.GroupByClause != nil {
+		for i := range q.GroupByClause.Keys {
+			key := &q.GroupByClause.Keys[i]
+			if key.Aliased != nil {
+				c.expr(&key.Aliased.Expr, s)
+			} else if key.Unaliased != nil {
+				c.expr(key.Unaliased, s)
+			}
+		}
+		for i := range q.GroupByClause.Aggregations {
+			c.expr(&q.GroupByClause.Aggregations[i].Expr, s)
+		}
+		// the clauses after GROUP BY reference the names it defines
+		for _, key := range q.GroupByClause.Keys {
+			if key.Aliased != nil {
+				s.computed[key.Aliased.Alias] = true
+			}
+		}
+		for _, aggregation := range q.GroupByClause.Aggregations {
+			s.computed[aggregation.Alias] = true
+		}
+	}
+
+	body := q.SelectClause.Body
+	for i := range body.Standard {
+		e := &body.Standard[i]
+		switch {
+		case e.Star:
+			for _, d := range s.datasources {
+				d.wholeDocument()
+			}
+		case e.Substar != nil:
+			c.substar(e.Substar, s)
+		case e.Expression != nil && e.Expression.Aliased != nil:
+			c.expr(&e.Expression.Aliased.Expr, s)
+		case e.Expression != nil && e.Expression.Unaliased != nil:
+			c.expr(e.Expression.Unaliased, s)
+		}
+	}
+	for i := range body.Values {
+		e := &body.Values[i]
+		switch {
+		case e.Substar != nil:
+			c.substar(e.Substar, s)
+		case e.Expression != nil:
+			c.expr(e.Expression, s)
+		}
+	}
+
+	if q.HavingClause != nil {
+		c.expr(q.HavingClause, s)
+	}
+	if q.OrderByClause != nil {
+		// ORDER BY can also reference the aliases of the SELECT list
+		for _, e := range body.Standard {
+			if e.Expression != nil && e.Expression.Aliased != nil {
+				s.computed[e.Expression.Aliased.Alias] = true
+			}
+		}
+		for _, spec := range q.OrderByClause.SortSpecs {
+			if spec.Key.Simple != nil {
+				c.expr(spec.Key.Simple, s)
+			}
+		}
+	}
+}
+
+func (c *referenceCollector) substar(e *SubstarExpr, s *scope) {
+	if d := s.datasource(e.Datasource); d != nil {
+		d.wholeDocument()
+	}
+}
+
+// datasource collects the references of a FROM clause entry whose
+// query has scope s, and returns the datasources it defines.
+func (c *referenceCollector) datasource(ds *Datasource, s *scope) []*datasource {
+	switch {
+	case ds.Collection != nil:
+		name := ds.Collection.Collection
+		if ds.Collection.Alias != nil {
+			name = *ds.Collection.Alias
+		}
+		if ds.Collection.Database == nil && s.withQueries[ds.Collection.Collection] {
+			return []*datasource{{name: name}}
+		}
+		db := c.currentDB
+		if ds.Collection.Database != nil {
+			db = *ds.Collection.Database
+		}
+		ns := c.namespace(db, ds.Collection.Collection)
+		ns.aliases[name] = true
+		return []*datasource{{name: name, ns: ns}}
+	case ds.Array != nil:
+		for i := range ds.Array.Array {
+			c.expr(&ds.Array.Array[i], s.parent)
+		}
+		return []*datasource{{name: ds.Array.Alias}}
+	case ds.Derived != nil:
+		c.query(ds.Derived.Query, s.parent)
+		return []*datasource{{name: ds.Derived.Alias}}
+	case ds.Join != nil:
+		datasources := append(c.datasource(ds.Join.Left, s), c.datasource(ds.Join.Right, s)...)
+		if ds.Join.Condition != nil {
+			joined := newScope(s.parent)
+			joined.datasources = datasources
+			c.expr(ds.Join.Condition, joined)
+		}
+		return datasources
+	case ds.Flatten != nil:
+		separator := "_"
+		for _, option := range ds.Flatten.Options {
+			if option.Separator != nil {
+				separator = *option.Separator
+			}
+		}
+		datasources := c.datasource(ds.Flatten.Datasource, s)
+		for _, d := range datasources {
+			d.flattenSeparator = &separator
+		}
+		return datasources
+	case ds.Unwind != nil:
+		datasources := c.datasource(ds.Unwind.Datasource, s)
+		unwound := newScope(s.parent)
+		unwound.datasources = datasources
+		indexes := map[string]bool{}
+		for _, option := range ds.Unwind.Options {
+			if option.Path != nil {
+				c.expr(option.Path, unwound)
+			}
+			if option.Index != nil {
+				indexes[*option.Index] = true
+			}
+		}
+		return withIndexes(datasources, indexes)
+	case ds.ExtendedUnwind != nil:
+		datasources := c.datasource(ds.ExtendedUnwind.Datasource, s)
+		indexes := map[string]bool{}
+		for _, option := range ds.ExtendedUnwind.Options {
+			for _, parts := range option.Paths {
+				var path []string
+				for _, part := range parts {
+					path = append(path, part.Field)
+					for _, partOptions := range part.Options {
+						for _, partOption := range partOptions {
+							if partOption.Index != nil {
+								indexes[*partOption.Index] = true
+							}
+						}
+					}
+				}
+				for _, d := range datasources {
+					d.field(path)
+				}
+			}
+			if option.Index != nil {
+				indexes[*option.Index] = true
+			}
+		}
+		return withIndexes(datasources, indexes)
+	}
+	return nil
+}
+
+func withIndexes(datasources []*datasource, indexes map[string]bool) []*datasource {
+	for _, d := range datasources {
+		if d.indexes == nil {
+			d.indexes = map[string]bool{}
+		}
+		for index := range indexes {
+			d.indexes[index] = true
+		}
+	}
+	return datasources
+}
+
+// expr collects the references of an expression of the query whose
+// scope is s.
+func (c *referenceCollector) expr(e *Expression, s *scope) {
+	if path, ok := fieldPath(e); ok {
+		c.reference(path, s)
+		return
+	}
+	walkChildren(expressionVisitor{c: c, s: s}, reflect.ValueOf(e).Elem())
+}
+
+// reference records a reference to the path of names, which starts
+// with either a datasource name or a field name.
+func (c *referenceCollector) reference(path []string, s *scope) {
+	if s == nil || s.computed[path[0]] {
+		return
+	}
+	if d := s.datasource(path[0]); d != nil {
+		if len(path) == 1 {
+			d.wholeDocument()
+		} else {
+			d.field(path[1:])
+		}
+		return
+	}
+	// an unqualified field of a subquery can be a field of a datasource of
+	// any enclosing query, so it is attributed to all of them
+	for ; s != nil; s = s.parent {
+		for _, d := range s.datasources {
+			d.field(path)
+		}
+	}
+}
+
+// fieldPath returns the names of a reference such as "a", "foo.a.b" or
+// "a['b']", and false if e is not a reference.
+func fieldPath(e *Expression) ([]string, bool) {
+	switch {
+	case e.Identifier != nil:
+		return []string{*e.Identifier}, true
+	case e.Subpath != nil && e.Subpath.Expr != nil:
+		path, ok := fieldPath(e.Subpath.Expr)
+		return append(path, e.Subpath.Subpath), ok
+	case e.Access != nil && e.Access.Expr != nil && e.Access.Subfield != nil && e.Access.Subfield.StringConstructor != nil:
+		path, ok := fieldPath(e.Access.Expr)
+		return append(path, *e.Access.Subfield.StringConstructor), ok
+	}
+	return nil, false
+}
+
+// expressionVisitor collects the references of the expressions and
+// queries nested in a node.
+type expressionVisitor struct {
+	c *referenceCollector
+	s *scope
+}
+
+func (v expressionVisitor) Visit(node Node) Visitor {
+	switch n := node.(type) {
+	case *Expression:
+		v.c.expr(n, v.s)
+		return nil
+	case *Query:
+		v.c.query(n, v.s)
+		return nil
+	}
+	return v
+}
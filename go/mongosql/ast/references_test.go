@@ -0,0 +1,160 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mongodb/mongosql/go/mongosql/ast"
+)
+
+func ident(name string) *ast.Expression {
+	return &ast.Expression{Identifier: str(name)}
+}
+
+func subpath(e *ast.Expression, name string) *ast.Expression {
+	return &ast.Expression{Subpath: &ast.SubpathExpr{Expr: e, Subpath: name}}
+}
+
+func gt(left, right *ast.Expression) *ast.Expression {
+	return &ast.Expression{Binary: &ast.BinaryExpr{Left: left, Op: ast.BinaryOpGt, Right: right}}
+}
+
+func eq(left, right *ast.Expression) *ast.Expression {
+	return &ast.Expression{Binary: &ast.BinaryExpr{Left: left, Op: ast.BinaryOpEq, Right: right}}
+}
+
+func one() *ast.Expression {
+	return &ast.Expression{Literal: &ast.Literal{Integer: int32p(1)}}
+}
+
+// selectExprs returns the query "SELECT <exprs> FROM <from>", where
+// each expression is aliased by its position.
+func selectExprs(from ast.Datasource, exprs ...*ast.Expression) *ast.SelectQuery {
+	q := selectFrom(from)
+	q.SelectClause.Body = ast.SelectBody{}
+	for i, e := range exprs {
+		q.SelectClause.Body.Standard = append(q.SelectClause.Body.Standard, ast.SelectExpression{
+			Expression: &ast.OptionallyAliasedExpr{Aliased: &ast.AliasedExpr{Expr: *e, Alias: string(rune('p' + i))}},
+		})
+	}
+	return q
+}
+
+func TestReferences(t *testing.T) {
+	// SELECT a, f.b.c, f['d'] FROM foo AS f WHERE e > 1
+	fields := selectExprs(
+		ast.Datasource{Collection: &ast.CollectionSource{Collection: "foo", Alias: str("f")}},
+		ident("a"),
+		subpath(subpath(ident("f"), "b"), "c"),
+		&ast.Expression{Access: &ast.AccessExpr{Expr: ident("f"), Subfield: &ast.Expression{StringConstructor: str("d")}}},
+	)
+	fields.WhereClause = gt(ident("e"), one())
+
+	// SELECT *, b.* FROM foo JOIN db2.bar AS b ON foo.x = b.y
+	join := selectFrom(ast.Datasource{Join: &ast.JoinSource{
+		JoinType:  ast.JoinTypeInner,
+		Left:      &ast.Datasource{Collection: &ast.CollectionSource{Collection: "foo"}},
+		Right:     &ast.Datasource{Collection: &ast.CollectionSource{Database: str("db2"), Collection: "bar", Alias: str("b")}},
+		Condition: eq(subpath(ident("foo"), "x"), subpath(ident("b"), "y")),
+	}})
+	join.SelectClause.Body.Standard = append(join.SelectClause.Body.Standard, ast.SelectExpression{Substar: &ast.SubstarExpr{Datasource: "b"}})
+
+	// SELECT a_b FROM FLATTEN(UNWIND(foo WITH PATH => arr, INDEX => i)) WHERE i > 1
+	unwind := selectExprs(
+		ast.Datasource{Flatten: &ast.FlattenSource{Datasource: &ast.Datasource{Unwind: &ast.UnwindSource{
+			Datasource: &ast.Datasource{Collection: &ast.CollectionSource{Collection: "foo"}},
+			Options:    []ast.UnwindOption{{Path: ident("arr")}, {Index: str("i")}},
+		}}}},
+		ident("a_b"),
+	)
+	unwind.WhereClause = gt(ident("i"), one())
+
+	// WITH q AS (SELECT * FROM foo)
+	// SELECT x, n FROM q GROUP BY q.a AS x AGGREGATE COUNT(*) AS n ORDER BY x
+	grouped := selectExprs(collection("q"), ident("x"), ident("n"))
+	grouped.GroupByClause = &ast.GroupByClause{
+		Keys: []ast.OptionallyAliasedExpr{{Aliased: &ast.AliasedExpr{Expr: *subpath(ident("q"), "a"), Alias: "x"}}},
+		Aggregations: []ast.AliasedExpr{{
+			Expr:  ast.Expression{Function: &ast.FunctionExpr{Function: ast.FunctionNameCount, Args: ast.FunctionArguments{Star: true}}},
+			Alias: "n",
+		}},
+	}
+	grouped.OrderByClause = &ast.OrderByClause{SortSpecs: []ast.SortSpec{{Key: ast.SortKey{Simple: ident("x")}, Direction: ast.SortDirectionAsc}}}
+	with := ast.Query{With: &ast.WithQuery{
+		Queries: []ast.NamedQuery{{Name: "q", Query: ast.Query{Select: selectFrom(collection("foo"))}}},
+		Body:    &ast.Query{Select: grouped},
+	}}
+
+	// SELECT a FROM foo WHERE EXISTS(SELECT * FROM bar WHERE bar.b = foo.c)
+	correlated := selectFrom(collection("bar"))
+	correlated.WhereClause = eq(subpath(ident("bar"), "b"), subpath(ident("foo"), "c"))
+	outer := selectExprs(collection("foo"), ident("a"))
+	outer.WhereClause = &ast.Expression{Exists: &ast.Query{Select: correlated}}
+
+	// SELECT a FROM foo WHERE EXISTS(SELECT * FROM bar WHERE bar.x = y)
+	unqualifiedCorrelated := selectFrom(collection("bar"))
+	unqualifiedCorrelated.WhereClause = eq(subpath(ident("bar"), "x"), ident("y"))
+	unqualifiedOuter := selectExprs(collection("foo"), ident("a"))
+	unqualifiedOuter.WhereClause = &ast.Expression{Exists: &ast.Query{Select: unqualifiedCorrelated}}
+
+	tests := []struct {
+		name     string
+		query    ast.Query
+		expected []ast.NamespaceReference
+	}{
+		{
+			name:  "qualified, unqualified and nested fields",
+			query: ast.Query{Select: fields},
+			expected: []ast.NamespaceReference{
+				{Database: "test", Collection: "foo", Aliases: []string{"f"}, Fields: []string{"a", "b.c", "d", "e"}},
+			},
+		},
+		{
+			name:  "join with star and substar",
+			query: ast.Query{Select: join},
+			expected: []ast.NamespaceReference{
+				{Database: "db2", Collection: "bar", Aliases: []string{"b"}, Fields: []string{"y"}, WholeDocument: true},
+				{Database: "test", Collection: "foo", Aliases: []string{"foo"}, Fields: []string{"x"}, WholeDocument: true},
+			},
+		},
+		{
+			name:  "flatten and unwind",
+			query: ast.Query{Select: unwind},
+			expected: []ast.NamespaceReference{
+				{Database: "test", Collection: "foo", Aliases: []string{"foo"}, Fields: []string{"a.b", "arr"}},
+			},
+		},
+		{
+			name:  "with query and computed names",
+			query: with,
+			expected: []ast.NamespaceReference{
+				{Database: "test", Collection: "foo", Aliases: []string{"foo"}, Fields: []string{}, WholeDocument: true},
+			},
+		},
+		{
+			name:  "correlated subquery",
+			query: ast.Query{Select: outer},
+			expected: []ast.NamespaceReference{
+				{Database: "test", Collection: "bar", Aliases: []string{"bar"}, Fields: []string{"b"}, WholeDocument: true},
+				{Database: "test", Collection: "foo", Aliases: []string{"foo"}, Fields: []string{"a", "c"}},
+			},
+		},
+		{
+			name:  "unqualified field of correlated subquery",
+			query: ast.Query{Select: unqualifiedOuter},
+			expected: []ast.NamespaceReference{
+				{Database: "test", Collection: "bar", Aliases: []string{"bar"}, Fields: []string{"x", "y"}, WholeDocument: true},
+				{Database: "test", Collection: "foo", Aliases: []string{"foo"}, Fields: []string{"a", "y"}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			query := test.query
+			if diff := cmp.Diff(test.expected, ast.References(&query, "test")); diff != "" {
+				t.Fatalf("unexpected references (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
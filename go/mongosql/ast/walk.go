@@ -0,0 +1,81 @@
+package ast
+
+import (
+	"reflect"
+)
+
+// Node is a pointer to any of the struct types in this package, such
+// as *Query, *Expression, or *BinaryExpr.
+type Node interface{}
+
+// A Visitor's Visit method is invoked for each node encountered by
+// Walk. If the result visitor w is not nil, Walk visits each of the
+// children of node with the visitor w, followed by a call of
+// w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses a syntax tree in depth-first order: it starts by
+// calling v.Visit(node); node must be a pointer to one of the struct
+// types in this package. If the visitor w returned by v.Visit(node) is
+// not nil, Walk is invoked recursively with visitor w for each of the
+// children of node, followed by a call of w.Visit(nil).
+//
+// The children of an enum struct, such as *Expression, are the
+// pointers to its set variant, such as *BinaryExpr. Because nodes are
+// pointers into the tree, a visitor may modify them in place.
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+	walkChildren(v, reflect.ValueOf(node).Elem())
+	v.Visit(nil)
+}
+
+// spanType is the type of the Span fields, which are not nodes.
+var spanType = reflect.TypeOf((*Span)(nil))
+
+// walkChildren walks every node contained in the struct s.
+func walkChildren(v Visitor, s reflect.Value) {
+	for i := 0; i < s.NumField(); i++ {
+		if s.Field(i).Type() == spanType {
+			continue
+		}
+		walkValue(v, s.Field(i))
+	}
+}
+
+// walkValue walks the nodes in a field, which may be a struct, a
+// pointer to a struct, or a slice of either.
+func walkValue(v Visitor, f reflect.Value) {
+	switch f.Kind() {
+	case reflect.Ptr:
+		if !f.IsNil() && f.Elem().Kind() == reflect.Struct {
+			Walk(v, f.Interface())
+		}
+	case reflect.Struct:
+		Walk(v, f.Addr().Interface())
+	case reflect.Slice:
+		for i := 0; i < f.Len(); i++ {
+			walkValue(v, f.Index(i))
+		}
+	}
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses a syntax tree in depth-first order: it starts by
+// calling f(node); node must not be nil. If f returns true, Inspect
+// invokes f recursively for each of the children of node, followed by
+// a call of f(nil).
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
@@ -0,0 +1,76 @@
+package ast_test
+
+import (
+	"testing"
+
+	"github.com/mongodb/mongosql/go/mongosql/ast"
+)
+
+func TestInspect(t *testing.T) {
+	query := selectWhere
+
+	var identifiers []string
+	var collections []string
+	ast.Inspect(&query, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.Expression:
+			if n.Identifier != nil {
+				identifiers = append(identifiers, *n.Identifier)
+			}
+		case *ast.CollectionSource:
+			collections = append(collections, n.Collection)
+		}
+		return true
+	})
+
+	if len(identifiers) != 1 || identifiers[0] != "a" {
+		t.Fatalf("expected identifiers [a], got %v", identifiers)
+	}
+	if len(collections) != 1 || collections[0] != "foo" {
+		t.Fatalf("expected collections [foo], got %v", collections)
+	}
+}
+
+func TestInspectCanSkipChildren(t *testing.T) {
+	query := selectWhere
+
+	visitedWhere, visitedCollection := false, false
+	ast.Inspect(&query, func(node ast.Node) bool {
+		switch node.(type) {
+		case *ast.BinaryExpr:
+			visitedWhere = true
+		case *ast.CollectionSource:
+			visitedCollection = true
+		}
+		_, isDatasource := node.(*ast.Datasource)
+		return !isDatasource
+	})
+
+	if !visitedWhere {
+		t.Fatalf("expected the WHERE clause to be visited")
+	}
+	if visitedCollection {
+		t.Fatalf("expected the children of the FROM clause to be skipped")
+	}
+}
+
+func TestWalkRewritesInPlace(t *testing.T) {
+	query := ast.Query{Select: &ast.SelectQuery{
+		SelectClause: ast.SelectClause{
+			SetQuantifier: ast.SetQuantifierAll,
+			Body:          ast.SelectBody{Standard: []ast.SelectExpression{{Star: true}}},
+		},
+		FromClause: &ast.Datasource{Collection: &ast.CollectionSource{Collection: "foo"}},
+	}}
+
+	ast.Inspect(&query, func(node ast.Node) bool {
+		if c, ok := node.(*ast.CollectionSource); ok {
+			c.Database = str("tenant1")
+		}
+		return true
+	})
+
+	if db := query.Select.FromClause.Collection.Database; db == nil || *db != "tenant1" {
+		t.Fatalf("expected the collection's database to be rewritten")
+	}
+}
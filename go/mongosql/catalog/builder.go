@@ -0,0 +1,298 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// PartitionDocsPerIteration is the number of documents read from a
+// partition at a time while deriving its schema.
+const PartitionDocsPerIteration = 20
+
+// viewSampleSize is the number of documents sampled to derive the
+// schema of a view.
+const viewSampleSize = 1000
+
+// DerivedSchema is a schema derived from the documents of a collection
+// by the schema builder. It keeps the information needed to union it
+// with the schemas of other documents, so that the schemas of the
+// partitions of a collection can be derived separately and unioned.
+// The zero value is the schema of no documents.
+type DerivedSchema struct {
+	schema *schema
+}
+
+func (s DerivedSchema) get() *schema {
+	if s.schema == nil {
+		return unsatisfiable
+	}
+	return s.schema
+}
+
+// Union returns a schema that matches the documents matched by s or
+// other.
+func (s DerivedSchema) Union(other DerivedSchema) DerivedSchema {
+	return DerivedSchema{union(s.get(), other.get())}
+}
+
+// Empty returns true if s was derived from no documents.
+func (s DerivedSchema) Empty() bool {
+	return s.get().kind == unsatKind
+}
+
+// JSONSchema returns the JSON Schema of s, which can be used as the
+// schema of a collection in a CatalogSchema.
+func (s DerivedSchema) JSONSchema() (bsoncore.Document, error) {
+	return marshalSchema(simplify(s.get()), InferOptions{})
+}
+
+// SinglePartition is a partition of a collection to derive the schema
+// of, as returned by GetPartitions.
+type SinglePartition struct {
+	Partition    Partition
+	PartitionKey string
+	Hint         bson.D
+	// Index is the position of the partition in its collection
+	Index int
+}
+
+// DeriveSchemaForCollection derives the schema of the collection
+// db.collection, by splitting it in partitions with GetPartitions and
+// deriving their schemas in order with DeriveSchemaForPartition. The
+// derived schema is unioned with initial, which is the zero value if
+// there is no previous schema to refine. Callers that want to control
+// how the partitions are processed can call these functions directly.
+func DeriveSchemaForCollection(ctx context.Context, service DataService, db, collection string, initial DerivedSchema) (DerivedSchema, error) {
+	collections, err := service.ListCollections(ctx, db)
+	if err != nil {
+		return DerivedSchema{}, fmt.Errorf("failed to list the collections of %s: %w", db, err)
+	}
+	var info *CollectionInfo
+	for i := range collections {
+		if collections[i].Name == collection {
+			info = &collections[i]
+			break
+		}
+	}
+	if info == nil {
+		return DerivedSchema{}, fmt.Errorf("%w: %s.%s", ErrNoCollection, db, collection)
+	}
+
+	partitioned, err := GetPartitions(ctx, service, db, *info)
+	if err != nil {
+		return DerivedSchema{}, err
+	}
+
+	var result DerivedSchema
+	for i, partition := range partitioned.Partitions {
+		schema, err := DeriveSchemaForPartition(ctx, service, db, collection, initial, SinglePartition{
+			Partition:    partition,
+			PartitionKey: partitioned.PartitionKey,
+			Hint:         partitioned.Hint,
+			Index:        i,
+		})
+		if err != nil {
+			return DerivedSchema{}, err
+		}
+		result = result.Union(schema)
+	}
+	return result, nil
+}
+
+// DeriveSchemaForPartition derives the schema of a partition of the
+// collection db.collection, starting from initial.
+//
+// The documents of the partition are read PartitionDocsPerIteration at
+// a time, leaving out the documents that already match the schema
+// derived so far, until no document is left. Once the schema becomes
+// unstable, meaning that the keys of the documents vary too much for
+// new documents to add useful information, at most one more batch is
+// read.
+func DeriveSchemaForPartition(ctx context.Context, service DataService, db, collection string, initial DerivedSchema, single SinglePartition) (DerivedSchema, error) {
+	partition := single.Partition
+	partitionKey := single.PartitionKey
+	var ignoredIDs []bson.RawValue
+	result := simplify(initial.get())
+	sawUnstable := false
+
+	for {
+		var filter bson.D
+		if result.kind != unsatKind {
+			filter = jsonSchema(result, InferOptions{}, 0)
+		}
+		cursor, err := service.Aggregate(ctx, db, collection, []bson.D{
+			partition.matchStage(filter, ignoredIDs, partitionKey),
+			{{Key: "$sort", Value: bson.D{{Key: partitionKey, Value: 1}}}},
+			{{Key: "$limit", Value: PartitionDocsPerIteration}},
+		}, AggregateOptions{KeyHint: single.Hint})
+		if err != nil {
+			return DerivedSchema{}, fmt.Errorf("failed to read partition %d of %s.%s: %w", single.Index, db, collection, err)
+		}
+
+		noResult := true
+		iteration := unsatisfiable
+		for cursor.Next(ctx) {
+			doc := cursor.Current()
+			id, err := doc.LookupErr(partitionKey)
+			if err != nil {
+				continue
+			}
+			partition.Min = copyValue(id)
+			previous := iteration
+			iteration = union(iteration, schemaForDocument(doc, InferOptions{}))
+			// $jsonSchema does not work with empty keys on the server
+			// (SERVER-92443), so a document that keeps being returned
+			// without changing the schema is ignored explicitly, to
+			// avoid looping forever
+			if compareSchemas(previous, iteration) == 0 {
+				ignoredIDs = append(ignoredIDs, partition.Min)
+			}
+			noResult = false
+		}
+		err = cursor.Err()
+		cursor.Close(ctx)
+		if err != nil {
+			return DerivedSchema{}, fmt.Errorf("failed to read partition %d of %s.%s: %w", single.Index, db, collection, err)
+		}
+		if noResult {
+			break
+		}
+
+		result = union(result, iteration)
+		if result.isUnstable() {
+			if sawUnstable {
+				break
+			}
+			sawUnstable = true
+		}
+	}
+	return DerivedSchema{result}, nil
+}
+
+// DeriveSchemaForView derives the schema of a view of the database db
+// from a sample of the documents it returns, obtained by running its
+// pipeline after a $sample of its source collection. If reading the
+// sample fails part way, the schema of the documents read so far is
+// returned along with the error. The schema is Empty if the view
+// returned no documents.
+func DeriveSchemaForView(ctx context.Context, service DataService, db string, view CollectionInfo) (DerivedSchema, error) {
+	pipeline := []bson.D{{{Key: "$sample", Value: bson.D{{Key: "size", Value: viewSampleSize}}}}}
+	pipeline = append(pipeline, view.Options.Pipeline...)
+
+	cursor, err := service.Aggregate(ctx, db, view.Options.ViewOn, pipeline, AggregateOptions{})
+	if err != nil {
+		return DerivedSchema{}, fmt.Errorf("failed to sample view %s.%s: %w", db, view.Name, err)
+	}
+	defer cursor.Close(ctx)
+
+	result := unsatisfiable
+	for cursor.Next(ctx) {
+		result = union(result, schemaForDocument(cursor.Current(), InferOptions{}))
+	}
+	if err := cursor.Err(); err != nil {
+		return DerivedSchema{result}, fmt.Errorf("failed to sample view %s.%s: %w", db, view.Name, err)
+	}
+	return DerivedSchema{result}, nil
+}
+
+// CollectionError reports a collection whose schema could not be
+// derived by BuildCatalog.
+type CollectionError struct {
+	Database   string
+	Collection string
+	Err        error
+}
+
+// Error implements the error interface.
+func (e *CollectionError) Error() string {
+	return fmt.Sprintf("failed to derive the schema of %s.%s: %s", e.Database, e.Collection, e.Err)
+}
+
+// Unwrap returns the reason the schema could not be derived.
+func (e *CollectionError) Unwrap() error {
+	return e.Err
+}
+
+// CollectionErrors holds a CollectionError for each collection whose
+// schema could not be derived, in the order the collections were
+// listed.
+type CollectionErrors []*CollectionError
+
+// Error implements the error interface by listing every error.
+func (e CollectionErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// BuildCatalog derives the schemas of the collections, views and
+// timeseries collections of the databases dbs, or of every database if
+// dbs is empty, and returns them as a CatalogSchema. System
+// collections and __sql_schemas collections are skipped, as are empty
+// collections and views that return no documents.
+//
+// Every collection is attempted, and the returned error, if any, is a
+// CollectionErrors holding a CollectionError for each collection whose
+// schema could not be derived; the returned CatalogSchema holds the
+// other schemas. An error listing the databases or collections is
+// returned as is.
+func BuildCatalog(ctx context.Context, service DataService, dbs ...string) (CatalogSchema, error) {
+	if len(dbs) == 0 {
+		var err error
+		if dbs, err = service.ListDatabaseNames(ctx); err != nil {
+			return nil, fmt.Errorf("failed to list the databases: %w", err)
+		}
+	}
+
+	catalogSchema := CatalogSchema{}
+	var errs CollectionErrors
+	for _, db := range dbs {
+		collections, err := service.ListCollections(ctx, db)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the collections of %s: %w", db, err)
+		}
+		for _, info := range collections {
+			if strings.HasPrefix(info.Name, "system.") || info.Name == SQLSchemasCollection {
+				continue
+			}
+
+			var derived DerivedSchema
+			if info.Type == CollectionTypeView {
+				derived, err = DeriveSchemaForView(ctx, service, db, info)
+			} else {
+				derived, err = DeriveSchemaForCollection(ctx, service, db, info.Name, DerivedSchema{})
+				if errors.Is(err, ErrEmptyCollection) {
+					continue
+				}
+			}
+			var schema bsoncore.Document
+			if err == nil && !derived.Empty() {
+				schema, err = derived.JSONSchema()
+			}
+			if err != nil {
+				errs = append(errs, &CollectionError{Database: db, Collection: info.Name, Err: err})
+				continue
+			}
+			if schema == nil {
+				continue
+			}
+
+			if catalogSchema[db] == nil {
+				catalogSchema[db] = map[string]bsoncore.Document{}
+			}
+			catalogSchema[db][info.Name] = schema
+		}
+	}
+
+	if len(errs) > 0 {
+		return catalogSchema, errs
+	}
+	return catalogSchema, nil
+}
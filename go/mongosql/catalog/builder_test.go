@@ -0,0 +1,659 @@
+package catalog_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mongodb/mongosql/go/mongosql/catalog"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// memCollection is a collection of a memDataService.
+type memCollection struct {
+	info catalog.CollectionInfo
+	docs []bson.Raw
+	// size overrides the size reported by $collStats when non-zero
+	size int64
+}
+
+// memDataService is an in-memory DataService that evaluates the
+// pipelines used by the schema builder.
+type memDataService struct {
+	dbs map[string][]*memCollection
+	// failSampling makes pipelines that use $sampleRate fail
+	failSampling bool
+}
+
+func (s *memDataService) collection(db, name string) (*memCollection, error) {
+	for _, c := range s.dbs[db] {
+		if c.info.Name == name {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("no collection %s.%s", db, name)
+}
+
+func (s *memDataService) ListDatabaseNames(ctx context.Context) ([]string, error) {
+	var names []string
+	for name := range s.dbs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (s *memDataService) ListCollections(ctx context.Context, db string) ([]catalog.CollectionInfo, error) {
+	var infos []catalog.CollectionInfo
+	for _, c := range s.dbs[db] {
+		infos = append(infos, c.info)
+	}
+	return infos, nil
+}
+
+func (s *memDataService) Aggregate(ctx context.Context, db, collection string, pipeline []bson.D, opts catalog.AggregateOptions) (catalog.Cursor, error) {
+	c, err := s.collection(db, collection)
+	if err != nil {
+		return nil, err
+	}
+	docs := c.docs
+	for _, stage := range pipeline {
+		raw, err := bson.Marshal(stage)
+		if err != nil {
+			return nil, err
+		}
+		element, err := bson.Raw(raw).IndexErr(0)
+		if err != nil {
+			return nil, err
+		}
+		value := element.Value()
+		switch element.Key() {
+		case "$collStats":
+			stats := bson.D{{Key: "size", Value: c.size}}
+			if c.size == 0 {
+				for _, doc := range docs {
+					stats[0].Value = stats[0].Value.(int64) + int64(len(doc))
+				}
+			}
+			if c.info.Type != catalog.CollectionTypeTimeseries {
+				stats = append(stats, bson.E{Key: "count", Value: int64(len(docs))})
+			}
+			data, err := bson.Marshal(bson.D{{Key: "storageStats", Value: stats}})
+			if err != nil {
+				return nil, err
+			}
+			docs = []bson.Raw{data}
+		case "$sample":
+			if size := int(value.Document().Lookup("size").AsInt64()); size < len(docs) {
+				docs = docs[:size]
+			}
+		case "$sort":
+			sortElement := value.Document().Index(0)
+			key, direction := sortElement.Key(), sortElement.Value().AsInt64()
+			sorted := append([]bson.Raw(nil), docs...)
+			sort.SliceStable(sorted, func(i, j int) bool {
+				return int64(compareValues(sorted[i].Lookup(key), sorted[j].Lookup(key)))*direction < 0
+			})
+			docs = sorted
+		case "$limit":
+			if limit := int(value.AsInt64()); limit < len(docs) {
+				docs = docs[:limit]
+			}
+		case "$project":
+			key := value.Document().Index(0).Key()
+			var projected []bson.Raw
+			for _, doc := range docs {
+				d := bson.D{}
+				if id, err := doc.LookupErr("_id"); err == nil {
+					d = append(d, bson.E{Key: "_id", Value: id})
+				}
+				if v, err := doc.LookupErr(key); err == nil && key != "_id" {
+					d = append(d, bson.E{Key: key, Value: v})
+				}
+				data, err := bson.Marshal(d)
+				if err != nil {
+					return nil, err
+				}
+				projected = append(projected, data)
+			}
+			docs = projected
+		case "$match":
+			filter := value.Document()
+			if rate, err := filter.LookupErr("$sampleRate"); err == nil {
+				if s.failSampling {
+					return nil, errors.New("$sampleRate is not supported")
+				}
+				// deterministically keep one document out of 1/rate
+				every := int(math.Round(1 / rate.Double()))
+				var sampled []bson.Raw
+				for i, doc := range docs {
+					if (i+1)%every == 0 {
+						sampled = append(sampled, doc)
+					}
+				}
+				docs = sampled
+				continue
+			}
+			docs = filterDocs(docs, filter)
+		default:
+			return nil, fmt.Errorf("unsupported stage %s", element.Key())
+		}
+	}
+	return &memCursor{docs: docs, index: -1}, nil
+}
+
+func (s *memDataService) Find(ctx context.Context, db, collection string, filter bson.D) (catalog.Cursor, error) {
+	c, err := s.collection(db, collection)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := bson.Marshal(filter)
+	if err != nil {
+		return nil, err
+	}
+	return &memCursor{docs: filterDocs(c.docs, raw), index: -1}, nil
+}
+
+type memCursor struct {
+	docs  []bson.Raw
+	index int
+}
+
+func (c *memCursor) Next(ctx context.Context) bool {
+	c.index++
+	return c.index < len(c.docs)
+}
+
+func (c *memCursor) Current() bson.Raw {
+	return c.docs[c.index]
+}
+
+func (c *memCursor) Err() error {
+	return nil
+}
+
+func (c *memCursor) Close(ctx context.Context) error {
+	return nil
+}
+
+func filterDocs(docs []bson.Raw, filter bson.Raw) []bson.Raw {
+	var matched []bson.Raw
+	for _, doc := range docs {
+		if matchesFilter(doc, filter) {
+			matched = append(matched, doc)
+		}
+	}
+	return matched
+}
+
+// matchesFilter supports equality, comparison operators, $nin, $nor and
+// $jsonSchema.
+func matchesFilter(doc, filter bson.Raw) bool {
+	elements, _ := filter.Elements()
+	for _, element := range elements {
+		value := element.Value()
+		switch element.Key() {
+		case "$nor":
+			branches, _ := value.Array().Values()
+			for _, branch := range branches {
+				if matchesFilter(doc, branch.Document()) {
+					return false
+				}
+			}
+			continue
+		case "$jsonSchema":
+			if !matchesSchema(bson.RawValue{Type: bsontype.EmbeddedDocument, Value: doc}, value.Document()) {
+				return false
+			}
+			continue
+		}
+
+		field := doc.Lookup(element.Key())
+		operators, ok := value.DocumentOK()
+		if !ok {
+			if field.Type == 0 || compareValues(field, value) != 0 {
+				return false
+			}
+			continue
+		}
+		opElements, _ := operators.Elements()
+		for _, op := range opElements {
+			operand := op.Value()
+			switch op.Key() {
+			case "$nin":
+				values, _ := operand.Array().Values()
+				for _, v := range values {
+					if field.Type != 0 && compareValues(field, v) == 0 {
+						return false
+					}
+				}
+				continue
+			}
+			if field.Type == 0 {
+				return false
+			}
+			c := compareValues(field, operand)
+			var ok bool
+			switch op.Key() {
+			case "$gte":
+				ok = c >= 0
+			case "$gt":
+				ok = c > 0
+			case "$lte":
+				ok = c <= 0
+			case "$lt":
+				ok = c < 0
+			}
+			if !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+var memBsonTypes = map[bsontype.Type]string{
+	bsontype.Double:           "double",
+	bsontype.String:           "string",
+	bsontype.EmbeddedDocument: "object",
+	bsontype.Array:            "array",
+	bsontype.ObjectID:         "objectId",
+	bsontype.Boolean:          "bool",
+	bsontype.DateTime:         "date",
+	bsontype.Null:             "null",
+	bsontype.Int32:            "int",
+	bsontype.Int64:            "long",
+	bsontype.Decimal128:       "decimal",
+	bsontype.Binary:           "binData",
+}
+
+// matchesSchema supports the keywords used in the schemas derived by the
+// schema builder.
+func matchesSchema(value bson.RawValue, schema bson.Raw) bool {
+	if anyOf, err := schema.LookupErr("anyOf"); err == nil {
+		branches, _ := anyOf.Array().Values()
+		for _, branch := range branches {
+			if matchesSchema(value, branch.Document()) {
+				return true
+			}
+		}
+		return false
+	}
+	if bsonType, err := schema.LookupErr("bsonType"); err == nil && memBsonTypes[value.Type] != bsonType.StringValue() {
+		return false
+	}
+
+	switch value.Type {
+	case bsontype.EmbeddedDocument:
+		doc := value.Document()
+		properties, _ := schema.Lookup("properties").DocumentOK()
+		additionalProperties, hasAdditional := schema.Lookup("additionalProperties").BooleanOK()
+		elements, _ := doc.Elements()
+		for _, element := range elements {
+			if property, err := properties.LookupErr(element.Key()); err == nil {
+				if !matchesSchema(element.Value(), property.Document()) {
+					return false
+				}
+			} else if hasAdditional && !additionalProperties {
+				return false
+			}
+		}
+		if required, ok := schema.Lookup("required").ArrayOK(); ok {
+			keys, _ := required.Values()
+			for _, key := range keys {
+				if _, err := doc.LookupErr(key.StringValue()); err != nil {
+					return false
+				}
+			}
+		}
+	case bsontype.Array:
+		values, _ := value.Array().Values()
+		if maxItems, ok := schema.Lookup("maxItems").Int32OK(); ok && len(values) > int(maxItems) {
+			return false
+		}
+		if items, ok := schema.Lookup("items").DocumentOK(); ok {
+			for _, v := range values {
+				if !matchesSchema(v, items) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// compareValues orders minKey, null, numbers, strings and maxKey.
+func compareValues(a, b bson.RawValue) int {
+	rank := func(v bson.RawValue) int {
+		switch v.Type {
+		case bsontype.MinKey:
+			return 0
+		case bsontype.Null:
+			return 1
+		case bsontype.Int32, bsontype.Int64, bsontype.Double:
+			return 2
+		case bsontype.String:
+			return 3
+		case bsontype.MaxKey:
+			return 5
+		}
+		return 4
+	}
+	if ra, rb := rank(a), rank(b); ra != rb {
+		return ra - rb
+	}
+	switch rank(a) {
+	case 2:
+		fa, fb := numberValue(a), numberValue(b)
+		if fa < fb {
+			return -1
+		} else if fa > fb {
+			return 1
+		}
+	case 3:
+		if a.StringValue() < b.StringValue() {
+			return -1
+		} else if a.StringValue() > b.StringValue() {
+			return 1
+		}
+	}
+	return 0
+}
+
+func numberValue(v bson.RawValue) float64 {
+	switch v.Type {
+	case bsontype.Int32:
+		return float64(v.Int32())
+	case bsontype.Int64:
+		return float64(v.Int64())
+	}
+	return v.Double()
+}
+
+func newCollection(t *testing.T, name string, docs ...bson.D) *memCollection {
+	return &memCollection{
+		info: catalog.CollectionInfo{Name: name, Type: catalog.CollectionTypeCollection},
+		docs: marshalDocs(t, docs...),
+	}
+}
+
+// numberedDocs returns n documents with _ids from 1 to n, built by doc.
+func numberedDocs(n int, doc func(i int) bson.D) []bson.D {
+	var docs []bson.D
+	for i := 1; i <= n; i++ {
+		docs = append(docs, append(bson.D{{Key: "_id", Value: int32(i)}}, doc(i)...))
+	}
+	return docs
+}
+
+func int32Value(i int32) bson.RawValue {
+	return bson.RawValue{Type: bsontype.Int32, Value: bsoncore.AppendInt32(nil, i)}
+}
+
+// equalRawValues compares bson.RawValues, whose registry is unexported.
+var equalRawValues = cmp.Comparer(func(a, b bson.RawValue) bool {
+	return a.Equal(b)
+})
+
+func TestGetPartitions(t *testing.T) {
+	ctx := context.Background()
+	docs := numberedDocs(40, func(i int) bson.D { return bson.D{{Key: "a", Value: int32(i)}} })
+
+	t.Run("small collections are a single partition", func(t *testing.T) {
+		coll := newCollection(t, "foo", docs...)
+		service := &memDataService{dbs: map[string][]*memCollection{"test": {coll}}}
+
+		partitioned, err := catalog.GetPartitions(ctx, service, "test", coll.info)
+		if err != nil {
+			t.Fatalf("expected err to be nil, got '%s'", err)
+		}
+		expected := catalog.PartitionedCollection{
+			Partitions:   []catalog.Partition{{Min: int32Value(1), Max: int32Value(40), MaxInclusive: true}},
+			PartitionKey: "_id",
+			Hint:         bson.D{{Key: "_id", Value: 1}},
+		}
+		if diff := cmp.Diff(expected, partitioned, equalRawValues); diff != "" {
+			t.Fatalf("unexpected partitions (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("large collections are split", func(t *testing.T) {
+		coll := newCollection(t, "foo", docs...)
+		coll.size = 3 * catalog.PartitionSizeInBytes
+		service := &memDataService{dbs: map[string][]*memCollection{"test": {coll}}}
+
+		partitioned, err := catalog.GetPartitions(ctx, service, "test", coll.info)
+		if err != nil {
+			t.Fatalf("expected err to be nil, got '%s'", err)
+		}
+		// 4 partitions out of 40 documents are sampled at a rate of
+		// 0.2, which splits the collection on every fifth _id
+		var expected []catalog.Partition
+		min := int32(1)
+		for max := int32(5); max <= 40; max += 5 {
+			expected = append(expected, catalog.Partition{Min: int32Value(min), Max: int32Value(max)})
+			min = max
+		}
+		expected = append(expected, catalog.Partition{Min: int32Value(40), Max: int32Value(40), MaxInclusive: true})
+		if diff := cmp.Diff(expected, partitioned.Partitions, equalRawValues); diff != "" {
+			t.Fatalf("unexpected partitions (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("sampling failures fall back to a single partition", func(t *testing.T) {
+		coll := newCollection(t, "foo", docs...)
+		coll.size = 3 * catalog.PartitionSizeInBytes
+		service := &memDataService{dbs: map[string][]*memCollection{"test": {coll}}, failSampling: true}
+
+		partitioned, err := catalog.GetPartitions(ctx, service, "test", coll.info)
+		if err != nil {
+			t.Fatalf("expected err to be nil, got '%s'", err)
+		}
+		expected := []catalog.Partition{{Min: int32Value(1), Max: int32Value(40), MaxInclusive: true}}
+		if diff := cmp.Diff(expected, partitioned.Partitions, equalRawValues); diff != "" {
+			t.Fatalf("unexpected partitions (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("timeseries collections are partitioned on their timeField", func(t *testing.T) {
+		coll := newCollection(t, "foo", bson.D{{Key: "t", Value: int32(3)}, {Key: "m", Value: "x"}}, bson.D{{Key: "t", Value: int32(7)}, {Key: "m", Value: "y"}})
+		coll.info.Type = catalog.CollectionTypeTimeseries
+		coll.info.Options.Timeseries = &catalog.TimeseriesOptions{TimeField: "t", MetaField: "m"}
+		service := &memDataService{dbs: map[string][]*memCollection{"test": {coll}}}
+
+		partitioned, err := catalog.GetPartitions(ctx, service, "test", coll.info)
+		if err != nil {
+			t.Fatalf("expected err to be nil, got '%s'", err)
+		}
+		expected := catalog.PartitionedCollection{
+			Partitions:   []catalog.Partition{{Min: int32Value(3), Max: int32Value(7), MaxInclusive: true}},
+			PartitionKey: "t",
+			Hint:         bson.D{{Key: "m", Value: 1}, {Key: "t", Value: 1}},
+		}
+		if diff := cmp.Diff(expected, partitioned, equalRawValues); diff != "" {
+			t.Fatalf("unexpected partitions (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestGetPartitionsErrors(t *testing.T) {
+	timeseries := newCollection(t, "ts", bson.D{{Key: "t", Value: int32(1)}})
+	timeseries.info.Type = catalog.CollectionTypeTimeseries
+	empty := newCollection(t, "empty")
+
+	tests := []struct {
+		name     string
+		coll     *memCollection
+		expected error
+	}{
+		{
+			name:     "timeseries collection without timeField",
+			coll:     timeseries,
+			expected: catalog.ErrNoTimeField,
+		},
+		{
+			name:     "empty collection",
+			coll:     empty,
+			expected: catalog.ErrEmptyCollection,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service := &memDataService{dbs: map[string][]*memCollection{"test": {test.coll}}}
+			_, err := catalog.GetPartitions(context.Background(), service, "test", test.coll.info)
+			if !errors.Is(err, test.expected) {
+				t.Fatalf("expected '%s', got '%v'", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestDeriveSchemaForCollection(t *testing.T) {
+	ctx := context.Background()
+	// more documents than are read per iteration, with fields that
+	// vary between documents
+	docs := numberedDocs(50, func(i int) bson.D {
+		doc := bson.D{{Key: "a", Value: int32(i)}}
+		switch i % 3 {
+		case 0:
+			doc = append(doc, bson.E{Key: "b", Value: "x"})
+		case 1:
+			doc = append(doc, bson.E{Key: "b", Value: bson.A{int64(i)}})
+		}
+		if i == 42 {
+			doc = append(doc, bson.E{Key: "c", Value: bson.D{{Key: "d", Value: nil}}})
+		}
+		return doc
+	})
+	expected, err := catalog.InferSchema(marshalDocs(t, docs...), catalog.InferOptions{})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	for _, size := range []int64{0, 3 * catalog.PartitionSizeInBytes} {
+		t.Run(fmt.Sprintf("size %d", size), func(t *testing.T) {
+			coll := newCollection(t, "foo", docs...)
+			coll.size = size
+			service := &memDataService{dbs: map[string][]*memCollection{"test": {coll}}}
+
+			derived, err := catalog.DeriveSchemaForCollection(ctx, service, "test", "foo", catalog.DerivedSchema{})
+			if err != nil {
+				t.Fatalf("expected err to be nil, got '%s'", err)
+			}
+			schema, err := derived.JSONSchema()
+			if err != nil {
+				t.Fatalf("expected err to be nil, got '%s'", err)
+			}
+			if diff := cmp.Diff(bson.Raw(expected).String(), bson.Raw(schema).String()); diff != "" {
+				t.Fatalf("unexpected schema (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("missing collection", func(t *testing.T) {
+		service := &memDataService{dbs: map[string][]*memCollection{}}
+		_, err := catalog.DeriveSchemaForCollection(ctx, service, "test", "foo", catalog.DerivedSchema{})
+		if !errors.Is(err, catalog.ErrNoCollection) {
+			t.Fatalf("expected ErrNoCollection, got '%v'", err)
+		}
+	})
+}
+
+func TestDeriveSchemaForView(t *testing.T) {
+	coll := newCollection(t, "foo",
+		bson.D{{Key: "_id", Value: int32(1)}, {Key: "kind", Value: "a"}, {Key: "x", Value: int32(1)}},
+		bson.D{{Key: "_id", Value: int32(2)}, {Key: "kind", Value: "b"}, {Key: "y", Value: true}},
+	)
+	service := &memDataService{dbs: map[string][]*memCollection{"test": {coll}}}
+	view := catalog.CollectionInfo{
+		Name: "bar",
+		Type: catalog.CollectionTypeView,
+		Options: catalog.CollectionOptions{
+			ViewOn:   "foo",
+			Pipeline: []bson.D{{{Key: "$match", Value: bson.D{{Key: "kind", Value: "a"}}}}},
+		},
+	}
+
+	derived, err := catalog.DeriveSchemaForView(context.Background(), service, "test", view)
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+	schema, err := derived.JSONSchema()
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+	expected, err := catalog.InferSchema(coll.docs[:1], catalog.InferOptions{})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+	if diff := cmp.Diff(bson.Raw(expected).String(), bson.Raw(schema).String()); diff != "" {
+		t.Fatalf("unexpected schema (-want +got):\n%s", diff)
+	}
+}
+
+func TestBuildCatalog(t *testing.T) {
+	foo := newCollection(t, "foo", bson.D{{Key: "_id", Value: int32(1)}, {Key: "a", Value: "x"}})
+	view := &memCollection{info: catalog.CollectionInfo{
+		Name:    "fooView",
+		Type:    catalog.CollectionTypeView,
+		Options: catalog.CollectionOptions{ViewOn: "foo"},
+	}}
+	emptyView := &memCollection{info: catalog.CollectionInfo{
+		Name: "emptyView",
+		Type: catalog.CollectionTypeView,
+		Options: catalog.CollectionOptions{
+			ViewOn:   "foo",
+			Pipeline: []bson.D{{{Key: "$match", Value: bson.D{{Key: "a", Value: "y"}}}}},
+		},
+	}}
+	broken := newCollection(t, "broken", bson.D{{Key: "t", Value: int32(1)}})
+	broken.info.Type = catalog.CollectionTypeTimeseries
+	service := &memDataService{dbs: map[string][]*memCollection{
+		"test": {
+			foo,
+			view,
+			emptyView,
+			broken,
+			newCollection(t, "empty"),
+			newCollection(t, catalog.SQLSchemasCollection, bson.D{{Key: "_id", Value: "foo"}}),
+			newCollection(t, "system.views", bson.D{{Key: "_id", Value: "test.fooView"}}),
+		},
+		"other": {newCollection(t, "bar", bson.D{{Key: "_id", Value: int32(1)}})},
+	}}
+
+	result, err := catalog.BuildCatalog(context.Background(), service)
+	var errs catalog.CollectionErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected CollectionErrors, got '%v'", err)
+	}
+	if len(errs) != 1 || errs[0].Collection != "broken" || !errors.Is(errs[0], catalog.ErrNoTimeField) {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	object := func(properties bson.D, required bson.A) string {
+		return marshalSchema(t, bson.D{
+			{Key: "bsonType", Value: "object"},
+			{Key: "properties", Value: properties},
+			{Key: "required", Value: required},
+			{Key: "additionalProperties", Value: false},
+		})
+	}
+	fooSchema := object(bson.D{
+		{Key: "_id", Value: bson.D{{Key: "bsonType", Value: "int"}}},
+		{Key: "a", Value: bson.D{{Key: "bsonType", Value: "string"}}},
+	}, bson.A{"_id", "a"})
+	expected := map[string]map[string]string{
+		"test":  {"foo": fooSchema, "fooView": fooSchema},
+		"other": {"bar": object(bson.D{{Key: "_id", Value: bson.D{{Key: "bsonType", Value: "int"}}}}, bson.A{"_id"})},
+	}
+	if diff := cmp.Diff(expected, schemaJSON(result)); diff != "" {
+		t.Fatalf("unexpected catalog (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,186 @@
+// Package catalog builds the catalog schemas passed to the mongosql
+// translation functions from the places schemas are usually kept, such
+// as the __sql_schemas collection written by the schema builder.
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// SQLSchemasCollection is the name of the collection that holds the
+// schemas of the collections of a database. Each of its documents
+// describes one collection: its _id is the name of the collection,
+// and its schema field is the JSON Schema of the collection's
+// documents.
+const SQLSchemasCollection = "__sql_schemas"
+
+// CatalogSchema maps database names to collection names to the JSON
+// Schemas that describe the documents of the collections. It can be
+// used as the CatalogSchema of mongosql.TranslationArgs.
+type CatalogSchema map[string]map[string]bsoncore.Document
+
+// The problems reported by an EntryError.
+var (
+	// ErrInvalidDocument is reported for an entry that is not a valid
+	// BSON document
+	ErrInvalidDocument = errors.New("invalid BSON document")
+	// ErrMissingField is reported for an entry without an _id or a
+	// schema field
+	ErrMissingField = errors.New("missing field")
+	// ErrWrongType is reported for an entry whose _id is not a string
+	// or whose schema is not a document
+	ErrWrongType = errors.New("wrong type")
+	// ErrDuplicateCollection is reported for an entry for a collection
+	// that an earlier entry already describes
+	ErrDuplicateCollection = errors.New("duplicate collection")
+)
+
+// EntryError reports a malformed document of a __sql_schemas
+// collection. Use errors.Is with the Err variables of this package to
+// find out what is wrong with the document.
+type EntryError struct {
+	// Database is the database the entry describes a collection of
+	Database string
+	// Index is the position of the entry in the documents it was read
+	// from
+	Index int
+	// Collection is the _id of the entry, or the empty string if the
+	// entry has no string _id
+	Collection string
+	// Field is the malformed field of the entry, or the empty string
+	// if the problem is not with a single field
+	Field string
+	// Err is the problem with the entry
+	Err error
+}
+
+// Error implements the error interface.
+func (e *EntryError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "malformed %s entry %d in database %q", SQLSchemasCollection, e.Index, e.Database)
+	if e.Collection != "" {
+		fmt.Fprintf(&b, " for collection %q", e.Collection)
+	}
+	if e.Field != "" {
+		fmt.Fprintf(&b, ": field %q", e.Field)
+	}
+	fmt.Fprintf(&b, ": %s", e.Err)
+	return b.String()
+}
+
+// Unwrap returns the problem with the entry.
+func (e *EntryError) Unwrap() error {
+	return e.Err
+}
+
+// EntryErrors holds an EntryError for each malformed entry found while
+// loading a catalog schema, in the order the entries were read.
+type EntryErrors []*EntryError
+
+// Error implements the error interface by listing every error.
+func (e EntryErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// FromSQLSchemaDocuments returns the CatalogSchema described by docs,
+// the documents of the __sql_schemas collection of the database db.
+// Fields of the documents other than _id and schema are ignored.
+//
+// Every document is checked, and the returned error, if any, is an
+// EntryErrors holding an EntryError for each malformed document. The
+// returned CatalogSchema holds the well-formed documents even when the
+// error is non-nil, so that callers can choose to use them.
+func FromSQLSchemaDocuments(db string, docs []bson.Raw) (CatalogSchema, error) {
+	collections := map[string]bsoncore.Document{}
+	var errs EntryErrors
+	for i, doc := range docs {
+		collection, schema, err := parseEntry(doc)
+		if err == nil {
+			if _, ok := collections[collection]; ok {
+				err = &EntryError{Err: ErrDuplicateCollection}
+			}
+		}
+		if err != nil {
+			err.Database, err.Index = db, i
+			if err.Collection == "" {
+				err.Collection = collection
+			}
+			errs = append(errs, err)
+			continue
+		}
+		collections[collection] = schema
+	}
+
+	catalogSchema := CatalogSchema{db: collections}
+	if len(errs) > 0 {
+		return catalogSchema, errs
+	}
+	return catalogSchema, nil
+}
+
+// parseEntry returns the collection name and schema of a document of a
+// __sql_schemas collection. The collection name is returned whenever
+// the document has one, even if the document is malformed. The
+// Database and Index of a returned error are left for the caller to
+// fill in.
+func parseEntry(doc bson.Raw) (string, bsoncore.Document, *EntryError) {
+	if err := doc.Validate(); err != nil {
+		return "", nil, &EntryError{Err: fmt.Errorf("%w: %s", ErrInvalidDocument, err)}
+	}
+
+	id, err := doc.LookupErr("_id")
+	if err != nil {
+		return "", nil, &EntryError{Field: "_id", Err: ErrMissingField}
+	}
+	collection, ok := id.StringValueOK()
+	if !ok {
+		return "", nil, &EntryError{Field: "_id", Err: fmt.Errorf("%w: expected a string, got %s", ErrWrongType, id.Type)}
+	}
+
+	schema, err := doc.LookupErr("schema")
+	if err != nil {
+		return collection, nil, &EntryError{Field: "schema", Err: ErrMissingField}
+	}
+	if schema.Type != bsontype.EmbeddedDocument {
+		return collection, nil, &EntryError{Field: "schema", Err: fmt.Errorf("%w: expected a document, got %s", ErrWrongType, schema.Type)}
+	}
+
+	// copy the schema so that it does not share memory with doc, which
+	// may be reused by a cursor
+	return collection, append(bsoncore.Document(nil), schema.Value...), nil
+}
+
+// LoadFromDatabase reads the __sql_schemas collection of db and returns
+// the CatalogSchema it describes, as FromSQLSchemaDocuments does. An
+// error reading the collection is returned as is; malformed documents
+// are reported as EntryErrors along with the schemas of the well-formed
+// documents.
+func LoadFromDatabase(ctx context.Context, db *mongo.Database) (CatalogSchema, error) {
+	cursor, err := db.Collection(SQLSchemasCollection).Find(ctx, bson.D{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s.%s: %w", db.Name(), SQLSchemasCollection, err)
+	}
+	defer cursor.Close(ctx)
+
+	var docs []bson.Raw
+	for cursor.Next(ctx) {
+		docs = append(docs, append(bson.Raw(nil), cursor.Current...))
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s.%s: %w", db.Name(), SQLSchemasCollection, err)
+	}
+
+	return FromSQLSchemaDocuments(db.Name(), docs)
+}
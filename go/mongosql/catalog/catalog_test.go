@@ -0,0 +1,183 @@
+package catalog_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mongodb/mongosql/go/mongosql/catalog"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// loadFixture reads the documents of a testdata file, which holds an
+// extended JSON object with a documents array.
+func loadFixture(t *testing.T, name string) []bson.Raw {
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("failed to read fixture %s: %s", name, err)
+	}
+	var fixture struct {
+		Documents []bson.Raw `bson:"documents"`
+	}
+	if err := bson.UnmarshalExtJSON(data, false, &fixture); err != nil {
+		t.Fatalf("failed to parse fixture %s: %s", name, err)
+	}
+	return fixture.Documents
+}
+
+// schemaJSON returns the relaxed extended JSON of each schema of
+// catalogSchema, which is easier to compare than the raw BSON.
+func schemaJSON(catalogSchema catalog.CatalogSchema) map[string]map[string]string {
+	result := map[string]map[string]string{}
+	for db, collections := range catalogSchema {
+		result[db] = map[string]string{}
+		for collection, schema := range collections {
+			result[db][collection] = bson.Raw(schema).String()
+		}
+	}
+	return result
+}
+
+func marshalSchema(t *testing.T, schema bson.D) string {
+	data, err := bson.Marshal(schema)
+	if err != nil {
+		t.Fatalf("failed to marshal schema: %s", err)
+	}
+	return bson.Raw(data).String()
+}
+
+func TestFromSQLSchemaDocuments(t *testing.T) {
+	docs := loadFixture(t, "sql_schemas.json")
+
+	catalogSchema, err := catalog.FromSQLSchemaDocuments("test", docs)
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	expected := map[string]map[string]string{
+		"test": {
+			"foo": marshalSchema(t, bson.D{
+				{Key: "bsonType", Value: "object"},
+				{Key: "properties", Value: bson.D{
+					{Key: "a", Value: bson.D{{Key: "bsonType", Value: "int"}}},
+					{Key: "b", Value: bson.D{{Key: "bsonType", Value: "string"}}},
+				}},
+				{Key: "required", Value: bson.A{"a"}},
+				{Key: "additionalProperties", Value: false},
+			}),
+			"bar": marshalSchema(t, bson.D{
+				{Key: "bsonType", Value: "object"},
+				{Key: "properties", Value: bson.D{
+					{Key: "c", Value: bson.D{{Key: "bsonType", Value: bson.A{"double", "null"}}}},
+				}},
+				{Key: "additionalProperties", Value: true},
+			}),
+		},
+	}
+	if diff := cmp.Diff(expected, schemaJSON(catalogSchema)); diff != "" {
+		t.Fatalf("unexpected catalog schema (-want +got):\n%s", diff)
+	}
+}
+
+func TestFromSQLSchemaDocumentsEmpty(t *testing.T) {
+	catalogSchema, err := catalog.FromSQLSchemaDocuments("test", nil)
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+	expected := catalog.CatalogSchema{"test": map[string]bsoncore.Document{}}
+	if diff := cmp.Diff(expected, catalogSchema); diff != "" {
+		t.Fatalf("unexpected catalog schema (-want +got):\n%s", diff)
+	}
+}
+
+func TestFromSQLSchemaDocumentsMalformed(t *testing.T) {
+	docs := loadFixture(t, "malformed_sql_schemas.json")
+	// a document whose length prefix does not match its contents
+	docs = append(docs, bson.Raw{0x05, 0x00, 0x00, 0x00, 0x01, 0x00})
+
+	catalogSchema, err := catalog.FromSQLSchemaDocuments("test", docs)
+	if err == nil {
+		t.Fatalf("expected error to be non-nil, but it was nil")
+	}
+
+	var entryErrs catalog.EntryErrors
+	if !errors.As(err, &entryErrs) {
+		t.Fatalf("expected an EntryErrors, got %T", err)
+	}
+
+	type entrySummary struct {
+		Database   string
+		Index      int
+		Collection string
+		Field      string
+		Sentinel   error
+	}
+	sentinels := []error{
+		catalog.ErrInvalidDocument,
+		catalog.ErrMissingField,
+		catalog.ErrWrongType,
+		catalog.ErrDuplicateCollection,
+	}
+	var summaries []entrySummary
+	for _, entryErr := range entryErrs {
+		summary := entrySummary{
+			Database:   entryErr.Database,
+			Index:      entryErr.Index,
+			Collection: entryErr.Collection,
+			Field:      entryErr.Field,
+		}
+		for _, sentinel := range sentinels {
+			if errors.Is(entryErr, sentinel) {
+				summary.Sentinel = sentinel
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+
+	expected := []entrySummary{
+		{Database: "test", Index: 1, Field: "_id", Sentinel: catalog.ErrMissingField},
+		{Database: "test", Index: 2, Field: "_id", Sentinel: catalog.ErrWrongType},
+		{Database: "test", Index: 3, Collection: "bar", Field: "schema", Sentinel: catalog.ErrMissingField},
+		{Database: "test", Index: 4, Collection: "baz", Field: "schema", Sentinel: catalog.ErrWrongType},
+		{Database: "test", Index: 5, Collection: "foo", Sentinel: catalog.ErrDuplicateCollection},
+		{Database: "test", Index: 7, Sentinel: catalog.ErrInvalidDocument},
+	}
+	if diff := cmp.Diff(expected, summaries, cmp.Comparer(func(a, b error) bool { return a == b })); diff != "" {
+		t.Fatalf("unexpected entry errors (-want +got):\n%s", diff)
+	}
+
+	// the well-formed entries are returned along with the errors, and
+	// the first entry for a collection wins
+	expectedSchemas := map[string]map[string]string{
+		"test": {
+			"foo": marshalSchema(t, bson.D{
+				{Key: "bsonType", Value: "object"},
+				{Key: "properties", Value: bson.D{{Key: "a", Value: bson.D{{Key: "bsonType", Value: "int"}}}}},
+			}),
+			"qux": marshalSchema(t, bson.D{
+				{Key: "bsonType", Value: "object"},
+				{Key: "properties", Value: bson.D{}},
+			}),
+		},
+	}
+	if diff := cmp.Diff(expectedSchemas, schemaJSON(catalogSchema)); diff != "" {
+		t.Fatalf("unexpected catalog schema (-want +got):\n%s", diff)
+	}
+}
+
+func TestEntryErrorMessage(t *testing.T) {
+	err := &catalog.EntryError{
+		Database:   "test",
+		Index:      3,
+		Collection: "bar",
+		Field:      "schema",
+		Err:        catalog.ErrMissingField,
+	}
+	expected := `malformed __sql_schemas entry 3 in database "test" for collection "bar": field "schema": missing field`
+	if err.Error() != expected {
+		t.Fatalf("expected message %q, got %q", expected, err.Error())
+	}
+}
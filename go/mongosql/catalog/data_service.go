@@ -0,0 +1,169 @@
+package catalog
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CollectionType is the type of an entry returned by listCollections.
+type CollectionType string
+
+// The types of listCollections entries.
+const (
+	CollectionTypeCollection CollectionType = "collection"
+	CollectionTypeView       CollectionType = "view"
+	CollectionTypeTimeseries CollectionType = "timeseries"
+)
+
+// CollectionInfo describes a collection, as returned by
+// listCollections.
+type CollectionInfo struct {
+	// Name is the name of the collection
+	Name string `bson:"name"`
+	// Type is the type of the collection
+	Type CollectionType `bson:"type"`
+	// Options holds the options of views and timeseries collections
+	Options CollectionOptions `bson:"options"`
+}
+
+// CollectionOptions are the options of a collection. The options of
+// views and of timeseries collections are both represented here, since
+// listCollections uses the same field for them; check the Type of the
+// CollectionInfo before using them.
+type CollectionOptions struct {
+	// ViewOn is the name of the source collection of a view
+	ViewOn string `bson:"viewOn,omitempty"`
+	// Pipeline is the aggregation pipeline of a view
+	Pipeline []bson.D `bson:"pipeline,omitempty"`
+	// Timeseries holds the options of a timeseries collection
+	Timeseries *TimeseriesOptions `bson:"timeseries,omitempty"`
+}
+
+// TimeseriesOptions are the options of a timeseries collection.
+type TimeseriesOptions struct {
+	// TimeField is the field that holds the time of each measurement
+	TimeField string `bson:"timeField"`
+	// MetaField is the field that holds the metadata of each
+	// measurement, or the empty string if there is none
+	MetaField string `bson:"metaField,omitempty"`
+}
+
+// AggregateOptions are the options of DataService.Aggregate.
+type AggregateOptions struct {
+	// KeyHint is the index to use, or nil to let the server choose
+	KeyHint bson.D
+}
+
+// Cursor iterates over the documents returned by a DataService.
+type Cursor interface {
+	// Next advances the cursor to the next document, and returns false
+	// when there are no more documents or an error occurred
+	Next(ctx context.Context) bool
+	// Current returns the current document. It is only valid until the
+	// next call to Next.
+	Current() bson.Raw
+	// Err returns the error that stopped the iteration, if any
+	Err() error
+	// Close releases the resources of the cursor
+	Close(ctx context.Context) error
+}
+
+// DataService is the database access used to derive schemas from the
+// documents of collections, like the LocalDataService of the Rust
+// schema builder. MongoDataService implements it with the Go driver.
+type DataService interface {
+	// ListDatabaseNames returns the names of the databases
+	ListDatabaseNames(ctx context.Context) ([]string, error)
+	// ListCollections returns the collections of the database db
+	ListCollections(ctx context.Context, db string) ([]CollectionInfo, error)
+	// Aggregate runs pipeline on the collection db.collection
+	Aggregate(ctx context.Context, db, collection string, pipeline []bson.D, opts AggregateOptions) (Cursor, error)
+	// Find returns the documents of the collection db.collection that
+	// match filter
+	Find(ctx context.Context, db, collection string, filter bson.D) (Cursor, error)
+}
+
+// MongoDataService is a DataService backed by a MongoDB client.
+type MongoDataService struct {
+	client *mongo.Client
+}
+
+// NewMongoDataService creates a MongoDataService that uses client.
+func NewMongoDataService(client *mongo.Client) *MongoDataService {
+	return &MongoDataService{client: client}
+}
+
+// ListDatabaseNames implements DataService.
+func (s *MongoDataService) ListDatabaseNames(ctx context.Context) ([]string, error) {
+	return s.client.ListDatabaseNames(ctx, bson.D{})
+}
+
+// ListCollections implements DataService. It runs listCollections with
+// authorizedCollections, so that users who can only read some of the
+// collections of db can still list them, and skips malformed entries.
+func (s *MongoDataService) ListCollections(ctx context.Context, db string) ([]CollectionInfo, error) {
+	cursor, err := s.client.Database(db).RunCommandCursor(ctx, bson.D{
+		{Key: "listCollections", Value: 1},
+		{Key: "authorizedCollections", Value: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var collections []CollectionInfo
+	for cursor.Next(ctx) {
+		var info CollectionInfo
+		if err := cursor.Decode(&info); err != nil {
+			continue
+		}
+		collections = append(collections, info)
+	}
+	return collections, cursor.Err()
+}
+
+// Aggregate implements DataService.
+func (s *MongoDataService) Aggregate(ctx context.Context, db, collection string, pipeline []bson.D, opts AggregateOptions) (Cursor, error) {
+	aggregateOptions := options.Aggregate()
+	if opts.KeyHint != nil {
+		aggregateOptions.SetHint(opts.KeyHint)
+	}
+	cursor, err := s.client.Database(db).Collection(collection).Aggregate(ctx, pipeline, aggregateOptions)
+	if err != nil {
+		return nil, err
+	}
+	return mongoCursor{cursor}, nil
+}
+
+// Find implements DataService.
+func (s *MongoDataService) Find(ctx context.Context, db, collection string, filter bson.D) (Cursor, error) {
+	cursor, err := s.client.Database(db).Collection(collection).Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return mongoCursor{cursor}, nil
+}
+
+// mongoCursor adapts a driver cursor to the Cursor interface.
+type mongoCursor struct {
+	cursor *mongo.Cursor
+}
+
+func (c mongoCursor) Next(ctx context.Context) bool {
+	return c.cursor.Next(ctx)
+}
+
+func (c mongoCursor) Current() bson.Raw {
+	return c.cursor.Current
+}
+
+func (c mongoCursor) Err() error {
+	return c.cursor.Err()
+}
+
+func (c mongoCursor) Close(ctx context.Context) error {
+	return c.cursor.Close(ctx)
+}
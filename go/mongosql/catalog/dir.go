@@ -0,0 +1,166 @@
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// schemaFileExtension is the extension of the schema files read by
+// LoadDir and written by WriteDir.
+const schemaFileExtension = ".json"
+
+// ErrUnexpectedFile is reported for a schema file that is not at
+// <db>/<collection>.json in the directory being loaded.
+var ErrUnexpectedFile = errors.New("schema files must be at <db>/<collection>" + schemaFileExtension)
+
+// FileError reports a schema file that could not be loaded.
+type FileError struct {
+	// Path is the path of the file within the directory being loaded
+	Path string
+	// Err is the problem with the file. It is a *SchemaError if the
+	// file holds a JSON Schema that mongosql cannot read.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *FileError) Error() string {
+	return fmt.Sprintf("failed to load schema file %s: %s", e.Path, e.Err)
+}
+
+// Unwrap returns the problem with the file.
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// FileErrors holds a FileError for each problem found while loading a
+// directory of schema files, in the order the files were read.
+type FileErrors []*FileError
+
+// Error implements the error interface by listing every error.
+func (e FileErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// LoadDir returns the CatalogSchema described by the schema files of
+// fsys, where the schema of the collection c of the database d is kept
+// in the file d/c.json. Each file holds a JSON Schema as Extended JSON,
+// either canonical or relaxed, which also covers plain JSON. Files
+// without the .json extension are ignored.
+//
+// Every file is checked, and the returned error, if any, is a
+// FileErrors holding a FileError for each file that cannot be parsed,
+// is not in the expected place, or holds a schema that mongosql would
+// reject. The returned CatalogSchema holds the schemas of the other
+// files even when the error is non-nil. An error reading fsys itself
+// is returned as is, along with a nil CatalogSchema.
+func LoadDir(fsys fs.FS) (CatalogSchema, error) {
+	catalogSchema := CatalogSchema{}
+	var errs FileErrors
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || path.Ext(p) != schemaFileExtension {
+			return nil
+		}
+
+		db, file := path.Split(p)
+		db = strings.TrimSuffix(db, "/")
+		collection := strings.TrimSuffix(file, schemaFileExtension)
+		if db == "" || strings.Contains(db, "/") || collection == "" {
+			errs = append(errs, &FileError{Path: p, Err: ErrUnexpectedFile})
+			return nil
+		}
+
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		var schema bson.Raw
+		if err := bson.UnmarshalExtJSON(data, false, &schema); err != nil {
+			errs = append(errs, &FileError{Path: p, Err: err})
+			return nil
+		}
+		if schemaErrs := checkSchema(schema, ""); len(schemaErrs) > 0 {
+			for _, schemaErr := range schemaErrs {
+				errs = append(errs, &FileError{Path: p, Err: schemaErr})
+			}
+			return nil
+		}
+
+		if catalogSchema[db] == nil {
+			catalogSchema[db] = map[string]bsoncore.Document{}
+		}
+		catalogSchema[db][collection] = bsoncore.Document(schema)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(errs) > 0 {
+		return catalogSchema, errs
+	}
+	return catalogSchema, nil
+}
+
+// WriteDir writes the schemas of catalogSchema to the directory dir in
+// the layout read by LoadDir, as indented relaxed Extended JSON. The
+// directories of the databases are created as needed, and existing
+// files for the same collections are replaced; other files are left
+// alone. It fails without writing anything if a database or collection
+// name cannot be used as a file name.
+func WriteDir(dir string, catalogSchema CatalogSchema) error {
+	for db, collections := range catalogSchema {
+		if err := checkFileName(db); err != nil {
+			return fmt.Errorf("cannot write the schemas of database %q: %w", db, err)
+		}
+		for collection := range collections {
+			if err := checkFileName(collection); err != nil {
+				return fmt.Errorf("cannot write the schema of %s.%s: %w", db, collection, err)
+			}
+		}
+	}
+
+	for db, collections := range catalogSchema {
+		dbDir := filepath.Join(dir, db)
+		if err := os.MkdirAll(dbDir, 0o755); err != nil {
+			return fmt.Errorf("failed to create directory for database %q: %w", db, err)
+		}
+		for collection, schema := range collections {
+			data, err := bson.MarshalExtJSONIndent(bson.Raw(schema), false, false, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal the schema of %s.%s: %w", db, collection, err)
+			}
+			data = append(data, '\n')
+			if err := os.WriteFile(filepath.Join(dbDir, collection+schemaFileExtension), data, 0o644); err != nil {
+				return fmt.Errorf("failed to write the schema of %s.%s: %w", db, collection, err)
+			}
+		}
+	}
+	return nil
+}
+
+// checkFileName returns an error if name cannot be written as a single
+// path element by WriteDir.
+func checkFileName(name string) error {
+	switch {
+	case name == "", name == ".", name == "..":
+		return fmt.Errorf("%q is not a valid file name", name)
+	case strings.ContainsAny(name, `/\`):
+		return fmt.Errorf("%q contains a path separator", name)
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package catalog_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mongodb/mongosql/go/mongosql/catalog"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+func TestLoadDir(t *testing.T) {
+	fsys := fstest.MapFS{
+		"test/foo.json": {Data: []byte(`{
+			"bsonType": "object",
+			"properties": {"a": {"bsonType": "int"}, "b": {"bsonType": ["string", "null"]}},
+			"required": ["a"],
+			"additionalProperties": false
+		}`)},
+		"test/bar.baz.json": {Data: []byte(`{
+			"bsonType": "object",
+			"properties": {"c": {"bsonType": "array", "items": {"bsonType": "long"}, "maxItems": {"$numberInt": "2"}}}
+		}`)},
+		"other/foo.json": {Data: []byte(`{"bsonType": "object", "x-comment": "unknown keywords are ignored"}`)},
+		"test/README.md": {Data: []byte("files without the .json extension are ignored")},
+	}
+
+	catalogSchema, err := catalog.LoadDir(fsys)
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	expected := map[string]map[string]string{
+		"test": {
+			"foo": marshalSchema(t, bson.D{
+				{Key: "bsonType", Value: "object"},
+				{Key: "properties", Value: bson.D{
+					{Key: "a", Value: bson.D{{Key: "bsonType", Value: "int"}}},
+					{Key: "b", Value: bson.D{{Key: "bsonType", Value: bson.A{"string", "null"}}}},
+				}},
+				{Key: "required", Value: bson.A{"a"}},
+				{Key: "additionalProperties", Value: false},
+			}),
+			"bar.baz": marshalSchema(t, bson.D{
+				{Key: "bsonType", Value: "object"},
+				{Key: "properties", Value: bson.D{
+					{Key: "c", Value: bson.D{
+						{Key: "bsonType", Value: "array"},
+						{Key: "items", Value: bson.D{{Key: "bsonType", Value: "long"}}},
+						{Key: "maxItems", Value: int32(2)},
+					}},
+				}},
+			}),
+		},
+		"other": {
+			"foo": marshalSchema(t, bson.D{
+				{Key: "bsonType", Value: "object"},
+				{Key: "x-comment", Value: "unknown keywords are ignored"},
+			}),
+		},
+	}
+	if diff := cmp.Diff(expected, schemaJSON(catalogSchema)); diff != "" {
+		t.Fatalf("unexpected catalog schema (-want +got):\n%s", diff)
+	}
+}
+
+func TestLoadDirErrors(t *testing.T) {
+	fsys := fstest.MapFS{
+		"test/good.json":       {Data: []byte(`{"bsonType": "object"}`)},
+		"top.json":             {Data: []byte(`{"bsonType": "object"}`)},
+		"test/nested/foo.json": {Data: []byte(`{"bsonType": "object"}`)},
+		"test/syntax.json":     {Data: []byte(`{"bsonType": `)},
+		"test/types.json": {Data: []byte(`{
+			"bsonType": "object",
+			"properties": {
+				"a": {"bsonType": "number"},
+				"b/c": {"bsonType": 1},
+				"d": "string",
+				"e": {"bsonType": "array", "items": [{"bsonType": "int"}, 1], "maxItems": -1}
+			},
+			"required": "a",
+			"additionalProperties": "no",
+			"anyOf": [{"bsonType": ["int", "float"]}],
+			"oneOf": null
+		}`)},
+		"test/maxitems.json": {Data: []byte(`{"maxItems": 1.5}`)},
+	}
+
+	catalogSchema, err := catalog.LoadDir(fsys)
+	if err == nil {
+		t.Fatalf("expected error to be non-nil, but it was nil")
+	}
+	var fileErrs catalog.FileErrors
+	if !errors.As(err, &fileErrs) {
+		t.Fatalf("expected a FileErrors, got %T", err)
+	}
+
+	type fileErrorSummary struct {
+		Path     string
+		Pointer  string
+		Sentinel error
+	}
+	sentinels := []error{
+		catalog.ErrUnexpectedFile,
+		catalog.ErrWrongType,
+		catalog.ErrUnknownBsonType,
+		catalog.ErrOutOfRange,
+	}
+	var summaries []fileErrorSummary
+	for _, fileErr := range fileErrs {
+		summary := fileErrorSummary{Path: fileErr.Path}
+		var schemaErr *catalog.SchemaError
+		if errors.As(fileErr, &schemaErr) {
+			summary.Pointer = schemaErr.Pointer
+		}
+		for _, sentinel := range sentinels {
+			if errors.Is(fileErr, sentinel) {
+				summary.Sentinel = sentinel
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+
+	// fs.WalkDir visits files in lexical order
+	expected := []fileErrorSummary{
+		{Path: "test/maxitems.json", Pointer: "/maxItems", Sentinel: catalog.ErrWrongType},
+		{Path: "test/nested/foo.json", Sentinel: catalog.ErrUnexpectedFile},
+		{Path: "test/syntax.json"},
+		{Path: "test/types.json", Pointer: "/properties/a/bsonType", Sentinel: catalog.ErrUnknownBsonType},
+		{Path: "test/types.json", Pointer: "/properties/b~1c/bsonType", Sentinel: catalog.ErrWrongType},
+		{Path: "test/types.json", Pointer: "/properties/d", Sentinel: catalog.ErrWrongType},
+		{Path: "test/types.json", Pointer: "/properties/e/items/1", Sentinel: catalog.ErrWrongType},
+		{Path: "test/types.json", Pointer: "/properties/e/maxItems", Sentinel: catalog.ErrOutOfRange},
+		{Path: "test/types.json", Pointer: "/required", Sentinel: catalog.ErrWrongType},
+		{Path: "test/types.json", Pointer: "/additionalProperties", Sentinel: catalog.ErrWrongType},
+		{Path: "test/types.json", Pointer: "/anyOf/0/bsonType/1", Sentinel: catalog.ErrUnknownBsonType},
+		{Path: "top.json", Sentinel: catalog.ErrUnexpectedFile},
+	}
+	if diff := cmp.Diff(expected, summaries, cmp.Comparer(func(a, b error) bool { return a == b })); diff != "" {
+		t.Fatalf("unexpected file errors (-want +got):\n%s", diff)
+	}
+
+	expectedSchemas := map[string]map[string]string{
+		"test": {"good": marshalSchema(t, bson.D{{Key: "bsonType", Value: "object"}})},
+	}
+	if diff := cmp.Diff(expectedSchemas, schemaJSON(catalogSchema)); diff != "" {
+		t.Fatalf("unexpected catalog schema (-want +got):\n%s", diff)
+	}
+}
+
+func TestWriteDirRoundTrip(t *testing.T) {
+	schema := func(doc bson.D) bsoncore.Document {
+		data, err := bson.Marshal(doc)
+		if err != nil {
+			t.Fatalf("failed to marshal schema: %s", err)
+		}
+		return data
+	}
+	catalogSchema := catalog.CatalogSchema{
+		"test": {
+			"foo": schema(bson.D{
+				{Key: "bsonType", Value: "object"},
+				{Key: "properties", Value: bson.D{{Key: "a", Value: bson.D{{Key: "bsonType", Value: "int"}}}}},
+				{Key: "required", Value: bson.A{"a"}},
+			}),
+			"bar.baz": schema(bson.D{{Key: "bsonType", Value: bson.A{"object", "null"}}}),
+		},
+		"other": {
+			"foo": schema(bson.D{{Key: "bsonType", Value: "array"}, {Key: "maxItems", Value: int32(3)}}),
+		},
+	}
+
+	dir := t.TempDir()
+	if err := catalog.WriteDir(dir, catalogSchema); err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "other", "foo.json"))
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+	expectedFile := "{\n  \"bsonType\": \"array\",\n  \"maxItems\": 3\n}\n"
+	if diff := cmp.Diff(expectedFile, string(data)); diff != "" {
+		t.Fatalf("unexpected file contents (-want +got):\n%s", diff)
+	}
+
+	loaded, err := catalog.LoadDir(os.DirFS(dir))
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+	if diff := cmp.Diff(schemaJSON(catalogSchema), schemaJSON(loaded)); diff != "" {
+		t.Fatalf("unexpected catalog schema (-want +got):\n%s", diff)
+	}
+}
+
+func TestWriteDirInvalidNames(t *testing.T) {
+	tests := []struct {
+		name          string
+		catalogSchema catalog.CatalogSchema
+	}{
+		{
+			name:          "empty database name",
+			catalogSchema: catalog.CatalogSchema{"": {"foo": nil}},
+		},
+		{
+			name:          "collection name with a path separator",
+			catalogSchema: catalog.CatalogSchema{"test": {"foo/bar": nil}},
+		},
+		{
+			name:          "parent directory as database name",
+			catalogSchema: catalog.CatalogSchema{"..": {"foo": nil}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := catalog.WriteDir(dir, test.catalogSchema); err == nil {
+				t.Fatalf("expected error to be non-nil, but it was nil")
+			}
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatalf("expected err to be nil, got '%s'", err)
+			}
+			if len(entries) != 0 {
+				t.Fatalf("expected nothing to be written, got %d entries", len(entries))
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package catalog
+
+import (
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// ErrNoDocuments is returned by InferSchema when it is given no
+// documents to infer a schema from.
+var ErrNoDocuments = errors.New("no documents to infer a schema from")
+
+// ArrayItemsMode tells InferSchema how to derive the items schema of
+// an array from its elements.
+type ArrayItemsMode int
+
+const (
+	// ArrayItemsUnion derives an items schema that matches every
+	// element of every array seen at the same place, as the schema
+	// builder does
+	ArrayItemsUnion ArrayItemsMode = iota
+	// ArrayItemsAny leaves the items of arrays unconstrained, which
+	// keeps schemas small for arrays of heterogeneous elements
+	ArrayItemsAny
+)
+
+// InferOptions are the options of InferSchema. The zero value infers
+// the same schema as the schema builder.
+type InferOptions struct {
+	// RequiredThreshold is the fraction of the documents, between 0
+	// and 1, that a field must appear in to be required. Zero requires
+	// the fields that appear in every document, like 1 does.
+	RequiredThreshold float64
+	// ArrayItems tells how the items schema of arrays is derived
+	ArrayItems ArrayItemsMode
+	// MaxArrayElements is the number of leading elements of each array
+	// that the items schema is derived from. Zero uses every element.
+	MaxArrayElements int
+	// MaxAnyOfDepth is the number of anyOfs that can be nested in each
+	// other. Deeper anyOfs are replaced by a schema that matches any
+	// value. Zero means no limit.
+	MaxAnyOfDepth int
+}
+
+// InferSchema returns a JSON Schema that matches every document of
+// docs, derived in the same way as the schema builder derives the
+// schema of a collection from its documents. The result can be used as
+// the schema of a collection in a CatalogSchema.
+func InferSchema(docs []bson.Raw, opts InferOptions) (bsoncore.Document, error) {
+	if opts.RequiredThreshold < 0 || opts.RequiredThreshold > 1 {
+		return nil, fmt.Errorf("RequiredThreshold must be between 0 and 1, got %v", opts.RequiredThreshold)
+	}
+	if opts.MaxArrayElements < 0 {
+		return nil, fmt.Errorf("MaxArrayElements must not be negative, got %d", opts.MaxArrayElements)
+	}
+	if opts.MaxAnyOfDepth < 0 {
+		return nil, fmt.Errorf("MaxAnyOfDepth must not be negative, got %d", opts.MaxAnyOfDepth)
+	}
+	if len(docs) == 0 {
+		return nil, ErrNoDocuments
+	}
+
+	result := unsatisfiable
+	for i, doc := range docs {
+		if err := doc.Validate(); err != nil {
+			return nil, fmt.Errorf("document %d is not valid BSON: %w", i, err)
+		}
+		result = union(result, schemaForDocument(doc, opts))
+	}
+	return marshalSchema(simplify(result), opts)
+}
+
+// schemaForDocument returns the schema of a single document, in which
+// every field is required.
+func schemaForDocument(doc bson.Raw, opts InferOptions) *schema {
+	elements, _ := doc.Elements()
+	d := &documentSchema{
+		keys:     make(map[string]*schema, len(elements)),
+		required: make(map[string]bool, len(elements)),
+		jaccard:  &jaccardIndex{avg: 1, stabilityLimit: defaultStabilityLimit},
+		counts:   make(map[string]int, len(elements)),
+		total:    1,
+	}
+	for _, element := range elements {
+		key := element.Key()
+		d.keys[key] = schemaForValue(element.Value(), opts)
+		d.required[key] = true
+		d.counts[key] = 1
+	}
+	return document(d)
+}
+
+// schemaForValue returns the schema of a single value.
+func schemaForValue(value bson.RawValue, opts InferOptions) *schema {
+	switch value.Type {
+	case bsontype.EmbeddedDocument:
+		return schemaForDocument(value.Document(), opts)
+	case bsontype.Array:
+		elements, _ := value.Array().Values()
+		// the only schema that matches the elements of an empty array
+		// is unsatisfiable
+		if len(elements) == 0 {
+			return array(unsatisfiable)
+		}
+		if opts.ArrayItems == ArrayItemsAny {
+			return array(anything)
+		}
+		if opts.MaxArrayElements > 0 && len(elements) > opts.MaxArrayElements {
+			elements = elements[:opts.MaxArrayElements]
+		}
+		items := unsatisfiable
+		for _, element := range elements {
+			items = union(items, schemaForValue(element, opts))
+		}
+		return array(items)
+	case bsontype.Double:
+		return atomic(doubleType)
+	case bsontype.String:
+		return atomic(stringType)
+	case bsontype.Boolean:
+		return atomic(boolType)
+	case bsontype.Null:
+		return atomic(nullType)
+	case bsontype.Regex:
+		return atomic(regexType)
+	case bsontype.JavaScript:
+		return atomic(javascriptType)
+	case bsontype.CodeWithScope:
+		return atomic(javascriptWithScopeType)
+	case bsontype.Int32:
+		return atomic(intType)
+	case bsontype.Int64:
+		return atomic(longType)
+	case bsontype.Timestamp:
+		return atomic(timestampType)
+	case bsontype.Binary:
+		return atomic(binDataType)
+	case bsontype.Undefined:
+		return atomic(undefinedType)
+	case bsontype.ObjectID:
+		return atomic(objectIDType)
+	case bsontype.DateTime:
+		return atomic(dateType)
+	case bsontype.Symbol:
+		return atomic(symbolType)
+	case bsontype.Decimal128:
+		return atomic(decimalType)
+	case bsontype.MaxKey:
+		return atomic(maxKeyType)
+	case bsontype.MinKey:
+		return atomic(minKeyType)
+	case bsontype.DBPointer:
+		return atomic(dbPointerType)
+	}
+	return anything
+}
+
+// marshalSchema returns the JSON Schema of s, which must be simplified.
+func marshalSchema(s *schema, opts InferOptions) (bsoncore.Document, error) {
+	doc, err := bson.Marshal(jsonSchema(s, opts, 0))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal schema: %w", err)
+	}
+	return doc, nil
+}
+
+// jsonSchema converts s to a JSON Schema as the Rust conversion of a
+// Schema to a json_schema::Schema does. anyOfDepth is the number of
+// anyOfs s is nested in.
+func jsonSchema(s *schema, opts InferOptions, anyOfDepth int) bson.D {
+	switch s.kind {
+	case unsatKind:
+		return bson.D{{Key: "anyOf", Value: bson.A{}}}
+	case atomicKind:
+		return bson.D{{Key: "bsonType", Value: s.atomic.bsonTypeName()}}
+	case arrayKind:
+		if s.items.kind == unsatKind {
+			return bson.D{{Key: "bsonType", Value: "array"}, {Key: "maxItems", Value: int32(0)}}
+		}
+		return bson.D{{Key: "bsonType", Value: "array"}, {Key: "items", Value: jsonSchema(s.items, opts, anyOfDepth)}}
+	case documentKind:
+		d := s.document
+		properties := bson.D{}
+		for _, key := range sortedKeys(d.keys) {
+			properties = append(properties, bson.E{Key: key, Value: jsonSchema(d.keys[key], opts, anyOfDepth)})
+		}
+		result := bson.D{{Key: "bsonType", Value: "object"}, {Key: "properties", Value: properties}}
+		var required bson.A
+		for _, key := range sortedKeys(d.keys) {
+			if isRequired(d, key, opts.RequiredThreshold) {
+				required = append(required, key)
+			}
+		}
+		if len(required) > 0 {
+			result = append(result, bson.E{Key: "required", Value: required})
+		}
+		return append(result, bson.E{Key: "additionalProperties", Value: d.additionalProperties})
+	case anyOfKind:
+		if opts.MaxAnyOfDepth > 0 && anyOfDepth >= opts.MaxAnyOfDepth {
+			return bson.D{}
+		}
+		branches := bson.A{}
+		for _, branch := range s.anyOf {
+			branches = append(branches, jsonSchema(branch, opts, anyOfDepth+1))
+		}
+		return bson.D{{Key: "anyOf", Value: branches}}
+	}
+	return bson.D{}
+}
+
+// isRequired returns true if key is a required field of d, given the
+// fraction of the documents it must appear in.
+func isRequired(d *documentSchema, key string, threshold float64) bool {
+	if threshold == 0 || threshold == 1 {
+		return d.required[key]
+	}
+	return float64(d.counts[key]) >= threshold*float64(d.total)
+}
@@ -0,0 +1,275 @@
+package catalog_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mongodb/mongosql/go/mongosql/catalog"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func marshalDocs(t *testing.T, docs ...bson.D) []bson.Raw {
+	var raws []bson.Raw
+	for _, doc := range docs {
+		data, err := bson.Marshal(doc)
+		if err != nil {
+			t.Fatalf("failed to marshal document: %s", err)
+		}
+		raws = append(raws, data)
+	}
+	return raws
+}
+
+func TestInferSchema(t *testing.T) {
+	object := func(properties bson.D, required bson.A) bson.D {
+		schema := bson.D{{Key: "bsonType", Value: "object"}, {Key: "properties", Value: properties}}
+		if required != nil {
+			schema = append(schema, bson.E{Key: "required", Value: required})
+		}
+		return append(schema, bson.E{Key: "additionalProperties", Value: false})
+	}
+	bsonType := func(name string) bson.D {
+		return bson.D{{Key: "bsonType", Value: name}}
+	}
+	anyOf := func(schemas ...interface{}) bson.D {
+		return bson.D{{Key: "anyOf", Value: bson.A(schemas)}}
+	}
+	arrayOf := func(items bson.D) bson.D {
+		return bson.D{{Key: "bsonType", Value: "array"}, {Key: "items", Value: items}}
+	}
+
+	tests := []struct {
+		name     string
+		docs     []bson.D
+		opts     catalog.InferOptions
+		expected bson.D
+	}{
+		{
+			name: "fields missing from some documents are not required",
+			docs: []bson.D{
+				{{Key: "a", Value: int32(1)}, {Key: "b", Value: "x"}},
+				{{Key: "a", Value: int32(2)}},
+			},
+			expected: object(bson.D{
+				{Key: "a", Value: bsonType("int")},
+				{Key: "b", Value: bsonType("string")},
+			}, bson.A{"a"}),
+		},
+		{
+			name: "polymorphic fields",
+			docs: []bson.D{
+				{{Key: "a", Value: "x"}},
+				{{Key: "a", Value: int32(1)}},
+				{{Key: "a", Value: nil}},
+			},
+			expected: object(bson.D{
+				{Key: "a", Value: anyOf(bsonType("null"), bsonType("int"), bsonType("string"))},
+			}, bson.A{"a"}),
+		},
+		{
+			name: "nested documents are unioned",
+			docs: []bson.D{
+				{{Key: "d", Value: bson.D{{Key: "x", Value: int64(1)}}}},
+				{{Key: "d", Value: bson.D{{Key: "x", Value: int64(2)}, {Key: "y", Value: true}}}},
+			},
+			expected: object(bson.D{
+				{Key: "d", Value: object(bson.D{
+					{Key: "x", Value: bsonType("long")},
+					{Key: "y", Value: bsonType("bool")},
+				}, bson.A{"x"})},
+			}, bson.A{"d"}),
+		},
+		{
+			name: "array items are unioned",
+			docs: []bson.D{
+				{{Key: "arr", Value: bson.A{int32(1), "x"}}, {Key: "empty", Value: bson.A{}}},
+				{{Key: "arr", Value: bson.A{}}, {Key: "empty", Value: bson.A{}}},
+			},
+			expected: object(bson.D{
+				{Key: "arr", Value: arrayOf(anyOf(bsonType("int"), bsonType("string")))},
+				{Key: "empty", Value: bson.D{{Key: "bsonType", Value: "array"}, {Key: "maxItems", Value: int32(0)}}},
+			}, bson.A{"arr", "empty"}),
+		},
+		{
+			name: "required threshold",
+			docs: []bson.D{
+				{{Key: "a", Value: int32(1)}, {Key: "b", Value: int32(1)}},
+				{{Key: "a", Value: int32(1)}, {Key: "c", Value: int32(1)}},
+				{{Key: "a", Value: int32(1)}, {Key: "b", Value: int32(1)}},
+			},
+			opts: catalog.InferOptions{RequiredThreshold: 0.5},
+			expected: object(bson.D{
+				{Key: "a", Value: bsonType("int")},
+				{Key: "b", Value: bsonType("int")},
+				{Key: "c", Value: bsonType("int")},
+			}, bson.A{"a", "b"}),
+		},
+		{
+			name: "array items left unconstrained",
+			docs: []bson.D{
+				{{Key: "arr", Value: bson.A{int32(1), "x"}}},
+			},
+			opts: catalog.InferOptions{ArrayItems: catalog.ArrayItemsAny},
+			expected: object(bson.D{
+				{Key: "arr", Value: arrayOf(bson.D{})},
+			}, bson.A{"arr"}),
+		},
+		{
+			name: "array elements limit",
+			docs: []bson.D{
+				{{Key: "arr", Value: bson.A{int32(1), "x"}}},
+			},
+			opts: catalog.InferOptions{MaxArrayElements: 1},
+			expected: object(bson.D{
+				{Key: "arr", Value: arrayOf(bsonType("int"))},
+			}, bson.A{"arr"}),
+		},
+		{
+			name: "anyOf depth limit",
+			docs: []bson.D{
+				{{Key: "a", Value: int32(1)}},
+				{{Key: "a", Value: bson.A{int32(1), "x"}}},
+			},
+			opts: catalog.InferOptions{MaxAnyOfDepth: 1},
+			expected: object(bson.D{
+				{Key: "a", Value: anyOf(bsonType("int"), arrayOf(bson.D{}))},
+			}, bson.A{"a"}),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			schema, err := catalog.InferSchema(marshalDocs(t, test.docs...), test.opts)
+			if err != nil {
+				t.Fatalf("expected err to be nil, got '%s'", err)
+			}
+			if diff := cmp.Diff(marshalSchema(t, test.expected), bson.Raw(schema).String()); diff != "" {
+				t.Fatalf("unexpected schema (-want +got):\n%s", diff)
+			}
+			if issues := catalog.Validate(catalog.CatalogSchema{"test": {"foo": schema}}); len(issues) > 0 {
+				t.Fatalf("expected the schema to be valid, got %v", issues)
+			}
+		})
+	}
+}
+
+func TestInferSchemaUnstableDocuments(t *testing.T) {
+	// documents that share no keys make the schema unstable after
+	// enough unions, after which new keys only allow additional
+	// properties
+	var docs []bson.D
+	for i := 0; i < 40; i++ {
+		docs = append(docs, bson.D{{Key: fmt.Sprintf("field%d", i), Value: int32(i)}})
+	}
+
+	schema, err := catalog.InferSchema(marshalDocs(t, docs...), catalog.InferOptions{})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	additionalProperties, err := bson.Raw(schema).LookupErr("additionalProperties")
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+	if !additionalProperties.Boolean() {
+		t.Fatalf("expected additional properties to be allowed")
+	}
+	properties, err := bson.Raw(schema).LookupErr("properties")
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+	elements, _ := properties.Document().Elements()
+	if len(elements) >= len(docs) {
+		t.Fatalf("expected fewer than %d properties, got %d", len(docs), len(elements))
+	}
+}
+
+// TestInferSchemaMatchesRust checks InferSchema against the schemas
+// that the Rust schema derivation, which it is a port of, derives from
+// the same documents. The cases are shared with the Rust tests of
+// schema_derivation, which regenerate the expected schemas when run
+// with UPDATE_GOLDEN set.
+func TestInferSchemaMatchesRust(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "..", "..", "testdata", "schema_for_documents", "cases.json"))
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+	var golden struct {
+		Cases []struct {
+			Description string     `bson:"description"`
+			Documents   []bson.Raw `bson:"documents"`
+			Schema      bson.Raw   `bson:"schema"`
+		} `bson:"cases"`
+	}
+	if err := bson.UnmarshalExtJSON(data, false, &golden); err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	for _, test := range golden.Cases {
+		t.Run(test.Description, func(t *testing.T) {
+			schema, err := catalog.InferSchema(test.Documents, catalog.InferOptions{})
+			if err != nil {
+				t.Fatalf("expected err to be nil, got '%s'", err)
+			}
+			// the keys of the Rust schemas are not in a fixed order
+			if diff := cmp.Diff(unorderedJSON(t, test.Schema), unorderedJSON(t, bson.Raw(schema))); diff != "" {
+				t.Fatalf("unexpected schema (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// unorderedJSON returns doc as a tree of JSON values, in which the
+// order of the keys of documents does not matter.
+func unorderedJSON(t *testing.T, doc bson.Raw) interface{} {
+	var value interface{}
+	if err := json.Unmarshal([]byte(doc.String()), &value); err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+	return value
+}
+
+func TestInferSchemaErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		docs []bson.Raw
+		opts catalog.InferOptions
+	}{
+		{
+			name: "required threshold out of range",
+			docs: marshalDocs(t, bson.D{}),
+			opts: catalog.InferOptions{RequiredThreshold: 1.5},
+		},
+		{
+			name: "negative array elements limit",
+			docs: marshalDocs(t, bson.D{}),
+			opts: catalog.InferOptions{MaxArrayElements: -1},
+		},
+		{
+			name: "negative anyOf depth limit",
+			docs: marshalDocs(t, bson.D{}),
+			opts: catalog.InferOptions{MaxAnyOfDepth: -1},
+		},
+		{
+			name: "invalid document",
+			docs: []bson.Raw{{0x05, 0x00, 0x00, 0x00, 0x01}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := catalog.InferSchema(test.docs, test.opts); err == nil {
+				t.Fatalf("expected error to be non-nil, but it was nil")
+			}
+		})
+	}
+
+	if _, err := catalog.InferSchema(nil, catalog.InferOptions{}); !errors.Is(err, catalog.ErrNoDocuments) {
+		t.Fatalf("expected ErrNoDocuments, got '%v'", err)
+	}
+}
@@ -0,0 +1,270 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// PartitionSizeInBytes is the size of the partitions that collections
+// are split into. It is the memory limit of $bucketAuto on the server.
+const PartitionSizeInBytes = 100 * 1024 * 1024
+
+// The errors returned when a collection cannot be partitioned or its
+// schema cannot be derived.
+var (
+	// ErrNoCollection is returned for a collection that does not exist
+	ErrNoCollection = errors.New("no such collection")
+	// ErrNoCollectionStats is returned when $collStats returns nothing
+	ErrNoCollectionStats = errors.New("unable to get collection stats")
+	// ErrUnexpectedCollectionStats is returned when the output of
+	// $collStats does not have the expected size and count
+	ErrUnexpectedCollectionStats = errors.New("unexpected collection stats")
+	// ErrEmptyCollection is returned for a collection without documents
+	ErrEmptyCollection = errors.New("collection appears to be empty")
+	// ErrNoBounds is returned when the minimum or maximum value of the
+	// partition key of a collection cannot be found
+	ErrNoBounds = errors.New("unable to get bounds")
+	// ErrNoTimeField is returned for a timeseries collection without a
+	// timeField option
+	ErrNoTimeField = errors.New("no timeField specified for timeseries collection")
+	// ErrMissingCount is returned for a collection whose stats have no
+	// count
+	ErrMissingCount = errors.New("no count field specified for collection")
+)
+
+// Partition is a range of the values of the partition key of a
+// collection.
+type Partition struct {
+	// Min is the inclusive lower bound of the partition
+	Min bson.RawValue
+	// Max is the upper bound of the partition
+	Max bson.RawValue
+	// MaxInclusive tells whether Max is in the partition
+	MaxInclusive bool
+}
+
+// matchStage returns the $match stage that selects the documents of the
+// partition whose partitionKey is not in ignoredIDs. If schema is not
+// nil, documents that match it are left out as well.
+func (p Partition) matchStage(schema bson.D, ignoredIDs []bson.RawValue, partitionKey string) bson.D {
+	ltOp := "$lt"
+	if p.MaxInclusive {
+		ltOp = "$lte"
+	}
+	nin := bson.A{}
+	for _, id := range ignoredIDs {
+		nin = append(nin, id)
+	}
+
+	match := bson.D{{Key: partitionKey, Value: bson.D{
+		{Key: "$nin", Value: nin},
+		{Key: "$gte", Value: p.Min},
+		{Key: ltOp, Value: p.Max},
+	}}}
+	if schema != nil {
+		match = append(match, bson.E{Key: "$nor", Value: bson.A{bson.D{{Key: "$jsonSchema", Value: schema}}}})
+	}
+	return bson.D{{Key: "$match", Value: match}}
+}
+
+// PartitionedCollection is a collection split into partitions.
+type PartitionedCollection struct {
+	// Partitions cover every value of PartitionKey in the collection,
+	// in order
+	Partitions []Partition
+	// PartitionKey is the field the collection is partitioned on
+	PartitionKey string
+	// Hint is the index to use to read the partitions, or nil
+	Hint bson.D
+}
+
+// GetPartitions splits the collection described by info in partitions.
+//
+// A collection larger than PartitionSizeInBytes is split into
+// partitions of about that size, whose bounds are found by sampling
+// the values of its partition key, which is _id, or the timeField of a
+// timeseries collection. Smaller collections are a single partition.
+// If the sampling fails, the whole collection is used as a single
+// partition, which is safer than missing some of it.
+func GetPartitions(ctx context.Context, service DataService, db string, info CollectionInfo) (PartitionedCollection, error) {
+	size, count, err := getSizeCounts(ctx, service, db, info.Name)
+	if err != nil {
+		return PartitionedCollection{}, err
+	}
+	numPartitions := getNumPartitions(size, PartitionSizeInBytes)
+
+	var sampleRate float64
+	var partitionKey string
+	var hint bson.D
+	switch info.Type {
+	case CollectionTypeTimeseries:
+		// timeseries collections report no count, so sample at a rate
+		// of one per partition, which is likely higher than for other
+		// collections, but avoids counting the whole collection
+		timeseries := info.Options.Timeseries
+		if timeseries == nil || timeseries.TimeField == "" {
+			return PartitionedCollection{}, fmt.Errorf("%w: %s", ErrNoTimeField, info.Name)
+		}
+		sampleRate = 1 / float64(numPartitions)
+		partitionKey = timeseries.TimeField
+		if timeseries.MetaField != "" {
+			hint = bson.D{{Key: timeseries.MetaField, Value: 1}, {Key: partitionKey, Value: 1}}
+		}
+	default:
+		if count < 0 {
+			return PartitionedCollection{}, fmt.Errorf("%w: %s", ErrMissingCount, info.Name)
+		}
+		sampleRate = float64(numPartitions) / float64(count) * 2
+		partitionKey = "_id"
+		hint = bson.D{{Key: "_id", Value: 1}}
+	}
+
+	minBound, maxBound, err := getBounds(ctx, service, db, info.Name, partitionKey)
+	if err != nil {
+		return PartitionedCollection{}, err
+	}
+	single := PartitionedCollection{
+		Partitions:   []Partition{{Min: minBound, Max: maxBound, MaxInclusive: true}},
+		PartitionKey: partitionKey,
+		Hint:         hint,
+	}
+	if numPartitions == 1 {
+		return single, nil
+	}
+
+	cursor, err := service.Aggregate(ctx, db, info.Name, []bson.D{
+		{{Key: "$sort", Value: bson.D{{Key: partitionKey, Value: 1}}}},
+		{{Key: "$project", Value: bson.D{{Key: partitionKey, Value: 1}}}},
+		{{Key: "$match", Value: bson.D{{Key: "$sampleRate", Value: sampleRate}}}},
+	}, AggregateOptions{KeyHint: hint})
+	if err != nil {
+		return single, nil
+	}
+	defer cursor.Close(ctx)
+
+	partitions := make([]Partition, 0, numPartitions)
+	for cursor.Next(ctx) {
+		localMax := bson.RawValue{Type: bsontype.MaxKey}
+		if value, err := cursor.Current().LookupErr(partitionKey); err == nil {
+			localMax = copyValue(value)
+		}
+		partitions = append(partitions, Partition{Min: minBound, Max: localMax})
+		minBound = localMax
+	}
+	if err := cursor.Err(); err != nil {
+		return PartitionedCollection{}, fmt.Errorf("failed to sample the partitions of %s.%s: %w", db, info.Name, err)
+	}
+	partitions = append(partitions, Partition{Min: minBound, Max: maxBound, MaxInclusive: true})
+
+	return PartitionedCollection{
+		Partitions:   partitions,
+		PartitionKey: partitionKey,
+		Hint:         hint,
+	}, nil
+}
+
+// getSizeCounts returns the size of the collection db.collection, and
+// its number of documents, or -1 if its stats have no count, which is
+// the case for timeseries collections.
+func getSizeCounts(ctx context.Context, service DataService, db, collection string) (int64, int64, error) {
+	cursor, err := service.Aggregate(ctx, db, collection, []bson.D{
+		{{Key: "$collStats", Value: bson.D{{Key: "storageStats", Value: bson.D{}}}}},
+	}, AggregateOptions{})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get the stats of %s.%s: %w", db, collection, err)
+	}
+	defer cursor.Close(ctx)
+
+	if !cursor.Next(ctx) {
+		if err := cursor.Err(); err != nil {
+			return 0, 0, fmt.Errorf("failed to get the stats of %s.%s: %w", db, collection, err)
+		}
+		return 0, 0, fmt.Errorf("%w: %s", ErrNoCollectionStats, collection)
+	}
+	stats, ok := cursor.Current().Lookup("storageStats").DocumentOK()
+	if !ok {
+		return 0, 0, fmt.Errorf("%w: %s has no storageStats", ErrUnexpectedCollectionStats, collection)
+	}
+	size, ok := integerValue(stats.Lookup("size"))
+	if !ok {
+		return 0, 0, fmt.Errorf("%w: %s has no integer size", ErrUnexpectedCollectionStats, collection)
+	}
+	count := int64(-1)
+	if value, err := stats.LookupErr("count"); err == nil {
+		if count, ok = integerValue(value); !ok {
+			return 0, 0, fmt.Errorf("%w: %s has no integer count", ErrUnexpectedCollectionStats, collection)
+		}
+	}
+
+	if size == 0 || count == 0 {
+		return 0, 0, fmt.Errorf("%w: %s", ErrEmptyCollection, collection)
+	}
+	return size, count, nil
+}
+
+// integerValue returns the value of an int32 or int64 value.
+func integerValue(value bson.RawValue) (int64, bool) {
+	switch value.Type {
+	case bsontype.Int32:
+		return int64(value.Int32()), true
+	case bsontype.Int64:
+		return value.Int64(), true
+	}
+	return 0, false
+}
+
+// getNumPartitions returns the number of partitions of a collection of
+// collSize bytes.
+func getNumPartitions(collSize, partitionSize int64) int {
+	return int(float64(collSize)/float64(partitionSize)) + 1
+}
+
+// getBounds returns the minimum and maximum values of partitionKey in
+// the collection db.collection.
+func getBounds(ctx context.Context, service DataService, db, collection, partitionKey string) (bson.RawValue, bson.RawValue, error) {
+	min, err := getBound(ctx, service, db, collection, partitionKey, 1)
+	if err != nil {
+		return bson.RawValue{}, bson.RawValue{}, err
+	}
+	max, err := getBound(ctx, service, db, collection, partitionKey, -1)
+	if err != nil {
+		return bson.RawValue{}, bson.RawValue{}, err
+	}
+	return min, max, nil
+}
+
+// getBound returns the minimum or maximum value of partitionKey in the
+// collection db.collection, depending on direction.
+func getBound(ctx context.Context, service DataService, db, collection, partitionKey string, direction int) (bson.RawValue, error) {
+	cursor, err := service.Aggregate(ctx, db, collection, []bson.D{
+		{{Key: "$sort", Value: bson.D{{Key: partitionKey, Value: direction}}}},
+		{{Key: "$limit", Value: 1}},
+		{{Key: "$project", Value: bson.D{{Key: partitionKey, Value: 1}}}},
+	}, AggregateOptions{})
+	if err != nil {
+		return bson.RawValue{}, fmt.Errorf("failed to get the bounds of %s.%s: %w", db, collection, err)
+	}
+	defer cursor.Close(ctx)
+
+	if !cursor.Next(ctx) {
+		if err := cursor.Err(); err != nil {
+			return bson.RawValue{}, fmt.Errorf("failed to get the bounds of %s.%s: %w", db, collection, err)
+		}
+		return bson.RawValue{}, fmt.Errorf("%w: %s", ErrNoBounds, collection)
+	}
+	value, err := cursor.Current().LookupErr(partitionKey)
+	if err != nil {
+		return bson.RawValue{}, fmt.Errorf("%w: %s", ErrNoBounds, collection)
+	}
+	return copyValue(value), nil
+}
+
+// copyValue returns a copy of value that does not share memory with
+// the document it was read from, which a cursor may reuse.
+func copyValue(value bson.RawValue) bson.RawValue {
+	return bson.RawValue{Type: value.Type, Value: append([]byte(nil), value.Value...)}
+}
@@ -0,0 +1,210 @@
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// The problems reported by a SchemaError, in addition to ErrWrongType.
+var (
+	// ErrUnknownBsonType is reported for a bsonType that names a type
+	// mongosql does not know
+	ErrUnknownBsonType = errors.New("unknown bsonType")
+	// ErrOutOfRange is reported for a maxItems that is negative or does
+	// not fit in 32 bits
+	ErrOutOfRange = errors.New("out of range")
+	// ErrDuplicateKeyword is reported for a keyword that appears more
+	// than once in the same schema
+	ErrDuplicateKeyword = errors.New("duplicate keyword")
+)
+
+// bsonTypeNames are the values of bsonType that mongosql accepts.
+var bsonTypeNames = map[string]bool{
+	"object":              true,
+	"array":               true,
+	"null":                true,
+	"string":              true,
+	"int":                 true,
+	"double":              true,
+	"long":                true,
+	"decimal":             true,
+	"binData":             true,
+	"undefined":           true,
+	"objectId":            true,
+	"bool":                true,
+	"date":                true,
+	"regex":               true,
+	"dbPointer":           true,
+	"javascript":          true,
+	"symbol":              true,
+	"javascriptWithScope": true,
+	"timestamp":           true,
+	"minKey":              true,
+	"maxKey":              true,
+}
+
+// SchemaError reports a part of a JSON Schema that mongosql cannot
+// read. Use errors.Is with the Err variables of this package to find
+// out what is wrong with it.
+type SchemaError struct {
+	// Pointer is the JSON pointer to the offending value within the
+	// schema
+	Pointer string
+	// Err is the problem with the value
+	Err error
+}
+
+// Error implements the error interface.
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("invalid schema at %q: %s", e.Pointer, e.Err)
+}
+
+// Unwrap returns the problem with the value.
+func (e *SchemaError) Unwrap() error {
+	return e.Err
+}
+
+// checkSchema returns a SchemaError for each part of schema that would
+// make mongosql reject it, following the rules of the Rust
+// json_schema::Schema::from_document: the keywords mongosql uses must
+// have the expected types, a keyword may only appear once, and unknown
+// keywords are ignored. A null keyword is treated as a missing one.
+// pointer is the JSON pointer to schema.
+func checkSchema(schema bson.Raw, pointer string) []*SchemaError {
+	elements, err := schema.Elements()
+	if err != nil {
+		return []*SchemaError{{Pointer: pointer, Err: fmt.Errorf("%w: %s", ErrInvalidDocument, err)}}
+	}
+
+	var errs []*SchemaError
+	wrongType := func(pointer string, expected string, value bson.RawValue) {
+		errs = append(errs, &SchemaError{Pointer: pointer, Err: fmt.Errorf("%w: expected %s, got %s", ErrWrongType, expected, value.Type)})
+	}
+	checkSchemas := func(pointer string, value bson.RawValue) {
+		values, err := value.Array().Values()
+		if err != nil {
+			errs = append(errs, &SchemaError{Pointer: pointer, Err: fmt.Errorf("%w: %s", ErrInvalidDocument, err)})
+			return
+		}
+		for i, v := range values {
+			p := fmt.Sprintf("%s/%d", pointer, i)
+			if v.Type != bsontype.EmbeddedDocument {
+				wrongType(p, "a document", v)
+				continue
+			}
+			errs = append(errs, checkSchema(v.Document(), p)...)
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, element := range elements {
+		key, value := element.Key(), element.Value()
+		p := pointer + "/" + escapePointerToken(key)
+		switch key {
+		case "bsonType", "properties", "required", "additionalProperties", "items", "maxItems", "anyOf", "oneOf":
+		default:
+			continue
+		}
+		if seen[key] {
+			errs = append(errs, &SchemaError{Pointer: p, Err: ErrDuplicateKeyword})
+			continue
+		}
+		seen[key] = true
+		if value.Type == bsontype.Null {
+			continue
+		}
+
+		switch key {
+		case "bsonType":
+			switch value.Type {
+			case bsontype.String:
+				if name := value.StringValue(); !bsonTypeNames[name] {
+					errs = append(errs, &SchemaError{Pointer: p, Err: fmt.Errorf("%w: %q", ErrUnknownBsonType, name)})
+				}
+			case bsontype.Array:
+				values, _ := value.Array().Values()
+				for i, v := range values {
+					name, ok := v.StringValueOK()
+					switch {
+					case !ok:
+						wrongType(fmt.Sprintf("%s/%d", p, i), "a string", v)
+					case !bsonTypeNames[name]:
+						errs = append(errs, &SchemaError{Pointer: fmt.Sprintf("%s/%d", p, i), Err: fmt.Errorf("%w: %q", ErrUnknownBsonType, name)})
+					}
+				}
+			default:
+				wrongType(p, "a string or an array", value)
+			}
+		case "properties":
+			if value.Type != bsontype.EmbeddedDocument {
+				wrongType(p, "a document", value)
+				continue
+			}
+			properties, _ := value.Document().Elements()
+			for _, property := range properties {
+				pp := p + "/" + escapePointerToken(property.Key())
+				if property.Value().Type != bsontype.EmbeddedDocument {
+					wrongType(pp, "a document", property.Value())
+					continue
+				}
+				errs = append(errs, checkSchema(property.Value().Document(), pp)...)
+			}
+		case "required":
+			if value.Type != bsontype.Array {
+				wrongType(p, "an array", value)
+				continue
+			}
+			values, _ := value.Array().Values()
+			for i, v := range values {
+				if v.Type != bsontype.String {
+					wrongType(fmt.Sprintf("%s/%d", p, i), "a string", v)
+				}
+			}
+		case "additionalProperties":
+			if value.Type != bsontype.Boolean {
+				wrongType(p, "a boolean", value)
+			}
+		case "items":
+			switch value.Type {
+			case bsontype.EmbeddedDocument:
+				errs = append(errs, checkSchema(value.Document(), p)...)
+			case bsontype.Array:
+				checkSchemas(p, value)
+			default:
+				wrongType(p, "a document or an array", value)
+			}
+		case "maxItems":
+			var n int64
+			switch value.Type {
+			case bsontype.Int32:
+				n = int64(value.Int32())
+			case bsontype.Int64:
+				n = value.Int64()
+			default:
+				wrongType(p, "an integer", value)
+				continue
+			}
+			if n < 0 || n > math.MaxUint32 {
+				errs = append(errs, &SchemaError{Pointer: p, Err: fmt.Errorf("%w: %d", ErrOutOfRange, n)})
+			}
+		case "anyOf", "oneOf":
+			if value.Type != bsontype.Array {
+				wrongType(p, "an array", value)
+				continue
+			}
+			checkSchemas(p, value)
+		}
+	}
+	return errs
+}
+
+// escapePointerToken escapes a key for use as a JSON pointer reference
+// token, as described in RFC 6901.
+func escapePointerToken(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
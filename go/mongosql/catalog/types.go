@@ -0,0 +1,309 @@
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsoncodec"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// ErrUnsupportedType is returned by SchemaFromType for types that the
+// driver cannot encode, or whose schema cannot be derived
+var ErrUnsupportedType = errors.New("unsupported type")
+
+// atomicTypes maps the Go types that the driver encodes as a single
+// BSON type, regardless of their kind, to that type.
+var atomicTypes = map[reflect.Type]atomicType{
+	reflect.TypeOf(primitive.ObjectID{}):      objectIDType,
+	reflect.TypeOf(time.Time{}):               dateType,
+	reflect.TypeOf(primitive.DateTime(0)):     dateType,
+	reflect.TypeOf(primitive.Decimal128{}):    decimalType,
+	reflect.TypeOf(primitive.Binary{}):        binDataType,
+	reflect.TypeOf(primitive.Timestamp{}):     timestampType,
+	reflect.TypeOf(primitive.Regex{}):         regexType,
+	reflect.TypeOf(primitive.JavaScript("")):  javascriptType,
+	reflect.TypeOf(primitive.CodeWithScope{}): javascriptWithScopeType,
+	reflect.TypeOf(primitive.Symbol("")):      symbolType,
+	reflect.TypeOf(primitive.DBPointer{}):     dbPointerType,
+	reflect.TypeOf(primitive.Undefined{}):     undefinedType,
+	reflect.TypeOf(primitive.Null{}):          nullType,
+	reflect.TypeOf(primitive.MinKey{}):        minKeyType,
+	reflect.TypeOf(primitive.MaxKey{}):        maxKeyType,
+}
+
+var (
+	documentTypes = map[reflect.Type]bool{
+		reflect.TypeOf(bson.D{}):   true,
+		reflect.TypeOf(bson.M{}):   true,
+		reflect.TypeOf(bson.Raw{}): true,
+	}
+	rawValueType       = reflect.TypeOf(bson.RawValue{})
+	marshalerType      = reflect.TypeOf((*bson.Marshaler)(nil)).Elem()
+	valueMarshalerType = reflect.TypeOf((*bsoncodec.ValueMarshaler)(nil)).Elem()
+)
+
+// SchemaFor returns the JSON Schema of the documents the driver encodes
+// values of type T to. See SchemaFromType.
+func SchemaFor[T any]() (bsoncore.Document, error) {
+	return SchemaFromType(reflect.TypeOf((*T)(nil)).Elem())
+}
+
+// SchemaFromType returns the JSON Schema of the documents the driver
+// encodes values of type t to, which must be a struct or a pointer to a
+// struct, so that the schemas of collections described by Go types can
+// be kept in a CatalogSchema.
+//
+// Fields are named and skipped following their bson tags, as the
+// driver's default struct codec does. Fields tagged omitempty are not
+// required, and pointers, slices, maps and interfaces can be null,
+// since the driver encodes their nil values as null. Inlined structs
+// and struct pointers contribute their fields, and an inlined map
+// allows additional properties. Types that implement bson.Marshaler
+// are documents with unknown fields, and types that implement
+// bsoncodec.ValueMarshaler can be any value, as their encoding cannot
+// be known from their type.
+func SchemaFromType(t reflect.Type) (bsoncore.Document, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: %s is not a struct", ErrUnsupportedType, t)
+	}
+	s, err := (&typeSchemaBuilder{visiting: map[reflect.Type]bool{}}).structSchema(t, t.Name())
+	if err != nil {
+		return nil, err
+	}
+	return marshalSchema(simplify(s), InferOptions{})
+}
+
+// typeSchemaBuilder derives the schemas of Go types.
+type typeSchemaBuilder struct {
+	// visiting holds the structs whose schema is being derived, to
+	// detect recursive types, whose schema would be infinite
+	visiting map[reflect.Type]bool
+}
+
+// structField is a field of a struct, with the name it is encoded as.
+type structField struct {
+	name     string
+	schema   *schema
+	required bool
+	// depth is the number of inlined structs the field is in
+	depth int
+}
+
+// structSchema returns the schema of the documents the struct type t is
+// encoded to. path names t in errors.
+func (b *typeSchemaBuilder) structSchema(t reflect.Type, path string) (*schema, error) {
+	if b.visiting[t] {
+		return nil, fmt.Errorf("%w: %s is recursive", ErrUnsupportedType, path)
+	}
+	b.visiting[t] = true
+	defer delete(b.visiting, t)
+
+	fields, additionalProperties, err := b.structFields(t, path, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &documentSchema{
+		keys:                 make(map[string]*schema, len(fields)),
+		required:             make(map[string]bool, len(fields)),
+		additionalProperties: additionalProperties,
+	}
+	// like the driver, a field hides the fields of the same name that
+	// are inlined deeper, and fields of the same name at the same depth
+	// are an error
+	depths := make(map[string]int, len(fields))
+	for _, field := range fields {
+		if depth, ok := depths[field.name]; ok {
+			if depth == field.depth {
+				return nil, fmt.Errorf("%w: %s has more than one field named %q", ErrUnsupportedType, path, field.name)
+			}
+			if depth < field.depth {
+				continue
+			}
+		}
+		depths[field.name] = field.depth
+		d.keys[field.name] = field.schema
+		delete(d.required, field.name)
+		if field.required {
+			d.required[field.name] = true
+		}
+	}
+	return document(d), nil
+}
+
+// structFields returns the fields of the struct type t, including the
+// fields of inlined structs, and whether t has an inlined map.
+func (b *typeSchemaBuilder) structFields(t reflect.Type, path string, depth int) ([]structField, bool, error) {
+	var fields []structField
+	inlineMap := false
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		name, tags := parseBSONTag(sf)
+		if name == "-" {
+			continue
+		}
+		fieldPath := path + "." + sf.Name
+
+		if tags["inline"] {
+			fieldType := sf.Type
+			if fieldType.Kind() == reflect.Ptr {
+				fieldType = fieldType.Elem()
+			}
+			switch {
+			case sf.Type.Kind() == reflect.Map:
+				if inlineMap {
+					return nil, false, fmt.Errorf("%w: %s has more than one inlined map", ErrUnsupportedType, path)
+				}
+				if sf.Type.Key().Kind() != reflect.String {
+					return nil, false, fmt.Errorf("%w: inlined map %s must have string keys", ErrUnsupportedType, fieldPath)
+				}
+				inlineMap = true
+			case fieldType.Kind() == reflect.Struct:
+				if b.visiting[fieldType] {
+					return nil, false, fmt.Errorf("%w: %s is recursive", ErrUnsupportedType, fieldPath)
+				}
+				b.visiting[fieldType] = true
+				inlined, inlinedMap, err := b.structFields(fieldType, fieldPath, depth+1)
+				delete(b.visiting, fieldType)
+				if err != nil {
+					return nil, false, err
+				}
+				// the fields of a nil inlined struct pointer are left out
+				if sf.Type.Kind() == reflect.Ptr {
+					for i := range inlined {
+						inlined[i].required = false
+					}
+				}
+				if inlinedMap && inlineMap {
+					return nil, false, fmt.Errorf("%w: %s has more than one inlined map", ErrUnsupportedType, path)
+				}
+				inlineMap = inlineMap || inlinedMap
+				fields = append(fields, inlined...)
+			default:
+				return nil, false, fmt.Errorf("%w: inlined field %s must be a struct, a struct pointer or a map", ErrUnsupportedType, fieldPath)
+			}
+			continue
+		}
+
+		s, err := b.typeSchema(sf.Type, fieldPath, tags["minsize"])
+		if err != nil {
+			return nil, false, err
+		}
+		fields = append(fields, structField{
+			name:     name,
+			schema:   s,
+			required: !tags["omitempty"],
+			depth:    depth,
+		})
+	}
+	return fields, inlineMap, nil
+}
+
+// parseBSONTag returns the name a struct field is encoded as, or "-"
+// if it is skipped, and the flags of its bson tag, as the driver's
+// DefaultStructTagParser does.
+func parseBSONTag(sf reflect.StructField) (string, map[string]bool) {
+	name := strings.ToLower(sf.Name)
+	tag, ok := sf.Tag.Lookup("bson")
+	if !ok && !strings.Contains(string(sf.Tag), ":") && len(sf.Tag) > 0 {
+		tag = string(sf.Tag)
+	}
+	if tag == "-" {
+		return "-", nil
+	}
+	flags := map[string]bool{}
+	for i, part := range strings.Split(tag, ",") {
+		if i == 0 {
+			if part != "" {
+				name = part
+			}
+			continue
+		}
+		flags[part] = true
+	}
+	return name, flags
+}
+
+// typeSchema returns the schema of the values of type t. minSize tells
+// whether integers are encoded in 32 bits when they fit, as the
+// minsize flag of a bson tag does.
+func (b *typeSchemaBuilder) typeSchema(t reflect.Type, path string, minSize bool) (*schema, error) {
+	if s, ok := atomicTypes[t]; ok {
+		return atomic(s), nil
+	}
+	switch {
+	case documentTypes[t]:
+		return nullable(document(&documentSchema{additionalProperties: true})), nil
+	case t == rawValueType:
+		return anything, nil
+	case t.Implements(valueMarshalerType):
+		return anything, nil
+	case t.Implements(marshalerType):
+		return document(&documentSchema{additionalProperties: true}), nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return atomic(boolType), nil
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return atomic(intType), nil
+	case reflect.Int:
+		return union(atomic(intType), atomic(longType)), nil
+	case reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		if minSize {
+			return union(atomic(intType), atomic(longType)), nil
+		}
+		return atomic(longType), nil
+	case reflect.Float32, reflect.Float64:
+		return atomic(doubleType), nil
+	case reflect.String:
+		return atomic(stringType), nil
+	case reflect.Interface:
+		return anything, nil
+	case reflect.Ptr:
+		s, err := b.typeSchema(t.Elem(), path, minSize)
+		if err != nil {
+			return nil, err
+		}
+		return nullable(s), nil
+	case reflect.Struct:
+		return b.structSchema(t, path)
+	case reflect.Map:
+		// the values of a map are not constrained, since a schema of
+		// additional properties cannot be expressed
+		return nullable(document(&documentSchema{additionalProperties: true})), nil
+	case reflect.Array, reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			if t.Kind() == reflect.Array {
+				return atomic(binDataType), nil
+			}
+			return nullable(atomic(binDataType)), nil
+		}
+		items, err := b.typeSchema(t.Elem(), path+"[]", minSize)
+		if err != nil {
+			return nil, err
+		}
+		if t.Kind() == reflect.Array {
+			return array(items), nil
+		}
+		return nullable(array(items)), nil
+	}
+	return nil, fmt.Errorf("%w: %s has type %s", ErrUnsupportedType, path, t)
+}
+
+// nullable returns a schema that matches null or the values s matches.
+func nullable(s *schema) *schema {
+	return union(atomic(nullType), s)
+}
@@ -0,0 +1,194 @@
+package catalog_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mongodb/mongosql/go/mongosql/catalog"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type testAddress struct {
+	Street string  `bson:"street"`
+	Zip    *string `bson:"zip,omitempty"`
+}
+
+type TestBase struct {
+	ID      primitive.ObjectID `bson:"_id"`
+	Created time.Time          `bson:"created"`
+}
+
+type testPerson struct {
+	TestBase `bson:",inline"`
+	Name     string               `bson:"name"`
+	Age      int32                `bson:"age,omitempty"`
+	Balance  primitive.Decimal128 `bson:"balance"`
+	Tags     []string             `bson:"tags"`
+	Address  testAddress          `bson:"address"`
+	Nickname *string              `bson:"nickname"`
+	Ignored  string               `bson:"-"`
+	Count    int64
+	secret   string
+}
+
+type testExtra struct {
+	A     int32                  `bson:"a"`
+	Extra map[string]interface{} `bson:",inline"`
+}
+
+type testOptionalBase struct {
+	*TestBase `bson:",inline"`
+	Name      string `bson:"name"`
+}
+
+type testNode struct {
+	Next *testNode `bson:"next"`
+}
+
+type testDuplicate struct {
+	A string `bson:"a"`
+	B string `bson:"a"`
+}
+
+type testChannel struct {
+	C chan int `bson:"c"`
+}
+
+type testBadInline struct {
+	A string `bson:",inline"`
+}
+
+func TestSchemaFromType(t *testing.T) {
+	bsonType := func(name string) bson.D {
+		return bson.D{{Key: "bsonType", Value: name}}
+	}
+	nullable := func(schema bson.D) bson.D {
+		return bson.D{{Key: "anyOf", Value: bson.A{bsonType("null"), schema}}}
+	}
+	object := func(properties bson.D, required bson.A, additionalProperties bool) bson.D {
+		schema := bson.D{{Key: "bsonType", Value: "object"}, {Key: "properties", Value: properties}}
+		if required != nil {
+			schema = append(schema, bson.E{Key: "required", Value: required})
+		}
+		return append(schema, bson.E{Key: "additionalProperties", Value: additionalProperties})
+	}
+
+	tests := []struct {
+		name     string
+		typ      reflect.Type
+		expected bson.D
+	}{
+		{
+			name: "struct",
+			typ:  reflect.TypeOf(testPerson{}),
+			expected: object(bson.D{
+				{Key: "_id", Value: bsonType("objectId")},
+				{Key: "address", Value: object(bson.D{
+					{Key: "street", Value: bsonType("string")},
+					{Key: "zip", Value: nullable(bsonType("string"))},
+				}, bson.A{"street"}, false)},
+				{Key: "age", Value: bsonType("int")},
+				{Key: "balance", Value: bsonType("decimal")},
+				{Key: "count", Value: bsonType("long")},
+				{Key: "created", Value: bsonType("date")},
+				{Key: "name", Value: bsonType("string")},
+				{Key: "nickname", Value: nullable(bsonType("string"))},
+				{Key: "tags", Value: nullable(bson.D{{Key: "bsonType", Value: "array"}, {Key: "items", Value: bsonType("string")}})},
+			}, bson.A{"_id", "address", "balance", "count", "created", "name", "nickname", "tags"}, false),
+		},
+		{
+			name: "inlined map",
+			typ:  reflect.TypeOf(&testExtra{}),
+			expected: object(bson.D{
+				{Key: "a", Value: bsonType("int")},
+			}, bson.A{"a"}, true),
+		},
+		{
+			name: "inlined struct pointer",
+			typ:  reflect.TypeOf(testOptionalBase{}),
+			expected: object(bson.D{
+				{Key: "_id", Value: bsonType("objectId")},
+				{Key: "created", Value: bsonType("date")},
+				{Key: "name", Value: bsonType("string")},
+			}, bson.A{"name"}, false),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			schema, err := catalog.SchemaFromType(test.typ)
+			if err != nil {
+				t.Fatalf("expected err to be nil, got '%s'", err)
+			}
+			if diff := cmp.Diff(marshalSchema(t, test.expected), bson.Raw(schema).String()); diff != "" {
+				t.Fatalf("unexpected schema (-want +got):\n%s", diff)
+			}
+			if issues := catalog.Validate(catalog.CatalogSchema{"test": {"foo": schema}}); catalog.HasErrors(issues) {
+				t.Fatalf("expected the schema to be valid, got %v", issues)
+			}
+		})
+	}
+}
+
+func TestSchemaForMatchesEncodedDocuments(t *testing.T) {
+	schema, err := catalog.SchemaFor[*testPerson]()
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+	fromType, err := catalog.SchemaFromType(reflect.TypeOf(testPerson{}))
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+	if diff := cmp.Diff(bson.Raw(fromType).String(), bson.Raw(schema).String()); diff != "" {
+		t.Fatalf("unexpected schema (-want +got):\n%s", diff)
+	}
+
+	zip, nickname := "10001", "bob"
+	for _, person := range []testPerson{
+		{},
+		{
+			TestBase: TestBase{ID: primitive.NewObjectID(), Created: time.Now()},
+			Name:     "Robert",
+			Age:      42,
+			Tags:     []string{"a", "b"},
+			Address:  testAddress{Street: "Broadway", Zip: &zip},
+			Nickname: &nickname,
+			Count:    1,
+		},
+	} {
+		doc, err := bson.Marshal(person)
+		if err != nil {
+			t.Fatalf("expected err to be nil, got '%s'", err)
+		}
+		if !matchesSchema(bson.RawValue{Type: bsontype.EmbeddedDocument, Value: doc}, bson.Raw(schema)) {
+			t.Fatalf("expected %s to match %s", bson.Raw(doc), bson.Raw(schema))
+		}
+	}
+}
+
+func TestSchemaFromTypeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		typ  reflect.Type
+	}{
+		{name: "not a struct", typ: reflect.TypeOf(0)},
+		{name: "recursive struct", typ: reflect.TypeOf(testNode{})},
+		{name: "duplicate field names", typ: reflect.TypeOf(testDuplicate{})},
+		{name: "unsupported field type", typ: reflect.TypeOf(testChannel{})},
+		{name: "inlined string", typ: reflect.TypeOf(testBadInline{})},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := catalog.SchemaFromType(test.typ)
+			if !errors.Is(err, catalog.ErrUnsupportedType) {
+				t.Fatalf("expected ErrUnsupportedType, got '%v'", err)
+			}
+		})
+	}
+}
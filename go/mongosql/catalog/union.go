@@ -0,0 +1,550 @@
+package catalog
+
+import (
+	"sort"
+)
+
+// This file is a port of the parts of the Rust mongosql::schema::Schema
+// used to derive a schema from sample documents: schema_for_document
+// from the schema_derivation crate, and Schema::union and
+// Schema::simplify, including the Jaccard index heuristics that stop
+// documents with unstable keys from growing without bound. Schemas are
+// never modified once built, so they can be shared freely.
+//
+// The cases in testdata/schema_for_documents at the root of the
+// repository are checked by both implementations, so that changes to
+// one of them that are not made to the other are caught.
+
+// schemaKind is the variant of a schema. The variants are ordered as
+// in Rust, which decides how schemas are ordered in an anyOf. Missing
+// is left out, since a schema derived from documents never has it.
+type schemaKind int
+
+const (
+	unsatKind schemaKind = iota
+	atomicKind
+	arrayKind
+	documentKind
+	anyOfKind
+	anyKind
+)
+
+// atomicType is an atomic BSON type, ordered as in Rust.
+type atomicType int
+
+const (
+	minKeyType atomicType = iota
+	nullType
+	intType
+	longType
+	doubleType
+	decimalType
+	symbolType
+	stringType
+	binDataType
+	undefinedType
+	objectIDType
+	boolType
+	dateType
+	timestampType
+	regexType
+	dbPointerType
+	javascriptType
+	javascriptWithScopeType
+	maxKeyType
+)
+
+// bsonTypeName returns the JSON Schema bsonType name of t.
+func (t atomicType) bsonTypeName() string {
+	return [...]string{
+		minKeyType:              "minKey",
+		nullType:                "null",
+		intType:                 "int",
+		longType:                "long",
+		doubleType:              "double",
+		decimalType:             "decimal",
+		symbolType:              "symbol",
+		stringType:              "string",
+		binDataType:             "binData",
+		undefinedType:           "undefined",
+		objectIDType:            "objectId",
+		boolType:                "bool",
+		dateType:                "date",
+		timestampType:           "timestamp",
+		regexType:               "regex",
+		dbPointerType:           "dbPointer",
+		javascriptType:          "javascript",
+		javascriptWithScopeType: "javascriptWithScope",
+		maxKeyType:              "maxKey",
+	}[t]
+}
+
+// schema is a derived schema. Only the fields of its kind are set.
+type schema struct {
+	kind     schemaKind
+	atomic   atomicType
+	items    *schema
+	document *documentSchema
+	// anyOf is sorted by compareSchemas and has no duplicates
+	anyOf []*schema
+}
+
+var (
+	unsatisfiable = &schema{kind: unsatKind}
+	anything      = &schema{kind: anyKind}
+)
+
+func atomic(t atomicType) *schema {
+	return &schema{kind: atomicKind, atomic: t}
+}
+
+func array(items *schema) *schema {
+	return &schema{kind: arrayKind, items: items}
+}
+
+func document(d *documentSchema) *schema {
+	return &schema{kind: documentKind, document: d}
+}
+
+// jaccardIndex tracks how similar the key sets of the unioned
+// documents are, to find out when a document schema is unstable.
+type jaccardIndex struct {
+	avg            float64
+	numUnions      int
+	stabilityLimit float64
+}
+
+// defaultStabilityLimit is the stability limit the schema builder uses.
+const defaultStabilityLimit = 0.8
+
+// maxNumDocUnions is the number of unions after which a document schema
+// whose keys vary too much is marked unstable.
+const maxNumDocUnions = 19
+
+// documentSchema is the schema of a document.
+type documentSchema struct {
+	keys                 map[string]*schema
+	required             map[string]bool
+	additionalProperties bool
+	jaccard              *jaccardIndex
+	unstable             bool
+	// counts is the number of unioned documents each key appeared in,
+	// and total the number of unioned documents. They are used to make
+	// the fields that appear in enough documents required, and are not
+	// part of the Rust schema.
+	counts map[string]int
+	total  int
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// compareSchemas orders schemas as the Rust Ord of Schema does, and
+// returns a negative number, zero or a positive number if a is less
+// than, equal to or greater than b.
+func compareSchemas(a, b *schema) int {
+	if a.kind != b.kind {
+		return int(a.kind) - int(b.kind)
+	}
+	switch a.kind {
+	case atomicKind:
+		return int(a.atomic) - int(b.atomic)
+	case arrayKind:
+		return compareSchemas(a.items, b.items)
+	case documentKind:
+		return compareDocuments(a.document, b.document)
+	case anyOfKind:
+		for i := 0; i < len(a.anyOf) && i < len(b.anyOf); i++ {
+			if c := compareSchemas(a.anyOf[i], b.anyOf[i]); c != 0 {
+				return c
+			}
+		}
+		return len(a.anyOf) - len(b.anyOf)
+	}
+	return 0
+}
+
+// compareDocuments orders document schemas by their keys, required
+// fields, additional properties and stability, as in Rust.
+func compareDocuments(a, b *documentSchema) int {
+	aKeys, bKeys := sortedKeys(a.keys), sortedKeys(b.keys)
+	for i := 0; i < len(aKeys) && i < len(bKeys); i++ {
+		if aKeys[i] != bKeys[i] {
+			return compareStrings(aKeys[i], bKeys[i])
+		}
+		if c := compareSchemas(a.keys[aKeys[i]], b.keys[bKeys[i]]); c != 0 {
+			return c
+		}
+	}
+	if len(aKeys) != len(bKeys) {
+		return len(aKeys) - len(bKeys)
+	}
+
+	aRequired, bRequired := sortedKeys(a.required), sortedKeys(b.required)
+	for i := 0; i < len(aRequired) && i < len(bRequired); i++ {
+		if aRequired[i] != bRequired[i] {
+			return compareStrings(aRequired[i], bRequired[i])
+		}
+	}
+	if len(aRequired) != len(bRequired) {
+		return len(aRequired) - len(bRequired)
+	}
+
+	if c := compareBools(a.additionalProperties, b.additionalProperties); c != 0 {
+		return c
+	}
+	return compareBools(a.unstable, b.unstable)
+}
+
+func compareStrings(a, b string) int {
+	if a < b {
+		return -1
+	}
+	return 1
+}
+
+func compareBools(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	}
+	return -1
+}
+
+// insertSchema adds s to the sorted set of schemas set. If set already
+// has an equal schema, their counts are merged.
+func insertSchema(set []*schema, s *schema) []*schema {
+	i := sort.Search(len(set), func(i int) bool { return compareSchemas(set[i], s) >= 0 })
+	if i < len(set) && compareSchemas(set[i], s) == 0 {
+		result := append([]*schema(nil), set...)
+		result[i] = mergeCounts(set[i], s)
+		return result
+	}
+	result := make([]*schema, 0, len(set)+1)
+	result = append(result, set[:i]...)
+	result = append(result, s)
+	return append(result, set[i:]...)
+}
+
+// mergeCounts returns a, with the key counts of the documents in b,
+// which must be equal to a, added to it.
+func mergeCounts(a, b *schema) *schema {
+	switch a.kind {
+	case arrayKind:
+		return array(mergeCounts(a.items, b.items))
+	case documentKind:
+		d := *a.document
+		d.keys = make(map[string]*schema, len(a.document.keys))
+		for k, s := range a.document.keys {
+			d.keys[k] = mergeCounts(s, b.document.keys[k])
+		}
+		d.counts = addCounts(a.document.counts, b.document.counts)
+		d.total = a.document.total + b.document.total
+		return document(&d)
+	case anyOfKind:
+		anyOf := make([]*schema, len(a.anyOf))
+		for i := range a.anyOf {
+			anyOf[i] = mergeCounts(a.anyOf[i], b.anyOf[i])
+		}
+		return &schema{kind: anyOfKind, anyOf: anyOf}
+	}
+	return a
+}
+
+func addCounts(a, b map[string]int) map[string]int {
+	counts := make(map[string]int, len(a))
+	for k, n := range a {
+		counts[k] = n
+	}
+	for k, n := range b {
+		counts[k] += n
+	}
+	return counts
+}
+
+// simplify returns an equivalent schema in which anyOfs are flattened,
+// the documents of an anyOf are unioned into one, and anyOfs of a
+// single schema are replaced by it.
+func simplify(s *schema) *schema {
+	switch s.kind {
+	case anyOfKind:
+		var flattened []*schema
+		for _, branch := range s.anyOf {
+			branch = simplify(branch)
+			if branch.kind == anyOfKind {
+				for _, b := range branch.anyOf {
+					flattened = insertSchema(flattened, b)
+				}
+			} else {
+				flattened = insertSchema(flattened, branch)
+			}
+		}
+
+		var result []*schema
+		var doc *documentSchema
+		for _, branch := range flattened {
+			switch {
+			case branch.kind != documentKind:
+				result = insertSchema(result, branch)
+			case doc == nil:
+				doc = branch.document
+			default:
+				doc = unionDocuments(doc, branch.document)
+			}
+		}
+		if doc != nil {
+			result = insertSchema(result, document(doc))
+		}
+
+		switch {
+		case len(result) == 0:
+			return unsatisfiable
+		case result[len(result)-1].kind == anyKind:
+			return anything
+		case len(result) == 1:
+			return result[0]
+		}
+		return &schema{kind: anyOfKind, anyOf: result}
+	case arrayKind:
+		return array(simplify(s.items))
+	case documentKind:
+		d := *s.document
+		d.keys = make(map[string]*schema, len(s.document.keys))
+		for k, v := range s.document.keys {
+			d.keys[k] = simplify(v)
+		}
+		return document(&d)
+	}
+	return s
+}
+
+// union returns a schema that matches every value matched by a or b.
+func union(a, b *schema) *schema {
+	left, right := simplify(a), simplify(b)
+	switch c := compareSchemas(left, right); {
+	case c == 0:
+		return mergeCounts(left, right)
+	case c > 0:
+		left, right = right, left
+	}
+
+	switch {
+	case left.kind == unsatKind:
+		return right
+	case right.kind == anyKind:
+		return anything
+	case left.kind == anyOfKind && right.kind == anyOfKind:
+		result := left.anyOf
+		for _, s := range right.anyOf {
+			result = insertSchema(result, s)
+		}
+		return &schema{kind: anyOfKind, anyOf: result}
+	case left.kind == arrayKind && right.kind == arrayKind:
+		return array(union(left.items, right.items))
+	case left.kind == documentKind && right.kind == documentKind:
+		return document(unionDocuments(left.document, right.document))
+	case (left.kind == arrayKind || left.kind == documentKind) && right.kind == anyOfKind:
+		var same, rest []*schema
+		for _, s := range right.anyOf {
+			if s.kind == left.kind {
+				same = append(same, s)
+			} else {
+				rest = append(rest, s)
+			}
+		}
+		switch {
+		case len(same) == 0:
+			rest = insertSchema(rest, left)
+		case left.kind == arrayKind && len(same) > 1:
+			rest = insertSchema(rest, array(anything))
+		case left.kind == arrayKind:
+			rest = insertSchema(rest, array(union(same[0].items, left.items)))
+		default:
+			rest = insertSchema(rest, document(unionDocuments(same[0].document, left.document)))
+		}
+		return &schema{kind: anyOfKind, anyOf: rest}
+	case right.kind == anyOfKind:
+		return &schema{kind: anyOfKind, anyOf: insertSchema(right.anyOf, left)}
+	}
+	return &schema{kind: anyOfKind, anyOf: insertSchema([]*schema{left}, right)}
+}
+
+// unionDocuments returns a document schema that matches every document
+// matched by a or b. Once the Jaccard index of the documents shows that
+// their keys vary too much, the result is marked unstable, and from
+// then on the keys of the preferred document are kept and the other
+// keys only make it allow additional properties.
+func unionDocuments(a, b *documentSchema) *documentSchema {
+	if a.unstable || b.unstable {
+		return unstableUnionDocuments(a, b)
+	}
+
+	result := &documentSchema{
+		keys:                 unionKeys(a.keys, b.keys),
+		required:             intersectRequired(a.required, b.required),
+		additionalProperties: a.additionalProperties || b.additionalProperties,
+		counts:               addCounts(a.counts, b.counts),
+		total:                a.total + b.total,
+	}
+	if ji := combineJaccardIndexes(a, b); ji != nil {
+		intersection := len(result.keys)
+		if !isSubset(a.keys, b.keys) && !isSubset(b.keys, a.keys) {
+			intersection = 0
+			for k := range a.keys {
+				if _, ok := b.keys[k]; ok {
+					intersection++
+				}
+			}
+		}
+		ji = updateJaccardIndex(ji, len(result.keys), intersection)
+		// as the number of unions grows, this number becomes smaller
+		stabilizationRate := 1 / float64(ji.numUnions)
+		result.jaccard = ji
+		result.unstable = ji.numUnions >= maxNumDocUnions && ji.avg+stabilizationRate < ji.stabilityLimit
+	}
+	return result
+}
+
+// unstableUnionDocuments performs the union of document schemas when
+// one of them is unstable.
+func unstableUnionDocuments(a, b *documentSchema) *documentSchema {
+	preferred, other := a, b
+	switch {
+	case a.unstable && b.unstable:
+		if a.jaccard == nil && b.jaccard != nil || a.jaccard != nil && b.jaccard != nil && a.jaccard.avg < b.jaccard.avg {
+			preferred, other = b, a
+		}
+	case a.unstable:
+		preferred, other = b, a
+	}
+
+	var ji *jaccardIndex
+	if preferred.jaccard != nil {
+		intersection := 0
+		for k := range preferred.keys {
+			if _, ok := other.keys[k]; ok {
+				intersection++
+			}
+		}
+		ji = updateJaccardIndex(preferred.jaccard, len(preferred.keys), intersection)
+	}
+
+	keys := make(map[string]*schema, len(preferred.keys))
+	for k, s := range preferred.keys {
+		if o, ok := other.keys[k]; ok {
+			keys[k] = union(o, s)
+		} else {
+			keys[k] = s
+		}
+	}
+	otherHasExtraKeys := false
+	for k := range other.keys {
+		if _, ok := keys[k]; !ok {
+			otherHasExtraKeys = true
+		}
+	}
+
+	return &documentSchema{
+		keys:                 keys,
+		required:             intersectRequired(preferred.required, other.required),
+		additionalProperties: preferred.additionalProperties || other.additionalProperties || otherHasExtraKeys,
+		jaccard:              ji,
+		unstable:             true,
+		counts:               addCounts(preferred.counts, other.counts),
+		total:                preferred.total + other.total,
+	}
+}
+
+func unionKeys(a, b map[string]*schema) map[string]*schema {
+	keys := make(map[string]*schema, len(a))
+	for k, s := range a {
+		keys[k] = s
+	}
+	for k, s := range b {
+		if old, ok := keys[k]; ok {
+			keys[k] = union(old, s)
+		} else {
+			keys[k] = s
+		}
+	}
+	return keys
+}
+
+func intersectRequired(a, b map[string]bool) map[string]bool {
+	required := map[string]bool{}
+	for k := range a {
+		if b[k] {
+			required[k] = true
+		}
+	}
+	return required
+}
+
+func isSubset(a, b map[string]*schema) bool {
+	for k := range a {
+		if _, ok := b[k]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// combineJaccardIndexes averages the Jaccard indexes of a and b, or
+// returns nil if neither has one.
+func combineJaccardIndexes(a, b *documentSchema) *jaccardIndex {
+	if a.jaccard == nil && b.jaccard == nil {
+		return nil
+	}
+	defaultIndex := &jaccardIndex{avg: 1, stabilityLimit: defaultStabilityLimit}
+	ja, jb := a.jaccard, b.jaccard
+	if ja == nil {
+		ja = defaultIndex
+	}
+	if jb == nil {
+		jb = defaultIndex
+	}
+	numUnions := ja.numUnions + jb.numUnions
+	avg := 1.0
+	if numUnions > 0 {
+		avg = (ja.avg*float64(ja.numUnions) + jb.avg*float64(jb.numUnions)) / float64(numUnions)
+	}
+	return &jaccardIndex{avg: avg, numUnions: numUnions, stabilityLimit: ja.stabilityLimit}
+}
+
+// updateJaccardIndex adds the Jaccard index of a union with the given
+// sizes to the running average of ji.
+func updateJaccardIndex(ji *jaccardIndex, unionSize, intersectionSize int) *jaccardIndex {
+	index := float64(intersectionSize) / float64(unionSize)
+	if ji.numUnions == 0 {
+		return &jaccardIndex{avg: index, numUnions: 1, stabilityLimit: ji.stabilityLimit}
+	}
+	return &jaccardIndex{
+		avg:            (ji.avg*float64(ji.numUnions) + index) / float64(ji.numUnions+1),
+		numUnions:      ji.numUnions + 1,
+		stabilityLimit: ji.stabilityLimit,
+	}
+}
+
+// isUnstable returns true if s is or has an unstable document schema.
+func (s *schema) isUnstable() bool {
+	switch s.kind {
+	case documentKind:
+		return s.document.unstable
+	case anyOfKind:
+		for _, branch := range s.anyOf {
+			if branch.isUnstable() {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,298 @@
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// The problems reported by Validate, in addition to the problems
+// reported by a SchemaError.
+var (
+	// ErrInvalidCombination is reported for a schema that combines
+	// anyOf or oneOf with another keyword, which the translator rejects
+	ErrInvalidCombination = errors.New("anyOf and oneOf cannot be combined with other keywords")
+	// ErrNotDocument is reported for a collection schema whose bsonType
+	// does not allow documents
+	ErrNotDocument = errors.New("collection schemas must describe documents")
+	// ErrIgnoredKeyword is reported for a keyword that does not apply
+	// to any of the types the schema allows, and so is ignored
+	ErrIgnoredKeyword = errors.New("keyword does not apply to the allowed types and is ignored")
+	// ErrPositionalItems is reported for an items array, which only
+	// constrains the leading elements of an array, so the translator
+	// treats the elements as any type
+	ErrPositionalItems = errors.New("an items array is treated as allowing elements of any type")
+	// ErrUnsatisfiable is reported for a part of a schema that no value
+	// can match
+	ErrUnsatisfiable = errors.New("no value can match")
+	// ErrDuplicateRequired is reported for a field that is listed in
+	// required more than once
+	ErrDuplicateRequired = errors.New("field is already required")
+	// ErrNestedAnyOf is reported for an anyOf or oneOf branch that only
+	// holds another anyOf or oneOf, which can be flattened into it
+	ErrNestedAnyOf = errors.New("nested anyOf can be flattened into its parent")
+	// ErrWrappedSchema is reported for a collection schema wrapped in
+	// $jsonSchema, which the catalog does not unwrap
+	ErrWrappedSchema = errors.New("catalog schemas must not be wrapped in $jsonSchema")
+)
+
+// Severity tells whether an Issue makes translations fail.
+type Severity int
+
+const (
+	// SeverityError is the Severity of an Issue that makes the
+	// translation of queries against the collection fail
+	SeverityError Severity = iota
+	// SeverityWarning is the Severity of an Issue that the translator
+	// accepts, but that likely does not describe the collection as
+	// intended
+	SeverityWarning
+)
+
+// String returns "error" or "warning".
+func (s Severity) String() string {
+	if s == SeverityWarning {
+		return "warning"
+	}
+	return "error"
+}
+
+// Issue is a problem found in the schema of a collection by Validate.
+type Issue struct {
+	// Database and Collection are the namespace of the schema
+	Database   string
+	Collection string
+	// Pointer is the JSON pointer to the offending value within the
+	// schema
+	Pointer string
+	// Severity tells whether the issue makes translations fail
+	Severity Severity
+	// Err is the problem, which can be checked with errors.Is against
+	// the Err variables of this package
+	Err error
+}
+
+// String describes the issue with its namespace and JSON pointer.
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s.%s at %q: %s", i.Severity, i.Database, i.Collection, i.Pointer, i.Err)
+}
+
+// HasErrors returns true if any of issues has SeverityError.
+func HasErrors(issues []Issue) bool {
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks every schema of catalogSchema against what the
+// translator supports, so that problems are found before a query
+// touches the collection. Schemas that mongosql cannot read are
+// reported as errors, as LoadDir does. The other schemas are also
+// checked for combinations of keywords that the translator rejects,
+// and for keywords that are ignored or make the schema unsatisfiable,
+// which are reported as warnings. The issues are sorted by namespace.
+func Validate(catalogSchema CatalogSchema) []Issue {
+	var namespaces [][2]string
+	for db, collections := range catalogSchema {
+		for collection := range collections {
+			namespaces = append(namespaces, [2]string{db, collection})
+		}
+	}
+	sort.Slice(namespaces, func(i, j int) bool {
+		if namespaces[i][0] != namespaces[j][0] {
+			return namespaces[i][0] < namespaces[j][0]
+		}
+		return namespaces[i][1] < namespaces[j][1]
+	})
+
+	var issues []Issue
+	for _, ns := range namespaces {
+		db, collection := ns[0], ns[1]
+		issue := func(pointer string, severity Severity, err error) {
+			issues = append(issues, Issue{Database: db, Collection: collection, Pointer: pointer, Severity: severity, Err: err})
+		}
+
+		schema := bson.Raw(catalogSchema[db][collection])
+		if err := schema.Validate(); err != nil {
+			issue("", SeverityError, fmt.Errorf("%w: %s", ErrInvalidDocument, err))
+			continue
+		}
+		if schemaErrs := checkSchema(schema, ""); len(schemaErrs) > 0 {
+			for _, schemaErr := range schemaErrs {
+				issue(schemaErr.Pointer, SeverityError, schemaErr.Err)
+			}
+			continue
+		}
+
+		if _, err := schema.LookupErr("$jsonSchema"); err == nil {
+			issue("/$jsonSchema", SeverityWarning, ErrWrappedSchema)
+		}
+		if types, ok := allowedTypes(schema); ok && !types["object"] {
+			issue("/bsonType", SeverityError, ErrNotDocument)
+		}
+		lintSchema(schema, "", issue)
+	}
+	return issues
+}
+
+// keyword returns the value of the keyword key of schema, and whether
+// it is set. A null keyword is treated as a missing one.
+func keyword(schema bson.Raw, key string) (bson.RawValue, bool) {
+	value, err := schema.LookupErr(key)
+	if err != nil || value.Type == bsontype.Null {
+		return bson.RawValue{}, false
+	}
+	return value, true
+}
+
+// allowedTypes returns the set of types named by the bsonType of
+// schema, and false if it has no bsonType, in which case every type is
+// allowed.
+func allowedTypes(schema bson.Raw) (map[string]bool, bool) {
+	value, ok := keyword(schema, "bsonType")
+	if !ok {
+		return nil, false
+	}
+	if name, ok := value.StringValueOK(); ok {
+		return map[string]bool{name: true}, true
+	}
+	types := map[string]bool{}
+	values, _ := value.Array().Values()
+	for _, v := range values {
+		types[v.StringValue()] = true
+	}
+	return types, true
+}
+
+// unionKeywords are the keywords that must be alone in a schema, and
+// typeKeywords the keywords that they cannot be combined with.
+var (
+	unionKeywords = []string{"anyOf", "oneOf"}
+	typeKeywords  = []string{"bsonType", "properties", "required", "additionalProperties", "items", "maxItems"}
+)
+
+// lintSchema reports the issues of schema, which is at pointer, and of
+// the schemas nested in it, following the rules of the Rust conversion
+// of a json_schema::Schema to a mongosql schema. schema must have
+// passed checkSchema.
+func lintSchema(schema bson.Raw, pointer string, issue func(pointer string, severity Severity, err error)) {
+	var unions, others []string
+	for _, key := range unionKeywords {
+		if _, ok := keyword(schema, key); ok {
+			unions = append(unions, key)
+		}
+	}
+	for _, key := range typeKeywords {
+		if _, ok := keyword(schema, key); ok {
+			others = append(others, key)
+		}
+	}
+	if len(unions) > 1 || (len(unions) == 1 && len(others) > 0) {
+		issue(pointer, SeverityError, ErrInvalidCombination)
+	}
+
+	for _, key := range unionKeywords {
+		value, ok := keyword(schema, key)
+		if !ok {
+			continue
+		}
+		branches, _ := value.Array().Values()
+		if len(branches) == 0 {
+			issue(pointer+"/"+key, SeverityWarning, ErrUnsatisfiable)
+		}
+		for i, branch := range branches {
+			p := fmt.Sprintf("%s/%s/%d", pointer, key, i)
+			if isOnlyUnion(branch.Document()) {
+				issue(p, SeverityWarning, ErrNestedAnyOf)
+			}
+			lintSchema(branch.Document(), p, issue)
+		}
+	}
+
+	types, typed := allowedTypes(schema)
+	if typed && len(types) == 0 {
+		issue(pointer+"/bsonType", SeverityWarning, ErrUnsatisfiable)
+	}
+	allows := func(name string) bool {
+		return !typed || types[name]
+	}
+	for _, key := range others {
+		switch key {
+		case "properties", "required", "additionalProperties":
+			if !allows("object") {
+				issue(pointer+"/"+key, SeverityWarning, ErrIgnoredKeyword)
+			}
+		case "items", "maxItems":
+			if !allows("array") {
+				issue(pointer+"/"+key, SeverityWarning, ErrIgnoredKeyword)
+			}
+		}
+	}
+
+	properties := map[string]bool{}
+	if value, ok := keyword(schema, "properties"); ok {
+		elements, _ := value.Document().Elements()
+		for _, element := range elements {
+			properties[element.Key()] = true
+			lintSchema(element.Value().Document(), pointer+"/properties/"+escapePointerToken(element.Key()), issue)
+		}
+	}
+
+	if value, ok := keyword(schema, "required"); ok && allows("object") {
+		closed := false
+		if additional, ok := keyword(schema, "additionalProperties"); ok {
+			closed = !additional.Boolean()
+		}
+		seen := map[string]bool{}
+		values, _ := value.Array().Values()
+		for i, v := range values {
+			p := fmt.Sprintf("%s/required/%d", pointer, i)
+			field := v.StringValue()
+			switch {
+			case seen[field]:
+				issue(p, SeverityWarning, fmt.Errorf("%w: %q", ErrDuplicateRequired, field))
+			case closed && !properties[field]:
+				issue(p, SeverityWarning, fmt.Errorf("%w: %q is required, but is not in properties and additionalProperties is false", ErrUnsatisfiable, field))
+			}
+			seen[field] = true
+		}
+	}
+
+	if value, ok := keyword(schema, "items"); ok {
+		switch value.Type {
+		case bsontype.EmbeddedDocument:
+			lintSchema(value.Document(), pointer+"/items", issue)
+		case bsontype.Array:
+			if allows("array") {
+				issue(pointer+"/items", SeverityWarning, ErrPositionalItems)
+			}
+			items, _ := value.Array().Values()
+			for i, item := range items {
+				lintSchema(item.Document(), fmt.Sprintf("%s/items/%d", pointer, i), issue)
+			}
+		}
+	}
+}
+
+// isOnlyUnion returns true if schema has a single anyOf or oneOf and no
+// other keyword the translator uses.
+func isOnlyUnion(schema bson.Raw) bool {
+	unions := 0
+	for _, key := range unionKeywords {
+		if _, ok := keyword(schema, key); ok {
+			unions++
+		}
+	}
+	for _, key := range typeKeywords {
+		if _, ok := keyword(schema, key); ok {
+			return false
+		}
+	}
+	return unions == 1
+}
@@ -0,0 +1,219 @@
+package catalog_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mongodb/mongosql/go/mongosql/catalog"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// issueSummary is a comparable description of an Issue.
+type issueSummary struct {
+	Namespace string
+	Pointer   string
+	Severity  catalog.Severity
+	Sentinel  error
+}
+
+var issueSentinels = []error{
+	catalog.ErrInvalidDocument,
+	catalog.ErrWrongType,
+	catalog.ErrUnknownBsonType,
+	catalog.ErrInvalidCombination,
+	catalog.ErrNotDocument,
+	catalog.ErrIgnoredKeyword,
+	catalog.ErrPositionalItems,
+	catalog.ErrUnsatisfiable,
+	catalog.ErrDuplicateRequired,
+	catalog.ErrNestedAnyOf,
+	catalog.ErrWrappedSchema,
+	catalog.ErrDroppedKeyword,
+	catalog.ErrWidenedKeyword,
+	catalog.ErrUnenforcedValidator,
+}
+
+func summarizeIssues(issues []catalog.Issue) []issueSummary {
+	var summaries []issueSummary
+	for _, issue := range issues {
+		summary := issueSummary{
+			Namespace: issue.Database + "." + issue.Collection,
+			Pointer:   issue.Pointer,
+			Severity:  issue.Severity,
+		}
+		for _, sentinel := range issueSentinels {
+			if errors.Is(issue.Err, sentinel) {
+				summary.Sentinel = sentinel
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
+func parseSchema(t *testing.T, extJSON string) bsoncore.Document {
+	var schema bson.Raw
+	if err := bson.UnmarshalExtJSON([]byte(extJSON), false, &schema); err != nil {
+		t.Fatalf("failed to parse schema: %s", err)
+	}
+	return bsoncore.Document(schema)
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   string
+		expected []issueSummary
+	}{
+		{
+			name: "valid schema",
+			schema: `{
+				"bsonType": "object",
+				"properties": {
+					"a": {"bsonType": ["int", "null"]},
+					"b": {"bsonType": "array", "items": {"anyOf": [{"bsonType": "string"}, {"bsonType": "object", "properties": {}}]}},
+					"c": {}
+				},
+				"required": ["a"],
+				"additionalProperties": false
+			}`,
+		},
+		{
+			name:   "unreadable schema",
+			schema: `{"bsonType": "object", "properties": {"a": {"bsonType": "number"}}, "required": "a"}`,
+			expected: []issueSummary{
+				{Namespace: "test.foo", Pointer: "/properties/a/bsonType", Severity: catalog.SeverityError, Sentinel: catalog.ErrUnknownBsonType},
+				{Namespace: "test.foo", Pointer: "/required", Severity: catalog.SeverityError, Sentinel: catalog.ErrWrongType},
+			},
+		},
+		{
+			name: "anyOf combined with other keywords",
+			schema: `{
+				"bsonType": "object",
+				"properties": {
+					"a": {"bsonType": "int", "anyOf": [{"bsonType": "int"}]},
+					"b": {"anyOf": [{"bsonType": "int"}], "oneOf": [{"bsonType": "string"}]}
+				}
+			}`,
+			expected: []issueSummary{
+				{Namespace: "test.foo", Pointer: "/properties/a", Severity: catalog.SeverityError, Sentinel: catalog.ErrInvalidCombination},
+				{Namespace: "test.foo", Pointer: "/properties/b", Severity: catalog.SeverityError, Sentinel: catalog.ErrInvalidCombination},
+			},
+		},
+		{
+			name:   "collection schema that cannot describe documents",
+			schema: `{"bsonType": ["array", "null"]}`,
+			expected: []issueSummary{
+				{Namespace: "test.foo", Pointer: "/bsonType", Severity: catalog.SeverityError, Sentinel: catalog.ErrNotDocument},
+			},
+		},
+		{
+			name: "nested and empty anyOf",
+			schema: `{
+				"bsonType": "object",
+				"properties": {
+					"a": {"anyOf": [{"oneOf": [{"bsonType": "int"}, {"bsonType": "long"}]}, {"bsonType": "null"}]},
+					"b": {"oneOf": []},
+					"c": {"bsonType": []}
+				}
+			}`,
+			expected: []issueSummary{
+				{Namespace: "test.foo", Pointer: "/properties/a/anyOf/0", Severity: catalog.SeverityWarning, Sentinel: catalog.ErrNestedAnyOf},
+				{Namespace: "test.foo", Pointer: "/properties/b/oneOf", Severity: catalog.SeverityWarning, Sentinel: catalog.ErrUnsatisfiable},
+				{Namespace: "test.foo", Pointer: "/properties/c/bsonType", Severity: catalog.SeverityWarning, Sentinel: catalog.ErrUnsatisfiable},
+			},
+		},
+		{
+			name: "keywords that do not apply to the allowed types",
+			schema: `{
+				"bsonType": "object",
+				"properties": {
+					"a": {"bsonType": "string", "properties": {"x": {}}, "maxItems": 1},
+					"b": {"bsonType": "object", "items": {"bsonType": "int"}},
+					"c": {"bsonType": ["array", "object"], "items": {}, "required": []}
+				}
+			}`,
+			expected: []issueSummary{
+				{Namespace: "test.foo", Pointer: "/properties/a/properties", Severity: catalog.SeverityWarning, Sentinel: catalog.ErrIgnoredKeyword},
+				{Namespace: "test.foo", Pointer: "/properties/a/maxItems", Severity: catalog.SeverityWarning, Sentinel: catalog.ErrIgnoredKeyword},
+				{Namespace: "test.foo", Pointer: "/properties/b/items", Severity: catalog.SeverityWarning, Sentinel: catalog.ErrIgnoredKeyword},
+			},
+		},
+		{
+			name: "items array",
+			schema: `{
+				"bsonType": "object",
+				"properties": {"a": {"bsonType": "array", "items": [{"bsonType": "int"}, {"bsonType": "object", "required": ["x"], "additionalProperties": false}]}}
+			}`,
+			expected: []issueSummary{
+				{Namespace: "test.foo", Pointer: "/properties/a/items", Severity: catalog.SeverityWarning, Sentinel: catalog.ErrPositionalItems},
+				{Namespace: "test.foo", Pointer: "/properties/a/items/1/required/0", Severity: catalog.SeverityWarning, Sentinel: catalog.ErrUnsatisfiable},
+			},
+		},
+		{
+			name: "inconsistent required",
+			schema: `{
+				"bsonType": "object",
+				"properties": {"a": {}, "b~c": {"bsonType": "object", "properties": {"x": {}}, "required": ["x", "y", "x"], "additionalProperties": false}},
+				"required": ["a", "z", "a"]
+			}`,
+			expected: []issueSummary{
+				{Namespace: "test.foo", Pointer: "/properties/b~0c/required/1", Severity: catalog.SeverityWarning, Sentinel: catalog.ErrUnsatisfiable},
+				{Namespace: "test.foo", Pointer: "/properties/b~0c/required/2", Severity: catalog.SeverityWarning, Sentinel: catalog.ErrDuplicateRequired},
+				{Namespace: "test.foo", Pointer: "/required/2", Severity: catalog.SeverityWarning, Sentinel: catalog.ErrDuplicateRequired},
+			},
+		},
+		{
+			name:   "schema wrapped in $jsonSchema",
+			schema: `{"$jsonSchema": {"bsonType": "object"}}`,
+			expected: []issueSummary{
+				{Namespace: "test.foo", Pointer: "/$jsonSchema", Severity: catalog.SeverityWarning, Sentinel: catalog.ErrWrappedSchema},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			issues := catalog.Validate(catalog.CatalogSchema{"test": {"foo": parseSchema(t, test.schema)}})
+			if diff := cmp.Diff(test.expected, summarizeIssues(issues), cmp.Comparer(func(a, b error) bool { return a == b })); diff != "" {
+				t.Fatalf("unexpected issues (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateNamespaces(t *testing.T) {
+	catalogSchema := catalog.CatalogSchema{
+		"b": {
+			"y": parseSchema(t, `{"bsonType": "int"}`),
+			"x": bsoncore.Document{0x05, 0x00, 0x00, 0x00, 0x01},
+		},
+		"a": {
+			"z":  parseSchema(t, `{"bsonType": "object", "required": ["a", "a"]}`),
+			"ok": parseSchema(t, `{"bsonType": "object"}`),
+		},
+	}
+
+	issues := catalog.Validate(catalogSchema)
+	expected := []issueSummary{
+		{Namespace: "a.z", Pointer: "/required/1", Severity: catalog.SeverityWarning, Sentinel: catalog.ErrDuplicateRequired},
+		{Namespace: "b.x", Pointer: "", Severity: catalog.SeverityError, Sentinel: catalog.ErrInvalidDocument},
+		{Namespace: "b.y", Pointer: "/bsonType", Severity: catalog.SeverityError, Sentinel: catalog.ErrNotDocument},
+	}
+	if diff := cmp.Diff(expected, summarizeIssues(issues), cmp.Comparer(func(a, b error) bool { return a == b })); diff != "" {
+		t.Fatalf("unexpected issues (-want +got):\n%s", diff)
+	}
+	if !catalog.HasErrors(issues) {
+		t.Fatalf("expected issues to have errors")
+	}
+	if catalog.HasErrors(issues[:1]) {
+		t.Fatalf("expected warnings not to count as errors")
+	}
+
+	expectedString := `warning: a.z at "/required/1": field is already required: "a"`
+	if issues[0].String() != expectedString {
+		t.Fatalf("expected %q, got %q", expectedString, issues[0].String())
+	}
+}
@@ -0,0 +1,571 @@
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// The errors of FromValidator, and the lossy conversions it reports in
+// addition to ErrPositionalItems, ErrUnknownBsonType and ErrWrongType.
+var (
+	// ErrNoValidator is returned for a listCollections entry without a
+	// $jsonSchema validator
+	ErrNoValidator = errors.New("no $jsonSchema validator")
+	// ErrDroppedKeyword is reported for a keyword that the translator
+	// does not support, and that was dropped, so the schema allows
+	// values the validator rejects
+	ErrDroppedKeyword = errors.New("keyword is not supported and was dropped")
+	// ErrWidenedKeyword is reported for a keyword that was replaced by
+	// one the translator supports, but that allows more values
+	ErrWidenedKeyword = errors.New("keyword was widened to one the translator supports")
+	// ErrUnenforcedValidator is reported for a validator whose
+	// validationLevel is not strict or whose validationAction is not
+	// error, so documents that it rejects can be in the collection
+	ErrUnenforcedValidator = errors.New("validator is not enforced on every document")
+)
+
+// numberTypes are the bsonTypes of numbers.
+var numberTypes = map[string]bool{"int": true, "long": true, "double": true, "decimal": true}
+
+// jsonTypes maps the values of the type keyword of JSON Schema to the
+// bsonTypes they allow.
+var jsonTypes = map[string][]string{
+	"object":  {"object"},
+	"array":   {"array"},
+	"string":  {"string"},
+	"boolean": {"bool"},
+	"null":    {"null"},
+	"number":  {"int", "long", "double", "decimal"},
+}
+
+// FromValidator converts the $jsonSchema validator of a collection,
+// given its listCollections entry, to a schema of the subset of JSON
+// Schema that the translator understands, which can be used as the
+// schema of the collection in a CatalogSchema.
+//
+// The bsonType, type, enum, encrypt, properties, required,
+// additionalProperties, items, maxItems, anyOf and oneOf keywords are
+// converted. A oneOf becomes an anyOf, an enum the bsonTypes of its
+// values, where a number allows every numeric type since the server
+// compares numbers by value, and the other keywords are dropped, so the schema matches
+// every document the validator accepts, and may match more. Each of
+// these lossy conversions is returned as an Issue with
+// SeverityWarning, whose Pointer is relative to the validator, and
+// whose Err is ErrDroppedKeyword, ErrWidenedKeyword or another Err
+// variable of this package. Query operators of the validator other
+// than $jsonSchema are dropped and reported as well.
+//
+// A validator is only a description of every document of its
+// collection when its validationLevel is strict and its
+// validationAction is error, which are their defaults. Otherwise, the
+// schema is widened to allow any value for each of its top-level
+// properties and any other property, and an Issue with the Pointer ""
+// and ErrUnenforcedValidator is returned for each of the two options
+// that has another value.
+//
+// The returned error is an *EntryError, and is ErrNoValidator for an
+// entry without a $jsonSchema validator, such as the entry of a view.
+func FromValidator(listCollectionsEntry bson.Raw) (bsoncore.Document, []Issue, error) {
+	_, schema, issues, err := parseValidator(listCollectionsEntry)
+	if err != nil {
+		return nil, issues, err
+	}
+	return schema, issues, nil
+}
+
+// FromListCollections returns the CatalogSchema converted by
+// FromValidator from the $jsonSchema validators of entries, the output
+// of listCollections for the database db. Entries without a $jsonSchema
+// validator, such as views, are skipped. The returned issues are the
+// lossy conversions of every schema, with their Database set to db.
+//
+// Every entry is converted, and the returned error, if any, is an
+// EntryErrors holding an EntryError for each malformed entry. The
+// returned CatalogSchema holds the other schemas.
+func FromListCollections(db string, entries []bson.Raw) (CatalogSchema, []Issue, error) {
+	collections := map[string]bsoncore.Document{}
+	var issues []Issue
+	var errs EntryErrors
+	for i, entry := range entries {
+		collection, schema, entryIssues, err := parseValidator(entry)
+		if err != nil && errors.Is(err.Err, ErrNoValidator) {
+			continue
+		}
+		if err == nil {
+			if _, ok := collections[collection]; ok {
+				err = &EntryError{Collection: collection, Err: ErrDuplicateCollection}
+			}
+		}
+		if err != nil {
+			err.Database, err.Index = db, i
+			errs = append(errs, err)
+			continue
+		}
+		for _, issue := range entryIssues {
+			issue.Database = db
+			issues = append(issues, issue)
+		}
+		collections[collection] = schema
+	}
+
+	catalogSchema := CatalogSchema{db: collections}
+	if len(errs) > 0 {
+		return catalogSchema, issues, errs
+	}
+	return catalogSchema, issues, nil
+}
+
+// parseValidator returns the collection name of a listCollections
+// entry, and the schema converted from its validator along with the
+// lossy conversions. The Database and Index of a returned error are
+// left for the caller to fill in.
+func parseValidator(entry bson.Raw) (string, bsoncore.Document, []Issue, *EntryError) {
+	if err := entry.Validate(); err != nil {
+		return "", nil, nil, &EntryError{Err: fmt.Errorf("%w: %s", ErrInvalidDocument, err)}
+	}
+
+	name, err := entry.LookupErr("name")
+	if err != nil {
+		return "", nil, nil, &EntryError{Field: "name", Err: ErrMissingField}
+	}
+	collection, ok := name.StringValueOK()
+	if !ok {
+		return "", nil, nil, &EntryError{Field: "name", Err: fmt.Errorf("%w: expected a string, got %s", ErrWrongType, name.Type)}
+	}
+
+	validator, ok := entry.Lookup("options", "validator").DocumentOK()
+	if !ok {
+		return collection, nil, nil, &EntryError{Collection: collection, Field: "options.validator", Err: ErrNoValidator}
+	}
+	jsonSchema, err := validator.LookupErr("$jsonSchema")
+	if err != nil {
+		return collection, nil, nil, &EntryError{Collection: collection, Field: "options.validator.$jsonSchema", Err: ErrNoValidator}
+	}
+	if jsonSchema.Type != bsontype.EmbeddedDocument {
+		return collection, nil, nil, &EntryError{
+			Collection: collection,
+			Field:      "options.validator.$jsonSchema",
+			Err:        fmt.Errorf("%w: expected a document, got %s", ErrWrongType, jsonSchema.Type),
+		}
+	}
+
+	c := &validatorConverter{collection: collection}
+	elements, _ := validator.Elements()
+	for _, element := range elements {
+		if element.Key() != "$jsonSchema" {
+			c.report("/"+escapePointerToken(element.Key()), ErrDroppedKeyword)
+		}
+	}
+	converted := c.convert(jsonSchema.Document(), "/$jsonSchema")
+
+	schema, err := bson.Marshal(converted)
+	if err != nil {
+		return collection, nil, c.issues, &EntryError{Collection: collection, Err: fmt.Errorf("failed to marshal schema: %w", err)}
+	}
+	if types, ok := allowedTypes(schema); ok && !types["object"] {
+		return collection, nil, c.issues, &EntryError{Collection: collection, Field: "options.validator.$jsonSchema.bsonType", Err: ErrNotDocument}
+	}
+
+	enforced := true
+	for _, option := range []struct{ name, enforced string }{
+		{"validationLevel", "strict"},
+		{"validationAction", "error"},
+	} {
+		value, err := entry.LookupErr("options", option.name)
+		if err != nil {
+			continue
+		}
+		if s, ok := value.StringValueOK(); !ok || s != option.enforced {
+			c.report("", fmt.Errorf("%w: %s is %s", ErrUnenforcedValidator, option.name, value))
+			enforced = false
+		}
+	}
+	if !enforced {
+		schema, err = bson.Marshal(widen(converted))
+		if err != nil {
+			return collection, nil, c.issues, &EntryError{Collection: collection, Err: fmt.Errorf("failed to marshal schema: %w", err)}
+		}
+	}
+	return collection, schema, c.issues, nil
+}
+
+// widen returns a schema of documents that have any value for each of
+// the top-level properties of schema, and any other property.
+func widen(schema bson.D) bson.D {
+	properties := bson.D{}
+	for _, e := range schema {
+		if d, ok := e.Value.(bson.D); ok && e.Key == "properties" {
+			for _, property := range d {
+				properties = append(properties, bson.E{Key: property.Key, Value: bson.D{}})
+			}
+		}
+	}
+	return bson.D{
+		{Key: "bsonType", Value: "object"},
+		{Key: "properties", Value: properties},
+		{Key: "additionalProperties", Value: true},
+	}
+}
+
+// validatorConverter converts the $jsonSchema validator of a
+// collection, and records its lossy conversions.
+type validatorConverter struct {
+	collection string
+	issues     []Issue
+}
+
+func (c *validatorConverter) report(pointer string, err error) {
+	c.issues = append(c.issues, Issue{Collection: c.collection, Pointer: pointer, Severity: SeverityWarning, Err: err})
+}
+
+func (c *validatorConverter) wrongType(pointer, expected string, value bson.RawValue) {
+	c.report(pointer, fmt.Errorf("%w: expected %s, got %s, so it was dropped", ErrWrongType, expected, value.Type))
+}
+
+// convert returns the schema the translator understands that is closest
+// to schema, which is at pointer in the validator, and allows every
+// value schema allows.
+func (c *validatorConverter) convert(schema bson.Raw, pointer string) bson.D {
+	var types []string
+	typed := false
+	// restrict keeps the types of types that are in allowed
+	restrict := func(allowed []string) {
+		if !typed {
+			typed = true
+			types = dedupe(allowed)
+			return
+		}
+		in := map[string]bool{}
+		for _, name := range allowed {
+			in[name] = true
+		}
+		var kept []string
+		for _, name := range types {
+			if in[name] {
+				kept = append(kept, name)
+			}
+		}
+		types = kept
+	}
+
+	var properties, items bson.D
+	var required, union bson.A
+	// enumTypes are the bsonTypes of the enum values, which restrict
+	// types once the type keywords are known
+	var enumTypes []string
+	var enumPointer string
+	var additionalProperties, maxItems interface{}
+	var unionPointer string
+	hasProperties, hasItems, hasPatternProperties := false, false, false
+
+	elements, _ := schema.Elements()
+	for _, element := range elements {
+		key, value := element.Key(), element.Value()
+		p := pointer + "/" + escapePointerToken(key)
+		if value.Type == bsontype.Null {
+			continue
+		}
+
+		switch key {
+		case "title", "description":
+			// annotations do not constrain values
+		case "bsonType":
+			if names, ok := c.bsonTypes(value, p); ok {
+				restrict(names)
+			}
+		case "type":
+			if names, ok := c.jsonTypes(value, p); ok {
+				restrict(names)
+			}
+		case "enum":
+			values, err := value.Array().Values()
+			if value.Type != bsontype.Array || err != nil {
+				c.wrongType(p, "an array", value)
+				continue
+			}
+			names := make([]string, 0, len(values))
+			for _, v := range values {
+				name := valueTypeName(v)
+				if name == "" {
+					names = nil
+					break
+				}
+				// the server compares numbers by value, so an enum
+				// number matches numbers of every numeric type
+				if numberTypes[name] {
+					names = append(names, jsonTypes["number"]...)
+				} else {
+					names = append(names, name)
+				}
+			}
+			enumTypes, enumPointer = names, p
+			c.report(p, fmt.Errorf("%w: the enum values are replaced by their bsonTypes", ErrWidenedKeyword))
+		case "encrypt":
+			// encrypted fields are stored as binData
+			restrict([]string{"binData"})
+		case "properties":
+			if value.Type != bsontype.EmbeddedDocument {
+				c.wrongType(p, "a document", value)
+				continue
+			}
+			hasProperties = true
+			properties = bson.D{}
+			propertyElements, _ := value.Document().Elements()
+			for _, property := range propertyElements {
+				pp := p + "/" + escapePointerToken(property.Key())
+				if property.Value().Type != bsontype.EmbeddedDocument {
+					c.wrongType(pp, "a document", property.Value())
+					properties = append(properties, bson.E{Key: property.Key(), Value: bson.D{}})
+					continue
+				}
+				properties = append(properties, bson.E{Key: property.Key(), Value: c.convert(property.Value().Document(), pp)})
+			}
+		case "required":
+			values, err := value.Array().Values()
+			if value.Type != bsontype.Array || err != nil {
+				c.wrongType(p, "an array", value)
+				continue
+			}
+			required = bson.A{}
+			for i, v := range values {
+				if field, ok := v.StringValueOK(); ok {
+					required = append(required, field)
+				} else {
+					c.wrongType(fmt.Sprintf("%s/%d", p, i), "a string", v)
+				}
+			}
+		case "additionalProperties":
+			switch value.Type {
+			case bsontype.Boolean:
+				additionalProperties = value.Boolean()
+			case bsontype.EmbeddedDocument:
+				additionalProperties = true
+				c.report(p, fmt.Errorf("%w: a schema of additional properties is replaced by true", ErrWidenedKeyword))
+			default:
+				c.wrongType(p, "a boolean or a document", value)
+			}
+		case "patternProperties":
+			hasPatternProperties = true
+			c.report(p, ErrDroppedKeyword)
+		case "items":
+			switch value.Type {
+			case bsontype.EmbeddedDocument:
+				hasItems = true
+				items = c.convert(value.Document(), p)
+			case bsontype.Array:
+				c.report(p, ErrPositionalItems)
+			default:
+				c.wrongType(p, "a document or an array", value)
+			}
+		case "maxItems":
+			n, ok := integralValue(value)
+			switch {
+			case !ok || n < 0 || n > math.MaxUint32:
+				c.report(p, fmt.Errorf("%w: %s is not a valid maxItems", ErrDroppedKeyword, value))
+			case value.Type == bsontype.Double:
+				maxItems = n
+			default:
+				maxItems = value
+			}
+		case "anyOf", "oneOf":
+			values, err := value.Array().Values()
+			if value.Type != bsontype.Array || err != nil {
+				c.wrongType(p, "an array", value)
+				continue
+			}
+			if union != nil {
+				c.report(p, fmt.Errorf("%w: it cannot be combined with %s", ErrDroppedKeyword, unionPointer))
+				continue
+			}
+			if key == "oneOf" {
+				c.report(p, fmt.Errorf("%w: oneOf is replaced by anyOf", ErrWidenedKeyword))
+			}
+			union, unionPointer = bson.A{}, p
+			for i, v := range values {
+				bp := fmt.Sprintf("%s/%d", p, i)
+				if v.Type != bsontype.EmbeddedDocument {
+					c.wrongType(bp, "a document", v)
+					union = append(union, bson.D{})
+					continue
+				}
+				union = append(union, c.convert(v.Document(), bp))
+			}
+		default:
+			c.report(p, ErrDroppedKeyword)
+		}
+	}
+
+	// the fields matched by patternProperties are additional properties
+	// once it is dropped
+	if hasPatternProperties && additionalProperties == false {
+		additionalProperties = true
+		c.report(pointer+"/additionalProperties", fmt.Errorf("%w: additional properties are allowed since patternProperties was dropped", ErrWidenedKeyword))
+	}
+
+	if enumTypes != nil {
+		// the enum is only kept if some of its values have a type the
+		// other keywords allow, since a schema that allows no type would
+		// reject the values the server accepts by comparing them
+		previous, wasTyped := types, typed
+		restrict(enumTypes)
+		if len(types) == 0 {
+			types, typed = previous, wasTyped
+			c.report(enumPointer, fmt.Errorf("%w: none of its values has one of the allowed bsonTypes", ErrDroppedKeyword))
+		}
+	}
+
+	result := bson.D{}
+	if typed && len(types) == 0 {
+		// a bsonType without types matches nothing, which the
+		// translator treats as an unsatisfiable schema
+		c.report(pointer, fmt.Errorf("%w: the type keywords allow no type, so they were dropped", ErrDroppedKeyword))
+		typed = false
+	}
+	if typed {
+		if len(types) == 1 {
+			result = append(result, bson.E{Key: "bsonType", Value: types[0]})
+		} else {
+			names := bson.A{}
+			for _, name := range types {
+				names = append(names, name)
+			}
+			result = append(result, bson.E{Key: "bsonType", Value: names})
+		}
+	}
+	if hasProperties {
+		result = append(result, bson.E{Key: "properties", Value: properties})
+	}
+	if required != nil {
+		result = append(result, bson.E{Key: "required", Value: required})
+	}
+	if additionalProperties != nil {
+		result = append(result, bson.E{Key: "additionalProperties", Value: additionalProperties})
+	}
+	if hasItems {
+		result = append(result, bson.E{Key: "items", Value: items})
+	}
+	if maxItems != nil {
+		result = append(result, bson.E{Key: "maxItems", Value: maxItems})
+	}
+
+	if union != nil {
+		// the translator rejects anyOf combined with other keywords, so
+		// the union is dropped in favor of them, which allows more
+		if len(result) > 0 {
+			c.report(unionPointer, fmt.Errorf("%w: it cannot be combined with other keywords", ErrDroppedKeyword))
+			return result
+		}
+		return bson.D{{Key: "anyOf", Value: union}}
+	}
+	return result
+}
+
+// bsonTypes returns the type names of a bsonType keyword, expanding the
+// number alias. A bsonType that names an unknown type is dropped.
+func (c *validatorConverter) bsonTypes(value bson.RawValue, pointer string) ([]string, bool) {
+	names, ok := c.typeNames(value, pointer)
+	if !ok {
+		return nil, false
+	}
+
+	var expanded []string
+	for _, name := range names {
+		switch {
+		case name == "number":
+			expanded = append(expanded, jsonTypes["number"]...)
+		case bsonTypeNames[name]:
+			expanded = append(expanded, name)
+		default:
+			c.report(pointer, fmt.Errorf("%w: %q, so it was dropped", ErrUnknownBsonType, name))
+			return nil, false
+		}
+	}
+	return expanded, true
+}
+
+// jsonTypes returns the bsonTypes allowed by a type keyword. A type
+// that names an unknown type is dropped.
+func (c *validatorConverter) jsonTypes(value bson.RawValue, pointer string) ([]string, bool) {
+	names, ok := c.typeNames(value, pointer)
+	if !ok {
+		return nil, false
+	}
+
+	var expanded []string
+	for _, name := range names {
+		bsonTypes, ok := jsonTypes[name]
+		if !ok {
+			c.report(pointer, fmt.Errorf("%w: unknown type %q", ErrDroppedKeyword, name))
+			return nil, false
+		}
+		expanded = append(expanded, bsonTypes...)
+	}
+	return expanded, true
+}
+
+// typeNames returns the names of a bsonType or type keyword, which is
+// a string or an array of strings.
+func (c *validatorConverter) typeNames(value bson.RawValue, pointer string) ([]string, bool) {
+	switch value.Type {
+	case bsontype.String:
+		return []string{value.StringValue()}, true
+	case bsontype.Array:
+		var names []string
+		values, _ := value.Array().Values()
+		for i, v := range values {
+			name, ok := v.StringValueOK()
+			if !ok {
+				c.wrongType(fmt.Sprintf("%s/%d", pointer, i), "a string", v)
+				return nil, false
+			}
+			names = append(names, name)
+		}
+		return names, true
+	}
+	c.wrongType(pointer, "a string or an array", value)
+	return nil, false
+}
+
+// valueTypeName returns the bsonType of value, or the empty string if
+// it has none.
+func valueTypeName(value bson.RawValue) string {
+	switch value.Type {
+	case bsontype.EmbeddedDocument:
+		return "object"
+	case bsontype.Array:
+		return "array"
+	}
+	if s := schemaForValue(value, InferOptions{}); s.kind == atomicKind {
+		return s.atomic.bsonTypeName()
+	}
+	return ""
+}
+
+// integralValue returns the value of a number that is an integer.
+func integralValue(value bson.RawValue) (int64, bool) {
+	if n, ok := integerValue(value); ok {
+		return n, true
+	}
+	if f, ok := value.DoubleOK(); ok && f == math.Trunc(f) && math.Abs(f) <= math.MaxInt64 {
+		return int64(f), true
+	}
+	return 0, false
+}
+
+// dedupe returns names without repetitions, in order.
+func dedupe(names []string) []string {
+	seen := map[string]bool{}
+	var result []string
+	for _, name := range names {
+		if !seen[name] {
+			seen[name] = true
+			result = append(result, name)
+		}
+	}
+	return result
+}
@@ -0,0 +1,281 @@
+package catalog_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mongodb/mongosql/go/mongosql/catalog"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestFromListCollections(t *testing.T) {
+	result, issues, err := catalog.FromListCollections("test", loadFixture(t, "list_collections.json"))
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	bsonType := func(name interface{}) bson.D {
+		return bson.D{{Key: "bsonType", Value: name}}
+	}
+	expected := map[string]map[string]string{
+		"test": {
+			"users": marshalSchema(t, bson.D{
+				{Key: "bsonType", Value: "object"},
+				{Key: "properties", Value: bson.D{
+					{Key: "_id", Value: bsonType("objectId")},
+					{Key: "name", Value: bsonType("string")},
+					{Key: "email", Value: bsonType("string")},
+					{Key: "age", Value: bsonType(bson.A{"int", "long"})},
+					{Key: "status", Value: bsonType("string")},
+					{Key: "tags", Value: bson.D{
+						{Key: "bsonType", Value: "array"},
+						{Key: "items", Value: bsonType("string")},
+						{Key: "maxItems", Value: int64(10)},
+					}},
+					{Key: "address", Value: bson.D{
+						{Key: "bsonType", Value: "object"},
+						{Key: "properties", Value: bson.D{{Key: "zip", Value: bsonType("string")}}},
+						{Key: "additionalProperties", Value: false},
+					}},
+				}},
+				{Key: "required", Value: bson.A{"name", "email"}},
+			}),
+			"payments": marshalSchema(t, bson.D{
+				{Key: "bsonType", Value: "object"},
+				{Key: "properties", Value: bson.D{
+					{Key: "amount", Value: bsonType(bson.A{"int", "long", "double", "decimal"})},
+					{Key: "method", Value: bson.D{{Key: "anyOf", Value: bson.A{
+						bsonType("string"),
+						bson.D{
+							{Key: "bsonType", Value: "object"},
+							{Key: "properties", Value: bson.D{{Key: "card", Value: bsonType("binData")}}},
+						},
+					}}}},
+				}},
+			}),
+			"events": marshalSchema(t, bson.D{
+				{Key: "bsonType", Value: "object"},
+				{Key: "properties", Value: bson.D{
+					{Key: "payload", Value: bson.D{
+						{Key: "bsonType", Value: "object"},
+						{Key: "additionalProperties", Value: true},
+					}},
+					{Key: "point", Value: bsonType("array")},
+					{Key: "kind", Value: bsonType("string")},
+				}},
+			}),
+		},
+	}
+	if diff := cmp.Diff(expected, schemaJSON(result)); diff != "" {
+		t.Fatalf("unexpected catalog (-want +got):\n%s", diff)
+	}
+
+	warning := func(collection, pointer string, sentinel error) issueSummary {
+		return issueSummary{Namespace: "test." + collection, Pointer: pointer, Severity: catalog.SeverityWarning, Sentinel: sentinel}
+	}
+	expectedIssues := []issueSummary{
+		warning("users", "/$jsonSchema/properties/email/pattern", catalog.ErrDroppedKeyword),
+		warning("users", "/$jsonSchema/properties/age/minimum", catalog.ErrDroppedKeyword),
+		warning("users", "/$jsonSchema/properties/status/enum", catalog.ErrWidenedKeyword),
+		warning("users", "/$jsonSchema/properties/tags/uniqueItems", catalog.ErrDroppedKeyword),
+		warning("users", "/$jsonSchema/properties/address/properties/zip/minLength", catalog.ErrDroppedKeyword),
+		warning("payments", "/status", catalog.ErrDroppedKeyword),
+		warning("payments", "/$jsonSchema/properties/method/oneOf", catalog.ErrWidenedKeyword),
+		warning("events", "/$jsonSchema/properties/payload/patternProperties", catalog.ErrDroppedKeyword),
+		warning("events", "/$jsonSchema/properties/payload/additionalProperties", catalog.ErrWidenedKeyword),
+		warning("events", "/$jsonSchema/properties/point/items", catalog.ErrPositionalItems),
+		warning("events", "/$jsonSchema/properties/kind/anyOf/0/enum", catalog.ErrWidenedKeyword),
+		warning("events", "/$jsonSchema/properties/kind/anyOf/1/enum", catalog.ErrWidenedKeyword),
+		warning("events", "/$jsonSchema/properties/kind/anyOf", catalog.ErrDroppedKeyword),
+	}
+	if diff := cmp.Diff(expectedIssues, summarizeIssues(issues), cmp.Comparer(func(a, b error) bool { return a == b })); diff != "" {
+		t.Fatalf("unexpected issues (-want +got):\n%s", diff)
+	}
+
+	if issues := catalog.Validate(result); catalog.HasErrors(issues) {
+		t.Fatalf("expected the schemas to be valid, got %v", issues)
+	}
+}
+
+func TestFromValidatorErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		entry    string
+		expected error
+	}{
+		{
+			name:     "missing name",
+			entry:    `{"options": {"validator": {"$jsonSchema": {}}}}`,
+			expected: catalog.ErrMissingField,
+		},
+		{
+			name:     "view",
+			entry:    `{"name": "foo", "type": "view", "options": {"viewOn": "bar", "pipeline": []}}`,
+			expected: catalog.ErrNoValidator,
+		},
+		{
+			name:     "query validator",
+			entry:    `{"name": "foo", "options": {"validator": {"a": {"$gt": 1}}}}`,
+			expected: catalog.ErrNoValidator,
+		},
+		{
+			name:     "$jsonSchema is not a document",
+			entry:    `{"name": "foo", "options": {"validator": {"$jsonSchema": "object"}}}`,
+			expected: catalog.ErrWrongType,
+		},
+		{
+			name:     "schema does not describe documents",
+			entry:    `{"name": "foo", "options": {"validator": {"$jsonSchema": {"type": "string"}}}}`,
+			expected: catalog.ErrNotDocument,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, err := catalog.FromValidator(bson.Raw(parseSchema(t, test.entry)))
+			var entryErr *catalog.EntryError
+			if !errors.As(err, &entryErr) {
+				t.Fatalf("expected an EntryError, got '%v'", err)
+			}
+			if !errors.Is(err, test.expected) {
+				t.Fatalf("expected '%s', got '%s'", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestFromValidatorEnum(t *testing.T) {
+	tests := []struct {
+		name           string
+		schema         string
+		expected       bson.D
+		expectedIssues []issueSummary
+	}{
+		{
+			name:     "double values under an int bsonType",
+			schema:   `{"bsonType": "int", "enum": [{"$numberDouble": "1.0"}, {"$numberDouble": "2.0"}]}`,
+			expected: bson.D{{Key: "bsonType", Value: "int"}},
+			expectedIssues: []issueSummary{
+				{Namespace: ".foo", Pointer: "/$jsonSchema/properties/a/enum", Severity: catalog.SeverityWarning, Sentinel: catalog.ErrWidenedKeyword},
+			},
+		},
+		{
+			name:     "values of other types than the bsonType",
+			schema:   `{"bsonType": "int", "enum": ["a", true]}`,
+			expected: bson.D{{Key: "bsonType", Value: "int"}},
+			expectedIssues: []issueSummary{
+				{Namespace: ".foo", Pointer: "/$jsonSchema/properties/a/enum", Severity: catalog.SeverityWarning, Sentinel: catalog.ErrWidenedKeyword},
+				{Namespace: ".foo", Pointer: "/$jsonSchema/properties/a/enum", Severity: catalog.SeverityWarning, Sentinel: catalog.ErrDroppedKeyword},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entry := `{"name": "foo", "options": {"validator": {"$jsonSchema": {
+				"bsonType": "object",
+				"properties": {"a": ` + test.schema + `}
+			}}}}`
+			schema, issues, err := catalog.FromValidator(bson.Raw(parseSchema(t, entry)))
+			if err != nil {
+				t.Fatalf("expected err to be nil, got '%s'", err)
+			}
+			expected := marshalSchema(t, bson.D{
+				{Key: "bsonType", Value: "object"},
+				{Key: "properties", Value: bson.D{{Key: "a", Value: test.expected}}},
+			})
+			if diff := cmp.Diff(expected, bson.Raw(schema).String()); diff != "" {
+				t.Fatalf("unexpected schema (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(test.expectedIssues, summarizeIssues(issues), cmp.Comparer(func(a, b error) bool { return a == b })); diff != "" {
+				t.Fatalf("unexpected issues (-want +got):\n%s", diff)
+			}
+			if issues := catalog.Validate(catalog.CatalogSchema{"test": {"foo": schema}}); len(issues) > 0 {
+				t.Fatalf("expected the schema to have no issues, got %v", issues)
+			}
+		})
+	}
+}
+
+func TestFromValidatorUnenforced(t *testing.T) {
+	widened := marshalSchema(t, bson.D{
+		{Key: "bsonType", Value: "object"},
+		{Key: "properties", Value: bson.D{
+			{Key: "a", Value: bson.D{}},
+			{Key: "b", Value: bson.D{}},
+		}},
+		{Key: "additionalProperties", Value: true},
+	})
+	unenforced := issueSummary{Namespace: ".foo", Pointer: "", Severity: catalog.SeverityWarning, Sentinel: catalog.ErrUnenforcedValidator}
+
+	tests := []struct {
+		name           string
+		options        string
+		expected       string
+		expectedIssues []issueSummary
+	}{
+		{
+			name:    "strict and error",
+			options: `"validationLevel": "strict", "validationAction": "error"`,
+			expected: marshalSchema(t, bson.D{
+				{Key: "bsonType", Value: "object"},
+				{Key: "properties", Value: bson.D{
+					{Key: "a", Value: bson.D{{Key: "bsonType", Value: "int"}}},
+					{Key: "b", Value: bson.D{{Key: "bsonType", Value: "string"}}},
+				}},
+				{Key: "required", Value: bson.A{"a"}},
+				{Key: "additionalProperties", Value: false},
+			}),
+		},
+		{
+			name:           "moderate",
+			options:        `"validationLevel": "moderate"`,
+			expected:       widened,
+			expectedIssues: []issueSummary{unenforced},
+		},
+		{
+			name:           "off and warn",
+			options:        `"validationLevel": "off", "validationAction": "warn"`,
+			expected:       widened,
+			expectedIssues: []issueSummary{unenforced, unenforced},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entry := `{"name": "foo", "options": {"validator": {"$jsonSchema": {
+				"bsonType": "object",
+				"properties": {"a": {"bsonType": "int"}, "b": {"bsonType": "string"}},
+				"required": ["a"],
+				"additionalProperties": false
+			}}, ` + test.options + `}}`
+			schema, issues, err := catalog.FromValidator(bson.Raw(parseSchema(t, entry)))
+			if err != nil {
+				t.Fatalf("expected err to be nil, got '%s'", err)
+			}
+			if diff := cmp.Diff(test.expected, bson.Raw(schema).String()); diff != "" {
+				t.Fatalf("unexpected schema (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(test.expectedIssues, summarizeIssues(issues), cmp.Comparer(func(a, b error) bool { return a == b })); diff != "" {
+				t.Fatalf("unexpected issues (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFromListCollectionsDuplicateCollection(t *testing.T) {
+	entry := bson.Raw(parseSchema(t, `{"name": "foo", "options": {"validator": {"$jsonSchema": {"bsonType": "object"}}}}`))
+	result, _, err := catalog.FromListCollections("test", []bson.Raw{entry, entry})
+
+	var errs catalog.EntryErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected EntryErrors, got '%v'", err)
+	}
+	if len(errs) != 1 || errs[0].Index != 1 || !errors.Is(errs[0], catalog.ErrDuplicateCollection) {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if _, ok := result["test"]["foo"]; !ok {
+		t.Fatalf("expected the first entry to be converted")
+	}
+}
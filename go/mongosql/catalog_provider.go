@@ -0,0 +1,180 @@
+package mongosql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// CatalogProvider supplies the JSON Schemas of collections on demand,
+// so that a translation only needs the schemas of the collections its
+// query references. Implementations must be safe for concurrent use.
+type CatalogProvider interface {
+	// Schema returns the JSON Schema that describes the documents of
+	// the collection db.collection, or nil if there is no schema for
+	// the collection. A query referencing a collection without a schema
+	// fails to translate in the same way as with a CatalogSchema that
+	// does not have it. The names of WITH queries can be looked up as
+	// well, and should return nil.
+	Schema(ctx context.Context, db, collection string) (bsoncore.Document, error)
+}
+
+// withProvidedSchemas returns args with the schemas of the namespaces
+// referenced by its query that are missing from its CatalogSchema
+// fetched from the CatalogProvider of t, in parallel. The CatalogSchema
+// of the returned args is a copy that the provided schemas were added
+// to. If the query has a syntax error, args are returned without the
+// provided schemas, so that the translation reports the error. Other
+// errors, such as a full queue or ctx being done while waiting to find
+// the namespaces, are returned.
+func (t *Translator) withProvidedSchemas(ctx context.Context, args TranslationArgs) (TranslationArgs, error) {
+	provider := t.provider
+	if provider == nil {
+		return args, nil
+	}
+
+	namespaces, err := t.GetNamespaces(ctx, args.DB, args.SQL)
+	if err != nil {
+		var tErr TranslationError
+		if errors.As(err, &tErr) && !tErr.IsInternal() {
+			return args, nil
+		}
+		return args, err
+	}
+
+	var missing []Namespace
+	for _, ns := range namespaces {
+		if _, ok := args.CatalogSchema[ns.Database][ns.Collection]; !ok {
+			missing = append(missing, ns)
+		}
+	}
+	if len(missing) == 0 {
+		return args, nil
+	}
+
+	schemas := make([]bsoncore.Document, len(missing))
+	errs := make([]error, len(missing))
+	var wg sync.WaitGroup
+	for i, ns := range missing {
+		wg.Add(1)
+		go func(i int, ns Namespace) {
+			defer wg.Done()
+			schemas[i], errs[i] = provider.Schema(ctx, ns.Database, ns.Collection)
+		}(i, ns)
+	}
+	wg.Wait()
+
+	catalogSchema := make(map[string]map[string]bsoncore.Document, len(args.CatalogSchema))
+	for db, collections := range args.CatalogSchema {
+		catalogSchema[db] = make(map[string]bsoncore.Document, len(collections))
+		for collection, schema := range collections {
+			catalogSchema[db][collection] = schema
+		}
+	}
+	for i, ns := range missing {
+		if errs[i] != nil {
+			return args, fmt.Errorf("failed to fetch the schema of %s.%s: %w", ns.Database, ns.Collection, errs[i])
+		}
+		if schemas[i] == nil {
+			continue
+		}
+		if catalogSchema[ns.Database] == nil {
+			catalogSchema[ns.Database] = map[string]bsoncore.Document{}
+		}
+		catalogSchema[ns.Database][ns.Collection] = schemas[i]
+	}
+	args.CatalogSchema = catalogSchema
+	return args, nil
+}
+
+// CachingCatalogProvider is a CatalogProvider that remembers the
+// schemas returned by another CatalogProvider, so that each schema is
+// only fetched once. Concurrent requests for a schema that is not
+// cached share a single fetch. Failed fetches are not cached. It is
+// safe for concurrent use.
+type CachingCatalogProvider struct {
+	provider CatalogProvider
+
+	mu      sync.Mutex
+	entries map[Namespace]*catalogCacheEntry
+}
+
+// catalogCacheEntry is the result of a fetch, which is available once
+// done is closed.
+type catalogCacheEntry struct {
+	done   chan struct{}
+	schema bsoncore.Document
+	err    error
+}
+
+// NewCachingCatalogProvider creates a CachingCatalogProvider that
+// fetches the schemas it does not have from provider.
+func NewCachingCatalogProvider(provider CatalogProvider) *CachingCatalogProvider {
+	return &CachingCatalogProvider{
+		provider: provider,
+		entries:  map[Namespace]*catalogCacheEntry{},
+	}
+}
+
+// Schema returns the cached schema of db.collection, fetching it if it
+// is not cached. A caller waiting for a fetch started by another
+// caller stops waiting when its context is done. If that fetch fails
+// because the context of the caller that started it is done, the
+// waiting caller fetches the schema again with its own context.
+func (c *CachingCatalogProvider) Schema(ctx context.Context, db, collection string) (bsoncore.Document, error) {
+	ns := Namespace{Database: db, Collection: collection}
+
+	for {
+		c.mu.Lock()
+		entry, ok := c.entries[ns]
+		if !ok {
+			entry = &catalogCacheEntry{done: make(chan struct{})}
+			c.entries[ns] = entry
+			c.mu.Unlock()
+			c.fetch(ctx, ns, entry)
+			return entry.schema, entry.err
+		}
+		c.mu.Unlock()
+
+		select {
+		case <-entry.done:
+			if isContextError(entry.err) && ctx.Err() == nil {
+				continue
+			}
+			return entry.schema, entry.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// isContextError returns true if err is caused by a context being
+// canceled or reaching its deadline.
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// fetch fills entry with the schema of ns, and removes it from the
+// cache if the fetch fails.
+func (c *CachingCatalogProvider) fetch(ctx context.Context, ns Namespace, entry *catalogCacheEntry) {
+	defer close(entry.done)
+	entry.schema, entry.err = c.provider.Schema(ctx, ns.Database, ns.Collection)
+	if entry.err != nil {
+		c.mu.Lock()
+		if c.entries[ns] == entry {
+			delete(c.entries, ns)
+		}
+		c.mu.Unlock()
+	}
+}
+
+// Invalidate removes the schema of db.collection from the cache, so
+// that it is fetched again the next time it is needed.
+func (c *CachingCatalogProvider) Invalidate(db, collection string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, Namespace{Database: db, Collection: collection})
+}
@@ -0,0 +1,238 @@
+package mongosql_test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mongodb/mongosql/go/mongosql"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// mapCatalogProvider is a CatalogProvider that serves the schemas of a
+// catalog map and records every fetch.
+type mapCatalogProvider struct {
+	catalog map[string]map[string]bsoncore.Document
+	err     error
+
+	mu      sync.Mutex
+	fetched []string
+}
+
+func (p *mapCatalogProvider) Schema(_ context.Context, db, collection string) (bsoncore.Document, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.fetched = append(p.fetched, db+"."+collection)
+	if p.err != nil {
+		return nil, p.err
+	}
+	return p.catalog[db][collection], nil
+}
+
+func (p *mapCatalogProvider) fetches() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fetched := append([]string(nil), p.fetched...)
+	sort.Strings(fetched)
+	return fetched
+}
+
+func TestTranslateFetchesOnlyReferencedSchemas(t *testing.T) {
+	schema := validateCatalogSchema(t)["bar"]["foo"]
+	provider := &mapCatalogProvider{catalog: map[string]map[string]bsoncore.Document{
+		"bar": {"foo": schema, "baz": schema, "unused": schema},
+		"qux": {"other": schema},
+	}}
+
+	translator := mongosql.NewTranslator(mongosql.TranslatorOptions{CatalogProvider: provider})
+	_, err := translator.Translate(context.Background(), mongosql.TranslationArgs{
+		DB:  "bar",
+		SQL: "select * from foo join baz on foo.a = baz.a",
+	})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	if diff := cmp.Diff([]string{"bar.baz", "bar.foo"}, provider.fetches()); diff != "" {
+		t.Fatalf("unexpected fetches (-want +got):\n%s", diff)
+	}
+}
+
+func TestTranslateDoesNotFetchSchemasInCatalogSchema(t *testing.T) {
+	catalogSchema := validateCatalogSchema(t)
+	provider := &mapCatalogProvider{catalog: map[string]map[string]bsoncore.Document{
+		"bar": {"baz": catalogSchema["bar"]["foo"]},
+	}}
+
+	translator := mongosql.NewTranslator(mongosql.TranslatorOptions{CatalogProvider: provider})
+	_, err := translator.Translate(context.Background(), mongosql.TranslationArgs{
+		DB:            "bar",
+		SQL:           "select * from foo join baz on foo.a = baz.a",
+		CatalogSchema: catalogSchema,
+	})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	if diff := cmp.Diff([]string{"bar.baz"}, provider.fetches()); diff != "" {
+		t.Fatalf("unexpected fetches (-want +got):\n%s", diff)
+	}
+	if len(catalogSchema["bar"]) != 1 {
+		t.Fatalf("expected the provided catalog schema to be left unchanged, got %d collections", len(catalogSchema["bar"]))
+	}
+}
+
+func TestTranslateCatalogProviderError(t *testing.T) {
+	providerErr := errors.New("schema store unavailable")
+	translator := mongosql.NewTranslator(mongosql.TranslatorOptions{
+		CatalogProvider: &mapCatalogProvider{err: providerErr},
+	})
+	_, err := translator.Translate(context.Background(), mongosql.TranslationArgs{
+		DB:  "bar",
+		SQL: "select * from foo",
+	})
+	if err == nil {
+		t.Fatalf("expected error to be non-nil, but it was nil")
+	}
+
+	if _, ok := err.(mongosql.TranslationError); ok {
+		t.Fatalf("expected error not to be a TranslationError, but it was")
+	}
+	if !errors.Is(err, providerErr) {
+		t.Fatalf("expected error to wrap the provider error, got '%s'", err)
+	}
+}
+
+// blockingCatalogProvider is a CatalogProvider whose fetches only end
+// when their context is done.
+type blockingCatalogProvider struct{}
+
+func (blockingCatalogProvider) Schema(ctx context.Context, _, _ string) (bsoncore.Document, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestTranslateCatalogProviderContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	translator := mongosql.NewTranslator(mongosql.TranslatorOptions{CatalogProvider: blockingCatalogProvider{}})
+	_, err := translator.Translate(ctx, mongosql.TranslationArgs{
+		DB:  "bar",
+		SQL: "select * from foo",
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected error to wrap context.DeadlineExceeded, got '%v'", err)
+	}
+}
+
+func TestCachingCatalogProvider(t *testing.T) {
+	ctx := context.Background()
+	schema := validateCatalogSchema(t)["bar"]["foo"]
+	provider := &mapCatalogProvider{catalog: map[string]map[string]bsoncore.Document{"bar": {"foo": schema}}}
+	cache := mongosql.NewCachingCatalogProvider(provider)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := cache.Schema(ctx, "bar", "foo")
+			if err != nil {
+				t.Errorf("expected err to be nil, got '%s'", err)
+			}
+			if string(got) != string(schema) {
+				t.Errorf("unexpected schema %s", got)
+			}
+		}()
+	}
+	wg.Wait()
+	if diff := cmp.Diff([]string{"bar.foo"}, provider.fetches()); diff != "" {
+		t.Fatalf("unexpected fetches (-want +got):\n%s", diff)
+	}
+
+	cache.Invalidate("bar", "foo")
+	if _, err := cache.Schema(ctx, "bar", "foo"); err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+	if diff := cmp.Diff([]string{"bar.foo", "bar.foo"}, provider.fetches()); diff != "" {
+		t.Fatalf("unexpected fetches after invalidation (-want +got):\n%s", diff)
+	}
+}
+
+func TestCachingCatalogProviderDoesNotCacheErrors(t *testing.T) {
+	ctx := context.Background()
+	provider := &mapCatalogProvider{err: errors.New("schema store unavailable")}
+	cache := mongosql.NewCachingCatalogProvider(provider)
+
+	for i := 0; i < 2; i++ {
+		if _, err := cache.Schema(ctx, "bar", "foo"); err == nil {
+			t.Fatalf("expected error to be non-nil, but it was nil")
+		}
+	}
+	if diff := cmp.Diff([]string{"bar.foo", "bar.foo"}, provider.fetches()); diff != "" {
+		t.Fatalf("unexpected fetches (-want +got):\n%s", diff)
+	}
+}
+
+// gatedCatalogProvider is a CatalogProvider whose fetches signal
+// started when they begin, and return schema once release is closed,
+// or fail when their context is done first.
+type gatedCatalogProvider struct {
+	schema  bsoncore.Document
+	started chan struct{}
+	release chan struct{}
+}
+
+func (p *gatedCatalogProvider) Schema(ctx context.Context, _, _ string) (bsoncore.Document, error) {
+	p.started <- struct{}{}
+	select {
+	case <-p.release:
+		return p.schema, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestCachingCatalogProviderRetriesCanceledFetch(t *testing.T) {
+	schema := validateCatalogSchema(t)["bar"]["foo"]
+	provider := &gatedCatalogProvider{schema: schema, started: make(chan struct{}, 2), release: make(chan struct{})}
+	cache := mongosql.NewCachingCatalogProvider(provider)
+
+	type result struct {
+		schema bsoncore.Document
+		err    error
+	}
+	first, second := make(chan result, 1), make(chan result, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		schema, err := cache.Schema(ctx, "bar", "foo")
+		first <- result{schema, err}
+	}()
+	<-provider.started
+	go func() {
+		schema, err := cache.Schema(context.Background(), "bar", "foo")
+		second <- result{schema, err}
+	}()
+	// give the second caller time to wait for the first fetch
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	if r := <-first; !errors.Is(r.err, context.Canceled) {
+		t.Fatalf("expected the first caller to get context.Canceled, got '%v'", r.err)
+	}
+	<-provider.started
+	close(provider.release)
+	r := <-second
+	if r.err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", r.err)
+	}
+	if string(r.schema) != string(schema) {
+		t.Fatalf("unexpected schema %s", r.schema)
+	}
+}
@@ -0,0 +1,22 @@
+package mongosql
+
+import (
+	"github.com/mongodb/mongosql/go/mongosql/ast"
+)
+
+// StatementInfo describes the kind of a sql statement and the features
+// it uses. See ast.StatementInfo.
+type StatementInfo = ast.StatementInfo
+
+// Classify parses the provided sql statement and reports what kind of
+// statement it is, the features it uses, and how deeply its queries
+// are nested. Like Parse, Classify only checks syntax, so it is much
+// cheaper than Translate and can be used to route a statement before
+// translating it. The returned error, if any, is a TranslationError.
+func Classify(sqlStatement string) (StatementInfo, error) {
+	query, err := Parse(sqlStatement)
+	if err != nil {
+		return StatementInfo{}, err
+	}
+	return ast.Classify(query), nil
+}
@@ -0,0 +1,69 @@
+package mongosql_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mongodb/mongosql/go/mongosql"
+	"github.com/mongodb/mongosql/go/mongosql/ast"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		sql      string
+		expected mongosql.StatementInfo
+	}{
+		{
+			sql:      "select * from foo",
+			expected: mongosql.StatementInfo{Kind: ast.StatementKindSelect, Depth: 1},
+		},
+		{
+			sql: "select a from foo union all select distinct a from bar limit 5",
+			expected: mongosql.StatementInfo{
+				Kind:        ast.StatementKindSet,
+				SetOperator: ast.SetOperatorUnionAll,
+				Features:    ast.Features{Distinct: true, Limit: true},
+				Depth:       1,
+			},
+		},
+		{
+			sql: "with q as (select * from foo) select count(*) as c from q join bar group by a",
+			expected: mongosql.StatementInfo{
+				Kind:     ast.StatementKindWith,
+				Features: ast.Features{Joins: true, Aggregates: true},
+				Depth:    1,
+			},
+		},
+		{
+			sql: "select * from flatten(unwind(foo with path => arr)) where exists(select * from bar where b in (select b from baz))",
+			expected: mongosql.StatementInfo{
+				Kind:     ast.StatementKindSelect,
+				Features: ast.Features{Subqueries: true, Flatten: true, Unwind: true},
+				Depth:    3,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.sql, func(t *testing.T) {
+			info, err := mongosql.Classify(test.sql)
+			if err != nil {
+				t.Fatalf("expected err to be nil, got '%s'", err)
+			}
+			if diff := cmp.Diff(test.expected, info); diff != "" {
+				t.Fatalf("unexpected statement info (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	_, err := mongosql.Classify("select from where")
+	if err == nil {
+		t.Fatalf("expected error to be non-nil, but it was nil")
+	}
+
+	if _, ok := err.(mongosql.TranslationError); !ok {
+		t.Fatalf("expected error to be a TranslationError, but it wasn't")
+	}
+}
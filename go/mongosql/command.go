@@ -0,0 +1,97 @@
+package mongosql
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/mongodb/mongosql/go/mongosql/internal/command"
+	"github.com/mongodb/mongosql/go/mongosql/internal/translation"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Command is a request in the versioned BSON command protocol that
+// the JDBC and ODBC drivers use to communicate with the translation
+// library.
+type Command = command.Command
+
+// CommandType identifies the operation a Command performs.
+type CommandType = command.CommandType
+
+// CommandOptions holds the arguments of a Command. Options that are
+// not used by a CommandType are ignored.
+type CommandOptions = command.CommandOptions
+
+const (
+	// TranslateCommand translates a sql query to MQL. It requires the
+	// SQL, DB, SchemaCatalog, ExcludeNamespaces and RelaxSchemaChecking
+	// options.
+	TranslateCommand = command.Translate
+	// GetNamespacesCommand returns the namespaces referenced by a sql
+	// query. It requires the SQL and DB options.
+	GetNamespacesCommand = command.GetNamespaces
+	// GetMongosqlTranslateVersionCommand returns the version of the
+	// translation library. It requires no options.
+	GetMongosqlTranslateVersionCommand = command.GetMongosqlTranslateVersion
+	// CheckDriverVersionCommand reports whether a driver version is
+	// compatible with the translation library. It requires the
+	// DriverVersion option.
+	CheckDriverVersionCommand = command.CheckDriverVersion
+)
+
+// ErrIncompatibleDriverVersion is returned by CheckDriverVersion when
+// the driver version is not supported by the translation library.
+var ErrIncompatibleDriverVersion = errors.New("incompatible driver version")
+
+// RunCommand runs the provided Command, returning the BSON document
+// produced by the command and an error if the command failed. If the
+// returned error is non-nil, it is a TranslationError and the returned
+// document should be disregarded.
+func RunCommand(cmd Command) (bson.Raw, error) {
+	commandBytes, err := bson.Marshal(cmd)
+	if err != nil {
+		return nil, NewInternalError(fmt.Errorf("failed to marshal command to BSON: %w", err))
+	}
+
+	base64Result := callRunCommand(base64.StdEncoding.EncodeToString(commandBytes))
+
+	resultBytes, err := base64.StdEncoding.DecodeString(base64Result)
+	if err != nil {
+		return nil, NewInternalError(fmt.Errorf("failed to decode base64 command result: %w", err))
+	}
+
+	if err := translation.DecodeError(resultBytes); err != nil {
+		return nil, err
+	}
+
+	return bson.Raw(resultBytes), nil
+}
+
+// CheckDriverVersion returns nil if the driver with the provided
+// SemVer version is compatible with the translation library. It
+// returns an error wrapping ErrIncompatibleDriverVersion if the driver
+// is not compatible, and a TranslationError if the check could not be
+// performed, for example because the version is not valid SemVer.
+func CheckDriverVersion(v string) error {
+	result, err := RunCommand(Command{
+		Command: CheckDriverVersionCommand,
+		Options: CommandOptions{
+			DriverVersion: v,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	compatibility := struct {
+		Compatible bool `bson:"compatible"`
+	}{}
+	if err := bson.Unmarshal(result, &compatibility); err != nil {
+		return NewInternalError(fmt.Errorf("failed to unmarshal driver compatibility result BSON into struct: %w", err))
+	}
+
+	if !compatibility.Compatible {
+		return fmt.Errorf("%w: driver version %q is not supported by this translation library", ErrIncompatibleDriverVersion, v)
+	}
+	return nil
+}
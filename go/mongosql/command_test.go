@@ -0,0 +1,106 @@
+package mongosql_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mongodb/mongosql/go/mongosql"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+func TestRunCommandGetMongosqlTranslateVersion(t *testing.T) {
+	result, err := mongosql.RunCommand(mongosql.Command{
+		Command: mongosql.GetMongosqlTranslateVersionCommand,
+	})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	version, ok := result.Lookup("version").StringValueOK()
+	if !ok {
+		t.Fatalf("expected result to contain a string version, got %s", result)
+	}
+
+	if !strings.HasPrefix(version, "v") {
+		t.Fatalf("expected version %q to start with 'v'", version)
+	}
+}
+
+func TestRunCommandGetNamespaces(t *testing.T) {
+	result, err := mongosql.RunCommand(mongosql.Command{
+		Command: mongosql.GetNamespacesCommand,
+		Options: mongosql.CommandOptions{
+			DB:  "bar",
+			SQL: "select * from foo join baz.qux",
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	namespaces := struct {
+		Namespaces []mongosql.Namespace `bson:"namespaces"`
+	}{}
+	if err := bson.Unmarshal(result, &namespaces); err != nil {
+		t.Fatalf("failed to unmarshal bson '%s'", err)
+	}
+
+	expected := []mongosql.Namespace{
+		{Database: "bar", Collection: "foo"},
+		{Database: "baz", Collection: "qux"},
+	}
+	if !reflect.DeepEqual(expected, namespaces.Namespaces) {
+		t.Fatalf("expected namespaces to be equal, but they weren't:\n%v\nand\n%v", expected, namespaces.Namespaces)
+	}
+}
+
+func TestRunCommandTranslateError(t *testing.T) {
+	excludeNamespaces, relaxSchemaChecking := false, false
+	_, err := mongosql.RunCommand(mongosql.Command{
+		Command: mongosql.TranslateCommand,
+		Options: mongosql.CommandOptions{
+			DB:                  "bar",
+			SQL:                 "notavalidquery",
+			ExcludeNamespaces:   &excludeNamespaces,
+			RelaxSchemaChecking: &relaxSchemaChecking,
+			SchemaCatalog:       map[string]map[string]bsoncore.Document{},
+		},
+	})
+	if err == nil {
+		t.Fatalf("expected error to be non-nil, but it was nil")
+	}
+
+	tErr, ok := err.(mongosql.TranslationError)
+	if !ok {
+		t.Fatalf("expected error to be a TranslationError, but it wasn't")
+	}
+
+	if tErr.IsInternal() {
+		t.Fatalf("semantic translation errors should be external, but an internal error was found")
+	}
+}
+
+func TestCheckDriverVersion(t *testing.T) {
+	for _, v := range []string{"3.0.0", "3.1.4", "3.0.0-SNAPSHOT", "1.0.0-dirty"} {
+		if err := mongosql.CheckDriverVersion(v); err != nil {
+			t.Fatalf("expected driver version %q to be compatible, got '%s'", v, err)
+		}
+	}
+
+	err := mongosql.CheckDriverVersion("1.0.0")
+	if !errors.Is(err, mongosql.ErrIncompatibleDriverVersion) {
+		t.Fatalf("expected ErrIncompatibleDriverVersion, got %v", err)
+	}
+
+	err = mongosql.CheckDriverVersion("not a version")
+	if err == nil {
+		t.Fatalf("expected error to be non-nil, but it was nil")
+	}
+
+	if _, ok := err.(mongosql.TranslationError); !ok {
+		t.Fatalf("expected an invalid version to produce a TranslationError, got %v", err)
+	}
+}
@@ -0,0 +1,196 @@
+package mongosql
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mongodb/mongosql/go/mongosql/internal/translation"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// ExplainStage is the rendering of one stage of the compilation of a
+// sql query.
+type ExplainStage struct {
+	// Name identifies the stage
+	Name string `json:"name"`
+	// Text is a human-readable rendering of the stage
+	Text string `json:"text"`
+	// JSON is a machine-readable rendering of the stage, as canonical
+	// extended JSON
+	JSON json.RawMessage `json:"json"`
+}
+
+// Explanation describes every stage of the compilation of a sql query
+// to MQL, along with the resulting Translation. It is intended to be
+// attached to bug reports when a translation looks wrong.
+type Explanation struct {
+	// RewrittenAST is the query after syntactic rewrites. Its Text is
+	// the query printed as sql.
+	RewrittenAST ExplainStage
+	// MIR is the plan produced by the algebrizer
+	MIR ExplainStage
+	// OptimizedMIR is the MIR plan after optimization
+	OptimizedMIR ExplainStage
+	// AIR is the aggregation plan produced from the optimized MIR plan
+	AIR ExplainStage
+	// DesugaredAIR is the AIR plan after desugaring
+	DesugaredAIR ExplainStage
+	// MQL is the final aggregation pipeline, as extended JSON
+	MQL ExplainStage
+	// Translation is the result of the translation
+	Translation Translation
+}
+
+// Stages returns the stages of the Explanation in compilation order.
+func (e Explanation) Stages() []ExplainStage {
+	return []ExplainStage{e.RewrittenAST, e.MIR, e.OptimizedMIR, e.AIR, e.DesugaredAIR, e.MQL}
+}
+
+// String returns the text of every stage of the Explanation, each
+// preceded by a header with the stage's name.
+func (e Explanation) String() string {
+	var sb strings.Builder
+	for i, stage := range e.Stages() {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "== %s ==\n%s\n", stage.Name, stage.Text)
+	}
+	return sb.String()
+}
+
+// MarshalJSON returns the JSON encoding of the Explanation's target
+// namespace and stages.
+func (e Explanation) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		TargetDB         string         `json:"target_db"`
+		TargetCollection string         `json:"target_collection"`
+		Stages           []ExplainStage `json:"stages"`
+	}{
+		TargetDB:         e.Translation.TargetDB,
+		TargetCollection: e.Translation.TargetCollection,
+		Stages:           e.Stages(),
+	})
+}
+
+// Explain translates a sql query in the same way as Translate, and
+// returns a rendering of every intermediate stage of the translation.
+// If the returned error is non-nil, it is a TranslationError and the
+// returned Explanation should be disregarded.
+func Explain(args TranslationArgs) (Explanation, error) {
+	base64ExplainResult, err := callExplain(args)
+	if err != nil {
+		return Explanation{}, err
+	}
+
+	explainBytes, err := base64.StdEncoding.DecodeString(base64ExplainResult)
+	if err != nil {
+		return Explanation{}, NewInternalError(fmt.Errorf("failed to decode base64 explain result: %w", err))
+	}
+
+	if err := translation.DecodeError(explainBytes); err != nil {
+		return Explanation{}, err
+	}
+
+	explainResult := struct {
+		RewrittenAST explainStageResult `bson:"rewritten_ast"`
+		MIR          explainStageResult `bson:"mir"`
+		OptimizedMIR explainStageResult `bson:"optimized_mir"`
+		AIR          explainStageResult `bson:"air"`
+		DesugaredAIR explainStageResult `bson:"desugared_air"`
+		Translation  bson.Raw           `bson:"translation"`
+	}{}
+	if err := bson.Unmarshal(explainBytes, &explainResult); err != nil {
+		return Explanation{}, NewInternalError(fmt.Errorf("failed to unmarshal explain result BSON into struct: %w", err))
+	}
+
+	result, err := translation.DecodeTranslation(explainResult.Translation)
+	if err != nil {
+		return Explanation{}, err
+	}
+	result.Version = libraryVersion()
+
+	mql, err := mqlStage(result.Pipeline)
+	if err != nil {
+		return Explanation{}, err
+	}
+
+	explanation := Explanation{
+		MQL:         mql,
+		Translation: result,
+	}
+	for _, stage := range []struct {
+		name   string
+		result explainStageResult
+		dest   *ExplainStage
+	}{
+		{"rewritten_ast", explainResult.RewrittenAST, &explanation.RewrittenAST},
+		{"mir", explainResult.MIR, &explanation.MIR},
+		{"optimized_mir", explainResult.OptimizedMIR, &explanation.OptimizedMIR},
+		{"air", explainResult.AIR, &explanation.AIR},
+		{"desugared_air", explainResult.DesugaredAIR, &explanation.DesugaredAIR},
+	} {
+		j, err := extJSON(stage.result.Tree)
+		if err != nil {
+			return Explanation{}, NewInternalError(fmt.Errorf("failed to convert stage %s to extended JSON: %w", stage.name, err))
+		}
+		*stage.dest = ExplainStage{
+			Name: stage.name,
+			Text: stage.result.Text,
+			JSON: j,
+		}
+	}
+	return explanation, nil
+}
+
+// explainStageResult is a stage of the explain result returned by the
+// library, holding the stage's text and its BSON serialization.
+type explainStageResult struct {
+	Text string        `bson:"text"`
+	Tree bson.RawValue `bson:"tree"`
+}
+
+// extJSON returns the canonical extended JSON of the provided value.
+func extJSON(value interface{}) (json.RawMessage, error) {
+	doc, err := bson.MarshalExtJSON(bson.D{{Key: "value", Value: value}}, true, false)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapper := struct {
+		Value json.RawMessage `json:"value"`
+	}{}
+	if err := json.Unmarshal(doc, &wrapper); err != nil {
+		return nil, err
+	}
+	return wrapper.Value, nil
+}
+
+// mqlStage returns the ExplainStage for the provided pipeline, which
+// is rendered as canonical extended JSON.
+func mqlStage(pipeline []byte) (ExplainStage, error) {
+	var stages bson.A
+	if err := (bson.RawValue{Type: bsontype.Array, Value: pipeline}).Unmarshal(&stages); err != nil {
+		return ExplainStage{}, NewInternalError(fmt.Errorf("failed to unmarshal pipeline: %w", err))
+	}
+
+	pipelineJSON, err := extJSON(stages)
+	if err != nil {
+		return ExplainStage{}, NewInternalError(fmt.Errorf("failed to marshal pipeline to extended JSON: %w", err))
+	}
+
+	var text bytes.Buffer
+	if err := json.Indent(&text, pipelineJSON, "", "  "); err != nil {
+		return ExplainStage{}, NewInternalError(fmt.Errorf("failed to indent extended JSON pipeline: %w", err))
+	}
+
+	return ExplainStage{
+		Name: "mql",
+		Text: text.String(),
+		JSON: pipelineJSON,
+	}, nil
+}
@@ -0,0 +1,84 @@
+package mongosql_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mongodb/mongosql/go/mongosql"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+func TestExplain(t *testing.T) {
+	schema := bson.M{
+		"bsonType": "object",
+		"properties": bson.M{
+			"a": bson.M{"bsonType": "int"},
+		},
+		"additionalProperties": false,
+	}
+
+	schemaBytes, err := bson.Marshal(&schema)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+
+	explanation, err := mongosql.Explain(mongosql.TranslationArgs{
+		DB:  "bar",
+		SQL: "select a from foo where a > 1 limit 5",
+		CatalogSchema: map[string]map[string]bsoncore.Document{
+			"bar": {"foo": schemaBytes},
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	if explanation.Translation.TargetCollection != "foo" {
+		t.Fatalf("expected targetCollection to be 'foo', got '%s'", explanation.Translation.TargetCollection)
+	}
+
+	if !strings.Contains(strings.ToLower(explanation.RewrittenAST.Text), "limit 5") {
+		t.Fatalf("expected rewritten ast to contain the limit, got %q", explanation.RewrittenAST.Text)
+	}
+
+	for _, stage := range explanation.Stages() {
+		if stage.Text == "" {
+			t.Fatalf("expected stage %q to have text, but it was empty", stage.Name)
+		}
+
+		var obj map[string]interface{}
+		var arr []interface{}
+		if json.Unmarshal(stage.JSON, &obj) != nil && json.Unmarshal(stage.JSON, &arr) != nil {
+			t.Fatalf("expected stage %q to have structured JSON, got %s", stage.Name, stage.JSON)
+		}
+
+		if !strings.Contains(explanation.String(), "== "+stage.Name+" ==") {
+			t.Fatalf("expected explanation text to contain stage %q", stage.Name)
+		}
+	}
+
+	if _, err := json.Marshal(explanation); err != nil {
+		t.Fatalf("expected explanation to marshal to JSON, got '%s'", err)
+	}
+}
+
+func TestExplainError(t *testing.T) {
+	_, err := mongosql.Explain(mongosql.TranslationArgs{
+		DB:  "bar",
+		SQL: "this is not sql",
+	})
+	if err == nil {
+		t.Fatalf("expected error to be non-nil, but it was nil")
+	}
+
+	tErr, ok := err.(mongosql.TranslationError)
+	if !ok {
+		t.Fatalf("expected error to be a TranslationError, but it wasn't")
+	}
+
+	if tErr.IsInternal() {
+		t.Fatalf("semantic translation errors should be external, but an internal error was found")
+	}
+}
@@ -0,0 +1,54 @@
+package mongosql
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/mongodb/mongosql/go/mongosql/internal/translation"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// QueryFingerprint identifies the shape of a query independently of its
+// literal values, the case of its identifiers and keywords, and its
+// whitespace.
+type QueryFingerprint struct {
+	// SQL is the normalized text of the query. Every literal is replaced
+	// by ?, every identifier is lowercased, and keywords and whitespace
+	// are printed canonically. It is meant for display and grouping, and
+	// is not itself a valid query.
+	SQL string
+	// Hash is the hex-encoded SHA-256 hash of SQL. It is stable across
+	// releases as long as the normalized text of the query is.
+	Hash string
+}
+
+// Fingerprint returns the fingerprint of the provided sql statement. Two
+// statements that differ only in their literal values, the case of their
+// identifiers and keywords, or their whitespace have the same
+// fingerprint. Fingerprint only checks syntax, so a statement that cannot
+// be translated may still have a fingerprint.
+func Fingerprint(sqlStatement string) (QueryFingerprint, error) {
+	resultBytes, err := base64.StdEncoding.DecodeString(callFingerprint(sqlStatement))
+	if err != nil {
+		return QueryFingerprint{}, NewInternalError(fmt.Errorf("failed to decode base64 fingerprint result: %w", err))
+	}
+
+	if err := translation.DecodeError(resultBytes); err != nil {
+		return QueryFingerprint{}, err
+	}
+
+	fingerprintResult := struct {
+		Normalized string `bson:"normalized"`
+	}{}
+	if err := bson.Unmarshal(resultBytes, &fingerprintResult); err != nil {
+		return QueryFingerprint{}, NewInternalError(fmt.Errorf("failed to unmarshal fingerprint result BSON into struct: %w", err))
+	}
+
+	hash := sha256.Sum256([]byte(fingerprintResult.Normalized))
+	return QueryFingerprint{
+		SQL:  fingerprintResult.Normalized,
+		Hash: hex.EncodeToString(hash[:]),
+	}, nil
+}
@@ -0,0 +1,54 @@
+package mongosql_test
+
+import (
+	"testing"
+
+	"github.com/mongodb/mongosql/go/mongosql"
+)
+
+func TestFingerprint(t *testing.T) {
+	fingerprint, err := mongosql.Fingerprint("select a from Foo where b = 42 and c = 'hello'")
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	expected := "SELECT a FROM foo WHERE b = ? AND c = ?"
+	if fingerprint.SQL != expected {
+		t.Fatalf("expected normalized sql to be '%s', got '%s'", expected, fingerprint.SQL)
+	}
+	if len(fingerprint.Hash) != 64 {
+		t.Fatalf("expected a hex-encoded SHA-256 hash, got '%s'", fingerprint.Hash)
+	}
+}
+
+func TestFingerprintIgnoresLiteralsCaseAndWhitespace(t *testing.T) {
+	first, err := mongosql.Fingerprint("select a from foo where b = 42")
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	second, err := mongosql.Fingerprint("SELECT A\n  FROM FOO\n  WHERE B = -7")
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	if first != second {
+		t.Fatalf("expected fingerprints to be equal, got %+v and %+v", first, second)
+	}
+}
+
+func TestFingerprintError(t *testing.T) {
+	_, err := mongosql.Fingerprint("select from where")
+	if err == nil {
+		t.Fatalf("expected error to be non-nil, but it was nil")
+	}
+
+	tErr, ok := err.(mongosql.TranslationError)
+	if !ok {
+		t.Fatalf("expected error to be a TranslationError, but it wasn't")
+	}
+
+	if tErr.IsInternal() {
+		t.Fatalf("fingerprint errors should be external, but an internal error was found")
+	}
+}
@@ -0,0 +1,92 @@
+package mongosql
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/mongodb/mongosql/go/mongosql/internal/translation"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// KeywordCase is the case used for keywords, function names, and type
+// names in formatted queries. Identifiers always keep their case.
+type KeywordCase int
+
+const (
+	// KeywordCaseUpper prints keywords in upper case. It is the
+	// default.
+	KeywordCaseUpper KeywordCase = iota
+	// KeywordCaseLower prints keywords in lower case.
+	KeywordCaseLower
+)
+
+// FormatOptions controls the layout of queries returned by Format. The
+// zero value formats queries with upper case keywords, an indentation
+// of 2 spaces, and a line width of 80 characters.
+type FormatOptions struct {
+	// KeywordCase is the case used for keywords
+	KeywordCase KeywordCase
+	// Indent is the number of spaces by which subqueries and wrapped
+	// lines are indented. When zero, 2 is used.
+	Indent int
+	// LineWidth is the width at which lines are wrapped when possible.
+	// When zero, 80 is used. Lines are only wrapped between tokens, so
+	// a line may be longer if it contains a long token.
+	LineWidth int
+}
+
+// formatOptionsDocument is the format options document expected by the
+// c translation library.
+type formatOptionsDocument struct {
+	KeywordCase string `bson:"keyword_case"`
+	Indent      int32  `bson:"indent,omitempty"`
+	LineWidth   int32  `bson:"line_width,omitempty"`
+}
+
+// Format returns the provided sql statement formatted according to
+// opts. Each clause of a query starts a new line, subqueries are
+// indented, and long lines are wrapped. Formatting never changes the
+// meaning of a statement: the formatted statement parses to the same
+// query as the original, and an error is returned if it would not.
+func Format(sqlStatement string, opts FormatOptions) (string, error) {
+	doc := formatOptionsDocument{
+		KeywordCase: "upper",
+		Indent:      int32(opts.Indent),
+		LineWidth:   int32(opts.LineWidth),
+	}
+	switch opts.KeywordCase {
+	case KeywordCaseUpper:
+	case KeywordCaseLower:
+		doc.KeywordCase = "lower"
+	default:
+		return "", fmt.Errorf("invalid keyword case %d", opts.KeywordCase)
+	}
+	if opts.Indent < 0 || opts.LineWidth < 0 {
+		return "", fmt.Errorf("indent and line width must not be negative")
+	}
+
+	optionsBson, err := bson.Marshal(doc)
+	if err != nil {
+		return "", NewInternalError(fmt.Errorf("failed to marshal format options to BSON: %w", err))
+	}
+
+	base64Result := callFormat(sqlStatement, base64.StdEncoding.EncodeToString(optionsBson))
+
+	resultBytes, err := base64.StdEncoding.DecodeString(base64Result)
+	if err != nil {
+		return "", NewInternalError(fmt.Errorf("failed to decode base64 format result: %w", err))
+	}
+
+	if err := translation.DecodeError(resultBytes); err != nil {
+		return "", err
+	}
+
+	formatResult := struct {
+		Formatted string `bson:"formatted"`
+	}{}
+	if err := bson.Unmarshal(resultBytes, &formatResult); err != nil {
+		return "", NewInternalError(fmt.Errorf("failed to unmarshal format result BSON into struct: %w", err))
+	}
+
+	return formatResult.Formatted, nil
+}
@@ -0,0 +1,51 @@
+package mongosql_test
+
+import (
+	"testing"
+
+	"github.com/mongodb/mongosql/go/mongosql"
+)
+
+func TestFormat(t *testing.T) {
+	formatted, err := mongosql.Format("SELECT a FROM foo WHERE a > 1", mongosql.FormatOptions{
+		KeywordCase: mongosql.KeywordCaseLower,
+	})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	expected := "select a\nfrom foo\nwhere a > 1"
+	if formatted != expected {
+		t.Fatalf("expected formatted query to be %q, got %q", expected, formatted)
+	}
+}
+
+func TestFormatIndentsSubqueries(t *testing.T) {
+	formatted, err := mongosql.Format("select * from (select a from foo) sub", mongosql.FormatOptions{
+		Indent: 4,
+	})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	expected := "SELECT *\nFROM (\n    SELECT a\n    FROM foo\n) AS sub"
+	if formatted != expected {
+		t.Fatalf("expected formatted query to be %q, got %q", expected, formatted)
+	}
+}
+
+func TestFormatError(t *testing.T) {
+	_, err := mongosql.Format("select from where", mongosql.FormatOptions{})
+	if err == nil {
+		t.Fatalf("expected error to be non-nil, but it was nil")
+	}
+
+	tErr, ok := err.(mongosql.TranslationError)
+	if !ok {
+		t.Fatalf("expected error to be a TranslationError, but it wasn't")
+	}
+
+	if tErr.IsInternal() {
+		t.Fatalf("parse errors should be external, but an internal error was found")
+	}
+}
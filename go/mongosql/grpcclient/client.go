@@ -0,0 +1,314 @@
+// Package grpcclient provides a cgo-free client for the MongoSQL
+// TranslatorService gRPC API. Results are returned using the same
+// Translation and Namespace types as the in-process mongosql package.
+package grpcclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mongodb/mongosql/go/mongosql/internal/translation"
+	"github.com/mongodb/mongosql/go/mongosql/internal/translatorv1"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Translation represents the result of translating a sql query to
+// MQL. It is the same type as mongosql.Translation.
+type Translation = translation.Translation
+
+// Namespace represents a MongoDB collection namespace. It is the same
+// type as mongosql.Namespace.
+type Namespace = translation.Namespace
+
+// TranslationError is the error type returned by the Client. It is the
+// same type as mongosql.TranslationError.
+type TranslationError = translation.TranslationError
+
+// internalServerErrorPrefix is the prefix the service uses for the
+// messages of errors caused by a panic during translation.
+const internalServerErrorPrefix = "Internal server error"
+
+// TranslationArgs contains the arguments to the translation service.
+type TranslationArgs struct {
+	// DB represents the current database in which the sql query was run
+	DB string
+	// SQL is a string containing the sql query
+	SQL string
+	// CatalogSchema maps namespaces to JSON Schemas that describe the
+	// shape of the documents in the namespace.
+	CatalogSchema map[string]map[string]bsoncore.Document
+	// ExcludeNamespaces when set to true will return a non-namespaced result set
+	ExcludeNamespaces bool
+}
+
+// Options configures the behavior of a Client.
+type Options struct {
+	// Timeout is the deadline applied to each attempt of a call. A
+	// deadline on the context passed to a call still bounds the call
+	// as a whole. Zero means no per-attempt deadline.
+	Timeout time.Duration
+	// MaxAttempts is the maximum number of times a call is attempted
+	// when the service is unavailable. Values less than one are
+	// treated as one.
+	MaxAttempts int
+	// Backoff is the delay before the first retry. The delay doubles
+	// for each subsequent retry.
+	Backoff time.Duration
+}
+
+// DefaultOptions returns the recommended Options for a Client.
+func DefaultOptions() Options {
+	return Options{
+		Timeout:     10 * time.Second,
+		MaxAttempts: 3,
+		Backoff:     100 * time.Millisecond,
+	}
+}
+
+// Client is a client for the TranslatorService. It is safe for
+// concurrent use.
+type Client struct {
+	conn    *grpc.ClientConn
+	service translatorv1.TranslatorServiceClient
+	opts    Options
+}
+
+// Dial creates a Client connected to the TranslatorService at the
+// provided target. The dialOpts are passed through to grpc.Dial, and
+// must include transport credentials.
+func Dial(target string, opts Options, dialOpts ...grpc.DialOption) (*Client, error) {
+	conn, err := grpc.Dial(target, dialOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial translator service at %q: %w", target, err)
+	}
+	client := New(conn, opts)
+	client.conn = conn
+	return client, nil
+}
+
+// New creates a Client that issues calls over the provided connection.
+// The caller retains ownership of the connection.
+func New(cc grpc.ClientConnInterface, opts Options) *Client {
+	return &Client{
+		service: translatorv1.NewTranslatorServiceClient(cc),
+		opts:    opts,
+	}
+}
+
+// Close closes the connection created by Dial. It is a no-op for
+// clients created with New.
+func (c *Client) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	return c.conn.Close()
+}
+
+// Translate sends the provided TranslationArgs to the service,
+// returning a Translation and an error if the translation failed. If
+// the returned error is non-nil, the returned Translation should be
+// disregarded.
+func (c *Client) Translate(ctx context.Context, args TranslationArgs) (Translation, error) {
+	catalogSchemaBson, err := bson.Marshal(bson.D{{Key: "catalog_schema", Value: args.CatalogSchema}})
+	if err != nil {
+		return Translation{}, translation.NewInternalError(fmt.Errorf("failed to marshal catalog schema to BSON: %w", err))
+	}
+
+	excludeNamespaces := translatorv1.ExcludeNamespacesOption_EXCLUDE_NAMESPACES_OPTION_INCLUDE_NAMESPACES
+	if args.ExcludeNamespaces {
+		excludeNamespaces = translatorv1.ExcludeNamespacesOption_EXCLUDE_NAMESPACES_OPTION_EXCLUDE_NAMESPACES_UNSPECIFIED
+	}
+
+	req := &translatorv1.TranslateSqlRequest{
+		Db:                args.DB,
+		Query:             args.SQL,
+		SchemaCatalog:     catalogSchemaBson,
+		ExcludeNamespaces: excludeNamespaces,
+	}
+
+	var resp *translatorv1.TranslateSqlResponse
+	err = c.invoke(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = c.service.TranslateSql(ctx, req)
+		return err
+	})
+	if err != nil {
+		return Translation{}, err
+	}
+
+	return translationFromResponse(resp, args.ExcludeNamespaces)
+}
+
+// GetNamespaces returns the Namespaces referenced in the provided
+// sqlStatement. Unqualified collections in the statement are assumed
+// to be in the provided database.
+func (c *Client) GetNamespaces(ctx context.Context, dbName, sqlStatement string) ([]Namespace, error) {
+	req := &translatorv1.GetNamespacesRequest{
+		Db:    dbName,
+		Query: sqlStatement,
+	}
+
+	var resp *translatorv1.GetNamespacesResponse
+	err := c.invoke(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = c.service.GetNamespaces(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces := make([]Namespace, 0, len(resp.GetNamespaces()))
+	for _, ns := range resp.GetNamespaces() {
+		namespaces = append(namespaces, Namespace{
+			Database:   ns.GetDb(),
+			Collection: ns.GetCollection(),
+		})
+	}
+	return namespaces, nil
+}
+
+// invoke runs call, applying the per-attempt deadline and retrying
+// with exponential backoff while the service is unavailable. The
+// returned error, if any, is a TranslationError.
+func (c *Client) invoke(ctx context.Context, call func(context.Context) error) error {
+	attempts := c.opts.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	backoff := c.opts.Backoff
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = c.attempt(ctx, call)
+		if err == nil || attempt >= attempts || !retryable(ctx, err) {
+			break
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return translation.NewInternalError(fmt.Errorf("translator service call abandoned after %d attempts: %w", attempt, ctx.Err()))
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+
+	if err != nil {
+		return toTranslationError(err)
+	}
+	return nil
+}
+
+// attempt runs a single attempt of call under the per-attempt deadline.
+func (c *Client) attempt(ctx context.Context, call func(context.Context) error) error {
+	if c.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.opts.Timeout)
+		defer cancel()
+	}
+	return call(ctx)
+}
+
+// retryable reports whether a failed attempt may be retried. Only
+// transport failures are retried, and only while the caller's context
+// is still live; errors returned by the translator itself are final.
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
+
+// toTranslationError converts an error returned by the service into a
+// TranslationError. The service reports invalid input and translation
+// failures with InvalidArgument and Internal codes; those are external
+// unless they were caused by a panic in the service. Every other
+// failure is internal.
+func toTranslationError(err error) TranslationError {
+	s, ok := status.FromError(err)
+	if !ok {
+		return translation.NewInternalError(err)
+	}
+	switch s.Code() {
+	case codes.InvalidArgument:
+		return translation.NewExternalError(errors.New(s.Message()))
+	case codes.Internal:
+		if strings.HasPrefix(s.Message(), internalServerErrorPrefix) {
+			return translation.NewInternalError(errors.New(s.Message()))
+		}
+		return translation.NewExternalError(errors.New(s.Message()))
+	default:
+		return translation.NewInternalError(fmt.Errorf("translator service call failed: %w", err))
+	}
+}
+
+// translationFromResponse converts a TranslateSqlResponse into a
+// Translation. The service sends the pipeline and result set schema as
+// JSON strings, so they are converted back into BSON here. The pipeline
+// is read from pipeline_ext_json, which keeps the type of every value,
+// and from pipeline for services that predate that field.
+func translationFromResponse(resp *translatorv1.TranslateSqlResponse, excludeNamespaces bool) (Translation, error) {
+	pipelineDoc := struct {
+		Pipeline []bson.D `bson:"pipeline"`
+	}{}
+	pipelineJSON, canonical := resp.GetPipelineExtJson(), true
+	if pipelineJSON == "" {
+		pipelineJSON, canonical = resp.GetPipeline(), false
+	}
+	err := bson.UnmarshalExtJSON([]byte(`{"pipeline":`+pipelineJSON+`}`), canonical, &pipelineDoc)
+	if err != nil {
+		return Translation{}, translation.NewInternalError(fmt.Errorf("failed to parse pipeline returned by translator service: %w", err))
+	}
+	_, pipelineBytes, err := bson.MarshalValue(pipelineDoc.Pipeline)
+	if err != nil {
+		return Translation{}, translation.NewInternalError(fmt.Errorf("failed to marshal pipeline to bytes: %w", err))
+	}
+
+	var resultSetSchema bson.Raw
+	err = bson.UnmarshalExtJSON([]byte(resp.GetResultSetSchema()), false, &resultSetSchema)
+	if err != nil {
+		return Translation{}, translation.NewInternalError(fmt.Errorf("failed to parse result set schema returned by translator service: %w", err))
+	}
+
+	return Translation{
+		TargetDB:         resp.GetDb(),
+		TargetCollection: resp.GetTargetCollection(),
+		Pipeline:         pipelineBytes,
+		ResultSetSchema:  bsoncore.Document(resultSetSchema),
+		SelectOrder:      selectOrderFromResponse(resp.GetSelectOrder(), excludeNamespaces),
+		Version:          resp.GetMetadata().GetVersion(),
+	}, nil
+}
+
+// selectOrderFromResponse converts SelectOrderItems into the array of
+// arrays used by Translation.SelectOrder. When namespaces are excluded
+// the service places the field name in the namespace slot and leaves
+// field_name empty, so each entry has a single element.
+func selectOrderFromResponse(items []*translatorv1.SelectOrderItem, excludeNamespaces bool) bsoncore.Array {
+	idx, arr := bsoncore.AppendArrayStart(nil)
+	for i, item := range items {
+		entryIdx, entry := bsoncore.AppendArrayStart(nil)
+		entry = bsoncore.AppendStringElement(entry, "0", item.GetNamespace())
+		if !excludeNamespaces {
+			entry = bsoncore.AppendStringElement(entry, "1", item.GetFieldName())
+		}
+		entry, _ = bsoncore.AppendArrayEnd(entry, entryIdx)
+		arr = bsoncore.AppendArrayElement(arr, fmt.Sprint(i), entry)
+	}
+	arr, _ = bsoncore.AppendArrayEnd(arr, idx)
+	return arr
+}
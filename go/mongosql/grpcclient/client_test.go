@@ -0,0 +1,400 @@
+package grpcclient_test
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mongodb/mongosql/go/mongosql/grpcclient"
+	"github.com/mongodb/mongosql/go/mongosql/internal/translatorv1"
+	"github.com/mongodb/mongosql/go/mongosql/internal/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// stubServer is an in-process TranslatorService whose responses are
+// provided by the test.
+type stubServer struct {
+	translatorv1.UnimplementedTranslatorServiceServer
+	calls         int32
+	translateSql  func(context.Context, *translatorv1.TranslateSqlRequest) (*translatorv1.TranslateSqlResponse, error)
+	getNamespaces func(context.Context, *translatorv1.GetNamespacesRequest) (*translatorv1.GetNamespacesResponse, error)
+}
+
+func (s *stubServer) TranslateSql(ctx context.Context, req *translatorv1.TranslateSqlRequest) (*translatorv1.TranslateSqlResponse, error) {
+	atomic.AddInt32(&s.calls, 1)
+	return s.translateSql(ctx, req)
+}
+
+func (s *stubServer) GetNamespaces(ctx context.Context, req *translatorv1.GetNamespacesRequest) (*translatorv1.GetNamespacesResponse, error) {
+	atomic.AddInt32(&s.calls, 1)
+	return s.getNamespaces(ctx, req)
+}
+
+func startStubServer(t *testing.T, stub *stubServer, opts grpcclient.Options) *grpcclient.Client {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	translatorv1.RegisterTranslatorServiceServer(server, stub)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	client, err := grpcclient.Dial("bufnet", opts,
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("failed to dial stub server: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func TestTranslate(t *testing.T) {
+	schema, err := util.GenerateDefaultCollectionSchema()
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	stub := &stubServer{
+		translateSql: func(_ context.Context, req *translatorv1.TranslateSqlRequest) (*translatorv1.TranslateSqlResponse, error) {
+			var catalog struct {
+				CatalogSchema map[string]map[string]bson.Raw `bson:"catalog_schema"`
+			}
+			if err := bson.Unmarshal(req.GetSchemaCatalog(), &catalog); err != nil {
+				return nil, status.Error(codes.Internal, err.Error())
+			}
+			if _, ok := catalog.CatalogSchema["bar"]["foo"]; !ok {
+				return nil, status.Error(codes.InvalidArgument, "missing bar.foo in catalog")
+			}
+			if req.GetExcludeNamespaces() != translatorv1.ExcludeNamespacesOption_EXCLUDE_NAMESPACES_OPTION_INCLUDE_NAMESPACES {
+				return nil, status.Error(codes.InvalidArgument, "expected namespaces to be included")
+			}
+			return &translatorv1.TranslateSqlResponse{
+				Metadata:         &translatorv1.Metadata{Version: "v1.2.3"},
+				Db:               req.GetDb(),
+				TargetCollection: "foo",
+				Pipeline:         `[{ "$project": { "foo": "$$ROOT", "_id": 0 } }]`,
+				ResultSetSchema:  `{"bsonType":"object","properties":{"foo":{"bsonType":"object","properties":{"a":{"bsonType":"int"}},"additionalProperties":false}},"required":["foo"],"additionalProperties":false}`,
+				SelectOrder: []*translatorv1.SelectOrderItem{
+					{Namespace: strPtr("foo"), FieldName: "a"},
+				},
+			}, nil
+		},
+	}
+	client := startStubServer(t, stub, grpcclient.DefaultOptions())
+
+	translation, err := client.Translate(context.Background(), grpcclient.TranslationArgs{
+		DB:            "bar",
+		SQL:           "select * from foo",
+		CatalogSchema: map[string]map[string]bsoncore.Document{"bar": {"foo": schema}},
+	})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	if translation.TargetDB != "bar" {
+		t.Fatalf("expected targetDB to be 'bar', got '%s'", translation.TargetDB)
+	}
+
+	if translation.TargetCollection != "foo" {
+		t.Fatalf("expected targetCollection to be 'foo', got '%s'", translation.TargetCollection)
+	}
+
+	if translation.Version != "v1.2.3" {
+		t.Fatalf("expected version to be 'v1.2.3', got '%s'", translation.Version)
+	}
+
+	var pipeline []bson.D
+	val := bson.RawValue{
+		Type:  bsontype.Array,
+		Value: translation.Pipeline,
+	}
+	err = val.Unmarshal(&pipeline)
+	if err != nil {
+		t.Fatalf("expected pipeline to unmarshal into []bson.D, but failed: %s", err)
+	}
+
+	expectedPipeline := []bson.D{
+		{{Key: "$project", Value: bson.D{
+			{Key: "foo", Value: "$$ROOT"},
+			{Key: "_id", Value: int32(0)},
+		}}},
+	}
+	if !reflect.DeepEqual(expectedPipeline, pipeline) {
+		t.Fatalf("expected pipelines to be equal, but they weren't:\n%s\nand\n%s", expectedPipeline, pipeline)
+	}
+
+	expectedResultSetSchema := bson.D{
+		{Key: "bsonType", Value: "object"},
+		{Key: "properties", Value: bson.D{
+			{Key: "foo", Value: bson.D{
+				{Key: "bsonType", Value: "object"},
+				{Key: "properties", Value: bson.D{
+					{Key: "a", Value: bson.D{{Key: "bsonType", Value: "int"}}},
+				}},
+				{Key: "additionalProperties", Value: false},
+			}},
+		}},
+		{Key: "required", Value: bson.A{"foo"}},
+		{Key: "additionalProperties", Value: false},
+	}
+	util.CheckResultSetSchema(t, expectedResultSetSchema, translation.ResultSetSchema)
+	util.CheckSelectListOrder(t, bson.A{bson.A{"foo", "a"}}, translation.SelectOrder)
+}
+
+func TestTranslateExcludeNamespacesSelectOrder(t *testing.T) {
+	stub := &stubServer{
+		translateSql: func(_ context.Context, req *translatorv1.TranslateSqlRequest) (*translatorv1.TranslateSqlResponse, error) {
+			if req.GetExcludeNamespaces() != translatorv1.ExcludeNamespacesOption_EXCLUDE_NAMESPACES_OPTION_EXCLUDE_NAMESPACES_UNSPECIFIED {
+				return nil, status.Error(codes.InvalidArgument, "expected namespaces to be excluded")
+			}
+			return &translatorv1.TranslateSqlResponse{
+				Db:              req.GetDb(),
+				Pipeline:        `[]`,
+				ResultSetSchema: `{}`,
+				SelectOrder: []*translatorv1.SelectOrderItem{
+					{Namespace: strPtr("a")},
+					{Namespace: strPtr("b")},
+				},
+			}, nil
+		},
+	}
+	client := startStubServer(t, stub, grpcclient.DefaultOptions())
+
+	translation, err := client.Translate(context.Background(), grpcclient.TranslationArgs{
+		DB:                "bar",
+		SQL:               "select a, b from foo",
+		ExcludeNamespaces: true,
+	})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	util.CheckSelectListOrder(t, bson.A{bson.A{"a"}, bson.A{"b"}}, translation.SelectOrder)
+}
+
+func TestTranslateTypedLiterals(t *testing.T) {
+	// what the service sends for
+	// SELECT TIMESTAMP '2024-01-02 03:04:05' AS d, 9007199254740993 AS l
+	stub := &stubServer{
+		translateSql: func(_ context.Context, req *translatorv1.TranslateSqlRequest) (*translatorv1.TranslateSqlResponse, error) {
+			return &translatorv1.TranslateSqlResponse{
+				Db:              req.GetDb(),
+				Pipeline:        `[{ "$project": { "_id": 0, "__bot": { "d": { "$literal": DateTime(2024-01-02 3:04:05.0 +00:00:00) }, "l": { "$literal": 9007199254740993 } } } }]`,
+				PipelineExtJson: `[{"$project": {"_id": {"$numberInt": "0"}, "__bot": {"d": {"$literal": {"$date": {"$numberLong": "1704164645000"}}}, "l": {"$literal": {"$numberLong": "9007199254740993"}}}}}]`,
+				ResultSetSchema: `{}`,
+			}, nil
+		},
+	}
+	client := startStubServer(t, stub, grpcclient.DefaultOptions())
+
+	translation, err := client.Translate(context.Background(), grpcclient.TranslationArgs{
+		DB:  "bar",
+		SQL: "SELECT TIMESTAMP '2024-01-02 03:04:05' AS d, 9007199254740993 AS l",
+	})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	var pipeline []bson.D
+	val := bson.RawValue{
+		Type:  bsontype.Array,
+		Value: translation.Pipeline,
+	}
+	if err := val.Unmarshal(&pipeline); err != nil {
+		t.Fatalf("expected pipeline to unmarshal into []bson.D, but failed: %s", err)
+	}
+
+	expectedPipeline := []bson.D{
+		{{Key: "$project", Value: bson.D{
+			{Key: "_id", Value: int32(0)},
+			{Key: "__bot", Value: bson.D{
+				{Key: "d", Value: bson.D{{Key: "$literal", Value: primitive.NewDateTimeFromTime(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))}}},
+				{Key: "l", Value: bson.D{{Key: "$literal", Value: int64(9007199254740993)}}},
+			}},
+		}}},
+	}
+	if !reflect.DeepEqual(expectedPipeline, pipeline) {
+		t.Fatalf("expected pipelines to be equal, but they weren't:\n%s\nand\n%s", expectedPipeline, pipeline)
+	}
+}
+
+func TestTranslateError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		internal bool
+	}{
+		{"invalid argument", status.Error(codes.InvalidArgument, "schema_catalog is empty"), false},
+		{"translation failure", status.Error(codes.Internal, "failed to translate SQL: parse error"), false},
+		{"panic", status.Error(codes.Internal, "Internal server error: This is a test panic"), true},
+		{"unimplemented", status.Error(codes.Unimplemented, "unknown method"), true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stub := &stubServer{
+				translateSql: func(context.Context, *translatorv1.TranslateSqlRequest) (*translatorv1.TranslateSqlResponse, error) {
+					return nil, test.err
+				},
+			}
+			client := startStubServer(t, stub, grpcclient.DefaultOptions())
+
+			_, err := client.Translate(context.Background(), grpcclient.TranslationArgs{DB: "bar", SQL: "select"})
+			if err == nil {
+				t.Fatalf("expected error to be non-nil, but it was nil")
+			}
+
+			tErr, ok := err.(grpcclient.TranslationError)
+			if !ok {
+				t.Fatalf("expected error to be a TranslationError, but it wasn't")
+			}
+
+			if tErr.IsInternal() != test.internal {
+				t.Fatalf("expected IsInternal to be %v, got %v", test.internal, tErr.IsInternal())
+			}
+
+			if stub.calls != 1 {
+				t.Fatalf("expected non-retryable error to be attempted once, got %d attempts", stub.calls)
+			}
+		})
+	}
+}
+
+func TestRetriesWhenUnavailable(t *testing.T) {
+	stub := &stubServer{}
+	stub.getNamespaces = func(context.Context, *translatorv1.GetNamespacesRequest) (*translatorv1.GetNamespacesResponse, error) {
+		if atomic.LoadInt32(&stub.calls) < 3 {
+			return nil, status.Error(codes.Unavailable, "try again")
+		}
+		return &translatorv1.GetNamespacesResponse{
+			Namespaces: []*translatorv1.Namespace{
+				{Db: "bar", Collection: "foo"},
+				{Db: "baz", Collection: "qux"},
+			},
+		}, nil
+	}
+	client := startStubServer(t, stub, grpcclient.Options{
+		MaxAttempts: 3,
+		Backoff:     time.Millisecond,
+	})
+
+	namespaces, err := client.GetNamespaces(context.Background(), "bar", "select * from foo join baz.qux")
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	expected := []grpcclient.Namespace{
+		{Database: "bar", Collection: "foo"},
+		{Database: "baz", Collection: "qux"},
+	}
+	if !reflect.DeepEqual(expected, namespaces) {
+		t.Fatalf("expected namespaces to be equal, but they weren't:\n%v\nand\n%v", expected, namespaces)
+	}
+
+	if stub.calls != 3 {
+		t.Fatalf("expected 3 attempts, got %d", stub.calls)
+	}
+}
+
+func TestRetriesExhausted(t *testing.T) {
+	stub := &stubServer{
+		getNamespaces: func(context.Context, *translatorv1.GetNamespacesRequest) (*translatorv1.GetNamespacesResponse, error) {
+			return nil, status.Error(codes.Unavailable, "down for maintenance")
+		},
+	}
+	client := startStubServer(t, stub, grpcclient.Options{
+		MaxAttempts: 2,
+		Backoff:     time.Millisecond,
+	})
+
+	_, err := client.GetNamespaces(context.Background(), "bar", "select * from foo")
+	if err == nil {
+		t.Fatalf("expected error to be non-nil, but it was nil")
+	}
+
+	if !err.(grpcclient.TranslationError).IsInternal() {
+		t.Fatalf("expected an unavailable service to produce an internal error")
+	}
+
+	if !strings.Contains(err.Error(), "down for maintenance") {
+		t.Fatalf("error message did not contain expected text: %q", err.Error())
+	}
+
+	if stub.calls != 2 {
+		t.Fatalf("expected 2 attempts, got %d", stub.calls)
+	}
+}
+
+func TestPerAttemptTimeout(t *testing.T) {
+	stub := &stubServer{}
+	stub.getNamespaces = func(ctx context.Context, _ *translatorv1.GetNamespacesRequest) (*translatorv1.GetNamespacesResponse, error) {
+		if atomic.LoadInt32(&stub.calls) == 1 {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return &translatorv1.GetNamespacesResponse{}, nil
+	}
+	client := startStubServer(t, stub, grpcclient.Options{
+		Timeout:     50 * time.Millisecond,
+		MaxAttempts: 2,
+		Backoff:     time.Millisecond,
+	})
+
+	_, err := client.GetNamespaces(context.Background(), "bar", "select * from foo")
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	if stub.calls != 2 {
+		t.Fatalf("expected the timed out attempt to be retried, got %d attempts", stub.calls)
+	}
+}
+
+func TestContextDeadlineIsNotRetried(t *testing.T) {
+	stub := &stubServer{
+		getNamespaces: func(ctx context.Context, _ *translatorv1.GetNamespacesRequest) (*translatorv1.GetNamespacesResponse, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}
+	client := startStubServer(t, stub, grpcclient.Options{
+		MaxAttempts: 5,
+		Backoff:     time.Millisecond,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := client.GetNamespaces(ctx, "bar", "select * from foo")
+	if err == nil {
+		t.Fatalf("expected error to be non-nil, but it was nil")
+	}
+
+	if status.Code(err.(grpcclient.TranslationError).Unwrap()) != codes.DeadlineExceeded {
+		t.Fatalf("expected a deadline exceeded error, got %q", err.Error())
+	}
+
+	if stub.calls != 1 {
+		t.Fatalf("expected the caller's deadline to stop retries, got %d attempts", stub.calls)
+	}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
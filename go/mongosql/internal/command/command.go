@@ -0,0 +1,65 @@
+// Package command contains the Go representation of the BSON command
+// protocol defined by mongosqltranslate. A Command is serialized to a
+// BSON document, run by the library, and answered with a BSON
+// document that either holds the result of the command or the error
+// fields "error" and "error_is_internal".
+package command
+
+import (
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// CommandType identifies the operation a Command performs.
+type CommandType string
+
+const (
+	// Translate translates a sql query to MQL. It requires the SQL, DB,
+	// SchemaCatalog, ExcludeNamespaces and RelaxSchemaChecking options.
+	Translate CommandType = "translate"
+	// GetNamespaces returns the namespaces referenced by a sql query. It
+	// requires the SQL and DB options.
+	GetNamespaces CommandType = "getNamespaces"
+	// GetMongosqlTranslateVersion returns the version of the library.
+	// It requires no options.
+	GetMongosqlTranslateVersion CommandType = "getMongosqlTranslateVersion"
+	// CheckDriverVersion reports whether a driver version is compatible
+	// with the library. It requires the DriverVersion option.
+	CheckDriverVersion CommandType = "checkDriverVersion"
+)
+
+// Command is a request to the mongosqltranslate library.
+type Command struct {
+	// Command is the operation to perform
+	Command CommandType `bson:"command"`
+	// Options holds the arguments to the operation
+	Options CommandOptions `bson:"options"`
+}
+
+// CommandOptions holds the arguments of a Command. Options that are
+// not used by a CommandType are ignored by the library.
+type CommandOptions struct {
+	// SQL is a string containing the sql query
+	SQL string `bson:"sql,omitempty"`
+	// ExcludeNamespaces when set to true will return a non-namespaced
+	// result set
+	ExcludeNamespaces *bool `bson:"excludeNamespaces,omitempty"`
+	// RelaxSchemaChecking relaxes schema checking for comparisons when
+	// set to true
+	RelaxSchemaChecking *bool `bson:"relaxSchemaChecking,omitempty"`
+	// DB represents the current database in which the sql query was run
+	DB string `bson:"db,omitempty"`
+	// SchemaCatalog maps namespaces to JSON Schemas that describe the
+	// shape of the documents in the namespace
+	SchemaCatalog map[string]map[string]bsoncore.Document `bson:"schemaCatalog,omitempty"`
+	// DriverVersion is the SemVer version of the driver whose
+	// compatibility is being checked
+	DriverVersion string `bson:"driverVersion,omitempty"`
+	// OdbcDriver is true when DriverVersion is an ODBC driver version,
+	// and false or unset when it is a JDBC driver version
+	OdbcDriver *bool `bson:"odbcDriver,omitempty"`
+}
+
+// Bool returns a pointer to b, for use in CommandOptions.
+func Bool(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,155 @@
+// Package limiter bounds the number of concurrent calls into the
+// native translation library, queueing callers until a slot is free
+// or their context is done.
+package limiter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// ErrQueueFull is returned by Acquire when the maximum number of
+// callers are already waiting for a slot.
+var ErrQueueFull = errors.New("too many translations waiting to run")
+
+// Observer receives a callback for every admission decision made by a
+// Limiter, so that queue wait times and rejections can be exported as
+// metrics. Implementations must be safe for concurrent use.
+type Observer interface {
+	// ObserveQueueWait is called when a caller is admitted, with the
+	// time it spent waiting for a slot.
+	ObserveQueueWait(wait time.Duration)
+	// ObserveRejection is called when a caller is rejected, either
+	// because the queue is full or because its context was done
+	// before a slot became free.
+	ObserveRejection(err error)
+}
+
+// Stats is a snapshot of the counters kept by a Limiter.
+type Stats struct {
+	// InFlight is the number of callers currently holding a slot
+	InFlight int64
+	// Waiting is the number of callers currently waiting for a slot
+	Waiting int64
+	// Admitted is the total number of callers that acquired a slot
+	Admitted uint64
+	// Rejected is the total number of callers that gave up or were
+	// turned away without acquiring a slot
+	Rejected uint64
+	// TotalQueueWait is the sum of the time admitted callers spent
+	// waiting for a slot
+	TotalQueueWait time.Duration
+	// MaxQueueWait is the longest time an admitted caller spent
+	// waiting for a slot
+	MaxQueueWait time.Duration
+}
+
+// Limiter is a counting semaphore with a bounded wait queue. The zero
+// value is not usable; use New.
+type Limiter struct {
+	// slots is nil when concurrency is unlimited
+	slots     chan struct{}
+	maxQueued int64
+	observer  Observer
+
+	inFlight       atomic.Int64
+	waiting        atomic.Int64
+	admitted       atomic.Uint64
+	rejected       atomic.Uint64
+	totalQueueWait atomic.Int64
+	maxQueueWait   atomic.Int64
+}
+
+// New returns a Limiter that admits at most maxConcurrent callers at a
+// time and lets at most maxQueued callers wait for a slot. A
+// maxConcurrent of zero or less means unlimited concurrency, and a
+// maxQueued of zero or less means an unbounded queue. The observer may
+// be nil.
+func New(maxConcurrent, maxQueued int, observer Observer) *Limiter {
+	l := &Limiter{
+		maxQueued: int64(maxQueued),
+		observer:  observer,
+	}
+	if maxConcurrent > 0 {
+		l.slots = make(chan struct{}, maxConcurrent)
+	}
+	return l
+}
+
+// Acquire waits until a slot is free and returns a function that
+// releases it. The release function must be called exactly once. If
+// ctx is done before a slot is free, or the queue is full, Acquire
+// returns an error and no slot is held.
+func (l *Limiter) Acquire(ctx context.Context) (func(), error) {
+	start := time.Now()
+
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		default:
+			if err := l.wait(ctx); err != nil {
+				l.rejected.Add(1)
+				if l.observer != nil {
+					l.observer.ObserveRejection(err)
+				}
+				return nil, err
+			}
+		}
+	}
+
+	wait := time.Since(start)
+	l.admitted.Add(1)
+	l.inFlight.Add(1)
+	l.totalQueueWait.Add(int64(wait))
+	for {
+		max := l.maxQueueWait.Load()
+		if int64(wait) <= max || l.maxQueueWait.CompareAndSwap(max, int64(wait)) {
+			break
+		}
+	}
+	if l.observer != nil {
+		l.observer.ObserveQueueWait(wait)
+	}
+
+	var released atomic.Bool
+	return func() {
+		if !released.CompareAndSwap(false, true) {
+			return
+		}
+		l.inFlight.Add(-1)
+		if l.slots != nil {
+			<-l.slots
+		}
+	}, nil
+}
+
+// wait queues the caller until a slot is free or ctx is done.
+func (l *Limiter) wait(ctx context.Context) error {
+	if waiting := l.waiting.Add(1); l.maxQueued > 0 && waiting > l.maxQueued {
+		l.waiting.Add(-1)
+		return ErrQueueFull
+	}
+	defer l.waiting.Add(-1)
+
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("gave up waiting to run translation: %w", ctx.Err())
+	}
+}
+
+// Stats returns a snapshot of the Limiter's counters.
+func (l *Limiter) Stats() Stats {
+	return Stats{
+		InFlight:       l.inFlight.Load(),
+		Waiting:        l.waiting.Load(),
+		Admitted:       l.admitted.Load(),
+		Rejected:       l.rejected.Load(),
+		TotalQueueWait: time.Duration(l.totalQueueWait.Load()),
+		MaxQueueWait:   time.Duration(l.maxQueueWait.Load()),
+	}
+}
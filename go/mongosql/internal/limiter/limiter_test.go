@@ -0,0 +1,159 @@
+package limiter_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mongodb/mongosql/go/mongosql/internal/limiter"
+)
+
+type recordingObserver struct {
+	waits      atomic.Int64
+	rejections atomic.Int64
+}
+
+func (o *recordingObserver) ObserveQueueWait(time.Duration) { o.waits.Add(1) }
+func (o *recordingObserver) ObserveRejection(error)         { o.rejections.Add(1) }
+
+func TestConcurrencyIsBounded(t *testing.T) {
+	const maxConcurrent = 3
+	l := limiter.New(maxConcurrent, 0, nil)
+
+	var current, peak atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := l.Acquire(context.Background())
+			if err != nil {
+				t.Errorf("expected err to be nil, got '%s'", err)
+				return
+			}
+			defer release()
+
+			n := current.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			current.Add(-1)
+		}()
+	}
+	wg.Wait()
+
+	if p := peak.Load(); p > maxConcurrent {
+		t.Fatalf("expected at most %d concurrent callers, but saw %d", maxConcurrent, p)
+	}
+
+	stats := l.Stats()
+	if stats.Admitted != 50 || stats.Rejected != 0 || stats.InFlight != 0 || stats.Waiting != 0 {
+		t.Fatalf("unexpected stats after all callers finished: %+v", stats)
+	}
+
+	if stats.MaxQueueWait <= 0 || stats.TotalQueueWait < stats.MaxQueueWait {
+		t.Fatalf("expected queue wait times to be recorded, got %+v", stats)
+	}
+}
+
+func TestContextDeadlineWhileQueued(t *testing.T) {
+	observer := &recordingObserver{}
+	l := limiter.New(1, 0, observer)
+
+	release, err := l.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err = l.Acquire(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a deadline exceeded error, got %v", err)
+	}
+
+	if stats := l.Stats(); stats.Rejected != 1 || stats.Waiting != 0 {
+		t.Fatalf("expected one rejection and no waiters, got %+v", stats)
+	}
+
+	if observer.waits.Load() != 1 || observer.rejections.Load() != 1 {
+		t.Fatalf("expected one wait and one rejection to be observed, got %d and %d", observer.waits.Load(), observer.rejections.Load())
+	}
+}
+
+func TestQueueFull(t *testing.T) {
+	l := limiter.New(1, 1, nil)
+
+	release, err := l.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	queued := make(chan error)
+	go func() {
+		release, err := l.Acquire(context.Background())
+		if err == nil {
+			release()
+		}
+		queued <- err
+	}()
+
+	for l.Stats().Waiting != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	_, err = l.Acquire(context.Background())
+	if !errors.Is(err, limiter.ErrQueueFull) {
+		t.Fatalf("expected ErrQueueFull, got %v", err)
+	}
+
+	release()
+	if err := <-queued; err != nil {
+		t.Fatalf("expected the queued caller to be admitted, got '%s'", err)
+	}
+}
+
+func TestReleaseIsIdempotent(t *testing.T) {
+	l := limiter.New(1, 0, nil)
+
+	release, err := l.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+	release()
+	release()
+
+	if stats := l.Stats(); stats.InFlight != 0 {
+		t.Fatalf("expected no callers in flight, got %d", stats.InFlight)
+	}
+}
+
+func TestUnlimited(t *testing.T) {
+	l := limiter.New(0, 0, nil)
+
+	var releases []func()
+	for i := 0; i < 100; i++ {
+		release, err := l.Acquire(context.Background())
+		if err != nil {
+			t.Fatalf("expected err to be nil, got '%s'", err)
+		}
+		releases = append(releases, release)
+	}
+
+	if stats := l.Stats(); stats.InFlight != 100 {
+		t.Fatalf("expected 100 callers in flight, got %d", stats.InFlight)
+	}
+
+	for _, release := range releases {
+		release()
+	}
+}
@@ -0,0 +1,131 @@
+package translation
+
+import (
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// ErrorDocument is the BSON representation of a single error found in
+// a sql query by the translation library.
+type ErrorDocument struct {
+	Error    string    `bson:"error"`
+	Code     int       `bson:"code"`
+	Position *Position `bson:"position"`
+}
+
+// TranslationError returns the external TranslationError described by
+// d.
+func (d ErrorDocument) TranslationError() TranslationError {
+	err := NewExternalError(errors.New(d.Error))
+	err.code = d.Code
+	err.position = d.Position
+	return err
+}
+
+// payloadError returns the TranslationError described by the error
+// fields of a payload, or nil if the payload does not describe an
+// error. A payload describing a single error in the query holds that
+// error in errs, which is used to fill in its code and position.
+func payloadError(msg string, internal bool, errs []ErrorDocument) error {
+	if msg == "" {
+		return nil
+	}
+	if internal {
+		return NewInternalError(errors.New(msg))
+	}
+	if len(errs) == 1 {
+		return errs[0].TranslationError()
+	}
+	err := NewExternalError(errors.New(msg))
+	for _, d := range errs {
+		err.errors = append(err.errors, d.TranslationError())
+	}
+	return err
+}
+
+// DecodeTranslation converts the BSON payload returned by the
+// translation library for a translate call into a Translation. The
+// Version of the returned Translation is left empty.
+func DecodeTranslation(payload []byte) (Translation, error) {
+	translationResult := struct {
+		Error           string                `bson:"error"`
+		ErrorIsInternal bool                  `bson:"error_is_internal"`
+		Errors          []ErrorDocument       `bson:"errors"`
+		DB              string                `bson:"target_db"`
+		Collection      string                `bson:"target_collection"`
+		Pipeline        []bson.D              `bson:"pipeline"`
+		ResultSetSchema bsoncore.Document     `bson:"result_set_schema"`
+		SelectOrder     bsoncore.Array        `bson:"select_order"`
+		OptimizerPasses []OptimizerPassReport `bson:"optimizer_passes"`
+		Lineage         []ColumnLineage       `bson:"lineage"`
+	}{}
+
+	err := bson.Unmarshal(payload, &translationResult)
+	if err != nil {
+		return Translation{}, NewInternalError(fmt.Errorf("failed to unmarshal translation result BSON into struct: %w", err))
+	}
+
+	if err := payloadError(translationResult.Error, translationResult.ErrorIsInternal, translationResult.Errors); err != nil {
+		return Translation{}, err
+	}
+
+	typ, pipelineBytes, err := bson.MarshalValue(translationResult.Pipeline)
+	if err != nil {
+		return Translation{}, NewInternalError(fmt.Errorf("failed to marshal pipeline to bytes: %w", err))
+	}
+	if typ.String() != "array" {
+		// this should never occur, but is here as a sanity check
+		panic("didn't marshal to array")
+	}
+
+	return Translation{
+		TargetDB:         translationResult.DB,
+		TargetCollection: translationResult.Collection,
+		Pipeline:         pipelineBytes,
+		ResultSetSchema:  translationResult.ResultSetSchema,
+		SelectOrder:      translationResult.SelectOrder,
+		OptimizerPasses:  translationResult.OptimizerPasses,
+		lineage:          translationResult.Lineage,
+	}, nil
+}
+
+// DecodeNamespaces converts the BSON payload returned by the
+// translation library for a get_namespaces call into Namespaces.
+func DecodeNamespaces(payload []byte) ([]Namespace, error) {
+	result := struct {
+		Namespaces      []Namespace `bson:"namespaces"`
+		Error           string      `bson:"error"`
+		ErrorIsInternal bool        `bson:"error_is_internal"`
+	}{}
+
+	err := bson.Unmarshal(payload, &result)
+	if err != nil {
+		return nil, NewInternalError(fmt.Errorf("failed to unmarshal translation result BSON into struct: %w", err))
+	}
+
+	if err := payloadError(result.Error, result.ErrorIsInternal, nil); err != nil {
+		return nil, err
+	}
+
+	return result.Namespaces, nil
+}
+
+// DecodeError returns the TranslationError described by the provided
+// payload, or nil if the payload does not describe an error.
+func DecodeError(payload []byte) error {
+	result := struct {
+		Error           string          `bson:"error"`
+		ErrorIsInternal bool            `bson:"error_is_internal"`
+		Errors          []ErrorDocument `bson:"errors"`
+	}{}
+
+	err := bson.Unmarshal(payload, &result)
+	if err != nil {
+		return NewInternalError(fmt.Errorf("failed to unmarshal result BSON into struct: %w", err))
+	}
+
+	return payloadError(result.Error, result.ErrorIsInternal, result.Errors)
+}
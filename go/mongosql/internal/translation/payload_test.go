@@ -0,0 +1,173 @@
+package translation_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mongodb/mongosql/go/mongosql/internal/translation"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func marshalPayload(t *testing.T, payload bson.D) []byte {
+	data, err := bson.Marshal(payload)
+	if err != nil {
+		t.Fatalf("failed to marshal payload: %s", err)
+	}
+	return data
+}
+
+// errorSummary is a comparable description of a TranslationError.
+type errorSummary struct {
+	Message  string
+	Internal bool
+	Code     int
+	Position *translation.Position
+}
+
+func summarize(errs []translation.TranslationError) []errorSummary {
+	var summaries []errorSummary
+	for _, e := range errs {
+		s := errorSummary{Message: e.Error(), Internal: e.IsInternal(), Code: e.Code()}
+		if position, ok := e.Position(); ok {
+			s.Position = &position
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries
+}
+
+func TestDecodeError(t *testing.T) {
+	position := bson.D{{Key: "offset", Value: int64(10)}, {Key: "line", Value: int64(2)}, {Key: "column", Value: int64(1)}}
+
+	tests := []struct {
+		name     string
+		payload  bson.D
+		expected []errorSummary
+	}{
+		{
+			name: "error without details",
+			payload: bson.D{
+				{Key: "error", Value: "invalid value 2 for relax_schema_checking"},
+				{Key: "error_is_internal", Value: false},
+			},
+			expected: []errorSummary{{Message: "invalid value 2 for relax_schema_checking"}},
+		},
+		{
+			name: "internal error",
+			payload: bson.D{
+				{Key: "error", Value: "Internal Error: report this to MongoDB"},
+				{Key: "error_is_internal", Value: true},
+			},
+			expected: []errorSummary{{Message: "Internal Error: report this to MongoDB", Internal: true}},
+		},
+		{
+			name: "single error",
+			payload: bson.D{
+				{Key: "error", Value: "parse error"},
+				{Key: "error_is_internal", Value: false},
+				{Key: "errors", Value: bson.A{
+					bson.D{{Key: "error", Value: "parse error"}, {Key: "code", Value: int32(2001)}, {Key: "position", Value: position}},
+				}},
+			},
+			expected: []errorSummary{{
+				Message:  "parse error",
+				Code:     2001,
+				Position: &translation.Position{Offset: 10, Line: 2, Column: 1},
+			}},
+		},
+		{
+			name: "multiple errors",
+			payload: bson.D{
+				{Key: "error", Value: "parse error\nalgebrize error"},
+				{Key: "error_is_internal", Value: false},
+				{Key: "errors", Value: bson.A{
+					bson.D{{Key: "error", Value: "parse error"}, {Key: "code", Value: int32(2001)}, {Key: "position", Value: position}},
+					bson.D{{Key: "error", Value: "algebrize error"}, {Key: "code", Value: int32(3008)}},
+				}},
+			},
+			expected: []errorSummary{
+				{
+					Message:  "parse error",
+					Code:     2001,
+					Position: &translation.Position{Offset: 10, Line: 2, Column: 1},
+				},
+				{Message: "algebrize error", Code: 3008},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := translation.DecodeError(marshalPayload(t, test.payload))
+			if err == nil {
+				t.Fatalf("expected error to be non-nil, but it was nil")
+			}
+			tErr, ok := err.(translation.TranslationError)
+			if !ok {
+				t.Fatalf("expected error to be a TranslationError, got %T", err)
+			}
+			if diff := cmp.Diff(test.expected, summarize(tErr.Errors())); diff != "" {
+				t.Fatalf("unexpected errors (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDecodeErrorWithoutError(t *testing.T) {
+	err := translation.DecodeError(marshalPayload(t, bson.D{{Key: "target_db", Value: "test"}}))
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+}
+
+func TestMultipleErrorsHaveNoCodeOrPosition(t *testing.T) {
+	err := translation.DecodeError(marshalPayload(t, bson.D{
+		{Key: "error", Value: "a\nb"},
+		{Key: "errors", Value: bson.A{
+			bson.D{{Key: "error", Value: "a"}, {Key: "code", Value: int32(3008)}},
+			bson.D{{Key: "error", Value: "b"}, {Key: "code", Value: int32(3008)}},
+		}},
+	})).(translation.TranslationError)
+	if err.Code() != 0 {
+		t.Fatalf("expected code to be 0, got %d", err.Code())
+	}
+	if _, ok := err.Position(); ok {
+		t.Fatalf("expected position to be unknown")
+	}
+	if err.Error() != "a\nb" {
+		t.Fatalf("expected message to be 'a\\nb', got '%s'", err.Error())
+	}
+}
+
+func TestDecodeTranslationLineage(t *testing.T) {
+	payload := marshalPayload(t, bson.D{
+		{Key: "target_db", Value: "test"},
+		{Key: "target_collection", Value: "foo"},
+		{Key: "pipeline", Value: bson.A{}},
+		{Key: "result_set_schema", Value: bson.D{}},
+		{Key: "select_order", Value: bson.A{bson.A{"", "s"}}},
+		{Key: "lineage", Value: bson.A{bson.D{
+			{Key: "column", Value: bson.A{"", "s"}},
+			{Key: "kind", Value: "Transformed"},
+			{Key: "sources", Value: bson.A{bson.D{
+				{Key: "database", Value: "test"},
+				{Key: "collection", Value: "foo"},
+				{Key: "path", Value: bson.A{"b", "c"}},
+			}}},
+		}}},
+	})
+
+	tr, err := translation.DecodeTranslation(payload)
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	expected := []translation.ColumnLineage{{
+		Column:  []string{"", "s"},
+		Kind:    translation.LineageKindTransformed,
+		Sources: []translation.SourceField{{Database: "test", Collection: "foo", Path: []string{"b", "c"}}},
+	}}
+	if diff := cmp.Diff(expected, tr.Lineage()); diff != "" {
+		t.Fatalf("unexpected lineage (-want +got):\n%s", diff)
+	}
+}
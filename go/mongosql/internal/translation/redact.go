@@ -0,0 +1,141 @@
+package translation
+
+import (
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// redactedValue replaces every masked constant in a redacted pipeline.
+var redactedValue = bsoncore.Value{Type: bsontype.String, Data: bsoncore.AppendString(nil, "?")}
+
+// matchOperators are the query operators whose operands are constants
+// from the sql query.
+var matchOperators = map[string]bool{
+	"$eq":    true,
+	"$ne":    true,
+	"$gt":    true,
+	"$gte":   true,
+	"$lt":    true,
+	"$lte":   true,
+	"$in":    true,
+	"$nin":   true,
+	"$regex": true,
+}
+
+// RedactedPipeline returns a copy of Pipeline in which every constant
+// that comes from the sql query is replaced by the string "?". Null,
+// boolean, MinKey and MaxKey constants are kept, as are field names,
+// field paths and operators, so the redacted pipeline has the same
+// shape as the original but contains none of its data. Constants are
+// the operands of $literal in aggregation expressions and the operands
+// of comparison, $in and $regex operators in $match stages.
+func (t Translation) RedactedPipeline() ([]byte, error) {
+	if t.Pipeline == nil {
+		return nil, nil
+	}
+	redacted, err := redactArray(t.Pipeline, false)
+	if err != nil {
+		return nil, NewInternalError(fmt.Errorf("failed to redact pipeline: %w", err))
+	}
+	return redacted, nil
+}
+
+// redactValue returns v with its constants masked. When inMatch is
+// true, v is part of a $match query; otherwise it is part of a stage
+// or an aggregation expression.
+func redactValue(v bsoncore.Value, inMatch bool) (bsoncore.Value, error) {
+	var data []byte
+	var err error
+	switch v.Type {
+	case bsontype.EmbeddedDocument:
+		data, err = redactDocument(v.Data, inMatch)
+	case bsontype.Array:
+		data, err = redactArray(v.Data, inMatch)
+	default:
+		return v, nil
+	}
+	if err != nil {
+		return bsoncore.Value{}, err
+	}
+	return bsoncore.Value{Type: v.Type, Data: data}, nil
+}
+
+func redactDocument(doc bsoncore.Document, inMatch bool) ([]byte, error) {
+	elements, err := doc.Elements()
+	if err != nil {
+		return nil, err
+	}
+
+	idx, dst := bsoncore.AppendDocumentStart(nil)
+	for _, element := range elements {
+		key, value := element.Key(), element.Value()
+		switch {
+		case key == "$literal" && !inMatch:
+			value, err = maskValue(value)
+		case key == "$match" && !inMatch:
+			value, err = redactValue(value, true)
+		case key == "$expr" && inMatch:
+			value, err = redactValue(value, false)
+		case matchOperators[key] && inMatch:
+			value, err = maskValue(value)
+		case inMatch && !strings.HasPrefix(key, "$") && value.Type != bsontype.EmbeddedDocument:
+			// an implicit equality, such as {"a": 5}
+			value, err = maskValue(value)
+		default:
+			value, err = redactValue(value, inMatch)
+		}
+		if err != nil {
+			return nil, err
+		}
+		dst = bsoncore.AppendValueElement(dst, key, value)
+	}
+	return bsoncore.AppendDocumentEnd(dst, idx)
+}
+
+func redactArray(arr bsoncore.Array, inMatch bool) ([]byte, error) {
+	values, err := arr.Values()
+	if err != nil {
+		return nil, err
+	}
+
+	idx, dst := bsoncore.AppendArrayStart(nil)
+	for i, value := range values {
+		value, err = redactValue(value, inMatch)
+		if err != nil {
+			return nil, err
+		}
+		dst = bsoncore.AppendValueElement(dst, fmt.Sprint(i), value)
+	}
+	return bsoncore.AppendArrayEnd(dst, idx)
+}
+
+// maskValue returns v with every constant it contains replaced by
+// redactedValue. The keys of documents are kept.
+func maskValue(v bsoncore.Value) (bsoncore.Value, error) {
+	switch v.Type {
+	case bsontype.Null, bsontype.Boolean, bsontype.MinKey, bsontype.MaxKey:
+		return v, nil
+	case bsontype.EmbeddedDocument, bsontype.Array:
+		values, err := bsoncore.Document(v.Data).Elements()
+		if err != nil {
+			return bsoncore.Value{}, err
+		}
+		idx, dst := bsoncore.AppendDocumentStart(nil)
+		for _, element := range values {
+			masked, err := maskValue(element.Value())
+			if err != nil {
+				return bsoncore.Value{}, err
+			}
+			dst = bsoncore.AppendValueElement(dst, element.Key(), masked)
+		}
+		dst, err = bsoncore.AppendDocumentEnd(dst, idx)
+		if err != nil {
+			return bsoncore.Value{}, err
+		}
+		return bsoncore.Value{Type: v.Type, Data: dst}, nil
+	}
+	return redactedValue, nil
+}
@@ -0,0 +1,149 @@
+package translation_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mongodb/mongosql/go/mongosql/internal/translation"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func marshalPipeline(t *testing.T, pipeline bson.A) []byte {
+	_, data, err := bson.MarshalValue(pipeline)
+	if err != nil {
+		t.Fatalf("failed to marshal pipeline: %s", err)
+	}
+	return data
+}
+
+func unmarshalPipeline(t *testing.T, data []byte) []bson.D {
+	var pipeline []bson.D
+	err := bson.RawValue{Type: bsontype.Array, Value: data}.Unmarshal(&pipeline)
+	if err != nil {
+		t.Fatalf("failed to unmarshal pipeline: %s", err)
+	}
+	return pipeline
+}
+
+func TestRedactedPipeline(t *testing.T) {
+	tests := []struct {
+		name     string
+		pipeline bson.A
+		expected []bson.D
+	}{
+		{
+			name: "literals in expressions",
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"_id", int32(0)},
+					{"name", bson.D{{"$literal", "alice"}}},
+					{"total", bson.D{{"$add", bson.A{"$a", bson.D{{"$literal", int64(42)}}}}}},
+					{"flag", bson.D{{"$literal", true}}},
+					{"none", bson.D{{"$literal", nil}}},
+				}}},
+			},
+			expected: []bson.D{
+				{{"$project", bson.D{
+					{"_id", int32(0)},
+					{"name", bson.D{{"$literal", "?"}}},
+					{"total", bson.D{{"$add", bson.A{"$a", bson.D{{"$literal", "?"}}}}}},
+					{"flag", bson.D{{"$literal", true}}},
+					{"none", bson.D{{"$literal", nil}}},
+				}}},
+			},
+		},
+		{
+			name: "document literals keep their keys",
+			pipeline: bson.A{
+				bson.D{{"$documents", bson.A{
+					bson.D{{"$literal", bson.D{{"ssn", "123-45-6789"}, {"ids", bson.A{int32(1), int32(2)}}}}},
+				}}},
+			},
+			expected: []bson.D{
+				{{"$documents", bson.A{
+					bson.D{{"$literal", bson.D{{"ssn", "?"}, {"ids", bson.A{"?", "?"}}}}},
+				}}},
+			},
+		},
+		{
+			name: "match query operands",
+			pipeline: bson.A{
+				bson.D{{"$match", bson.D{{"$and", bson.A{
+					bson.D{{"a", bson.D{{"$gt", int32(5)}}}},
+					bson.D{{"b", bson.D{{"$in", bson.A{"x", "y"}}}}},
+					bson.D{{"c", bson.D{{"$regex", "^secret"}, {"$options", "i"}}}},
+					bson.D{{"d", bson.D{{"$type", "string"}}}},
+					bson.D{{"e", bson.D{{"$not", bson.D{{"$eq", "z"}}}}}},
+					bson.D{{"f", bson.D{{"$elemMatch", bson.D{{"$lte", 1.5}}}}}},
+					bson.D{{"$expr", bson.D{{"$eq", bson.A{"$g", bson.D{{"$literal", "$h"}}}}}}},
+				}}}}},
+			},
+			expected: []bson.D{
+				{{"$match", bson.D{{"$and", bson.A{
+					bson.D{{"a", bson.D{{"$gt", "?"}}}},
+					bson.D{{"b", bson.D{{"$in", bson.A{"?", "?"}}}}},
+					bson.D{{"c", bson.D{{"$regex", "?"}, {"$options", "i"}}}},
+					bson.D{{"d", bson.D{{"$type", "string"}}}},
+					bson.D{{"e", bson.D{{"$not", bson.D{{"$eq", "?"}}}}}},
+					bson.D{{"f", bson.D{{"$elemMatch", bson.D{{"$lte", "?"}}}}}},
+					bson.D{{"$expr", bson.D{{"$eq", bson.A{"$g", bson.D{{"$literal", "?"}}}}}}},
+				}}}}},
+			},
+		},
+		{
+			name: "nested pipelines",
+			pipeline: bson.A{
+				bson.D{{"$lookup", bson.D{
+					{"from", "bar"},
+					{"pipeline", bson.A{
+						bson.D{{"$match", bson.D{{"_id", primitive.MinKey{}}, {"$expr", false}}}},
+						bson.D{{"$match", bson.D{{"x", "secret"}}}},
+					}},
+					{"as", "bar"},
+				}}},
+				bson.D{{"$limit", int64(10)}},
+			},
+			expected: []bson.D{
+				{{"$lookup", bson.D{
+					{"from", "bar"},
+					{"pipeline", bson.A{
+						bson.D{{"$match", bson.D{{"_id", primitive.MinKey{}}, {"$expr", false}}}},
+						bson.D{{"$match", bson.D{{"x", "?"}}}},
+					}},
+					{"as", "bar"},
+				}}},
+				{{"$limit", int64(10)}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tr := translation.Translation{Pipeline: marshalPipeline(t, test.pipeline)}
+			redacted, err := tr.RedactedPipeline()
+			if err != nil {
+				t.Fatalf("expected err to be nil, got '%s'", err)
+			}
+
+			actual := unmarshalPipeline(t, redacted)
+			if diff := cmp.Diff(test.expected, actual); diff != "" {
+				t.Fatalf("unexpected redacted pipeline (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRedactedPipelineDoesNotModifyPipeline(t *testing.T) {
+	pipeline := marshalPipeline(t, bson.A{bson.D{{"$project", bson.D{{"a", bson.D{{"$literal", "secret"}}}}}}})
+	tr := translation.Translation{Pipeline: pipeline}
+	if _, err := tr.RedactedPipeline(); err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	expected := []bson.D{{{"$project", bson.D{{"a", bson.D{{"$literal", "secret"}}}}}}}
+	if diff := cmp.Diff(expected, unmarshalPipeline(t, tr.Pipeline)); diff != "" {
+		t.Fatalf("pipeline was modified (-want +got):\n%s", diff)
+	}
+}
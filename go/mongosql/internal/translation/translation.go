@@ -0,0 +1,195 @@
+// Package translation contains the result and error types shared by
+// every translation backend. The mongosql package and its cgo-free
+// clients re-export these types as aliases, so a Translation produced
+// by any backend has the same Go type.
+package translation
+
+import (
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// Translation represents the result of translating a sql query to
+// MQL. The fields of this struct can be used to construct an
+// aggregate command equivalent to a SQL query.
+type Translation struct {
+	// TargetDB is the MongoDB database against which the aggregate
+	// command should run
+	TargetDB string
+	// TargetCollection is the collection to be specified in the
+	// aggregate command, or nil if an "aggregate: 1" style aggregate
+	// command should be used
+	TargetCollection string
+	// Pipeline is the array representing the aggregation pipeline
+	// serialized to BSON
+	Pipeline []byte
+	// ResultSetSchema is a JSON Schema document that describes
+	// the documents returned by this Translation
+	ResultSetSchema bsoncore.Document
+	// SelectOrder is an Array of Arrays, with each sub array
+	// describing a singular field in the result set
+	SelectOrder bsoncore.Array
+	// Version is the version of the translation library that
+	// produced this Translation
+	Version string
+	// OptimizerPasses describes the optimizer passes that ran, in the
+	// order they are applied. It is only populated by the in-process
+	// translation library.
+	OptimizerPasses []OptimizerPassReport
+
+	lineage []ColumnLineage
+}
+
+// Lineage returns the lineage of each column of the result set, in the
+// order of SelectOrder: the collection fields the column is derived
+// from, and whether it copies, transforms or aggregates them. Lineage
+// follows columns through derived tables, WITH queries, joins and set
+// operations. It is only populated by the in-process translation
+// library, when TranslationOptions.IncludeLineage is set.
+func (t Translation) Lineage() []ColumnLineage {
+	return t.lineage
+}
+
+// LineageKind describes how a result set column is derived from its
+// sources.
+type LineageKind string
+
+// The values of LineageKind.
+const (
+	// LineageKindDirect is the kind of a column that is a copy of one
+	// of its sources. A column has several sources when it copies
+	// different fields in different rows, as with UNION ALL.
+	LineageKindDirect LineageKind = "Direct"
+	// LineageKindTransformed is the kind of a column computed by an
+	// expression over its sources
+	LineageKindTransformed LineageKind = "Transformed"
+	// LineageKindAggregate is the kind of a column computed by an
+	// aggregation function over its sources
+	LineageKindAggregate LineageKind = "Aggregate"
+)
+
+// ColumnLineage is the lineage of a column of a result set.
+type ColumnLineage struct {
+	// Column is the column, as it appears in SelectOrder
+	Column []string `bson:"column"`
+	// Kind describes how the column is derived from its sources
+	Kind LineageKind `bson:"kind"`
+	// Sources are the fields the column is derived from, in sorted
+	// order. A column computed only from literals has no sources.
+	Sources []SourceField `bson:"sources"`
+}
+
+// SourceField is a field of the documents of a collection.
+type SourceField struct {
+	// Database is the database of the collection
+	Database string `bson:"database"`
+	// Collection is the name of the collection
+	Collection string `bson:"collection"`
+	// Path is the path of the field in the documents of the
+	// collection, one element per level of nesting. The empty path is
+	// the whole document.
+	Path []string `bson:"path"`
+}
+
+// OptimizerPassReport describes how an optimizer pass affected the
+// plan during a translation.
+type OptimizerPassReport struct {
+	// Name is the name of the pass
+	Name string `bson:"name"`
+	// Runs is the number of times the pass ran. The optimizer repeats
+	// its passes until none of them changes the plan.
+	Runs int64 `bson:"runs"`
+	// Changed is true if any run of the pass changed the plan
+	Changed bool `bson:"changed"`
+}
+
+// Namespace represents a MongoDB collection namespace.
+type Namespace struct {
+	// Database is the database component of the namespace
+	Database string `bson:"database"`
+	// Collection is the collection component of the namespace
+	Collection string `bson:"collection"`
+}
+
+// TranslationError is an error type that includes additional
+// information about whether an error is "internal" or "external"
+// (i.e. whether it is safe and useful to expose to end users).
+type TranslationError struct {
+	internal bool
+	err      error
+	code     int
+	position *Position
+	errors   []TranslationError
+}
+
+// Position is the location of an error in a sql query.
+type Position struct {
+	// Offset is the byte offset of the error in the query
+	Offset int `bson:"offset"`
+	// Line is the line of the error, starting at 1
+	Line int `bson:"line"`
+	// Column is the column of the error in its line, starting at 1.
+	// Columns are counted in characters, not bytes.
+	Column int `bson:"column"`
+}
+
+// NewInternalError creates a TranslationError from the provided error
+// that should not be exposed to end users.
+func NewInternalError(err error) TranslationError {
+	return TranslationError{internal: true, err: err}
+}
+
+// NewExternalError creates a TranslationError from the provided error
+// that is okay/useful to expose to end users.
+func NewExternalError(err error) TranslationError {
+	return TranslationError{internal: false, err: err}
+}
+
+// Error implements the error interface by returning the string
+// representation of the underlying error.
+func (e TranslationError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e TranslationError) Unwrap() error {
+	return e.err
+}
+
+// IsInternal returns true if this is an "internal" error that should
+// not be exposed to end users, and false otherwise.
+func (e TranslationError) IsInternal() bool {
+	return e.internal
+}
+
+// Code returns the error code of this error, as listed in errors.md,
+// or 0 if the error has no code. An error holding several errors has
+// no code of its own.
+func (e TranslationError) Code() int {
+	return e.code
+}
+
+// Position returns the location of this error in the sql query, and
+// false if the location is unknown. Syntax errors are located where
+// they were found, and errors about a clause, a select item or a
+// collection are located at it.
+func (e TranslationError) Position() (Position, bool) {
+	if e.position == nil {
+		return Position{}, false
+	}
+	return *e.position, true
+}
+
+// Errors returns every error found in the sql query when this error
+// was caused by several of them, each with its own code. Otherwise, it
+// returns a slice holding only this error.
+//
+// Like the errors themselves, each of them has a position when its
+// location is known; errors found in a query that parses, such as
+// references to unknown fields, are located at the clause or select
+// item they were found in.
+func (e TranslationError) Errors() []TranslationError {
+	if len(e.errors) == 0 {
+		return []TranslationError{e}
+	}
+	return append([]TranslationError(nil), e.errors...)
+}
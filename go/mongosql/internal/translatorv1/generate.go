@@ -0,0 +1,5 @@
+// Package translatorv1 contains the Go bindings for the
+// TranslatorService protobuf definitions in service/proto.
+package translatorv1
+
+//go:generate protoc -I ../../../../service/proto --go_out=. --go_opt=module=github.com/mongodb/mongosql/go/mongosql/internal/translatorv1 --go_opt=Mtranslator/v1/translator.proto=github.com/mongodb/mongosql/go/mongosql/internal/translatorv1 --go-grpc_out=. --go-grpc_opt=module=github.com/mongodb/mongosql/go/mongosql/internal/translatorv1 --go-grpc_opt=Mtranslator/v1/translator.proto=github.com/mongodb/mongosql/go/mongosql/internal/translatorv1 translator/v1/translator.proto
@@ -0,0 +1,146 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v4.24.4
+// source: translator/v1/translator.proto
+
+package translatorv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	TranslatorService_TranslateSql_FullMethodName  = "/translator.v1.TranslatorService/TranslateSql"
+	TranslatorService_GetNamespaces_FullMethodName = "/translator.v1.TranslatorService/GetNamespaces"
+)
+
+// TranslatorServiceClient is the client API for TranslatorService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type TranslatorServiceClient interface {
+	TranslateSql(ctx context.Context, in *TranslateSqlRequest, opts ...grpc.CallOption) (*TranslateSqlResponse, error)
+	GetNamespaces(ctx context.Context, in *GetNamespacesRequest, opts ...grpc.CallOption) (*GetNamespacesResponse, error)
+}
+
+type translatorServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTranslatorServiceClient(cc grpc.ClientConnInterface) TranslatorServiceClient {
+	return &translatorServiceClient{cc}
+}
+
+func (c *translatorServiceClient) TranslateSql(ctx context.Context, in *TranslateSqlRequest, opts ...grpc.CallOption) (*TranslateSqlResponse, error) {
+	out := new(TranslateSqlResponse)
+	err := c.cc.Invoke(ctx, TranslatorService_TranslateSql_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *translatorServiceClient) GetNamespaces(ctx context.Context, in *GetNamespacesRequest, opts ...grpc.CallOption) (*GetNamespacesResponse, error) {
+	out := new(GetNamespacesResponse)
+	err := c.cc.Invoke(ctx, TranslatorService_GetNamespaces_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TranslatorServiceServer is the server API for TranslatorService service.
+// All implementations must embed UnimplementedTranslatorServiceServer
+// for forward compatibility
+type TranslatorServiceServer interface {
+	TranslateSql(context.Context, *TranslateSqlRequest) (*TranslateSqlResponse, error)
+	GetNamespaces(context.Context, *GetNamespacesRequest) (*GetNamespacesResponse, error)
+	mustEmbedUnimplementedTranslatorServiceServer()
+}
+
+// UnimplementedTranslatorServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedTranslatorServiceServer struct {
+}
+
+func (UnimplementedTranslatorServiceServer) TranslateSql(context.Context, *TranslateSqlRequest) (*TranslateSqlResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TranslateSql not implemented")
+}
+func (UnimplementedTranslatorServiceServer) GetNamespaces(context.Context, *GetNamespacesRequest) (*GetNamespacesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetNamespaces not implemented")
+}
+func (UnimplementedTranslatorServiceServer) mustEmbedUnimplementedTranslatorServiceServer() {}
+
+// UnsafeTranslatorServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TranslatorServiceServer will
+// result in compilation errors.
+type UnsafeTranslatorServiceServer interface {
+	mustEmbedUnimplementedTranslatorServiceServer()
+}
+
+func RegisterTranslatorServiceServer(s grpc.ServiceRegistrar, srv TranslatorServiceServer) {
+	s.RegisterService(&TranslatorService_ServiceDesc, srv)
+}
+
+func _TranslatorService_TranslateSql_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TranslateSqlRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TranslatorServiceServer).TranslateSql(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TranslatorService_TranslateSql_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TranslatorServiceServer).TranslateSql(ctx, req.(*TranslateSqlRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TranslatorService_GetNamespaces_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetNamespacesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TranslatorServiceServer).GetNamespaces(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TranslatorService_GetNamespaces_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TranslatorServiceServer).GetNamespaces(ctx, req.(*GetNamespacesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// TranslatorService_ServiceDesc is the grpc.ServiceDesc for TranslatorService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var TranslatorService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "translator.v1.TranslatorService",
+	HandlerType: (*TranslatorServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "TranslateSql",
+			Handler:    _TranslatorService_TranslateSql_Handler,
+		},
+		{
+			MethodName: "GetNamespaces",
+			Handler:    _TranslatorService_GetNamespaces_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "translator/v1/translator.proto",
+}
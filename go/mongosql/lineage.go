@@ -0,0 +1,23 @@
+package mongosql
+
+import (
+	"github.com/mongodb/mongosql/go/mongosql/internal/translation"
+)
+
+// ColumnLineage is the lineage of a column of a result set, as
+// returned by Translation.Lineage.
+type ColumnLineage = translation.ColumnLineage
+
+// LineageKind describes how a result set column is derived from its
+// sources.
+type LineageKind = translation.LineageKind
+
+// SourceField is a field of the documents of a collection.
+type SourceField = translation.SourceField
+
+// The values of LineageKind.
+const (
+	LineageKindDirect      = translation.LineageKindDirect
+	LineageKindTransformed = translation.LineageKindTransformed
+	LineageKindAggregate   = translation.LineageKindAggregate
+)
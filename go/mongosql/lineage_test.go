@@ -0,0 +1,47 @@
+package mongosql_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mongodb/mongosql/go/mongosql"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+func TestTranslationLineage(t *testing.T) {
+	schema := bson.M{
+		"bsonType": "object",
+		"properties": bson.M{
+			"a": bson.M{"bsonType": "int"},
+			"b": bson.M{"bsonType": "int"},
+		},
+		"additionalProperties": false,
+	}
+	bytes, err := bson.Marshal(&schema)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+
+	translation, err := mongosql.Translate(mongosql.TranslationArgs{
+		DB:            "test",
+		SQL:           "select d.a, d.s, sum(d.b) as total from (select a, a + b as s, b from foo) as d group by d.a, d.s",
+		CatalogSchema: map[string]map[string]bsoncore.Document{"test": {"foo": bsoncore.Document(bytes)}},
+		Options:       mongosql.TranslationOptions{IncludeLineage: true},
+	})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	source := func(field string) mongosql.SourceField {
+		return mongosql.SourceField{Database: "test", Collection: "foo", Path: []string{field}}
+	}
+	expected := []mongosql.ColumnLineage{
+		{Column: []string{"", "a"}, Kind: mongosql.LineageKindDirect, Sources: []mongosql.SourceField{source("a")}},
+		{Column: []string{"", "s"}, Kind: mongosql.LineageKindTransformed, Sources: []mongosql.SourceField{source("a"), source("b")}},
+		{Column: []string{"", "total"}, Kind: mongosql.LineageKindAggregate, Sources: []mongosql.SourceField{source("b")}},
+	}
+	if diff := cmp.Diff(expected, translation.Lineage()); diff != "" {
+		t.Fatalf("unexpected lineage (-want +got):\n%s", diff)
+	}
+}
@@ -2,10 +2,10 @@ package mongosql
 
 import (
 	"encoding/base64"
-	"errors"
 	"fmt"
+	"sync"
 
-	"go.mongodb.org/mongo-driver/bson"
+	"github.com/mongodb/mongosql/go/mongosql/internal/translation"
 	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
 )
 
@@ -13,7 +13,21 @@ import (
 // library. The consumer of this library should ensure that the
 // version of the go library matches that of the c library.
 func Version() string {
-	return version()
+	return libraryVersion()
+}
+
+var (
+	versionOnce   sync.Once
+	cachedVersion string
+)
+
+// libraryVersion returns the version of the c library, which is only
+// read once, since it cannot change while the process runs.
+func libraryVersion() string {
+	versionOnce.Do(func() {
+		cachedVersion = version()
+	})
+	return cachedVersion
 }
 
 // TranslationArgs contains the arguments to the translation engine.
@@ -31,60 +45,36 @@ type TranslationArgs struct {
 	relaxSchemaChecking bool
 	// ExcludeNamespaces when set to true will return a non-namespaced result set
 	ExcludeNamespaces bool
+	// Optimizer controls which query optimizer passes run
+	Optimizer OptimizerOptions
+	// Options holds the remaining translation options
+	Options TranslationOptions
 }
 
 // Translation represents the result of translating a sql query to
 // MQL. The fields of this struct can be used to construct an
 // aggregate command equivalent to a SQL query.
-type Translation struct {
-	// TargetDB is the MongoDB database against which the aggregate
-	// command should run
-	TargetDB string
-	// TargetCollection is the collection to be specified in the
-	// aggregate command, or nil if an "aggregate: 1" style aggregate
-	// command should be used
-	TargetCollection string
-	// Pipeline is the array representing the aggregation pipeline
-	// serialized to BSON
-	Pipeline []byte
-	// ResultSetSchema is a JSON Schema document that describes
-	// the documents returned by this Translation
-	ResultSetSchema bsoncore.Document
-	// SelectOrder is an Array of Arrays, with each sub array
-	// describing a singular field in the result set
-	SelectOrder bsoncore.Array
-}
+type Translation = translation.Translation
 
 // TranslationError is an error type that includes additional
 // information about whether an error is "internal" or "external"
 // (i.e. whether it is safe and useful to expose to end users).
-type TranslationError struct {
-	internal bool
-	err      error
-}
+type TranslationError = translation.TranslationError
+
+// Position is the location of an error in a sql query, as returned by
+// TranslationError.Position.
+type Position = translation.Position
 
 // NewInternalError creates a TranslationError from the provided error
 // that should not be exposed to end users.
 func NewInternalError(err error) TranslationError {
-	return TranslationError{internal: true, err: err}
+	return translation.NewInternalError(err)
 }
 
 // NewExternalError creates a TranslationError from the provided error
 // that is okay/useful to expose to end users.
 func NewExternalError(err error) TranslationError {
-	return TranslationError{internal: false, err: err}
-}
-
-// Error implements the error interface by returning the string
-// representation of the underlying error.
-func (e TranslationError) Error() string {
-	return e.err.Error()
-}
-
-// IsInternal returns true if this is an "internal" error that should
-// not be exposed to end users, and false otherwise.
-func (e TranslationError) IsInternal() bool {
-	return e.internal
+	return translation.NewExternalError(err)
 }
 
 // Translate accepts TranslationArgs, returning a Translation and an
@@ -96,61 +86,22 @@ func Translate(args TranslationArgs) (Translation, error) {
 		return Translation{}, err
 	}
 
-	translationResult := struct {
-		DB              string            `bson:"target_db"`
-		Collection      string            `bson:"target_collection"`
-		Pipeline        []bson.D          `bson:"pipeline"`
-		Error           string            `bson:"error"`
-		ErrorIsInternal bool              `bson:"error_is_internal"`
-		ResultSetSchema bsoncore.Document `bson:"result_set_schema"`
-		Namespaces      []Namespace       `bson:"namespaces"`
-		SelectOrder     bsoncore.Array    `bson:"select_order"`
-	}{}
-
 	translationBytes, err := base64.StdEncoding.DecodeString(base64TranslationResult)
 	if err != nil {
 		return Translation{}, NewInternalError(fmt.Errorf("failed to decode base64 translation result: %w", err))
 	}
 
-	err = bson.Unmarshal(translationBytes, &translationResult)
+	result, err := translation.DecodeTranslation(translationBytes)
 	if err != nil {
-		return Translation{}, NewInternalError(fmt.Errorf("failed to unmarshal translation result BSON into struct: %w", err))
-	}
-
-	if translationResult.Error != "" {
-		if translationResult.ErrorIsInternal {
-			err = NewInternalError(errors.New(translationResult.Error))
-		} else {
-			err = NewExternalError(errors.New(translationResult.Error))
-		}
 		return Translation{}, err
 	}
+	result.Version = libraryVersion()
 
-	typ, pipelineBytes, err := bson.MarshalValue(translationResult.Pipeline)
-	if err != nil {
-		return Translation{}, NewInternalError(fmt.Errorf("failed to marshal pipeline to bytes: %w", err))
-	}
-	if typ.String() != "array" {
-		// this should never occur, but is here as a sanity check
-		panic("didn't marshal to array")
-	}
-
-	return Translation{
-		TargetDB:         translationResult.DB,
-		TargetCollection: translationResult.Collection,
-		Pipeline:         pipelineBytes,
-		ResultSetSchema:  translationResult.ResultSetSchema,
-		SelectOrder:      translationResult.SelectOrder,
-	}, nil
+	return result, nil
 }
 
 // Namespace represents a MongoDB collection namespace.
-type Namespace struct {
-	// Database is the database component of the namespace
-	Database string `bson:"database"`
-	// Collection is the collection component of the namespace
-	Collection string `bson:"collection"`
-}
+type Namespace = translation.Namespace
 
 // GetNamespaces returns the Namespaces referenced in the provided
 // sqlStatement. Unqualified collections in the statement are assumed
@@ -158,30 +109,10 @@ type Namespace struct {
 func GetNamespaces(dbName, sqlStatement string) ([]Namespace, error) {
 	base64Result := callGetNamespaces(dbName, sqlStatement)
 
-	result := struct {
-		Namespaces      []Namespace `bson:"namespaces"`
-		Error           string      `bson:"error"`
-		ErrorIsInternal bool        `bson:"error_is_internal"`
-	}{}
-
 	resultBytes, err := base64.StdEncoding.DecodeString(base64Result)
 	if err != nil {
 		return nil, NewInternalError(fmt.Errorf("failed to decode base64 translation result: %w", err))
 	}
 
-	err = bson.Unmarshal(resultBytes, &result)
-	if err != nil {
-		return nil, NewInternalError(fmt.Errorf("failed to unmarshal translation result BSON into struct: %w", err))
-	}
-
-	if result.Error != "" {
-		if result.ErrorIsInternal {
-			err = NewInternalError(errors.New(result.Error))
-		} else {
-			err = NewExternalError(errors.New(result.Error))
-		}
-		return nil, err
-	}
-
-	return result.Namespaces, nil
+	return translation.DecodeNamespaces(resultBytes)
 }
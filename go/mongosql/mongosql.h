@@ -1,4 +1,12 @@
-char* translate(char *current_db, char *sql, char *catalog, int relax_schema_checking, int exclude_namespaces);
+char* translate(char *current_db, char *sql, char *catalog, int relax_schema_checking, int exclude_namespaces, char *options);
 char* version();
 char *get_namespaces(char *current_db, char *sql);
 void delete_string(char *str);
+char *run_command(char *command);
+char *explain(char *current_db, char *sql, char *catalog, int relax_schema_checking, int exclude_namespaces, char *options);
+char *format_sql(char *sql, char *options);
+char *parse_sql(char *sql);
+char *render_query(char *query);
+char *fingerprint_sql(char *sql);
+char *redact_sql(char *sql);
+char *validate(char *current_db, char *sql, char *catalog, int relax_schema_checking, int exclude_namespaces, char *options);
@@ -7,13 +7,7 @@ package mongosql
 */
 import "C"
 
-import (
-	"encoding/base64"
-	"fmt"
-	"unsafe"
-
-	"go.mongodb.org/mongo-driver/bson"
-)
+import "unsafe"
 
 // version returns the version of the underlying c translation
 // library. The consumer of this library should ensure that the
@@ -30,37 +24,37 @@ func version() string {
 // and returns the string returned by the c library (a base64-encoded
 // bson document representing the result of the translation).
 func callTranslate(args TranslationArgs) (string, error) {
-	cSQL := C.CString(args.SQL)
-	defer C.free(unsafe.Pointer(cSQL))
-
-	cDB := C.CString(args.DB)
-	defer C.free(unsafe.Pointer(cDB))
+	return callWithArgs(args, func(db, sql, catalogSchema *C.char, relaxSchemaChecking, excludeNamespaces C.int, options *C.char) *C.char {
+		return C.translate(db, sql, catalogSchema, relaxSchemaChecking, excludeNamespaces, options)
+	})
+}
 
-	// Convert the catalog schema into a base64-encoded bson document
-	catalogSchemaBson, err := bson.Marshal(args.CatalogSchema)
+// callWithArgs passes the provided TranslationArgs to call, which must
+// make one of the FFI calls that take them, and returns the string
+// returned by the c library.
+func callWithArgs(args TranslationArgs, call func(db, sql, catalogSchema *C.char, relaxSchemaChecking, excludeNamespaces C.int, options *C.char) *C.char) (string, error) {
+	encoded, err := args.encode()
 	if err != nil {
-		return "", NewInternalError(fmt.Errorf("failed to marshal catalog schema to BSON: %w", err))
+		return "", err
 	}
 
-	cCatalogSchema := C.CString(base64.StdEncoding.EncodeToString(catalogSchemaBson))
-	defer C.free(unsafe.Pointer(cCatalogSchema))
+	cSQL := C.CString(encoded.sql)
+	defer C.free(unsafe.Pointer(cSQL))
 
-	cRelaxSchemaChecking := C.int(0)
-	if args.relaxSchemaChecking {
-		cRelaxSchemaChecking = C.int(1)
-	}
+	cDB := C.CString(encoded.db)
+	defer C.free(unsafe.Pointer(cDB))
 
-	cExcludeNamespaces := C.int(0)
-	if args.ExcludeNamespaces {
-		cExcludeNamespaces = C.int(1)
-	}
+	cCatalogSchema := C.CString(encoded.catalogSchema)
+	defer C.free(unsafe.Pointer(cCatalogSchema))
 
-	cTranslationBase64 := C.translate(cDB, cSQL, cCatalogSchema, cRelaxSchemaChecking, cExcludeNamespaces)
-	defer C.delete_string(cTranslationBase64)
+	cOptions := C.CString(encoded.options)
+	defer C.free(unsafe.Pointer(cOptions))
 
-	translationBase64 := C.GoString(cTranslationBase64)
+	cResultBase64 := call(cDB, cSQL, cCatalogSchema, C.int(encoded.relaxSchemaChecking), C.int(encoded.excludeNamespaces), cOptions)
+	defer C.delete_string(cResultBase64)
 
-	return translationBase64, nil
+	resultBase64 := C.GoString(cResultBase64)
+	return resultBase64, nil
 }
 
 // callGetNamespaces is a thin wrapper around the translate FFI call. It
@@ -80,3 +74,118 @@ func callGetNamespaces(currentDB, sql string) string {
 	resultBase64 := C.GoString(cResultBase64)
 	return resultBase64
 }
+
+// callRunCommand is a thin wrapper around the run_command FFI call. It
+// passes the provided base64-encoded bson command to the c translation
+// library, and returns the string returned by the c library (a
+// base64-encoded bson document representing the result of the command).
+func callRunCommand(commandBase64 string) string {
+	cCommand := C.CString(commandBase64)
+	defer C.free(unsafe.Pointer(cCommand))
+
+	cResultBase64 := C.run_command(cCommand)
+	defer C.delete_string(cResultBase64)
+
+	resultBase64 := C.GoString(cResultBase64)
+	return resultBase64
+}
+
+// callExplain is a thin wrapper around the explain FFI call. It passes
+// the provided TranslationArgs to the c translation library, and
+// returns the string returned by the c library (a base64-encoded bson
+// document representing the result of the explain call).
+func callExplain(args TranslationArgs) (string, error) {
+	return callWithArgs(args, func(db, sql, catalogSchema *C.char, relaxSchemaChecking, excludeNamespaces C.int, options *C.char) *C.char {
+		return C.explain(db, sql, catalogSchema, relaxSchemaChecking, excludeNamespaces, options)
+	})
+}
+
+// callFormat is a thin wrapper around the format_sql FFI call. It
+// passes the provided query and base64-encoded bson format options to
+// the c translation library, and returns the string returned by the c
+// library (a base64-encoded bson document representing the result of
+// the format call).
+func callFormat(sql, optionsBase64 string) string {
+	cSQL := C.CString(sql)
+	defer C.free(unsafe.Pointer(cSQL))
+
+	cOptions := C.CString(optionsBase64)
+	defer C.free(unsafe.Pointer(cOptions))
+
+	cResultBase64 := C.format_sql(cSQL, cOptions)
+	defer C.delete_string(cResultBase64)
+
+	resultBase64 := C.GoString(cResultBase64)
+	return resultBase64
+}
+
+// callParse is a thin wrapper around the parse_sql FFI call. It passes
+// the provided query to the c translation library, and returns the
+// string returned by the c library (a base64-encoded bson document
+// representing the result of the parse call).
+func callParse(sql string) string {
+	cSQL := C.CString(sql)
+	defer C.free(unsafe.Pointer(cSQL))
+
+	cResultBase64 := C.parse_sql(cSQL)
+	defer C.delete_string(cResultBase64)
+
+	resultBase64 := C.GoString(cResultBase64)
+	return resultBase64
+}
+
+// callRender is a thin wrapper around the render_query FFI call. It
+// passes the provided base64-encoded bson syntax tree to the c
+// translation library, and returns the string returned by the c
+// library (a base64-encoded bson document representing the result of
+// the render call).
+func callRender(queryBase64 string) string {
+	cQuery := C.CString(queryBase64)
+	defer C.free(unsafe.Pointer(cQuery))
+
+	cResultBase64 := C.render_query(cQuery)
+	defer C.delete_string(cResultBase64)
+
+	resultBase64 := C.GoString(cResultBase64)
+	return resultBase64
+}
+
+// callFingerprint is a thin wrapper around the fingerprint_sql FFI call.
+// It passes the provided query to the c translation library, and returns
+// the string returned by the c library (a base64-encoded bson document
+// representing the result of the fingerprint call).
+func callFingerprint(sql string) string {
+	cSQL := C.CString(sql)
+	defer C.free(unsafe.Pointer(cSQL))
+
+	cResultBase64 := C.fingerprint_sql(cSQL)
+	defer C.delete_string(cResultBase64)
+
+	resultBase64 := C.GoString(cResultBase64)
+	return resultBase64
+}
+
+// callRedact is a thin wrapper around the redact_sql FFI call. It
+// passes the provided query to the c translation library, and returns
+// the string returned by the c library (a base64-encoded bson document
+// representing the result of the redact call).
+func callRedact(sql string) string {
+	cSQL := C.CString(sql)
+	defer C.free(unsafe.Pointer(cSQL))
+
+	cResultBase64 := C.redact_sql(cSQL)
+	defer C.delete_string(cResultBase64)
+
+	resultBase64 := C.GoString(cResultBase64)
+	return resultBase64
+}
+
+// callValidate is a thin wrapper around the validate FFI call. It passes
+// the provided TranslationArgs to the c translation library, and
+// returns the string returned by the c library (a base64-encoded bson
+// document representing the result of the validate call).
+func callValidate(args TranslationArgs) (string, error) {
+	return callWithArgs(args, func(db, sql, catalogSchema *C.char, relaxSchemaChecking, excludeNamespaces C.int, options *C.char) *C.char {
+		return C.validate(db, sql, catalogSchema, relaxSchemaChecking, excludeNamespaces, options)
+	})
+}
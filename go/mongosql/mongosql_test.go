@@ -124,6 +124,55 @@ func TestTranslateError(t *testing.T) {
 	}
 }
 
+func TestTranslateErrorHasCodeAndPosition(t *testing.T) {
+	_, err := mongosql.Translate(mongosql.TranslationArgs{
+		DB:            "bar",
+		SQL:           "select a,\nfrom foo",
+		CatalogSchema: nil,
+	})
+
+	tErr, ok := err.(mongosql.TranslationError)
+	if !ok {
+		t.Fatalf("expected error to be a TranslationError, but it wasn't")
+	}
+
+	if tErr.Code() != 2001 {
+		t.Fatalf("expected error code 2001, got %d", tErr.Code())
+	}
+
+	position, ok := tErr.Position()
+	if !ok {
+		t.Fatalf("expected error to have a position, but it didn't")
+	}
+	expected := mongosql.Position{Offset: 10, Line: 2, Column: 1}
+	if position != expected {
+		t.Fatalf("expected position %+v, got %+v", expected, position)
+	}
+}
+
+func TestTranslateReturnsEveryError(t *testing.T) {
+	_, err := mongosql.Translate(mongosql.TranslationArgs{
+		DB:            "bar",
+		SQL:           "select c, a, d from foo where e = 1",
+		CatalogSchema: validateCatalogSchema(t),
+	})
+
+	tErr, ok := err.(mongosql.TranslationError)
+	if !ok {
+		t.Fatalf("expected error to be a TranslationError, but it wasn't")
+	}
+
+	errs := tErr.Errors()
+	if len(errs) != 3 {
+		t.Fatalf("expected 3 errors, got %v", errs)
+	}
+	for _, e := range errs {
+		if e.Code() != 3008 {
+			t.Fatalf("expected error code 3008, got %d for error '%s'", e.Code(), e)
+		}
+	}
+}
+
 func TestTranslatePanic(t *testing.T) {
 	_, err := mongosql.Translate(mongosql.TranslationArgs{
 		DB:            "__test_panic",
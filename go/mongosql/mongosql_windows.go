@@ -1,18 +1,22 @@
 package mongosql
 
 import (
-	"encoding/base64"
-	"fmt"
 	"syscall"
 	"unsafe"
-
-	"go.mongodb.org/mongo-driver/bson"
 )
 
 var versionProc *syscall.LazyProc
 var deleteStringProc *syscall.LazyProc
 var translateProc *syscall.LazyProc
 var getNamespacesProc *syscall.LazyProc
+var runCommandProc *syscall.LazyProc
+var explainProc *syscall.LazyProc
+var formatProc *syscall.LazyProc
+var parseProc *syscall.LazyProc
+var renderProc *syscall.LazyProc
+var fingerprintProc *syscall.LazyProc
+var redactProc *syscall.LazyProc
+var validateProc *syscall.LazyProc
 
 func init() {
 	dll := syscall.NewLazyDLL("mongosql.dll")
@@ -20,6 +24,14 @@ func init() {
 	deleteStringProc = dll.NewProc("delete_string")
 	translateProc = dll.NewProc("translate")
 	getNamespacesProc = dll.NewProc("get_namespaces")
+	runCommandProc = dll.NewProc("run_command")
+	explainProc = dll.NewProc("explain")
+	formatProc = dll.NewProc("format_sql")
+	parseProc = dll.NewProc("parse_sql")
+	renderProc = dll.NewProc("render_query")
+	fingerprintProc = dll.NewProc("fingerprint_sql")
+	redactProc = dll.NewProc("redact_sql")
+	validateProc = dll.NewProc("validate")
 }
 
 // uintptrToString converts a uintptr return value from
@@ -71,31 +83,28 @@ func version() string {
 // and returns the string returned by the c library (a base64-encoded
 // bson document representing the result of the translation).
 func callTranslate(args TranslationArgs) (string, error) {
+	return callWithArgs(translateProc, args)
+}
 
-	// Convert the catalog schema into a base64-encoded bson document
-	catalogSchemaBson, err := bson.Marshal(args.CatalogSchema)
+// callWithArgs calls proc, which must be one of the FFI calls that
+// take TranslationArgs, with the provided TranslationArgs, and returns
+// the string returned by the c library.
+func callWithArgs(proc *syscall.LazyProc, args TranslationArgs) (string, error) {
+	encoded, err := args.encode()
 	if err != nil {
-		return "", NewInternalError(fmt.Errorf("failed to marshal catalog schema to BSON: %w", err))
-	}
-	catalogSchemaBase64 := base64.StdEncoding.EncodeToString(catalogSchemaBson)
-	dbArg, sqlArg, catalogArg := stringToUnsafePointer(args.DB), stringToUnsafePointer(args.SQL), stringToUnsafePointer(catalogSchemaBase64)
-	relaxSchemaCheckingArg := 0
-	if args.relaxSchemaChecking {
-		relaxSchemaCheckingArg = 1
+		return "", err
 	}
 
-	excludeNamespacesArg := 0
-	if args.ExcludeNamespaces {
-		excludeNamespacesArg = 1
-	}
+	dbArg, sqlArg, catalogArg := stringToUnsafePointer(encoded.db), stringToUnsafePointer(encoded.sql), stringToUnsafePointer(encoded.catalogSchema)
+	optionsArg := stringToUnsafePointer(encoded.options)
 
-	ret1, _, _ := translateProc.Call(uintptr(dbArg), uintptr(sqlArg), uintptr(catalogArg), uintptr(relaxSchemaCheckingArg), uintptr(excludeNamespacesArg))
-	translationBase64 := uintptrToString(ret1)
+	ret1, _, _ := proc.Call(uintptr(dbArg), uintptr(sqlArg), uintptr(catalogArg), uintptr(encoded.relaxSchemaChecking), uintptr(encoded.excludeNamespaces), uintptr(optionsArg))
+	resultBase64 := uintptrToString(ret1)
 
 	// delete the returned uintptr
 	deleteStringProc.Call(ret1)
 
-	return translationBase64, nil
+	return resultBase64, nil
 }
 
 // callGetNamespaces is a thin wrapper around the translate FFI call. It
@@ -113,3 +122,118 @@ func callGetNamespaces(currentDB, sql string) string {
 
 	return resultBase64
 }
+
+// callRunCommand is a thin wrapper around the run_command FFI call. It
+// passes the provided base64-encoded bson command to the c translation
+// library, and returns the string returned by the c library (a
+// base64-encoded bson document representing the result of the command).
+func callRunCommand(commandBase64 string) string {
+	commandArg := stringToUnsafePointer(commandBase64)
+
+	ret1, _, _ := runCommandProc.Call(uintptr(commandArg))
+	resultBase64 := uintptrToString(ret1)
+
+	// delete the returned uintptr
+	deleteStringProc.Call(ret1)
+
+	return resultBase64
+}
+
+// callExplain is a thin wrapper around the explain FFI call. It passes
+// the provided TranslationArgs to the c translation library, and
+// returns the string returned by the c library (a base64-encoded bson
+// document representing the result of the explain call).
+func callExplain(args TranslationArgs) (string, error) {
+	return callWithArgs(explainProc, args)
+}
+
+// callFormat is a thin wrapper around the format_sql FFI call. It
+// passes the provided query and base64-encoded bson format options to
+// the c translation library, and returns the string returned by the c
+// library (a base64-encoded bson document representing the result of
+// the format call).
+func callFormat(sql, optionsBase64 string) string {
+	sqlArg := stringToUnsafePointer(sql)
+	optionsArg := stringToUnsafePointer(optionsBase64)
+
+	ret1, _, _ := formatProc.Call(uintptr(sqlArg), uintptr(optionsArg))
+	resultBase64 := uintptrToString(ret1)
+
+	// delete the returned uintptr
+	deleteStringProc.Call(ret1)
+
+	return resultBase64
+}
+
+// callParse is a thin wrapper around the parse_sql FFI call. It passes
+// the provided query to the c translation library, and returns the
+// string returned by the c library (a base64-encoded bson document
+// representing the result of the parse call).
+func callParse(sql string) string {
+	sqlArg := stringToUnsafePointer(sql)
+
+	ret1, _, _ := parseProc.Call(uintptr(sqlArg))
+	resultBase64 := uintptrToString(ret1)
+
+	// delete the returned uintptr
+	deleteStringProc.Call(ret1)
+
+	return resultBase64
+}
+
+// callRender is a thin wrapper around the render_query FFI call. It
+// passes the provided base64-encoded bson syntax tree to the c
+// translation library, and returns the string returned by the c
+// library (a base64-encoded bson document representing the result of
+// the render call).
+func callRender(queryBase64 string) string {
+	queryArg := stringToUnsafePointer(queryBase64)
+
+	ret1, _, _ := renderProc.Call(uintptr(queryArg))
+	resultBase64 := uintptrToString(ret1)
+
+	// delete the returned uintptr
+	deleteStringProc.Call(ret1)
+
+	return resultBase64
+}
+
+// callFingerprint is a thin wrapper around the fingerprint_sql FFI call.
+// It passes the provided query to the c translation library, and returns
+// the string returned by the c library (a base64-encoded bson document
+// representing the result of the fingerprint call).
+func callFingerprint(sql string) string {
+	sqlArg := stringToUnsafePointer(sql)
+
+	ret1, _, _ := fingerprintProc.Call(uintptr(sqlArg))
+	resultBase64 := uintptrToString(ret1)
+
+	// delete the returned uintptr
+	deleteStringProc.Call(ret1)
+
+	return resultBase64
+}
+
+// callRedact is a thin wrapper around the redact_sql FFI call. It
+// passes the provided query to the c translation library, and returns
+// the string returned by the c library (a base64-encoded bson document
+// representing the result of the redact call).
+func callRedact(sql string) string {
+	sqlArg := stringToUnsafePointer(sql)
+
+	ret1, _, _ := redactProc.Call(uintptr(sqlArg))
+	resultBase64 := uintptrToString(ret1)
+
+	// delete the returned uintptr
+	deleteStringProc.Call(ret1)
+
+	return resultBase64
+}
+
+// callValidate is a thin wrapper around the validate FFI call. It passes
+// the provided TranslationArgs to the c translation library, and
+// returns the string returned by the c library (a base64-encoded bson
+// document representing the result of the validate call).
+func callValidate(args TranslationArgs) (string, error) {
+	return callWithArgs(validateProc, args)
+}
@@ -0,0 +1,32 @@
+package mongosql
+
+import (
+	"github.com/mongodb/mongosql/go/mongosql/ast"
+)
+
+// NamespaceUse is a reference to a namespace in a FROM clause of a sql
+// statement, with its alias, position and role. See ast.NamespaceUse.
+type NamespaceUse = ast.NamespaceUse
+
+// NamespaceRole is the role of a collection in its FROM clause.
+type NamespaceRole = ast.NamespaceRole
+
+// NestingKind is a construct in which a query can be nested.
+type NestingKind = ast.NestingKind
+
+// GetNamespaceUses returns a NamespaceUse for each reference to a
+// namespace in the FROM clauses of the provided sqlStatement, in the
+// order they appear in the statement. Unlike GetNamespaces, a
+// namespace that is read several times is returned once per read, with
+// the alias it is read by, its position in the statement, its role in
+// its FROM clause, and the subqueries, derived tables, WITH queries and
+// set operation operands it is nested in. Unqualified collections in
+// the statement are assumed to be in the provided database. The
+// returned error, if any, is a TranslationError.
+func GetNamespaceUses(dbName, sqlStatement string) ([]NamespaceUse, error) {
+	query, err := Parse(sqlStatement)
+	if err != nil {
+		return nil, err
+	}
+	return ast.NamespaceUses(query, dbName), nil
+}
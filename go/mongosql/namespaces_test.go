@@ -0,0 +1,56 @@
+package mongosql_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mongodb/mongosql/go/mongosql"
+	"github.com/mongodb/mongosql/go/mongosql/ast"
+)
+
+func TestGetNamespaceUses(t *testing.T) {
+	uses, err := mongosql.GetNamespaceUses("test", `select * from foo as f join db2.bar on f.a = bar.b
+where exists(select * from foo)`)
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	expected := []mongosql.NamespaceUse{
+		{
+			Database:   "test",
+			Collection: "foo",
+			Alias:      "f",
+			Position:   &mongosql.Position{Offset: 14, Line: 1, Column: 15},
+			Role:       ast.NamespaceRoleJoinLeft,
+		},
+		{
+			Database:   "db2",
+			Collection: "bar",
+			Alias:      "bar",
+			Position:   &mongosql.Position{Offset: 28, Line: 1, Column: 29},
+			Role:       ast.NamespaceRoleJoinRight,
+		},
+		{
+			Database:   "test",
+			Collection: "foo",
+			Alias:      "foo",
+			Position:   &mongosql.Position{Offset: 78, Line: 2, Column: 28},
+			Role:       ast.NamespaceRoleFrom,
+			Nesting:    []mongosql.NestingKind{ast.NestingSubquery},
+		},
+	}
+	if diff := cmp.Diff(expected, uses); diff != "" {
+		t.Fatalf("unexpected namespace uses (-want +got):\n%s", diff)
+	}
+}
+
+func TestGetNamespaceUsesError(t *testing.T) {
+	_, err := mongosql.GetNamespaceUses("test", "select from where")
+	if err == nil {
+		t.Fatalf("expected error to be non-nil, but it was nil")
+	}
+
+	if _, ok := err.(mongosql.TranslationError); !ok {
+		t.Fatalf("expected error to be a TranslationError, but it wasn't")
+	}
+}
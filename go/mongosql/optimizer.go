@@ -0,0 +1,41 @@
+package mongosql
+
+import (
+	"github.com/mongodb/mongosql/go/mongosql/internal/translation"
+)
+
+// The names of the passes run by the query optimizer, in the order in
+// which they are applied. They can be used in
+// OptimizerOptions.DisabledPasses and appear in
+// Translation.OptimizerPasses.
+const (
+	OptimizerPassFlattenVariadics        = "flatten_variadics"
+	OptimizerPassConstantFolding         = "constant_folding"
+	OptimizerPassMatchSplitting          = "match_splitting"
+	OptimizerPassRewriteToMatchLanguage  = "rewrite_to_match_language"
+	OptimizerPassMatchNullFiltering      = "match_null_filtering"
+	OptimizerPassStageMovement           = "stage_movement"
+	OptimizerPassDetermineJoinSemantics  = "determine_join_semantics"
+	OptimizerPassLowerJoins              = "lower_joins"
+	OptimizerPassPrefilterUnwinds        = "prefilter_unwinds"
+	OptimizerPassDeadCodeElimination     = "dead_code_elimination"
+	OptimizerPassMergeNeighboringMatches = "merge_neighboring_matches"
+)
+
+// OptimizerOptions controls which passes the query optimizer runs.
+// The zero value runs every pass. Disabling passes is intended as a
+// workaround for optimizer regressions; the resulting pipelines are
+// correct but may be slower.
+type OptimizerOptions struct {
+	// DisableOptimization when set to true prevents every optimizer
+	// pass from running
+	DisableOptimization bool `bson:"disable_optimization"`
+	// DisabledPasses lists the names of the optimizer passes that
+	// should not run. Translation fails if a name is not one of the
+	// OptimizerPass constants.
+	DisabledPasses []string `bson:"disabled_passes"`
+}
+
+// OptimizerPassReport describes how an optimizer pass affected the
+// plan during a translation.
+type OptimizerPassReport = translation.OptimizerPassReport
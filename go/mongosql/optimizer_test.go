@@ -0,0 +1,83 @@
+package mongosql_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mongodb/mongosql/go/mongosql"
+)
+
+func TestOptimizerPassesAreReported(t *testing.T) {
+	translation, err := mongosql.Translate(mongosql.TranslationArgs{
+		DB:  "test",
+		SQL: "select * from foo where 1 + 2 > 0",
+	})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	if len(translation.OptimizerPasses) == 0 {
+		t.Fatalf("expected optimizer passes to be reported, but none were")
+	}
+
+	var constantFolding *mongosql.OptimizerPassReport
+	for i := range translation.OptimizerPasses {
+		if translation.OptimizerPasses[i].Name == mongosql.OptimizerPassConstantFolding {
+			constantFolding = &translation.OptimizerPasses[i]
+		}
+	}
+	if constantFolding == nil || !constantFolding.Changed || constantFolding.Runs < 1 {
+		t.Fatalf("expected constant folding to run and change the plan, got %+v", translation.OptimizerPasses)
+	}
+}
+
+func TestOptimizerPassCanBeDisabled(t *testing.T) {
+	translation, err := mongosql.Translate(mongosql.TranslationArgs{
+		DB:  "test",
+		SQL: "select * from foo where 1 + 2 > 0",
+		Optimizer: mongosql.OptimizerOptions{
+			DisabledPasses: []string{mongosql.OptimizerPassConstantFolding},
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	for _, pass := range translation.OptimizerPasses {
+		if pass.Name == mongosql.OptimizerPassConstantFolding {
+			t.Fatalf("expected constant folding not to run, but it did")
+		}
+	}
+}
+
+func TestOptimizationCanBeDisabled(t *testing.T) {
+	translation, err := mongosql.Translate(mongosql.TranslationArgs{
+		DB:        "test",
+		SQL:       "select * from foo where 1 + 2 > 0",
+		Optimizer: mongosql.OptimizerOptions{DisableOptimization: true},
+	})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	if len(translation.OptimizerPasses) != 0 {
+		t.Fatalf("expected no optimizer passes to run, got %+v", translation.OptimizerPasses)
+	}
+}
+
+func TestUnknownOptimizerPass(t *testing.T) {
+	_, err := mongosql.Translate(mongosql.TranslationArgs{
+		DB:  "test",
+		SQL: "select * from foo",
+		Optimizer: mongosql.OptimizerOptions{
+			DisabledPasses: []string{"not_a_pass"},
+		},
+	})
+	if err == nil {
+		t.Fatalf("expected error to be non-nil, but it was nil")
+	}
+
+	if !strings.Contains(err.Error(), "unknown optimizer pass 'not_a_pass'") {
+		t.Fatalf("error message did not contain expected text: %q", err.Error())
+	}
+}
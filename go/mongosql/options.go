@@ -0,0 +1,106 @@
+package mongosql
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// TranslationOptions holds translation options that are not covered
+// by the other TranslationArgs fields. The zero value keeps the
+// translation library's defaults.
+//
+// The options are passed to the c translation library as a document,
+// and only options that differ from their defaults are included in
+// it. The library rejects options it does not recognize, so using an
+// option that is newer than the library fails the translation instead
+// of being silently ignored.
+type TranslationOptions struct {
+	// StrictOrderBy when set to true requires every column referenced
+	// in an ORDER BY clause to also appear in the SELECT clause. By
+	// default, ORDER BY may reference columns that are not selected.
+	StrictOrderBy bool
+	// IncludeLineage when set to true computes the lineage of the
+	// result set columns, as returned by Translation.Lineage. Lineage
+	// is not computed by default.
+	IncludeLineage bool
+}
+
+// optionsDocument is the options document expected by the c
+// translation library.
+type optionsDocument struct {
+	AllowOrderByMissingColumns *bool             `bson:"allow_order_by_missing_columns,omitempty"`
+	Optimizer                  *OptimizerOptions `bson:"optimizer,omitempty"`
+	IncludeLineage             bool              `bson:"include_lineage,omitempty"`
+}
+
+// optionsBase64 returns the base64-encoded BSON options document for
+// the TranslationArgs.
+func (args TranslationArgs) optionsBase64() (string, error) {
+	var doc optionsDocument
+
+	if args.Options.StrictOrderBy {
+		allow := false
+		doc.AllowOrderByMissingColumns = &allow
+	}
+
+	doc.IncludeLineage = args.Options.IncludeLineage
+
+	if args.Optimizer.DisableOptimization || len(args.Optimizer.DisabledPasses) > 0 {
+		optimizer := args.Optimizer
+		if optimizer.DisabledPasses == nil {
+			optimizer.DisabledPasses = []string{}
+		}
+		doc.Optimizer = &optimizer
+	}
+
+	optionsBson, err := bson.Marshal(doc)
+	if err != nil {
+		return "", NewInternalError(fmt.Errorf("failed to marshal translation options to BSON: %w", err))
+	}
+	return base64.StdEncoding.EncodeToString(optionsBson), nil
+}
+
+// encodedArgs holds TranslationArgs encoded as the arguments of the
+// translate, explain and validate calls of the c translation library.
+type encodedArgs struct {
+	db  string
+	sql string
+	// catalogSchema is the base64-encoded BSON catalog schema
+	catalogSchema string
+	// relaxSchemaChecking and excludeNamespaces are 1 when set and 0
+	// otherwise
+	relaxSchemaChecking int
+	excludeNamespaces   int
+	// options is the base64-encoded BSON options document
+	options string
+}
+
+// encode returns the TranslationArgs encoded as the arguments of the
+// translate, explain and validate calls of the c translation library.
+func (args TranslationArgs) encode() (encodedArgs, error) {
+	catalogSchemaBson, err := bson.Marshal(args.CatalogSchema)
+	if err != nil {
+		return encodedArgs{}, NewInternalError(fmt.Errorf("failed to marshal catalog schema to BSON: %w", err))
+	}
+
+	optionsBase64, err := args.optionsBase64()
+	if err != nil {
+		return encodedArgs{}, err
+	}
+
+	encoded := encodedArgs{
+		db:            args.DB,
+		sql:           args.SQL,
+		catalogSchema: base64.StdEncoding.EncodeToString(catalogSchemaBson),
+		options:       optionsBase64,
+	}
+	if args.relaxSchemaChecking {
+		encoded.relaxSchemaChecking = 1
+	}
+	if args.ExcludeNamespaces {
+		encoded.excludeNamespaces = 1
+	}
+	return encoded, nil
+}
@@ -0,0 +1,58 @@
+package mongosql_test
+
+import (
+	"testing"
+
+	"github.com/mongodb/mongosql/go/mongosql"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+func orderByArgs(t *testing.T, options mongosql.TranslationOptions) mongosql.TranslationArgs {
+	schema := bson.M{
+		"bsonType": "object",
+		"properties": bson.M{
+			"a": bson.M{"bsonType": "int"},
+			"b": bson.M{"bsonType": "int"},
+		},
+		"required":             bson.A{"a", "b"},
+		"additionalProperties": false,
+	}
+
+	bytes, err := bson.Marshal(&schema)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+
+	return mongosql.TranslationArgs{
+		DB:  "bar",
+		SQL: "select a from foo order by b",
+		CatalogSchema: map[string]map[string]bsoncore.Document{
+			"bar": {"foo": bsoncore.Document(bytes)},
+		},
+		Options: options,
+	}
+}
+
+func TestOrderByMissingColumnsAllowedByDefault(t *testing.T) {
+	_, err := mongosql.Translate(orderByArgs(t, mongosql.TranslationOptions{}))
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+}
+
+func TestStrictOrderBy(t *testing.T) {
+	_, err := mongosql.Translate(orderByArgs(t, mongosql.TranslationOptions{StrictOrderBy: true}))
+	if err == nil {
+		t.Fatalf("expected error to be non-nil, but it was nil")
+	}
+
+	tErr, ok := err.(mongosql.TranslationError)
+	if !ok {
+		t.Fatalf("expected error to be a TranslationError, but it wasn't")
+	}
+
+	if tErr.IsInternal() {
+		t.Fatalf("semantic translation errors should be external, but an internal error was found")
+	}
+}
@@ -0,0 +1,72 @@
+package mongosql
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/mongodb/mongosql/go/mongosql/ast"
+	"github.com/mongodb/mongosql/go/mongosql/internal/translation"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Parse parses the provided sql statement and returns its syntax tree.
+// The tree is the parser's output before any rewrites, so it reflects
+// the statement as written. Parse only checks syntax; a query that
+// parses may still fail to translate.
+func Parse(sqlStatement string) (*ast.Query, error) {
+	resultBytes, err := base64.StdEncoding.DecodeString(callParse(sqlStatement))
+	if err != nil {
+		return nil, NewInternalError(fmt.Errorf("failed to decode base64 parse result: %w", err))
+	}
+
+	if err := translation.DecodeError(resultBytes); err != nil {
+		return nil, err
+	}
+
+	parseResult := struct {
+		Query bson.Raw `bson:"query"`
+	}{}
+	if err := bson.Unmarshal(resultBytes, &parseResult); err != nil {
+		return nil, NewInternalError(fmt.Errorf("failed to unmarshal parse result BSON into struct: %w", err))
+	}
+
+	query := &ast.Query{}
+	if err := query.UnmarshalBSON(parseResult.Query); err != nil {
+		return nil, NewInternalError(fmt.Errorf("failed to decode syntax tree: %w", err))
+	}
+	return query, nil
+}
+
+// Render returns the sql text for a syntax tree, such as one returned
+// by Parse and modified with ast.Walk. The text is produced by the
+// translation library's pretty printer, so parsing it yields an
+// equivalent query. The returned error, if any, is a TranslationError,
+// and is returned for a tree that is not well formed.
+func Render(query *ast.Query) (string, error) {
+	queryBSON, err := query.MarshalBSON()
+	if err != nil {
+		return "", NewInternalError(fmt.Errorf("failed to encode syntax tree: %w", err))
+	}
+
+	argBytes, err := bson.Marshal(bson.D{{Key: "query", Value: bson.Raw(queryBSON)}})
+	if err != nil {
+		return "", NewInternalError(fmt.Errorf("failed to marshal query to BSON: %w", err))
+	}
+
+	resultBytes, err := base64.StdEncoding.DecodeString(callRender(base64.StdEncoding.EncodeToString(argBytes)))
+	if err != nil {
+		return "", NewInternalError(fmt.Errorf("failed to decode base64 render result: %w", err))
+	}
+
+	if err := translation.DecodeError(resultBytes); err != nil {
+		return "", err
+	}
+
+	renderResult := struct {
+		SQL string `bson:"sql"`
+	}{}
+	if err := bson.Unmarshal(resultBytes, &renderResult); err != nil {
+		return "", NewInternalError(fmt.Errorf("failed to unmarshal render result BSON into struct: %w", err))
+	}
+	return renderResult.SQL, nil
+}
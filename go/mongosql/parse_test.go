@@ -0,0 +1,71 @@
+package mongosql_test
+
+import (
+	"testing"
+
+	"github.com/mongodb/mongosql/go/mongosql"
+	"github.com/mongodb/mongosql/go/mongosql/ast"
+)
+
+func TestParse(t *testing.T) {
+	query, err := mongosql.Parse("select a from foo where exists(select * from bar)")
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	if query.Select == nil {
+		t.Fatalf("expected a SELECT query, got %+v", query)
+	}
+
+	subqueries := 0
+	ast.Inspect(query, func(node ast.Node) bool {
+		if e, ok := node.(*ast.Expression); ok && (e.Subquery != nil || e.Exists != nil) {
+			subqueries++
+		}
+		return true
+	})
+	if subqueries != 1 {
+		t.Fatalf("expected 1 subquery, found %d", subqueries)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	_, err := mongosql.Parse("select from where")
+	if err == nil {
+		t.Fatalf("expected error to be non-nil, but it was nil")
+	}
+
+	tErr, ok := err.(mongosql.TranslationError)
+	if !ok {
+		t.Fatalf("expected error to be a TranslationError, but it wasn't")
+	}
+
+	if tErr.IsInternal() {
+		t.Fatalf("parse errors should be external, but an internal error was found")
+	}
+}
+
+func TestRender(t *testing.T) {
+	query, err := mongosql.Parse("select a from foo")
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	db := "tenant1"
+	ast.Inspect(query, func(node ast.Node) bool {
+		if c, ok := node.(*ast.CollectionSource); ok {
+			c.Database = &db
+		}
+		return true
+	})
+
+	sql, err := mongosql.Render(query)
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	expected := "SELECT a FROM tenant1.foo"
+	if sql != expected {
+		t.Fatalf("expected rendered query to be %q, got %q", expected, sql)
+	}
+}
@@ -0,0 +1,35 @@
+package mongosql
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/mongodb/mongosql/go/mongosql/internal/translation"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Redact returns the provided sql statement with every string, numeric
+// and date literal replaced by ?, so that it can be logged without
+// exposing the data it contains. NULL, TRUE, FALSE and identifiers are
+// kept, and the statement is printed in a canonical layout. The result
+// is meant for logs and is not itself a valid statement. Use
+// Translation.RedactedPipeline to redact the corresponding pipeline.
+func Redact(sqlStatement string) (string, error) {
+	resultBytes, err := base64.StdEncoding.DecodeString(callRedact(sqlStatement))
+	if err != nil {
+		return "", NewInternalError(fmt.Errorf("failed to decode base64 redact result: %w", err))
+	}
+
+	if err := translation.DecodeError(resultBytes); err != nil {
+		return "", err
+	}
+
+	redactResult := struct {
+		Redacted string `bson:"redacted"`
+	}{}
+	if err := bson.Unmarshal(resultBytes, &redactResult); err != nil {
+		return "", NewInternalError(fmt.Errorf("failed to unmarshal redact result BSON into struct: %w", err))
+	}
+
+	return redactResult.Redacted, nil
+}
@@ -0,0 +1,78 @@
+package mongosql_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mongodb/mongosql/go/mongosql"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+func TestRedact(t *testing.T) {
+	redacted, err := mongosql.Redact("select a from foo where name = 'alice' and age > 30 and d = {ts '2020-01-01 00:00:00'} and b = true")
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	expected := "SELECT a FROM foo WHERE name = ? AND age > ? AND d = CAST(? AS BSON_DATE) AND b = true"
+	if redacted != expected {
+		t.Fatalf("expected redacted sql to be '%s', got '%s'", expected, redacted)
+	}
+}
+
+func TestRedactError(t *testing.T) {
+	_, err := mongosql.Redact("select from where")
+	if err == nil {
+		t.Fatalf("expected error to be non-nil, but it was nil")
+	}
+
+	tErr, ok := err.(mongosql.TranslationError)
+	if !ok {
+		t.Fatalf("expected error to be a TranslationError, but it wasn't")
+	}
+
+	if tErr.IsInternal() {
+		t.Fatalf("redact errors should be external, but an internal error was found")
+	}
+}
+
+func TestRedactedPipeline(t *testing.T) {
+	schema := bson.M{
+		"bsonType": "object",
+		"properties": bson.M{
+			"a": bson.M{"bsonType": "string"},
+			"b": bson.M{"bsonType": "int"},
+		},
+	}
+
+	bytes, err := bson.Marshal(&schema)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+
+	translation, err := mongosql.Translate(mongosql.TranslationArgs{
+		DB:  "bar",
+		SQL: "select * from foo where a = 'alice' or b = 4242",
+		CatalogSchema: map[string]map[string]bsoncore.Document{
+			"bar": {"foo": bsoncore.Document(bytes)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	redacted, err := translation.RedactedPipeline()
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	pipeline := bson.RawValue{Type: bsontype.Array, Value: redacted}.String()
+	if strings.Contains(pipeline, "alice") || strings.Contains(pipeline, "4242") {
+		t.Fatalf("expected constants to be redacted, got %s", pipeline)
+	}
+	if !strings.Contains(pipeline, `"?"`) {
+		t.Fatalf("expected redacted constants to be replaced by \"?\", got %s", pipeline)
+	}
+}
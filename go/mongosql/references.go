@@ -0,0 +1,29 @@
+package mongosql
+
+import (
+	"github.com/mongodb/mongosql/go/mongosql/ast"
+)
+
+// NamespaceReference describes the aliases and fields by which a sql
+// statement references a namespace. See ast.NamespaceReference.
+type NamespaceReference = ast.NamespaceReference
+
+// GetReferences returns, for each namespace referenced in the provided
+// sqlStatement, the aliases it is referenced by, the paths of the
+// fields the statement references, and whether the statement reads
+// whole documents, as with SELECT *. Unqualified collections in the
+// statement are assumed to be in the provided database.
+//
+// Like GetNamespaces, GetReferences only looks at the syntax of the
+// statement. Fields are attributed to namespaces without schemas, so
+// an unqualified field is attributed to every namespace read by its
+// query or by the queries it is nested in; see ast.References for the
+// details. The
+// returned error, if any, is a TranslationError.
+func GetReferences(dbName, sqlStatement string) ([]NamespaceReference, error) {
+	query, err := Parse(sqlStatement)
+	if err != nil {
+		return nil, err
+	}
+	return ast.References(query, dbName), nil
+}
@@ -0,0 +1,37 @@
+package mongosql_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/mongodb/mongosql/go/mongosql"
+)
+
+func TestGetReferences(t *testing.T) {
+	refs, err := mongosql.GetReferences("test", `select f.a, b.c.d, e
+		from foo as f join db2.bar as b on f.x = b.y
+		where exists(select * from baz where baz.z = f.w)`)
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	expected := []mongosql.NamespaceReference{
+		{Database: "db2", Collection: "bar", Aliases: []string{"b"}, Fields: []string{"c.d", "e", "y"}},
+		{Database: "test", Collection: "baz", Aliases: []string{"baz"}, Fields: []string{"z"}, WholeDocument: true},
+		{Database: "test", Collection: "foo", Aliases: []string{"f"}, Fields: []string{"a", "e", "w", "x"}},
+	}
+	if diff := cmp.Diff(expected, refs); diff != "" {
+		t.Fatalf("unexpected references (-want +got):\n%s", diff)
+	}
+}
+
+func TestGetReferencesError(t *testing.T) {
+	_, err := mongosql.GetReferences("test", "select from where")
+	if err == nil {
+		t.Fatalf("expected error to be non-nil, but it was nil")
+	}
+
+	if _, ok := err.(mongosql.TranslationError); !ok {
+		t.Fatalf("expected error to be a TranslationError, but it wasn't")
+	}
+}
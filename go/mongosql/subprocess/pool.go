@@ -0,0 +1,279 @@
+// Package subprocess runs translations in a pool of worker
+// subprocesses, so that a crash in the translation library (such as an
+// abort or a stack overflow) terminates a worker instead of the
+// calling process.
+//
+// Workers speak the mongosqltranslate BSON command protocol: each
+// command is written to the worker's stdin as a BSON document, and the
+// worker answers with a single BSON document on its stdout. The
+// mongosqltranslate-worker binary built from the mongosqltranslate
+// crate implements this protocol.
+package subprocess
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/mongodb/mongosql/go/mongosql/internal/command"
+	"github.com/mongodb/mongosql/go/mongosql/internal/translation"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// Translation represents the result of translating a sql query to
+// MQL. It is the same type as mongosql.Translation.
+type Translation = translation.Translation
+
+// Namespace represents a MongoDB collection namespace. It is the same
+// type as mongosql.Namespace.
+type Namespace = translation.Namespace
+
+// TranslationError is the error type returned by the Pool. It is the
+// same type as mongosql.TranslationError.
+type TranslationError = translation.TranslationError
+
+// ErrClosed is returned by calls made on a Pool after Close.
+var ErrClosed = errors.New("translation worker pool is closed")
+
+// TranslationArgs contains the arguments to the translation engine.
+type TranslationArgs struct {
+	// DB represents the current database in which the sql query was run
+	DB string
+	// SQL is a string containing the sql query
+	SQL string
+	// CatalogSchema maps namespaces to JSON Schemas that describe the
+	// shape of the documents in the namespace.
+	CatalogSchema map[string]map[string]bsoncore.Document
+	// ExcludeNamespaces when set to true will return a non-namespaced result set
+	ExcludeNamespaces bool
+}
+
+// Options configures a Pool.
+type Options struct {
+	// Path is the path of the worker executable
+	Path string
+	// Args are the arguments passed to the worker executable
+	Args []string
+	// Workers is the number of worker subprocesses. It defaults to
+	// runtime.NumCPU().
+	Workers int
+	// Timeout bounds the time a worker may spend on a single command.
+	// A worker that exceeds it is killed and replaced. Zero means that
+	// only the deadline of the caller's context applies.
+	Timeout time.Duration
+}
+
+// Pool is a fixed-size pool of translation worker subprocesses. Each
+// worker handles one command at a time, and a worker that crashes or
+// times out is replaced before its slot is reused. It is safe for
+// concurrent use.
+type Pool struct {
+	opts Options
+	// slots holds one entry per worker. A nil entry is a slot whose
+	// worker must be started before use.
+	slots   chan *worker
+	closed  chan struct{}
+	version string
+}
+
+// NewPool starts the worker subprocesses described by opts and returns
+// a Pool that dispatches commands to them.
+func NewPool(opts Options) (*Pool, error) {
+	if opts.Path == "" {
+		return nil, errors.New("a worker executable path is required")
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.NumCPU()
+	}
+
+	p := &Pool{
+		opts:   opts,
+		slots:  make(chan *worker, opts.Workers),
+		closed: make(chan struct{}),
+	}
+	for i := 0; i < opts.Workers; i++ {
+		w, err := startWorker(opts.Path, opts.Args)
+		if err != nil {
+			for ; i < opts.Workers; i++ {
+				p.slots <- nil
+			}
+			_ = p.Close()
+			return nil, err
+		}
+		p.slots <- w
+	}
+
+	result, err := p.run(context.Background(), command.Command{Command: command.GetMongosqlTranslateVersion})
+	if err != nil {
+		_ = p.Close()
+		return nil, err
+	}
+	versionResult := struct {
+		Version string `bson:"version"`
+	}{}
+	if err := bson.Unmarshal(result, &versionResult); err != nil {
+		_ = p.Close()
+		return nil, translation.NewInternalError(fmt.Errorf("failed to unmarshal version result BSON into struct: %w", err))
+	}
+	p.version = versionResult.Version
+
+	return p, nil
+}
+
+// Version returns the version of the translation library run by the
+// workers.
+func (p *Pool) Version() string {
+	return p.version
+}
+
+// Translate accepts TranslationArgs, returning a Translation and an
+// error if the translation failed. If the returned error is non-nil,
+// the returned Translation should be disregarded.
+func (p *Pool) Translate(ctx context.Context, args TranslationArgs) (Translation, error) {
+	catalogSchema := args.CatalogSchema
+	if catalogSchema == nil {
+		catalogSchema = map[string]map[string]bsoncore.Document{}
+	}
+
+	result, err := p.run(ctx, command.Command{
+		Command: command.Translate,
+		Options: command.CommandOptions{
+			SQL:                 args.SQL,
+			DB:                  args.DB,
+			SchemaCatalog:       catalogSchema,
+			ExcludeNamespaces:   command.Bool(args.ExcludeNamespaces),
+			RelaxSchemaChecking: command.Bool(false),
+		},
+	})
+	if err != nil {
+		return Translation{}, err
+	}
+
+	t, err := translation.DecodeTranslation(result)
+	if err != nil {
+		return Translation{}, err
+	}
+	t.Version = p.version
+
+	return t, nil
+}
+
+// GetNamespaces returns the Namespaces referenced in the provided
+// sqlStatement. Unqualified collections in the statement are assumed
+// to be in the provided database.
+func (p *Pool) GetNamespaces(ctx context.Context, dbName, sqlStatement string) ([]Namespace, error) {
+	result, err := p.run(ctx, command.Command{
+		Command: command.GetNamespaces,
+		Options: command.CommandOptions{
+			SQL: sqlStatement,
+			DB:  dbName,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return translation.DecodeNamespaces(result)
+}
+
+// Close stops every worker. It waits for in-flight commands to finish,
+// and commands issued after Close return ErrClosed.
+func (p *Pool) Close() error {
+	select {
+	case <-p.closed:
+		return nil
+	default:
+		close(p.closed)
+	}
+
+	for i := 0; i < cap(p.slots); i++ {
+		if w := <-p.slots; w != nil {
+			w.close()
+		}
+	}
+	return nil
+}
+
+// run sends the command to an idle worker and returns the worker's
+// BSON response. If the worker crashes, or the command does not
+// complete before the context is done or the pool's timeout elapses,
+// the worker is killed and its slot is marked for restart.
+func (p *Pool) run(ctx context.Context, cmd command.Command) ([]byte, error) {
+	commandBytes, err := bson.Marshal(cmd)
+	if err != nil {
+		return nil, translation.NewInternalError(fmt.Errorf("failed to marshal command to BSON: %w", err))
+	}
+
+	w, err := p.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// the timeout starts once a worker is acquired, so that waiting for
+	// a free worker does not count against it
+	if p.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.opts.Timeout)
+		defer cancel()
+	}
+
+	type roundTripResult struct {
+		response []byte
+		err      error
+	}
+	results := make(chan roundTripResult, 1)
+	go func() {
+		response, err := w.roundTrip(commandBytes)
+		results <- roundTripResult{response, err}
+	}()
+
+	select {
+	case result := <-results:
+		if result.err != nil {
+			// the worker either crashed or wrote a malformed response,
+			// so it cannot be reused
+			w.kill()
+			p.slots <- nil
+			return nil, translation.NewInternalError(w.exitError(result.err))
+		}
+		p.slots <- w
+		return result.response, nil
+	case <-ctx.Done():
+		w.kill()
+		<-results
+		p.slots <- nil
+		return nil, translation.NewExternalError(fmt.Errorf("translation did not complete in time, worker was killed: %w", ctx.Err()))
+	}
+}
+
+// acquire waits for a free slot and returns its worker, starting a new
+// worker if the slot is empty.
+func (p *Pool) acquire(ctx context.Context) (*worker, error) {
+	select {
+	case <-p.closed:
+		return nil, translation.NewInternalError(ErrClosed)
+	default:
+	}
+
+	var w *worker
+	select {
+	case w = <-p.slots:
+	case <-p.closed:
+		return nil, translation.NewInternalError(ErrClosed)
+	case <-ctx.Done():
+		return nil, translation.NewExternalError(fmt.Errorf("no translation worker became available in time: %w", ctx.Err()))
+	}
+
+	if w == nil {
+		var err error
+		w, err = startWorker(p.opts.Path, p.opts.Args)
+		if err != nil {
+			p.slots <- nil
+			return nil, translation.NewInternalError(err)
+		}
+	}
+	return w, nil
+}
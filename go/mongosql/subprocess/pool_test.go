@@ -0,0 +1,308 @@
+package subprocess_test
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mongodb/mongosql/go/mongosql/subprocess"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// fakeWorkerEnv is set in the environment of the test binary when it
+// is re-executed as a fake translation worker.
+const fakeWorkerEnv = "MONGOSQL_SUBPROCESS_FAKE_WORKER"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(fakeWorkerEnv) == "1" {
+		runFakeWorker()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runFakeWorker implements the worker side of the command protocol.
+// The translate command reports the worker's pid as the target
+// collection, and crashes, hangs or fails depending on the sql.
+func runFakeWorker() {
+	for {
+		var length [4]byte
+		if _, err := io.ReadFull(os.Stdin, length[:]); err != nil {
+			return
+		}
+		doc := make([]byte, binary.LittleEndian.Uint32(length[:]))
+		copy(doc, length[:])
+		if _, err := io.ReadFull(os.Stdin, doc[4:]); err != nil {
+			return
+		}
+
+		command := struct {
+			Command string `bson:"command"`
+			Options bson.M `bson:"options"`
+		}{}
+		if err := bson.Unmarshal(doc, &command); err != nil {
+			panic(err)
+		}
+
+		var response bson.D
+		switch command.Command {
+		case "getMongosqlTranslateVersion":
+			response = bson.D{{Key: "version", Value: "v9.9.9"}}
+		case "getNamespaces":
+			response = bson.D{{Key: "namespaces", Value: bson.A{
+				bson.D{{Key: "database", Value: command.Options["db"]}, {Key: "collection", Value: "foo"}},
+			}}}
+		case "translate":
+			switch command.Options["sql"] {
+			case "crash":
+				fmt.Fprintln(os.Stderr, "thread 'main' has overflowed its stack")
+				os.Exit(134)
+			case "hang":
+				time.Sleep(time.Hour)
+			case "slow":
+				time.Sleep(200 * time.Millisecond)
+				response = bson.D{
+					{Key: "target_db", Value: command.Options["db"]},
+					{Key: "pipeline", Value: bson.A{}},
+					{Key: "result_set_schema", Value: bson.D{{Key: "bsonType", Value: "object"}}},
+					{Key: "select_order", Value: bson.A{}},
+				}
+			case "error":
+				response = bson.D{
+					{Key: "error", Value: "parse error: Error 2001: Unrecognized token"},
+					{Key: "error_is_internal", Value: false},
+				}
+			default:
+				response = bson.D{
+					{Key: "target_db", Value: command.Options["db"]},
+					{Key: "target_collection", Value: fmt.Sprint(os.Getpid())},
+					{Key: "pipeline", Value: bson.A{bson.D{{Key: "$project", Value: bson.D{{Key: "_id", Value: int32(0)}}}}}},
+					{Key: "result_set_schema", Value: bson.D{{Key: "bsonType", Value: "object"}}},
+					{Key: "select_order", Value: bson.A{}},
+				}
+			}
+		}
+
+		out, err := bson.Marshal(response)
+		if err != nil {
+			panic(err)
+		}
+		if _, err := os.Stdout.Write(out); err != nil {
+			return
+		}
+	}
+}
+
+func newTestPool(t *testing.T, opts subprocess.Options) *subprocess.Pool {
+	t.Setenv(fakeWorkerEnv, "1")
+	opts.Path = os.Args[0]
+	pool, err := subprocess.NewPool(opts)
+	if err != nil {
+		t.Fatalf("failed to create pool: %v", err)
+	}
+	t.Cleanup(func() { _ = pool.Close() })
+	return pool
+}
+
+func TestTranslate(t *testing.T) {
+	pool := newTestPool(t, subprocess.Options{Workers: 1})
+
+	translation, err := pool.Translate(context.Background(), subprocess.TranslationArgs{
+		DB:  "bar",
+		SQL: "select * from foo",
+	})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	if translation.TargetDB != "bar" {
+		t.Fatalf("expected targetDB to be 'bar', got '%s'", translation.TargetDB)
+	}
+
+	if translation.Version != "v9.9.9" {
+		t.Fatalf("expected version to be 'v9.9.9', got '%s'", translation.Version)
+	}
+
+	if pool.Version() != "v9.9.9" {
+		t.Fatalf("expected pool version to be 'v9.9.9', got '%s'", pool.Version())
+	}
+}
+
+func TestTranslateError(t *testing.T) {
+	pool := newTestPool(t, subprocess.Options{Workers: 1})
+
+	_, err := pool.Translate(context.Background(), subprocess.TranslationArgs{DB: "bar", SQL: "error"})
+	if err == nil {
+		t.Fatalf("expected error to be non-nil, but it was nil")
+	}
+
+	tErr, ok := err.(subprocess.TranslationError)
+	if !ok {
+		t.Fatalf("expected error to be a TranslationError, but it wasn't")
+	}
+
+	if tErr.IsInternal() {
+		t.Fatalf("semantic translation errors should be external, but an internal error was found")
+	}
+
+	if !strings.Contains(err.Error(), "Unrecognized token") {
+		t.Fatalf("error message did not contain expected text: %q", err.Error())
+	}
+}
+
+func TestGetNamespaces(t *testing.T) {
+	pool := newTestPool(t, subprocess.Options{Workers: 1})
+
+	namespaces, err := pool.GetNamespaces(context.Background(), "bar", "select * from foo")
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	expected := []subprocess.Namespace{{Database: "bar", Collection: "foo"}}
+	if !reflect.DeepEqual(expected, namespaces) {
+		t.Fatalf("expected namespaces to be equal, but they weren't:\n%v\nand\n%v", expected, namespaces)
+	}
+}
+
+func TestCrashedWorkerIsRestarted(t *testing.T) {
+	pool := newTestPool(t, subprocess.Options{Workers: 1})
+
+	before, err := pool.Translate(context.Background(), subprocess.TranslationArgs{DB: "bar", SQL: "select 1"})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	_, err = pool.Translate(context.Background(), subprocess.TranslationArgs{DB: "bar", SQL: "crash"})
+	if err == nil {
+		t.Fatalf("expected error to be non-nil, but it was nil")
+	}
+
+	if !err.(subprocess.TranslationError).IsInternal() {
+		t.Fatalf("an error from a crashed worker should be internal, but an external error was found")
+	}
+
+	if !strings.Contains(err.Error(), "overflowed its stack") {
+		t.Fatalf("error message did not contain the worker's stderr: %q", err.Error())
+	}
+
+	after, err := pool.Translate(context.Background(), subprocess.TranslationArgs{DB: "bar", SQL: "select 1"})
+	if err != nil {
+		t.Fatalf("expected err to be nil after restart, got '%s'", err)
+	}
+
+	if before.TargetCollection == after.TargetCollection {
+		t.Fatalf("expected the crashed worker to be replaced, but pid %s was reused", after.TargetCollection)
+	}
+}
+
+func TestTimeoutKillsWorker(t *testing.T) {
+	pool := newTestPool(t, subprocess.Options{Workers: 1, Timeout: 100 * time.Millisecond})
+
+	start := time.Now()
+	_, err := pool.Translate(context.Background(), subprocess.TranslationArgs{DB: "bar", SQL: "hang"})
+	if err == nil {
+		t.Fatalf("expected error to be non-nil, but it was nil")
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("expected the hung worker to be killed promptly, but it took %s", elapsed)
+	}
+
+	if !strings.Contains(err.Error(), "did not complete in time") {
+		t.Fatalf("error message did not contain expected text: %q", err.Error())
+	}
+
+	_, err = pool.Translate(context.Background(), subprocess.TranslationArgs{DB: "bar", SQL: "select 1"})
+	if err != nil {
+		t.Fatalf("expected err to be nil after restart, got '%s'", err)
+	}
+}
+
+func TestTimeoutExcludesQueueTime(t *testing.T) {
+	// the second command waits for the first one, so it completes after
+	// more than the timeout, but spends less than the timeout on the
+	// worker
+	pool := newTestPool(t, subprocess.Options{Workers: 1, Timeout: 300 * time.Millisecond})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := pool.Translate(context.Background(), subprocess.TranslationArgs{DB: "bar", SQL: "slow"}); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("expected err to be nil, got '%s'", err)
+	}
+}
+
+func TestContextCancellationKillsWorker(t *testing.T) {
+	pool := newTestPool(t, subprocess.Options{Workers: 1})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err := pool.Translate(ctx, subprocess.TranslationArgs{DB: "bar", SQL: "hang"})
+	if err == nil {
+		t.Fatalf("expected error to be non-nil, but it was nil")
+	}
+
+	_, err = pool.Translate(context.Background(), subprocess.TranslationArgs{DB: "bar", SQL: "select 1"})
+	if err != nil {
+		t.Fatalf("expected err to be nil after restart, got '%s'", err)
+	}
+}
+
+func TestConcurrentTranslations(t *testing.T) {
+	pool := newTestPool(t, subprocess.Options{Workers: 3})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sql := "select 1"
+			if i%5 == 0 {
+				sql = "crash"
+			}
+			_, err := pool.Translate(context.Background(), subprocess.TranslationArgs{DB: "bar", SQL: sql})
+			if (err != nil) != (sql == "crash") {
+				errs <- fmt.Errorf("unexpected result for %q: %v", sql, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestClosedPool(t *testing.T) {
+	pool := newTestPool(t, subprocess.Options{Workers: 2})
+
+	if err := pool.Close(); err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	_, err := pool.Translate(context.Background(), subprocess.TranslationArgs{DB: "bar", SQL: "select 1"})
+	if err == nil || !strings.Contains(err.Error(), subprocess.ErrClosed.Error()) {
+		t.Fatalf("expected ErrClosed, got %v", err)
+	}
+}
@@ -0,0 +1,154 @@
+package subprocess
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+)
+
+// maxStderrBytes is the number of trailing bytes of a worker's stderr
+// that are kept for error messages.
+const maxStderrBytes = 4096
+
+// worker is a single translator subprocess. A worker handles one
+// command at a time; the Pool guarantees exclusive access.
+type worker struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+	pipe   *os.File
+	stderr *tailBuffer
+	done   chan struct{}
+	err    error
+}
+
+// startWorker starts a worker subprocess using the provided command
+// line.
+func startWorker(path string, args []string) (*worker, error) {
+	cmd := exec.Command(path, args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	// The stdout pipe is created by hand rather than with StdoutPipe,
+	// because Wait closes the read end of a StdoutPipe and Wait runs
+	// concurrently with reads here.
+	stdout, stdoutWriter, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stdout = stdoutWriter
+	stderr := &tailBuffer{limit: maxStderrBytes}
+	cmd.Stderr = stderr
+
+	err = cmd.Start()
+	_ = stdoutWriter.Close()
+	if err != nil {
+		_ = stdin.Close()
+		_ = stdout.Close()
+		return nil, fmt.Errorf("failed to start translation worker %q: %w", path, err)
+	}
+
+	w := &worker{
+		cmd:    cmd,
+		stdin:  stdin,
+		stdout: bufio.NewReader(stdout),
+		pipe:   stdout,
+		stderr: stderr,
+		done:   make(chan struct{}),
+	}
+	go func() {
+		w.err = cmd.Wait()
+		close(w.done)
+	}()
+	return w, nil
+}
+
+// roundTrip writes the BSON command to the worker and reads back the
+// BSON response. It must not be called concurrently.
+func (w *worker) roundTrip(command []byte) ([]byte, error) {
+	if _, err := w.stdin.Write(command); err != nil {
+		return nil, err
+	}
+	return readDocument(w.stdout)
+}
+
+// kill terminates the worker and waits for it to exit.
+func (w *worker) kill() {
+	_ = w.cmd.Process.Kill()
+	<-w.done
+	_ = w.pipe.Close()
+}
+
+// close asks an idle worker to exit by closing its stdin, and waits
+// for it to exit.
+func (w *worker) close() {
+	_ = w.stdin.Close()
+	<-w.done
+	_ = w.pipe.Close()
+}
+
+// exitError describes why a command failed on a worker that has
+// exited, including the worker's exit status and the tail of its
+// stderr.
+func (w *worker) exitError(cause error) error {
+	msg := fmt.Sprintf("translation worker failed: %s", cause)
+	if w.err != nil {
+		msg = fmt.Sprintf("%s: worker exited with %s", msg, w.err)
+	}
+	if stderr := w.stderr.String(); stderr != "" {
+		msg = fmt.Sprintf("%s\n%s", msg, stderr)
+	}
+	return errors.New(msg)
+}
+
+// readDocument reads a single BSON document from r. BSON documents
+// begin with their total length as a little-endian int32.
+func readDocument(r io.Reader) ([]byte, error) {
+	var length [4]byte
+	if _, err := io.ReadFull(r, length[:]); err != nil {
+		return nil, err
+	}
+	n := int32(binary.LittleEndian.Uint32(length[:]))
+	if n < 5 {
+		return nil, fmt.Errorf("invalid BSON document length %d", n)
+	}
+
+	doc := make([]byte, n)
+	copy(doc, length[:])
+	if _, err := io.ReadFull(r, doc[4:]); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// tailBuffer is an io.Writer that keeps the last limit bytes written
+// to it.
+type tailBuffer struct {
+	mu    sync.Mutex
+	limit int
+	buf   []byte
+}
+
+// Write implements io.Writer.
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.limit {
+		b.buf = b.buf[len(b.buf)-b.limit:]
+	}
+	return len(p), nil
+}
+
+// String returns the bytes currently held by the buffer.
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.buf)
+}
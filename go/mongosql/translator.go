@@ -0,0 +1,105 @@
+package mongosql
+
+import (
+	"context"
+
+	"github.com/mongodb/mongosql/go/mongosql/internal/limiter"
+)
+
+// TranslatorObserver receives a callback for every admission decision
+// made by a Translator, so that queue wait times and rejections can be
+// exported as metrics. Implementations must be safe for concurrent
+// use.
+type TranslatorObserver = limiter.Observer
+
+// TranslatorStats is a snapshot of the admission counters kept by a
+// Translator.
+type TranslatorStats = limiter.Stats
+
+// ErrTranslationQueueFull is returned by a Translator when
+// MaxQueuedCalls callers are already waiting to run.
+var ErrTranslationQueueFull = limiter.ErrQueueFull
+
+// TranslatorOptions configures a Translator.
+type TranslatorOptions struct {
+	// MaxConcurrentCalls is the maximum number of calls into the c
+	// translation library that may run at once. Every in-flight call
+	// occupies an OS thread, so this bounds the number of threads used
+	// by translation. Zero means unlimited.
+	MaxConcurrentCalls int
+	// MaxQueuedCalls is the maximum number of callers that may wait
+	// for a free slot when MaxConcurrentCalls calls are in flight.
+	// Callers beyond it are rejected immediately with
+	// ErrTranslationQueueFull. Zero means unlimited.
+	MaxQueuedCalls int
+	// Observer, if non-nil, is notified of queue wait times and
+	// rejections.
+	Observer TranslatorObserver
+	// CatalogProvider, if non-nil, supplies the schemas of the
+	// namespaces referenced by a query that are missing from the
+	// CatalogSchema of its TranslationArgs. Only those schemas are
+	// fetched, in parallel, before the query is translated, so
+	// CatalogSchema does not need to hold the whole catalog. Wrap the
+	// provider with NewCachingCatalogProvider to reuse schemas across
+	// translations.
+	CatalogProvider CatalogProvider
+}
+
+// Translator performs translations while limiting how many calls into
+// the c translation library run concurrently. Callers beyond the limit
+// wait in a queue until a call finishes or their context is done. It
+// is safe for concurrent use.
+type Translator struct {
+	limiter  *limiter.Limiter
+	provider CatalogProvider
+}
+
+// NewTranslator creates a Translator configured by opts.
+func NewTranslator(opts TranslatorOptions) *Translator {
+	return &Translator{
+		limiter:  limiter.New(opts.MaxConcurrentCalls, opts.MaxQueuedCalls, opts.Observer),
+		provider: opts.CatalogProvider,
+	}
+}
+
+// Translate behaves like the package-level Translate, but first waits
+// for a free slot. The context only bounds the time spent waiting; a
+// call that has entered the c library runs to completion. An error
+// caused by giving up on the wait is an internal TranslationError.
+//
+// Schemas supplied by the CatalogProvider of the Translator are
+// fetched with ctx before waiting, so a slot is not held while they
+// are fetched. An error returned by the provider, including one caused
+// by ctx being done, is not a TranslationError: it is returned wrapped
+// with the namespace whose schema could not be fetched.
+func (t *Translator) Translate(ctx context.Context, args TranslationArgs) (Translation, error) {
+	args, err := t.withProvidedSchemas(ctx, args)
+	if err != nil {
+		return Translation{}, err
+	}
+
+	release, err := t.limiter.Acquire(ctx)
+	if err != nil {
+		return Translation{}, NewInternalError(err)
+	}
+	defer release()
+
+	return Translate(args)
+}
+
+// GetNamespaces behaves like the package-level GetNamespaces, but
+// first waits for a free slot in the same way as Translate.
+func (t *Translator) GetNamespaces(ctx context.Context, dbName, sqlStatement string) ([]Namespace, error) {
+	release, err := t.limiter.Acquire(ctx)
+	if err != nil {
+		return nil, NewInternalError(err)
+	}
+	defer release()
+
+	return GetNamespaces(dbName, sqlStatement)
+}
+
+// Stats returns a snapshot of the Translator's admission counters.
+func (t *Translator) Stats() TranslatorStats {
+	return t.limiter.Stats()
+}
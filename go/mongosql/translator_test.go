@@ -0,0 +1,67 @@
+package mongosql_test
+
+import (
+	"context"
+	"fmt"
+	"runtime/pprof"
+	"sync"
+	"testing"
+
+	"github.com/mongodb/mongosql/go/mongosql"
+	"github.com/mongodb/mongosql/go/mongosql/internal/util"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// complexQuery builds a query with many nested subqueries so that each
+// translation spends a noticeable amount of time in the c library.
+func complexQuery(depth int) string {
+	query := "select * from foo"
+	for i := 0; i < depth; i++ {
+		query = fmt.Sprintf("select * from (%s) as t%d", query, i)
+	}
+	return query
+}
+
+func TestTranslatorBoundsThreadCount(t *testing.T) {
+	schema, err := util.GenerateDefaultCollectionSchema()
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+	args := mongosql.TranslationArgs{
+		DB:            "bar",
+		SQL:           complexQuery(20),
+		CatalogSchema: map[string]map[string]bsoncore.Document{"bar": {"foo": schema}},
+	}
+
+	const maxConcurrentCalls = 4
+	translator := mongosql.NewTranslator(mongosql.TranslatorOptions{
+		MaxConcurrentCalls: maxConcurrentCalls,
+	})
+
+	threadsBefore := pprof.Lookup("threadcreate").Count()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := translator.Translate(context.Background(), args); err != nil {
+				t.Errorf("expected err to be nil, got '%s'", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Each in-flight cgo call may pin a thread in addition to the
+	// threads the runtime already needs, so allow some headroom above
+	// the configured limit.
+	created := pprof.Lookup("threadcreate").Count() - threadsBefore
+	if created > maxConcurrentCalls+8 {
+		t.Fatalf("expected at most %d new threads, but %d were created", maxConcurrentCalls+8, created)
+	}
+
+	stats := translator.Stats()
+	if stats.Admitted != 200 || stats.Rejected != 0 || stats.InFlight != 0 {
+		t.Fatalf("unexpected stats after all translations finished: %+v", stats)
+	}
+}
@@ -0,0 +1,81 @@
+package mongosql
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/mongodb/mongosql/go/mongosql/internal/translation"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// ValidationResult describes the outcome of validating a sql query.
+type ValidationResult struct {
+	// Errors holds every error found in the query, in the order they
+	// were found, each with its code and position when they are known.
+	// It is empty if the query is valid.
+	Errors []TranslationError
+	// ResultSetSchema is a JSON Schema document that describes the
+	// documents the query would return. It is only set if the query is
+	// valid.
+	ResultSetSchema bsoncore.Document
+	// SelectOrder is an Array of Arrays, with each sub array describing
+	// a singular field in the result set. It is only set if the query
+	// is valid.
+	SelectOrder bsoncore.Array
+}
+
+// Valid returns true if no errors were found in the query.
+func (r ValidationResult) Valid() bool {
+	return len(r.Errors) == 0
+}
+
+// Validate checks a sql query in the same way as Translate, but stops
+// before generating MQL, which makes it cheap enough to run on every
+// change to a query in an editor. The query is parsed, its names are
+// resolved against args.CatalogSchema, and its expressions are
+// schema-checked, so Validate finds the same errors as Translate
+// except for the few raised while generating MQL. Instead of stopping
+// at the first error, Validate keeps checking the query wherever it
+// can and reports every error in the returned ValidationResult.
+//
+// The query optimizer does not run during validation, so the
+// ResultSetSchema of a query with filters may be less precise than the
+// one returned by Translate.
+//
+// A query with errors is not a failure of Validate: the returned error
+// is only non-nil if the query could not be validated at all, such as
+// when args holds an invalid catalog schema. It is a TranslationError.
+func Validate(args TranslationArgs) (ValidationResult, error) {
+	base64ValidationResult, err := callValidate(args)
+	if err != nil {
+		return ValidationResult{}, err
+	}
+
+	validationBytes, err := base64.StdEncoding.DecodeString(base64ValidationResult)
+	if err != nil {
+		return ValidationResult{}, NewInternalError(fmt.Errorf("failed to decode base64 validation result: %w", err))
+	}
+
+	if err := translation.DecodeError(validationBytes); err != nil {
+		return ValidationResult{}, err
+	}
+
+	validationResult := struct {
+		Errors          []translation.ErrorDocument `bson:"errors"`
+		ResultSetSchema bsoncore.Document           `bson:"result_set_schema"`
+		SelectOrder     bsoncore.Array              `bson:"select_order"`
+	}{}
+	if err := bson.Unmarshal(validationBytes, &validationResult); err != nil {
+		return ValidationResult{}, NewInternalError(fmt.Errorf("failed to unmarshal validation result BSON into struct: %w", err))
+	}
+
+	result := ValidationResult{
+		ResultSetSchema: validationResult.ResultSetSchema,
+		SelectOrder:     validationResult.SelectOrder,
+	}
+	for _, e := range validationResult.Errors {
+		result.Errors = append(result.Errors, e.TranslationError())
+	}
+	return result, nil
+}
@@ -0,0 +1,130 @@
+package mongosql_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mongodb/mongosql/go/mongosql"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+func validateCatalogSchema(t testing.TB) map[string]map[string]bsoncore.Document {
+	schema := bson.M{
+		"bsonType": "object",
+		"properties": bson.M{
+			"a": bson.M{"bsonType": "int"},
+			"b": bson.M{"bsonType": "string"},
+		},
+		"additionalProperties": false,
+	}
+
+	bytes, err := bson.Marshal(&schema)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+
+	return map[string]map[string]bsoncore.Document{
+		"bar": {"foo": bsoncore.Document(bytes)},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	args := mongosql.TranslationArgs{
+		DB:            "bar",
+		SQL:           "select a, b from foo order by a",
+		CatalogSchema: validateCatalogSchema(t),
+	}
+
+	result, err := mongosql.Validate(args)
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	if !result.Valid() {
+		t.Fatalf("expected query to be valid, got errors %v", result.Errors)
+	}
+
+	translation, err := mongosql.Translate(args)
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	if !bytes.Equal(translation.ResultSetSchema, result.ResultSetSchema) {
+		t.Fatalf("expected result set schema %s, got %s", translation.ResultSetSchema, result.ResultSetSchema)
+	}
+	if !bytes.Equal(translation.SelectOrder, result.SelectOrder) {
+		t.Fatalf("expected select order %s, got %s", translation.SelectOrder, result.SelectOrder)
+	}
+}
+
+func TestValidateReturnsEveryError(t *testing.T) {
+	result, err := mongosql.Validate(mongosql.TranslationArgs{
+		DB:            "bar",
+		SQL:           "select c from foo where d = 1",
+		CatalogSchema: validateCatalogSchema(t),
+	})
+	if err != nil {
+		t.Fatalf("expected err to be nil, got '%s'", err)
+	}
+
+	if len(result.Errors) != 2 {
+		t.Fatalf("expected 2 errors, got %v", result.Errors)
+	}
+	for _, e := range result.Errors {
+		if e.IsInternal() {
+			t.Fatalf("validation errors should be external, but an internal error was found")
+		}
+	}
+	if result.ResultSetSchema != nil {
+		t.Fatalf("expected no result set schema for an invalid query, got %s", result.ResultSetSchema)
+	}
+}
+
+func TestValidateInvalidOptions(t *testing.T) {
+	_, err := mongosql.Validate(mongosql.TranslationArgs{
+		DB:            "bar",
+		SQL:           "select a from foo",
+		CatalogSchema: validateCatalogSchema(t),
+		Optimizer: mongosql.OptimizerOptions{
+			DisabledPasses: []string{"not_a_pass"},
+		},
+	})
+	if err == nil {
+		t.Fatalf("expected error to be non-nil, but it was nil")
+	}
+}
+
+const benchmarkSQL = `select foo.a, f2.b, count(*) as c
+	from foo join foo as f2 on foo.a = f2.a
+	where foo.b like 'x%' and f2.a between 1 and 100
+	group by foo.a, f2.b
+	having count(*) > 1
+	order by foo.a
+	limit 10`
+
+func BenchmarkValidate(b *testing.B) {
+	args := mongosql.TranslationArgs{
+		DB:            "bar",
+		SQL:           benchmarkSQL,
+		CatalogSchema: validateCatalogSchema(b),
+	}
+	for i := 0; i < b.N; i++ {
+		if _, err := mongosql.Validate(args); err != nil {
+			b.Fatalf("expected err to be nil, got '%s'", err)
+		}
+	}
+}
+
+func BenchmarkTranslate(b *testing.B) {
+	args := mongosql.TranslationArgs{
+		DB:            "bar",
+		SQL:           benchmarkSQL,
+		CatalogSchema: validateCatalogSchema(b),
+	}
+	for i := 0; i < b.N; i++ {
+		if _, err := mongosql.Translate(args); err != nil {
+			b.Fatalf("expected err to be nil, got '%s'", err)
+		}
+	}
+}
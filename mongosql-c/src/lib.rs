@@ -1,10 +1,12 @@
 use base64::Engine;
 use lazy_static::lazy_static;
 use mongosql::{
+    ast::format::{FormatOptions, KeywordCase},
     build_catalog_from_base_64,
-    options::{ExcludeNamespacesOption, SqlOptions},
+    options::{ExcludeNamespacesOption, OptimizerOptions, OptimizerPass, SqlOptions},
     SchemaCheckingMode,
 };
+use serde::Deserialize;
 use std::{
     collections::BTreeSet,
     ffi::{CStr, CString, NulError},
@@ -41,6 +43,7 @@ pub extern "C" fn translate(
     catalog: *const libc::c_char,
     relax_schema_checking: libc::c_int,
     exclude_namespaces: libc::c_int,
+    options: *const libc::c_char,
 ) -> *const raw::c_char {
     panic_safe_exec(
         || {
@@ -50,22 +53,63 @@ pub extern "C" fn translate(
                 catalog,
                 relax_schema_checking,
                 exclude_namespaces,
+                options,
             )
         },
-        Box::new(translation_success_payload),
+        or_query_failure_payload(translation_success_payload),
         Box::new(translation_failure_payload),
     )
 }
 
 /// A helper function that encapsulates all the fallible parts of
-/// translation whose errors can be returned in the FFI payload.
+/// translation whose errors can be returned in the FFI payload. Errors
+/// found in the query are returned in the inner Result, so that their
+/// codes and positions can be included in the payload.
 fn translate_helper(
     current_db: *const libc::c_char,
     sql: *const libc::c_char,
     catalog: *const libc::c_char,
     relax_schema_checking: libc::c_int,
     exclude_namespaces: libc::c_int,
-) -> Result<mongosql::Translation, String> {
+    options: *const libc::c_char,
+) -> Result<Result<mongosql::Translation, QueryFailure>, String> {
+    let args = translation_args(
+        current_db,
+        sql,
+        catalog,
+        relax_schema_checking,
+        exclude_namespaces,
+        options,
+    )?;
+
+    Ok(
+        mongosql::translate_sql(&args.current_db, &args.sql, &args.catalog, args.options).map_err(
+            |error| QueryFailure {
+                sql: args.sql,
+                error,
+            },
+        ),
+    )
+}
+
+/// The arguments to translate and explain, converted from their FFI
+/// representations.
+struct TranslationArgs {
+    current_db: String,
+    sql: String,
+    catalog: mongosql::catalog::Catalog,
+    options: SqlOptions,
+}
+
+/// Converts the FFI arguments shared by translate and explain.
+fn translation_args(
+    current_db: *const libc::c_char,
+    sql: *const libc::c_char,
+    catalog: *const libc::c_char,
+    relax_schema_checking: libc::c_int,
+    exclude_namespaces: libc::c_int,
+    options: *const libc::c_char,
+) -> Result<TranslationArgs, String> {
     let current_db =
         from_extern_string(current_db).map_err(|_| "current_db not valid UTF-8".to_string())?;
     let sql =
@@ -83,6 +127,10 @@ fn translate_helper(
     let catalog_str = from_extern_string(catalog)
         .map_err(|_| "catalog schema string not valid UTF-8".to_string())?;
     let catalog = build_catalog_from_base_64(catalog_str.as_str()).map_err(|e| e.to_string())?;
+    let options_str =
+        from_extern_string(options).map_err(|_| "options string not valid UTF-8".to_string())?;
+    let mut options = SqlOptions::new(exclude_namespaces_mode, schema_checking_mode);
+    apply_options_from_base_64(options_str.as_str(), &mut options)?;
 
     // used for testing purpose
     #[cfg(feature = "test")]
@@ -92,18 +140,77 @@ fn translate_helper(
         }
     }
 
-    mongosql::translate_sql(
-        &current_db,
-        &sql,
-        &catalog,
-        SqlOptions::new(exclude_namespaces_mode, schema_checking_mode),
-    )
-    .map_err(|e| format!("{e}"))
+    Ok(TranslationArgs {
+        current_db,
+        sql,
+        catalog,
+        options,
+    })
+}
+
+/// The BSON document of options passed to translate and explain. Options
+/// that are absent keep the values set by SqlOptions::new. Unknown options
+/// are rejected, so that a caller never silently gets a translation that
+/// ignored an option it asked for.
+#[derive(Deserialize)]
+#[serde(deny_unknown_fields)]
+struct OptionsDocument {
+    #[serde(default)]
+    allow_order_by_missing_columns: Option<bool>,
+    #[serde(default)]
+    optimizer: Option<OptimizerOptionsDocument>,
+    #[serde(default)]
+    include_lineage: bool,
+}
+
+/// The BSON representation of OptimizerOptions.
+#[derive(Deserialize)]
+#[serde(deny_unknown_fields)]
+struct OptimizerOptionsDocument {
+    #[serde(default)]
+    disable_optimization: bool,
+    #[serde(default)]
+    disabled_passes: Vec<String>,
+}
+
+/// Applies the options in a base64-encoded OptionsDocument to `options`.
+fn apply_options_from_base_64(base_64_doc: &str, options: &mut SqlOptions) -> Result<(), String> {
+    let bson_doc_bytes = base64::engine::general_purpose::STANDARD
+        .decode(base_64_doc)
+        .map_err(|e| format!("failed to decode base64 options: {e}"))?;
+    let doc: OptionsDocument = bson::from_reader(&mut bson_doc_bytes.as_slice())
+        .map_err(|e| format!("invalid translation options: {e}"))?;
+
+    if let Some(allow_order_by_missing_columns) = doc.allow_order_by_missing_columns {
+        options.allow_order_by_missing_columns = allow_order_by_missing_columns;
+    }
+
+    options.include_lineage = doc.include_lineage;
+
+    if let Some(optimizer) = doc.optimizer {
+        options.optimizer = if optimizer.disable_optimization {
+            OptimizerOptions::disabled()
+        } else {
+            OptimizerOptions::default()
+        };
+        for name in optimizer.disabled_passes {
+            options
+                .optimizer
+                .disable_pass(name.parse::<OptimizerPass>()?);
+        }
+    }
+    Ok(())
 }
 
 /// Returns a base64-encoded BSON document representing the payload
 /// returned for a successful translation.
 fn translation_success_payload(t: mongosql::Translation) -> String {
+    base64::engine::general_purpose::STANDARD
+        .encode(bson::to_vec(&translation_document(t)).expect("serializing bson to bytes failed"))
+}
+
+/// Returns the BSON document describing a successful translation.
+fn translation_document(t: mongosql::Translation) -> bson::Document {
     use serde::ser::Serialize;
     let serializer = bson::Serializer::new();
     let serializer = serde_stacker::Serializer::new(serializer);
@@ -111,16 +218,39 @@ fn translation_success_payload(t: mongosql::Translation) -> String {
         .select_order
         .serialize(serializer)
         .expect("failed to convert select_order to bson");
-    let translation = bson::doc! {
+    bson::doc! {
         "target_db": t.target_db,
         "target_collection": t.target_collection.unwrap_or_default(),
         "pipeline": t.pipeline,
         "result_set_schema": &t.result_set_schema.to_bson().expect("failed to convert result_set_schema to bson"),
         "select_order": &so,
-    };
-
-    base64::engine::general_purpose::STANDARD
-        .encode(bson::to_vec(&translation).expect("serializing bson to bytes failed"))
+        "optimizer_passes": t
+            .optimizer_passes
+            .into_iter()
+            .map(|report| bson::doc! {
+                "name": report.pass.name(),
+                "runs": report.runs as i64,
+                "changed": report.changed,
+            })
+            .collect::<Vec<_>>(),
+        "lineage": t
+            .lineage
+            .into_iter()
+            .map(|column| bson::doc! {
+                "column": column.column,
+                "kind": column.kind.as_str(),
+                "sources": column
+                    .sources
+                    .into_iter()
+                    .map(|source| bson::doc! {
+                        "database": source.database,
+                        "collection": source.collection,
+                        "path": source.path,
+                    })
+                    .collect::<Vec<_>>(),
+            })
+            .collect::<Vec<_>>(),
+    }
 }
 
 /// ErrorVisibility describes whether an error is "internal" or
@@ -152,6 +282,233 @@ fn translation_failure_payload(error: String, error_visibility: ErrorVisibility)
     base64::engine::general_purpose::STANDARD.encode(buf)
 }
 
+/// The errors found in a query, along with the query itself, which is
+/// needed to compute the position of each error.
+struct QueryFailure {
+    sql: String,
+    error: mongosql::result::Error,
+}
+
+/// Wraps a success payload function so that errors found in the query
+/// are returned in the translation failure payload.
+fn or_query_failure_payload<T: 'static>(
+    handle_success: fn(T) -> String,
+) -> Box<dyn FnOnce(Result<T, QueryFailure>) -> String> {
+    Box::new(move |result| match result {
+        Ok(success) => handle_success(success),
+        Err(failure) => query_failure_payload(failure),
+    })
+}
+
+/// Returns a base64-encoded BSON document representing the payload
+/// returned for a query with errors. In addition to the fields of the
+/// translation failure payload, it holds an errors array describing
+/// each individual error found in the query.
+fn query_failure_payload(failure: QueryFailure) -> String {
+    let payload = bson::doc! {
+        "error": format!("{}", failure.error),
+        "error_is_internal": false,
+        "errors": error_documents(&failure.sql, failure.error.into_errors()),
+    };
+
+    base64::engine::general_purpose::STANDARD
+        .encode(bson::to_vec(&payload).expect("serializing bson to bytes failed"))
+}
+
+/// Returns a BSON document for each of the provided errors found in
+/// sql, holding its message, along with its code and position when
+/// they are known.
+fn error_documents(sql: &str, errors: Vec<mongosql::result::Error>) -> Vec<bson::Document> {
+    errors
+        .into_iter()
+        .map(|e| {
+            let mut doc = bson::doc! { "error": format!("{e}") };
+            if let Some(code) = e.code() {
+                doc.insert("code", code as i32);
+            }
+            if let Some(position) = e.position(sql) {
+                doc.insert(
+                    "position",
+                    bson::doc! {
+                        "offset": position.offset as i64,
+                        "line": position.line as i64,
+                        "column": position.column as i64,
+                    },
+                );
+            }
+            doc
+        })
+        .collect()
+}
+
+/// Returns a base64-encoded bson representation of
+/// [Explanation](/mongosql/struct.Explanation.html) for the provided
+/// Sql query, database, catalog schema, and schema checking mode. The
+/// arguments are the same as those of translate.
+#[no_mangle]
+pub extern "C" fn explain(
+    current_db: *const libc::c_char,
+    sql: *const libc::c_char,
+    catalog: *const libc::c_char,
+    relax_schema_checking: libc::c_int,
+    exclude_namespaces: libc::c_int,
+    options: *const libc::c_char,
+) -> *const raw::c_char {
+    panic_safe_exec(
+        || {
+            explain_helper(
+                current_db,
+                sql,
+                catalog,
+                relax_schema_checking,
+                exclude_namespaces,
+                options,
+            )
+        },
+        or_query_failure_payload(explain_success_payload),
+        Box::new(translation_failure_payload),
+    )
+}
+
+/// A helper function that encapsulates all the fallible parts of
+/// explain whose errors can be returned in the FFI payload. Errors
+/// found in the query are returned in the inner Result.
+fn explain_helper(
+    current_db: *const libc::c_char,
+    sql: *const libc::c_char,
+    catalog: *const libc::c_char,
+    relax_schema_checking: libc::c_int,
+    exclude_namespaces: libc::c_int,
+    options: *const libc::c_char,
+) -> Result<Result<mongosql::Explanation, QueryFailure>, String> {
+    let args = translation_args(
+        current_db,
+        sql,
+        catalog,
+        relax_schema_checking,
+        exclude_namespaces,
+        options,
+    )?;
+
+    Ok(
+        mongosql::explain_sql(&args.current_db, &args.sql, &args.catalog, args.options).map_err(
+            |error| QueryFailure {
+                sql: args.sql,
+                error,
+            },
+        ),
+    )
+}
+
+/// Returns a base64-encoded BSON document representing the payload
+/// returned for a successful explain.
+fn explain_success_payload(e: mongosql::Explanation) -> String {
+    let stage = |stage: mongosql::ExplainStage| {
+        bson::doc! {
+            "text": stage.text,
+            "tree": stage.tree,
+        }
+    };
+    let explanation = bson::doc! {
+        "rewritten_ast": stage(e.stages.rewritten_ast),
+        "mir": stage(e.stages.mir),
+        "optimized_mir": stage(e.stages.optimized_mir),
+        "air": stage(e.stages.air),
+        "desugared_air": stage(e.stages.desugared_air),
+        "translation": translation_document(e.translation),
+    };
+
+    base64::engine::general_purpose::STANDARD
+        .encode(bson::to_vec(&explanation).expect("serializing bson to bytes failed"))
+}
+
+/// Returns a base64-encoded bson document holding the errors found in
+/// the provided Sql query, along with its result set schema and select
+/// order if it is valid. The arguments are the same as those of translate.
+#[no_mangle]
+pub extern "C" fn validate(
+    current_db: *const libc::c_char,
+    sql: *const libc::c_char,
+    catalog: *const libc::c_char,
+    relax_schema_checking: libc::c_int,
+    exclude_namespaces: libc::c_int,
+    options: *const libc::c_char,
+) -> *const raw::c_char {
+    panic_safe_exec(
+        || {
+            validate_helper(
+                current_db,
+                sql,
+                catalog,
+                relax_schema_checking,
+                exclude_namespaces,
+                options,
+            )
+        },
+        Box::new(validate_success_payload),
+        Box::new(translation_failure_payload),
+    )
+}
+
+/// A helper function that encapsulates all the fallible parts of
+/// validate whose errors can be returned in the FFI payload. Errors
+/// found in the query are not failures of the call, and are returned
+/// in the inner Result.
+fn validate_helper(
+    current_db: *const libc::c_char,
+    sql: *const libc::c_char,
+    catalog: *const libc::c_char,
+    relax_schema_checking: libc::c_int,
+    exclude_namespaces: libc::c_int,
+    options: *const libc::c_char,
+) -> Result<Result<mongosql::Validation, QueryFailure>, String> {
+    let args = translation_args(
+        current_db,
+        sql,
+        catalog,
+        relax_schema_checking,
+        exclude_namespaces,
+        options,
+    )?;
+
+    Ok(
+        mongosql::validate_sql(&args.current_db, &args.sql, &args.catalog, args.options).map_err(
+            |errors| QueryFailure {
+                sql: args.sql,
+                error: mongosql::result::Error::from_errors(errors),
+            },
+        ),
+    )
+}
+
+/// Returns a base64-encoded BSON document representing the payload
+/// returned for a validate call that did not fail. The errors array is
+/// empty when the query is valid.
+fn validate_success_payload(validation: Result<mongosql::Validation, QueryFailure>) -> String {
+    let result = match validation {
+        Ok(v) => {
+            use serde::ser::Serialize;
+            let serializer = bson::Serializer::new();
+            let serializer = serde_stacker::Serializer::new(serializer);
+            let so = v
+                .select_order
+                .serialize(serializer)
+                .expect("failed to convert select_order to bson");
+            bson::doc! {
+                "errors": [],
+                "result_set_schema": &v.result_set_schema.to_bson().expect("failed to convert result_set_schema to bson"),
+                "select_order": &so,
+            }
+        }
+        Err(failure) => bson::doc! {
+            "errors": error_documents(&failure.sql, failure.error.into_errors()),
+        },
+    };
+
+    base64::engine::general_purpose::STANDARD
+        .encode(bson::to_vec(&result).expect("serializing bson to bytes failed"))
+}
+
 /// Returns a base64-encoded bson representation of
 /// the namespaces referenced by the the provided
 /// Sql query, when executed in the provided database.
@@ -218,6 +575,260 @@ fn get_namespaces_failure_payload(error: String, error_visibility: ErrorVisibili
     base64::engine::general_purpose::STANDARD.encode(buf)
 }
 
+/// Returns a base64-encoded bson document holding the provided Sql
+/// query formatted according to the provided base64-encoded BSON
+/// FormatOptionsDocument.
+#[no_mangle]
+pub extern "C" fn format_sql(
+    sql: *const libc::c_char,
+    options: *const libc::c_char,
+) -> *const raw::c_char {
+    panic_safe_exec(
+        || format_helper(sql, options),
+        Box::new(format_success_payload),
+        Box::new(translation_failure_payload),
+    )
+}
+
+/// The BSON document of options passed to format_sql. Options that are absent
+/// keep their default values, and unknown options are rejected.
+#[derive(Deserialize)]
+#[serde(deny_unknown_fields)]
+struct FormatOptionsDocument {
+    #[serde(default)]
+    keyword_case: Option<String>,
+    #[serde(default)]
+    indent: Option<u32>,
+    #[serde(default)]
+    line_width: Option<u32>,
+}
+
+/// A helper function that encapsulates all the fallible parts of
+/// format_sql whose errors can be returned in the FFI payload.
+fn format_helper(sql: *const libc::c_char, options: *const libc::c_char) -> Result<String, String> {
+    let sql =
+        from_extern_string(sql).map_err(|_| "sql query string not valid UTF-8".to_string())?;
+    let options_str =
+        from_extern_string(options).map_err(|_| "options string not valid UTF-8".to_string())?;
+    let bson_doc_bytes = base64::engine::general_purpose::STANDARD
+        .decode(options_str)
+        .map_err(|e| format!("failed to decode base64 options: {e}"))?;
+    let doc: FormatOptionsDocument = bson::from_reader(&mut bson_doc_bytes.as_slice())
+        .map_err(|e| format!("invalid format options: {e}"))?;
+
+    let mut options = FormatOptions::default();
+    match doc.keyword_case.as_deref() {
+        None | Some("upper") => options.keyword_case = KeywordCase::Upper,
+        Some("lower") => options.keyword_case = KeywordCase::Lower,
+        Some(case) => return Err(format!("invalid value '{case}' for keyword_case")),
+    }
+    if let Some(indent) = doc.indent {
+        options.indent = indent as usize;
+    }
+    if let Some(line_width) = doc.line_width {
+        options.line_width = line_width as usize;
+    }
+
+    mongosql::format_sql(&sql, options).map_err(|e| format!("{e}"))
+}
+
+/// Returns a base64-encoded BSON document representing the payload
+/// returned for a successful format_sql call.
+fn format_success_payload(formatted: String) -> String {
+    let result = bson::doc! {
+        "formatted": formatted,
+    };
+
+    base64::engine::general_purpose::STANDARD
+        .encode(bson::to_vec(&result).expect("serializing bson to bytes failed"))
+}
+
+/// Returns a base64-encoded bson representation of the syntax tree of
+/// the provided Sql query, as produced by the parser.
+#[no_mangle]
+pub extern "C" fn parse_sql(sql: *const libc::c_char) -> *const raw::c_char {
+    panic_safe_exec(
+        || parse_helper(sql),
+        Box::new(parse_success_payload),
+        Box::new(translation_failure_payload),
+    )
+}
+
+/// A helper function that encapsulates all the fallible parts of
+/// parse_sql whose errors can be returned in the FFI payload.
+fn parse_helper(sql: *const libc::c_char) -> Result<mongosql::ast::Query, String> {
+    let sql =
+        from_extern_string(sql).map_err(|_| "sql query string not valid UTF-8".to_string())?;
+
+    mongosql::parse_query(&sql).map_err(|e| format!("parse error: {e}"))
+}
+
+/// Returns a base64-encoded BSON document representing the payload
+/// returned for a successful parse_sql call.
+fn parse_success_payload(query: mongosql::ast::Query) -> String {
+    use serde::ser::Serialize;
+    let serializer = bson::Serializer::new();
+    let serializer = serde_stacker::Serializer::new(serializer);
+    let query = query
+        .serialize(serializer)
+        .expect("failed to convert query to bson");
+    let result = bson::doc! {
+        "query": query,
+    };
+
+    base64::engine::general_purpose::STANDARD
+        .encode(bson::to_vec(&result).expect("serializing bson to bytes failed"))
+}
+
+/// Returns a base64-encoded bson document holding the Sql text for the
+/// syntax tree in the provided base64-encoded BSON RenderDocument.
+#[no_mangle]
+pub extern "C" fn render_query(query: *const libc::c_char) -> *const raw::c_char {
+    panic_safe_exec(
+        || render_helper(query),
+        Box::new(render_success_payload),
+        Box::new(translation_failure_payload),
+    )
+}
+
+/// The BSON document passed to render_query.
+#[derive(Deserialize)]
+struct RenderDocument {
+    query: mongosql::ast::Query,
+}
+
+/// A helper function that encapsulates all the fallible parts of
+/// render_query whose errors can be returned in the FFI payload.
+fn render_helper(query: *const libc::c_char) -> Result<String, String> {
+    use mongosql::ast::pretty_print::PrettyPrint;
+
+    let query_str =
+        from_extern_string(query).map_err(|_| "query string not valid UTF-8".to_string())?;
+    let bson_doc_bytes = base64::engine::general_purpose::STANDARD
+        .decode(query_str)
+        .map_err(|e| format!("failed to decode base64 query: {e}"))?;
+    let doc: RenderDocument = bson::from_reader(&mut bson_doc_bytes.as_slice())
+        .map_err(|e| format!("invalid syntax tree: {e}"))?;
+
+    doc.query
+        .pretty_print()
+        .map_err(|e| format!("pretty print error: {e}"))
+}
+
+/// Returns a base64-encoded BSON document representing the payload
+/// returned for a successful render_query call.
+fn render_success_payload(sql: String) -> String {
+    let result = bson::doc! {
+        "sql": sql,
+    };
+
+    base64::engine::general_purpose::STANDARD
+        .encode(bson::to_vec(&result).expect("serializing bson to bytes failed"))
+}
+
+/// Returns a base64-encoded bson document holding the normalized text of
+/// the provided Sql query, in which literals are replaced by placeholders
+/// and identifiers are lowercased.
+#[no_mangle]
+pub extern "C" fn fingerprint_sql(sql: *const libc::c_char) -> *const raw::c_char {
+    panic_safe_exec(
+        || fingerprint_helper(sql),
+        Box::new(fingerprint_success_payload),
+        Box::new(translation_failure_payload),
+    )
+}
+
+/// A helper function that encapsulates all the fallible parts of
+/// fingerprint_sql whose errors can be returned in the FFI payload.
+fn fingerprint_helper(sql: *const libc::c_char) -> Result<String, String> {
+    let sql =
+        from_extern_string(sql).map_err(|_| "sql query string not valid UTF-8".to_string())?;
+
+    mongosql::fingerprint_sql(&sql).map_err(|e| format!("{e}"))
+}
+
+/// Returns a base64-encoded BSON document representing the payload
+/// returned for a successful fingerprint_sql call.
+fn fingerprint_success_payload(normalized: String) -> String {
+    let result = bson::doc! {
+        "normalized": normalized,
+    };
+
+    base64::engine::general_purpose::STANDARD
+        .encode(bson::to_vec(&result).expect("serializing bson to bytes failed"))
+}
+
+/// Returns a base64-encoded bson document holding the provided Sql query
+/// with its string and numeric literals replaced by placeholders.
+#[no_mangle]
+pub extern "C" fn redact_sql(sql: *const libc::c_char) -> *const raw::c_char {
+    panic_safe_exec(
+        || redact_helper(sql),
+        Box::new(redact_success_payload),
+        Box::new(translation_failure_payload),
+    )
+}
+
+/// A helper function that encapsulates all the fallible parts of
+/// redact_sql whose errors can be returned in the FFI payload.
+fn redact_helper(sql: *const libc::c_char) -> Result<String, String> {
+    let sql =
+        from_extern_string(sql).map_err(|_| "sql query string not valid UTF-8".to_string())?;
+
+    mongosql::redact_sql(&sql).map_err(|e| format!("{e}"))
+}
+
+/// Returns a base64-encoded BSON document representing the payload
+/// returned for a successful redact_sql call.
+fn redact_success_payload(redacted: String) -> String {
+    let result = bson::doc! {
+        "redacted": redacted,
+    };
+
+    base64::engine::general_purpose::STANDARD
+        .encode(bson::to_vec(&result).expect("serializing bson to bytes failed"))
+}
+
+/// Returns a base64-encoded BSON document holding the result of running the
+/// provided base64-encoded BSON Command, as defined by the mongosqltranslate
+/// command protocol.
+#[no_mangle]
+pub extern "C" fn run_command(command: *const libc::c_char) -> *const raw::c_char {
+    panic_safe_exec(
+        || run_command_helper(command),
+        Box::new(run_command_success_payload),
+        Box::new(translation_failure_payload),
+    )
+}
+
+/// A helper function that encapsulates all the fallible parts of
+/// run_command whose errors can be returned in the FFI payload.
+fn run_command_helper(command: *const libc::c_char) -> Result<Vec<u8>, String> {
+    let command = decode_command(command)?;
+    let result = mongosqltranslate::execute_command(&command).map_err(|e| e.to_string())?;
+
+    let mut buf = Vec::new();
+    result
+        .to_writer(&mut buf)
+        .expect("serializing bson to bytes failed");
+    Ok(buf)
+}
+
+/// Returns the base64-encoded BSON document returned by a successful
+/// run_command call.
+fn run_command_success_payload(result: Vec<u8>) -> String {
+    base64::engine::general_purpose::STANDARD.encode(result)
+}
+
+/// Decodes the base64-encoded BSON Command passed to run_command.
+fn decode_command(command: *const libc::c_char) -> Result<Vec<u8>, String> {
+    let command =
+        from_extern_string(command).map_err(|_| "command string not valid UTF-8".to_string())?;
+    base64::engine::general_purpose::STANDARD
+        .decode(command)
+        .map_err(|e| format!("failed to decode base64 command: {e}"))
+}
+
 /// Executes function `f` such that any panics do not crash the runtime. The
 /// function `f` returns a `Result<T, String>`, and the caller specifies how
 /// to handle a success (a `T`) and how to handle a failure (a `String`). If
@@ -252,6 +863,12 @@ fn panic_safe_exec<F: FnOnce() -> Result<T, String> + UnwindSafe, T>(
                     msg,
                     r.recv()
                 )
+            } else if let Some(msg) = err.downcast_ref::<String>() {
+                format!(
+                    "Internal Error: report this to MongoDB: {}\n{:?}",
+                    msg,
+                    r.recv()
+                )
             } else {
                 format!(
                     "Internal Error: report this to MongoDB: {:?}\n{:?}",
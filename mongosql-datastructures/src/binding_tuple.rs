@@ -1,3 +1,7 @@
+use serde::{
+    ser::{SerializeSeq, Serializer},
+    Serialize,
+};
 use std::collections::{btree_map, BTreeMap};
 
 #[derive(Debug)]
@@ -8,7 +12,7 @@ pub struct DuplicateKeyError {
 #[derive(PartialEq, Eq, Debug, Clone)]
 pub struct BindingTuple<T>(pub BTreeMap<Key, T>);
 
-#[derive(PartialEq, Eq, PartialOrd, Ord, Debug, Clone, Hash)]
+#[derive(PartialEq, Eq, PartialOrd, Ord, Debug, Clone, Hash, Serialize)]
 pub struct Key {
     pub datasource: DatasourceName,
     pub scope: u16,
@@ -44,7 +48,7 @@ where
     }
 }
 
-#[derive(PartialEq, Eq, PartialOrd, Ord, Debug, Clone, Hash)]
+#[derive(PartialEq, Eq, PartialOrd, Ord, Debug, Clone, Hash, Serialize)]
 pub enum DatasourceName {
     Bottom,
     Named(String),
@@ -161,3 +165,24 @@ where
         bt
     }
 }
+
+/// Serializes the tuple as a sequence of its entries, ordered by key, since
+/// its keys are not strings.
+impl<T> Serialize for BindingTuple<T>
+where
+    T: Serialize,
+{
+    fn serialize<S: Serializer>(&self, serializer: S) -> Result<S::Ok, S::Error> {
+        #[derive(Serialize)]
+        struct Entry<'a, T> {
+            key: &'a Key,
+            value: &'a T,
+        }
+
+        let mut seq = serializer.serialize_seq(Some(self.0.len()))?;
+        for (key, value) in self.0.iter() {
+            seq.serialize_element(&Entry { key, value })?;
+        }
+        seq.end()
+    }
+}
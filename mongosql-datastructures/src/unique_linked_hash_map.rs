@@ -1,4 +1,5 @@
 use linked_hash_map::LinkedHashMap;
+use serde::ser::{Serialize, SerializeMap, Serializer};
 use std::{fmt::Display, hash::Hash, iter::IntoIterator};
 use thiserror::Error;
 
@@ -87,6 +88,21 @@ where
     }
 }
 
+/// Serializes the map as a map with the same entries, in insertion order.
+impl<K, V> Serialize for UniqueLinkedHashMap<K, V>
+where
+    K: Hash + PartialEq + Eq + Display + Serialize,
+    V: Serialize,
+{
+    fn serialize<S: Serializer>(&self, serializer: S) -> Result<S::Ok, S::Error> {
+        let mut map = serializer.serialize_map(Some(self.0.len()))?;
+        for (k, v) in self.0.iter() {
+            map.serialize_entry(k, v)?;
+        }
+        map.end()
+    }
+}
+
 pub struct UniqueLinkedHashMapEntry<K, V>(K, V);
 
 impl<K, V> UniqueLinkedHashMapEntry<K, V>
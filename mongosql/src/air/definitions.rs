@@ -1,9 +1,10 @@
 use crate::{schema::Satisfaction, util::unique_linked_hash_map::UniqueLinkedHashMap};
 use bson::{oid::ObjectId, DateTime, Decimal128};
+use serde::Serialize;
 
 visitgen::generate_visitors! {
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub enum Stage {
     AddFields(AddFields),
     Project(Project),
@@ -24,39 +25,39 @@ pub enum Stage {
     Sentinel
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct Project {
     pub source: Box<Stage>,
     pub specifications: UniqueLinkedHashMap<String, ProjectItem>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct AddFields {
     pub source: Box<Stage>,
     pub specifications: UniqueLinkedHashMap<String, Expression>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub enum ProjectItem {
     Exclusion,
     Inclusion,
     Assignment(Expression)
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct Group {
     pub source: Box<Stage>,
     pub keys: Vec<NameExprPair>,
     pub aggregations: Vec<AccumulatorExpr>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct NameExprPair {
     pub name: String,
     pub expr: Expression,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct AccumulatorExpr {
     pub alias: String,
     pub function: AggregationFunction,
@@ -70,7 +71,7 @@ pub struct AccumulatorExpr {
 }
 
 #[allow(dead_code)]
-#[derive(PartialEq, Eq, Debug, Clone, Copy)]
+#[derive(PartialEq, Eq, Debug, Clone, Copy, Serialize)]
 pub enum AggregationFunction {
     AddToArray,
     AddToSet,
@@ -86,32 +87,32 @@ pub enum AggregationFunction {
     Sum,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct Limit {
     pub source: Box<Stage>,
     pub limit: i64,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct Sort {
     pub source: Box<Stage>,
     pub specs: Vec<SortSpecification>,
 }
 
 #[allow(dead_code)]
-#[derive(PartialEq, Eq, Debug, Clone)]
+#[derive(PartialEq, Eq, Debug, Clone, Serialize)]
 pub enum SortSpecification {
     Asc(String),
     Desc(String),
 }
 
-#[derive(PartialEq, Eq, Debug, Clone)]
+#[derive(PartialEq, Eq, Debug, Clone, Serialize)]
 pub struct Collection {
     pub db: String,
     pub collection: String,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct Join {
     pub join_type: JoinType,
     pub left: Box<Stage>,
@@ -121,13 +122,13 @@ pub struct Join {
 }
 
 #[allow(dead_code)]
-#[derive(PartialEq, Eq, Debug, Clone, Copy)]
+#[derive(PartialEq, Eq, Debug, Clone, Copy, Serialize)]
 pub enum JoinType {
     Left,
     Inner,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct Unwind {
     pub source: Box<Stage>,
     pub path: Expression,
@@ -135,7 +136,7 @@ pub struct Unwind {
     pub outer: bool,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct Lookup {
     pub source: Box<Stage>,
     pub let_vars: Option<Vec<LetVariable>>,
@@ -143,54 +144,54 @@ pub struct Lookup {
     pub as_var: String,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct LetVariable {
     pub name: String,
     pub expr: Box<Expression>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct ReplaceWith {
     pub source: Box<Stage>,
     pub new_root: Box<Expression>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub enum Match {
     ExprLanguage(ExprLanguage),
     MatchLanguage(MatchLanguage),
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct ExprLanguage {
     pub source: Box<Stage>,
     pub expr: Box<Expression>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct MatchLanguage {
     pub source: Box<Stage>,
     pub expr: Box<MatchQuery>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct UnionWith {
     pub source: Box<Stage>,
     pub pipeline: Box<Stage>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct Skip {
     pub source: Box<Stage>,
     pub skip: i64,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct Documents {
     pub array: Vec<Expression>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct EquiJoin {
     pub join_type: JoinType,
     pub source: Box<Stage>,
@@ -200,7 +201,7 @@ pub struct EquiJoin {
     pub as_name: String,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct EquiLookup {
     pub source: Box<Stage>,
     pub from: Collection,
@@ -210,7 +211,7 @@ pub struct EquiLookup {
 }
 
 #[allow(dead_code)]
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub enum Expression {
     MqlSemanticOperator(MqlSemanticOperator),
     SqlSemanticOperator(SqlSemanticOperator),
@@ -241,7 +242,7 @@ pub enum Expression {
 }
 
 #[allow(dead_code)]
-#[derive(PartialEq, Eq, Debug, Clone, Copy)]
+#[derive(PartialEq, Eq, Debug, Clone, Copy, Serialize)]
 pub enum MqlOperator {
     // String operators
     Concat,
@@ -343,7 +344,7 @@ pub enum MqlOperator {
 }
 
 #[allow(dead_code)]
-#[derive(PartialEq, Eq, Debug, Clone, Copy)]
+#[derive(PartialEq, Eq, Debug, Clone, Copy, Serialize)]
 pub enum SqlOperator {
     // Arithmetic operators
     Pos,
@@ -398,7 +399,7 @@ pub enum SqlOperator {
 }
 
 #[allow(dead_code)]
-#[derive(PartialEq, Eq, Debug, Clone, Copy)]
+#[derive(PartialEq, Eq, Debug, Clone, Copy, Serialize)]
 pub enum Type {
     Array,
     BinData,
@@ -453,7 +454,7 @@ impl Type {
 }
 
 
-#[derive(PartialEq, Eq, Debug, Clone, Copy)]
+#[derive(PartialEq, Eq, Debug, Clone, Copy, Serialize)]
 pub enum SqlConvertTargetType {
     Array,
     Document,
@@ -469,19 +470,19 @@ impl SqlConvertTargetType {
     }
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct MqlSemanticOperator {
     pub op: MqlOperator,
     pub args: Vec<Expression>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct SqlSemanticOperator {
     pub op: SqlOperator,
     pub args: Vec<Expression>,
 }
 
-#[derive(Debug, Clone)]
+#[derive(Debug, Clone, Serialize)]
 pub enum LiteralValue {
     Null,
     Boolean(bool),
@@ -505,51 +506,51 @@ pub enum LiteralValue {
     DbPointer(bson::DbPointer),
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct GetField {
     pub field: String,
     pub input: Box<Expression>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct SetField {
     pub field: String,
     pub input: Box<Expression>,
     pub value: Box<Expression>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct UnsetField {
     pub field: String,
     pub input: Box<Expression>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct
     FieldRef {
     pub parent: Option<Box<FieldRef>>,
     pub name: String,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct SwitchCase {
     pub case: Box<Expression>,
     pub then: Box<Expression>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct Switch {
     pub branches: Vec<SwitchCase>,
     pub default: Box<Expression>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct Let {
     pub vars: Vec<LetVariable>,
     pub inside: Box<Expression>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct SqlConvert {
     pub input: Box<Expression>,
     pub to: SqlConvertTargetType,
@@ -557,7 +558,7 @@ pub struct SqlConvert {
     pub on_error: Box<Expression>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct Convert {
     pub input: Box<Expression>,
     pub to: Type,
@@ -565,21 +566,21 @@ pub struct Convert {
     pub on_error: Box<Expression>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct Like {
     pub expr: Box<Expression>,
     pub pattern: Box<Expression>,
     pub escape: Option<char>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct Subquery {
     pub let_bindings: Vec<LetVariable>,
     pub output_path: Vec<String>,
     pub pipeline: Box<Stage>,
 }
 
-#[derive(PartialEq, Eq, Debug, Clone)]
+#[derive(PartialEq, Eq, Debug, Clone, Serialize)]
 pub enum SubqueryComparisonOp {
     Lt,
     Lte,
@@ -589,19 +590,19 @@ pub enum SubqueryComparisonOp {
     Gte,
 }
 
-#[derive(PartialEq, Eq, Debug, Clone, Copy)]
+#[derive(PartialEq, Eq, Debug, Clone, Copy, Serialize)]
 pub enum SubqueryComparisonOpType {
     Mql,
     Sql,
 }
 
-#[derive(PartialEq, Eq, Debug, Clone, Copy)]
+#[derive(PartialEq, Eq, Debug, Clone, Copy, Serialize)]
 pub enum SubqueryModifier {
     Any,
     All,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct SubqueryComparison {
     pub op: SubqueryComparisonOp,
     pub op_type: SubqueryComparisonOpType,
@@ -610,14 +611,14 @@ pub struct SubqueryComparison {
     pub subquery: Box<Subquery>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct SubqueryExists {
     pub let_bindings: Vec<LetVariable>,
     pub pipeline: Box<Stage>,
 }
 
 #[allow(dead_code)]
-#[derive(PartialEq, Eq, Debug, Clone)]
+#[derive(PartialEq, Eq, Debug, Clone, Serialize)]
 pub enum TypeOrMissing {
     Missing,
     Number,
@@ -635,33 +636,33 @@ impl TypeOrMissing {
     }
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct Is {
     pub expr: Box<Expression>,
     pub target_type: TypeOrMissing,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct Variable {
     pub parent: Option<Box<Variable>>,
     pub name: String,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct RegexMatch {
     pub input: Box<Expression>,
     pub regex: Box<Expression>,
     pub options: Option<Box<Expression>>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct SqlDivide {
     pub dividend: Box<Expression>,
     pub divisor: Box<Expression>,
     pub on_error: Box<Expression>
 }
 
-#[derive(PartialEq, Eq, Debug, Clone, Copy)]
+#[derive(PartialEq, Eq, Debug, Clone, Copy, Serialize)]
 pub enum DatePart {
     Year,
     Quarter,
@@ -674,21 +675,21 @@ pub enum DatePart {
     Millisecond,
 }
 
-#[derive(PartialEq, Eq, Debug, Clone)]
+#[derive(PartialEq, Eq, Debug, Clone, Serialize)]
 pub enum DateFunction {
     Add,
     Diff,
     Trunc,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct DateFunctionApplication{
     pub function: DateFunction,
     pub unit: DatePart,
     pub args: Vec<Expression>,
 }
 
-#[derive(PartialEq, Eq, Debug, Clone, Copy)]
+#[derive(PartialEq, Eq, Debug, Clone, Copy, Serialize)]
 pub enum TrimOperator {
     Trim,
     LTrim,
@@ -696,35 +697,35 @@ pub enum TrimOperator {
 }
 
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct Trim {
     pub op: TrimOperator,
     pub input: Box<Expression>,
     pub chars: Box<Expression>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct Map {
     pub input: Box<Expression>,
     pub as_name: Option<String>,
     pub inside: Box<Expression>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct Filter {
     pub input: Box<Expression>,
     pub as_name: Option<String>,
     pub inside: Box<Expression>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct Reduce {
     pub input: Box<Expression>,
     pub init_value: Box<Expression>,
     pub inside: Box<Expression>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub enum MatchQuery {
     Or(Vec<MatchQuery>),
     And(Vec<MatchQuery>),
@@ -737,33 +738,33 @@ pub enum MatchQuery {
     False,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct MatchLanguageType {
     pub input: Option<FieldRef>,
     pub target_type: TypeOrMissing,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct MatchLanguageRegex {
     pub input: Option<FieldRef>,
     pub regex: String,
     pub options: String,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct ElemMatch {
     pub input: FieldRef,
     pub condition: Box<MatchQuery>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct MatchLanguageComparison {
     pub function: MatchLanguageComparisonOp,
     pub input: Option<FieldRef>,
     pub arg: LiteralValue,
 }
 
-#[derive(PartialEq, Eq, Debug, Clone, Copy)]
+#[derive(PartialEq, Eq, Debug, Clone, Copy, Serialize)]
 pub enum MatchLanguageComparisonOp {
     Lt,
     Lte,
@@ -773,13 +774,13 @@ pub enum MatchLanguageComparisonOp {
     Gte,
 }
 
-#[derive(PartialEq, Eq, Debug, Clone, Copy)]
+#[derive(PartialEq, Eq, Debug, Clone, Copy, Serialize)]
 pub enum MatchLanguageInOp {
     In,
     NotIn,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 /// Match language `$in` / `$nin` operator applied to a field path.
 ///
 /// Emits `{ <field>: { $in: [<values>] } }` for `In` and
@@ -366,35 +366,234 @@ impl<'a> Algebrizer<'a> {
         })?
     }
 
-    pub fn algebrize_select_query(&self, ast_node: ast::SelectQuery) -> Result<mir::Stage> {
-        let plan = self.algebrize_from_clause(ast_node.from_clause)?;
-        let plan = self.algebrize_where_clause(ast_node.where_clause, plan)?;
-        let plan = self.algebrize_group_by_clause(ast_node.group_by_clause, plan)?;
-        let plan = self.algebrize_having_clause(ast_node.having_clause, plan)?;
-        let plan = if self.allow_order_by_missing_columns
-            && ast_node.select_clause.set_quantifier != ast::SetQuantifier::Distinct
-        {
-            self.algebrize_select_and_order_by_clause(
-                ast_node.select_clause,
-                ast_node.order_by_clause,
-                plan,
-            )?
-        } else {
-            let plan = self.algebrize_select_clause(ast_node.select_clause, plan, false)?;
-            self.algebrize_order_by_clause(ast_node.order_by_clause, plan)?
-        };
+    pub fn algebrize_select_query(&self, mut ast_node: ast::SelectQuery) -> Result<mir::Stage> {
+        let plan = self.algebrize_from_clause(ast_node.from_clause.take())?;
+        let plan = self.algebrize_where_clause(ast_node.where_clause.take(), plan)?;
+        let plan = self.algebrize_group_by_clause(ast_node.group_by_clause.take(), plan)?;
+        let plan = self.algebrize_having_clause(ast_node.having_clause.take(), plan)?;
+        self.algebrize_select_query_tail(ast_node, plan)
+    }
+
+    /// Algebrizes the SELECT, ORDER BY, OFFSET and LIMIT clauses of a query on top of `plan`, the
+    /// plan for its other clauses, which are ignored.
+    fn algebrize_select_query_tail(
+        &self,
+        ast_node: ast::SelectQuery,
+        plan: mir::Stage,
+    ) -> Result<mir::Stage> {
+        let plan =
+            self.algebrize_select_and_sort(ast_node.select_clause, ast_node.order_by_clause, plan)?;
         let plan = self.algebrize_offset_clause(ast_node.offset, plan)?;
         let plan = self.algebrize_limit_clause(ast_node.limit, plan)?;
         Ok(plan)
     }
 
+    fn algebrize_select_and_sort(
+        &self,
+        select_clause: ast::SelectClause,
+        order_by_clause: Option<ast::OrderByClause>,
+        plan: mir::Stage,
+    ) -> Result<mir::Stage> {
+        if self.allow_order_by_missing_columns
+            && select_clause.set_quantifier != ast::SetQuantifier::Distinct
+        {
+            self.algebrize_select_and_order_by_clause(select_clause, order_by_clause, plan)
+        } else {
+            let plan = self.algebrize_select_clause(select_clause, plan, false)?;
+            self.algebrize_order_by_clause(order_by_clause, plan)
+        }
+    }
+
+    /// Algebrizes a query like algebrize_query, but if the query has an error, checks the rest of
+    /// it wherever it can still be algebrized, and returns every error found. An error in a WHERE
+    /// or HAVING clause does not stop the clauses after it from being checked, since a filter
+    /// does not change what those clauses can reference, and an error in one side of a set
+    /// operation does not stop the other side from being checked. Each item of a SELECT list and
+    /// each key of an ORDER BY clause is checked on its own. An error in a FROM or GROUP BY clause
+    /// stops the checking of its query, since the clauses after it reference the names it
+    /// defines. Since algebrize_query consumes ast_node, the syntax tree checked after an error
+    /// is the one returned by rebuild_ast, which must be equal to ast_node. If it returns None,
+    /// only the first error is returned.
+    pub fn algebrize_query_collecting_errors(
+        &self,
+        ast_node: ast::Query,
+        rebuild_ast: impl FnOnce() -> Option<ast::Query>,
+    ) -> std::result::Result<mir::Stage, Vec<Error>> {
+        // checking every clause is only worth its cost for queries that have errors, so the
+        // syntax tree is rebuilt for them rather than copied for every query
+        self.algebrize_query(ast_node).map_err(|e| {
+            match rebuild_ast().map(|ast_node| self.algebrize_query_checking_each_clause(ast_node))
+            {
+                Some(Err(errors)) if !errors.is_empty() => errors,
+                _ => vec![e],
+            }
+        })
+    }
+
+    fn algebrize_query_checking_each_clause(
+        &self,
+        ast_node: ast::Query,
+    ) -> std::result::Result<mir::Stage, Vec<Error>> {
+        match ast_node {
+            ast::Query::Select(q) => self.algebrize_select_query_checking_each_clause(*q),
+            ast::Query::Set(s) => {
+                let left = self.algebrize_query_checking_each_clause(*s.left);
+                let right = self.algebrize_query_checking_each_clause(*s.right);
+                match (left, right) {
+                    (Ok(left), Ok(right)) => self
+                        .algebrize_set_operation(s.op, left, right)
+                        .map_err(|e| vec![e]),
+                    (left, right) => Err(left
+                        .err()
+                        .into_iter()
+                        .chain(right.err())
+                        .flatten()
+                        .collect()),
+                }
+            }
+            ast::Query::With(_) => panic!("WITH should be removed before algebrizing"),
+        }
+    }
+
+    fn algebrize_select_query_checking_each_clause(
+        &self,
+        mut ast_node: ast::SelectQuery,
+    ) -> std::result::Result<mir::Stage, Vec<Error>> {
+        // each error is located at the clause, SELECT list item or ORDER BY key it was found in
+        let spans = ast_node.spans.take();
+        let select_span = spans.as_ref().map(|s| s.select_clause);
+        let mut errors = Vec::new();
+        let plan = self
+            .algebrize_from_clause(ast_node.from_clause.take())
+            .map_err(|e| vec![e.located_at(spans.as_ref().and_then(|s| s.from_clause))])?;
+        let plan = match ast_node.where_clause.take() {
+            None => plan,
+            where_clause => self
+                .algebrize_where_clause(where_clause, plan.clone())
+                .unwrap_or_else(|e| {
+                    errors.push(e.located_at(spans.as_ref().and_then(|s| s.where_clause)));
+                    plan
+                }),
+        };
+        let plan = match self.algebrize_group_by_clause(ast_node.group_by_clause.take(), plan) {
+            Ok(plan) => plan,
+            Err(e) => {
+                errors.push(e.located_at(spans.as_ref().and_then(|s| s.group_by_clause)));
+                return Err(errors);
+            }
+        };
+        let plan = match ast_node.having_clause.take() {
+            None => plan,
+            having_clause => self
+                .algebrize_having_clause(having_clause, plan.clone())
+                .unwrap_or_else(|e| {
+                    errors.push(e.located_at(spans.as_ref().and_then(|s| s.having_clause)));
+                    plan
+                }),
+        };
+        match self.algebrize_select_query_tail(ast_node.clone(), plan.clone()) {
+            Ok(plan) if errors.is_empty() => Ok(plan),
+            Ok(_) => Err(errors),
+            Err(e) => {
+                let tail_errors = self.select_and_sort_errors(
+                    ast_node.select_clause,
+                    ast_node.order_by_clause,
+                    spans.as_ref(),
+                    plan,
+                );
+                if tail_errors.is_empty() {
+                    errors.push(e.located_at(select_span));
+                } else {
+                    errors.extend(tail_errors);
+                }
+                Err(errors)
+            }
+        }
+    }
+
+    /// Returns the errors found by algebrizing each item of a SELECT list on its own, or, if
+    /// the SELECT list is valid, by algebrizing each ORDER BY key on its own. An error that only
+    /// arises from the combination of several items or keys is not found this way, so the
+    /// returned errors are empty if the SELECT list and ORDER BY clause are valid on their own.
+    /// Each error is located at the span of its item or key in spans. The rewrites can split an
+    /// item of a SELECT VALUE list into several, so when the items no longer match their spans,
+    /// the errors of the SELECT list are located at the whole list.
+    fn select_and_sort_errors(
+        &self,
+        select_clause: ast::SelectClause,
+        order_by_clause: Option<ast::OrderByClause>,
+        spans: Option<&ast::SelectQuerySpans>,
+        plan: mir::Stage,
+    ) -> Vec<Error> {
+        if let Err(e) = self.algebrize_select_and_sort(select_clause.clone(), None, plan.clone()) {
+            let select_span = spans.map(|s| s.select_clause);
+            let items = split_select_clause(select_clause);
+            let item_spans = match spans {
+                Some(s) if s.select_items.len() == items.len() => {
+                    s.select_items.iter().copied().map(Some).collect()
+                }
+                _ => vec![select_span; items.len()],
+            };
+            let item_errors: Vec<Error> = items
+                .into_iter()
+                .zip(item_spans)
+                .filter_map(|(item, span)| {
+                    self.algebrize_select_and_sort(item, None, plan.clone())
+                        .err()
+                        .map(|e| e.located_at(span))
+                })
+                .collect();
+            return if item_errors.is_empty() {
+                vec![e.located_at(select_span)]
+            } else {
+                item_errors
+            };
+        }
+        let sort_specs: Vec<ast::SortSpec> = order_by_clause
+            .into_iter()
+            .flat_map(|o| o.sort_specs)
+            .collect();
+        let key_spans = match spans {
+            Some(s) if s.sort_keys.len() == sort_specs.len() => {
+                s.sort_keys.iter().copied().map(Some).collect()
+            }
+            _ => vec![None; sort_specs.len()],
+        };
+        sort_specs
+            .into_iter()
+            .zip(key_spans)
+            .filter_map(|(sort_spec, span)| {
+                self.algebrize_select_and_sort(
+                    select_clause.clone(),
+                    Some(ast::OrderByClause {
+                        sort_specs: vec![sort_spec],
+                    }),
+                    plan.clone(),
+                )
+                .err()
+                .map(|e| e.located_at(span))
+            })
+            .collect()
+    }
+
     pub fn algebrize_set_query(&self, ast_node: ast::SetQuery) -> Result<mir::Stage> {
-        match ast_node.op {
+        let left = self.algebrize_query(*ast_node.left)?;
+        let right = self.algebrize_query(*ast_node.right)?;
+        self.algebrize_set_operation(ast_node.op, left, right)
+    }
+
+    fn algebrize_set_operation(
+        &self,
+        op: ast::SetOperator,
+        left: mir::Stage,
+        right: mir::Stage,
+    ) -> Result<mir::Stage> {
+        match op {
             ast::SetOperator::Union => {
                 let union_all_stage = mir::Stage::Set(mir::Set {
                     operation: mir::SetOperation::UnionAll,
-                    left: Box::new(self.algebrize_query(*ast_node.left)?),
-                    right: Box::new(self.algebrize_query(*ast_node.right)?),
+                    left: Box::new(left),
+                    right: Box::new(right),
                     cache: SchemaCache::new(),
                 });
 
@@ -456,8 +655,8 @@ impl<'a> Algebrizer<'a> {
                 self,
                 mir::Stage::Set(mir::Set {
                     operation: mir::SetOperation::UnionAll,
-                    left: Box::new(self.algebrize_query(*ast_node.left)?),
-                    right: Box::new(self.algebrize_query(*ast_node.right)?),
+                    left: Box::new(left),
+                    right: Box::new(right),
                     cache: SchemaCache::new(),
                 })
             ),
@@ -682,6 +881,7 @@ impl<'a> Algebrizer<'a> {
     }
 
     fn algebrize_collection_datasource(&self, c: ast::CollectionSource) -> Result<mir::Stage> {
+        let span = c.span;
         let src = mir::Stage::Collection(mir::Collection {
             db: c.database.unwrap_or_else(|| self.current_db.to_string()),
             collection: c.collection.clone(),
@@ -703,7 +903,10 @@ impl<'a> Algebrizer<'a> {
             }
             None => panic!("collection datasources must have aliases"),
         };
-        stage.schema(&self.schema_inference_state())?;
+        // errors about a collection, such as a missing collection, are located at it
+        stage
+            .schema(&self.schema_inference_state())
+            .map_err(|e| Error::from(e).located_at(span))?;
         Ok(stage)
     }
 
@@ -2523,6 +2726,38 @@ impl<'a> Algebrizer<'a> {
     }
 }
 
+/// Splits a SELECT clause into one clause per item of its SELECT list. The rewrites turn the
+/// aliased expressions of a standard SELECT list into the pairs of document expressions, so each
+/// pair of a document expression is also split into its own item.
+fn split_select_clause(select_clause: ast::SelectClause) -> Vec<ast::SelectClause> {
+    let set_quantifier = select_clause.set_quantifier;
+    let exprs = match select_clause.body {
+        ast::SelectBody::Values(exprs) => exprs,
+        body => {
+            return vec![ast::SelectClause {
+                set_quantifier,
+                body,
+            }]
+        }
+    };
+    exprs
+        .into_iter()
+        .flat_map(|expr| match expr {
+            ast::SelectValuesExpression::Expression(ast::Expression::Document(pairs)) => pairs
+                .into_iter()
+                .map(|pair| {
+                    ast::SelectValuesExpression::Expression(ast::Expression::Document(vec![pair]))
+                })
+                .collect(),
+            expr => vec![expr],
+        })
+        .map(|expr| ast::SelectClause {
+            set_quantifier,
+            body: ast::SelectBody::Values(vec![expr]),
+        })
+        .collect()
+}
+
 #[cfg(test)]
 mod in_operator_nullability {
 
@@ -42,6 +42,35 @@ pub enum Error {
     InvalidUnwindPath,
     InvalidCast(ast::Type),
     InvalidSortKey(mir::Expression),
+    // Located wraps an error found in the part of the query at span, so that the error can be
+    // reported along with its position. It has the code and messages of the error it wraps.
+    Located {
+        span: ast::Span,
+        error: Box<Error>,
+    },
+}
+
+impl Error {
+    /// Returns this error located at span, if span is known. An error that is already located
+    /// keeps its location, which is that of a smaller part of the query.
+    pub(crate) fn located_at(self, span: Option<ast::Span>) -> Self {
+        match (self, span) {
+            (Error::Located { span, error }, _) => Error::Located { span, error },
+            (error, Some(span)) => Error::Located {
+                span,
+                error: Box::new(error),
+            },
+            (error, None) => error,
+        }
+    }
+
+    /// Returns the span of the part of the query in which this error was found, if it is known.
+    pub fn span(&self) -> Option<ast::Span> {
+        match self {
+            Error::Located { span, .. } => Some(*span),
+            _ => None,
+        }
+    }
 }
 
 impl From<mir::schema::Error> for Error {
@@ -86,6 +115,7 @@ impl UserError for Error {
             Error::InvalidUnwindPath => 3029,
             Error::InvalidCast(_) => 3030,
             Error::InvalidSortKey(_) => 3034,
+            Error::Located { error, .. } => error.code(),
         }
     }
 
@@ -166,6 +196,7 @@ impl UserError for Error {
             Error::InvalidSortKey(_) => {
                 Some("expressions are not allowed in sort key field paths".to_string())
             }
+            Error::Located { error, .. } => error.user_message(),
         }
     }
 
@@ -199,6 +230,7 @@ impl UserError for Error {
             Error::InvalidCast(ast_type) => format!("invalid CAST target type '{ast_type:?}'"),
             Error::InvalidSortKey(e) =>
                 format!("sort key field path must be a pure field path with no expressions in this context. found {e:?}"),
+            Error::Located { error, .. } => error.technical_message(),
         }
     }
 }
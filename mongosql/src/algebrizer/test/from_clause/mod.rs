@@ -63,6 +63,7 @@ test_algebrize!(
         database: None,
         collection: "foo".into(),
         alias: Some("bar".into()),
+        span: None,
     })),
     catalog = catalog(vec![("test", "foo")]),
 );
@@ -86,6 +87,7 @@ test_algebrize!(
         database: Some("test2".into()),
         collection: "foo".into(),
         alias: Some("bar".into()),
+        span: None,
     })),
     catalog = catalog(vec![("test2", "foo")]),
 );
@@ -109,6 +111,7 @@ test_algebrize!(
         database: Some("test2".into()),
         collection: "foo.bar".into(),
         alias: Some("foo.bar".into()),
+        span: None,
     })),
     catalog = catalog(vec![("test2", "foo.bar")]),
 );
@@ -132,6 +135,7 @@ test_algebrize!(
         database: Some("test2".into()),
         collection: "$foo".into(),
         alias: Some("$foo".into()),
+        span: None,
     })),
     catalog = catalog(vec![("test2", "$foo")]),
 );
@@ -549,6 +553,7 @@ test_algebrize!(
             order_by_clause: None,
             limit: None,
             offset: None,
+            spans: None,
         }))),
         alias: "d".into(),
     })),
@@ -630,6 +635,7 @@ test_algebrize!(
             order_by_clause: None,
             limit: None,
             offset: None,
+            spans: None,
         }))),
         alias: "d".into(),
     })),
@@ -728,6 +734,7 @@ test_algebrize!(
             order_by_clause: None,
             limit: None,
             offset: None,
+            spans: None,
         }))),
         alias: "d".into(),
     })),
@@ -873,6 +880,7 @@ test_algebrize!(
             order_by_clause: None,
             limit: None,
             offset: None,
+            spans: None,
         }))),
         alias: "d".into(),
     })),
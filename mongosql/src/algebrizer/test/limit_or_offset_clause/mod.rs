@@ -62,7 +62,8 @@ test_algebrize!(
         having_clause: None,
         order_by_clause: None,
         limit: Some(10_u32),
-        offset: Some(3_u32)
+        offset: Some(3_u32),
+        spans: None,
     },
     catalog = catalog(vec![("test", "foo")]),
 );
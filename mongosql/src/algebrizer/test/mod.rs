@@ -212,6 +212,7 @@ lazy_static! {
         database: Some("test".into()),
         collection: "foo".into(),
         alias: Some("foo".into()),
+        span: None,
     });
     static ref AST_QUERY_FOO: ast::Query = ast::Query::Select(Box::new(ast::SelectQuery {
         select_clause: ast::SelectClause {
@@ -225,11 +226,13 @@ lazy_static! {
         order_by_clause: None,
         limit: None,
         offset: None,
+        spans: None,
     }));
     static ref AST_SOURCE_BAR: Datasource = Datasource::Collection(CollectionSource {
         database: Some("test".into()),
         collection: "bar".into(),
         alias: Some("bar".into()),
+        span: None,
     });
     static ref AST_QUERY_BAR: ast::Query = ast::Query::Select(Box::new(ast::SelectQuery {
         select_clause: ast::SelectClause {
@@ -243,5 +246,6 @@ lazy_static! {
         order_by_clause: None,
         limit: None,
         offset: None,
+        spans: None,
     }));
 }
@@ -107,6 +107,7 @@ test_algebrize!(
             order_by_clause: None,
             limit: None,
             offset: None,
+            spans: None,
         }))),
         op: ast::SetOperator::Union,
         right: Box::new(ast::Query::Select(Box::new(ast::SelectQuery {
@@ -132,6 +133,7 @@ test_algebrize!(
             order_by_clause: None,
             limit: None,
             offset: None,
+            spans: None,
         }))),
     },
     env = map! {
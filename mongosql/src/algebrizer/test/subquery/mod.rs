@@ -66,6 +66,7 @@ test_algebrize!(
         order_by_clause: None,
         limit: None,
         offset: None,
+        spans: None,
     },)))),
 );
 test_algebrize!(
@@ -102,6 +103,7 @@ test_algebrize!(
         order_by_clause: None,
         limit: None,
         offset: None,
+        spans: None,
     },)))),
     env = map! {
         ("foo", 1u16).into() => Schema::Document( Document {
@@ -159,6 +161,7 @@ test_algebrize!(
         order_by_clause: None,
         limit: None,
         offset: None,
+        spans: None,
     },)))),
 );
 test_algebrize!(
@@ -204,6 +207,7 @@ test_algebrize!(
         order_by_clause: None,
         limit: None,
         offset: None,
+        spans: None,
     },)))),
 );
 test_algebrize!(
@@ -248,6 +252,7 @@ test_algebrize!(
         order_by_clause: None,
         limit: None,
         offset: None,
+        spans: None,
     },)))),
 );
 test_algebrize!(
@@ -292,6 +297,7 @@ test_algebrize!(
         order_by_clause: None,
         limit: None,
         offset: None,
+        spans: None,
     },)))),
     env = map! {
         ("foo", 1u16).into() => Schema::Document( Document {
@@ -325,6 +331,7 @@ test_algebrize!(
         order_by_clause: None,
         limit: None,
         offset: None,
+        spans: None,
     },)))),
 );
 test_algebrize!(
@@ -363,6 +370,7 @@ test_algebrize!(
         order_by_clause: None,
         limit: None,
         offset: None,
+        spans: None,
     },)))),
 );
 test_algebrize!(
@@ -398,6 +406,7 @@ test_algebrize!(
         order_by_clause: None,
         limit: None,
         offset: None,
+        spans: None,
     },)))),
 );
 test_algebrize!(
@@ -425,6 +434,7 @@ test_algebrize!(
         order_by_clause: None,
         limit: None,
         offset: None,
+        spans: None,
     },)))),
 );
 test_algebrize!(
@@ -451,6 +461,7 @@ test_algebrize!(
         order_by_clause: None,
         limit: None,
         offset: None,
+        spans: None,
     },)))),
 );
 test_algebrize!(
@@ -481,6 +492,7 @@ test_algebrize!(
         order_by_clause: None,
         limit: None,
         offset: None,
+        spans: None,
     },)))),
 );
 test_algebrize!(
@@ -537,6 +549,7 @@ test_algebrize!(
             order_by_clause: None,
             limit: None,
             offset: None,
+            spans: None,
         },)))
     }),
 );
@@ -592,6 +605,7 @@ test_algebrize!(
             order_by_clause: None,
             limit: None,
             offset: None,
+            spans: None,
         },)))
     }),
 );
@@ -649,6 +663,7 @@ test_algebrize!(
             order_by_clause: None,
             limit: None,
             offset: None,
+            spans: None,
         },)))
     }),
 );
@@ -725,6 +740,7 @@ test_algebrize!(
             order_by_clause: None,
             limit: None,
             offset: None,
+            spans: None,
         },)))
     }),
 );
@@ -784,6 +800,7 @@ test_algebrize!(
             order_by_clause: None,
             limit: None,
             offset: None,
+            spans: None,
         },)))
     }),
     env = map! {
@@ -828,6 +845,7 @@ test_algebrize!(
             order_by_clause: None,
             limit: None,
             offset: None,
+            spans: None,
         },)))
     }),
 );
@@ -888,6 +906,7 @@ test_algebrize!(
             database: None,
             collection: "bar".to_string(),
             alias: Some("bar".to_string()),
+            span: None,
         })),
         where_clause: None,
         group_by_clause: None,
@@ -895,6 +914,7 @@ test_algebrize!(
         order_by_clause: None,
         limit: Some(1),
         offset: None,
+        spans: None,
     })))),
     catalog = catalog(vec![("test", "bar")]),
 );
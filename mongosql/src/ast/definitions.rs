@@ -1,3 +1,5 @@
+use crate::result::Position;
+use serde::{Deserialize, Serialize};
 use variant_count::VariantCount;
 
 #[macro_export]
@@ -16,7 +18,7 @@ macro_rules! multimap {
 
 visitgen::generate_visitors! {
 
-#[derive(PartialEq, Debug, Clone, VariantCount)]
+#[derive(PartialEq, Debug, Clone, VariantCount, Serialize, Deserialize)]
 pub enum Query {
     Select(Box<SelectQuery>),
     Set(SetQuery),
@@ -24,19 +26,19 @@ pub enum Query {
     With(Box<WithQuery>),
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize, Deserialize)]
 pub struct WithQuery {
     pub queries: Vec<NamedQuery>,
     pub body: Box<Query>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize, Deserialize)]
 pub struct NamedQuery {
     pub name: String,
     pub query: Query,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(Debug, Clone, Serialize, Deserialize)]
 pub struct SelectQuery {
     pub select_clause: SelectClause,
     pub from_clause: Option<Datasource>,
@@ -46,53 +48,57 @@ pub struct SelectQuery {
     pub order_by_clause: Option<OrderByClause>,
     pub limit: Option<u32>,
     pub offset: Option<u32>,
+    // spans holds the locations of the clauses of the query, if it was parsed from a query. It
+    // is ignored when comparing queries.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub spans: Option<SelectQuerySpans>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize, Deserialize)]
 pub struct SetQuery {
     pub left: Box<Query>,
     pub op: SetOperator,
     pub right: Box<Query>,
 }
 
-#[derive(PartialEq, Eq, Debug, Clone, Copy, VariantCount)]
+#[derive(PartialEq, Eq, Debug, Clone, Copy, VariantCount, Serialize, Deserialize)]
 pub enum SetOperator {
     Union,
     UnionAll,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize, Deserialize)]
 pub struct SelectClause {
     pub set_quantifier: SetQuantifier,
     pub body: SelectBody,
 }
 
-#[derive(PartialEq, Eq, Debug, Clone, Copy, VariantCount)]
+#[derive(PartialEq, Eq, Debug, Clone, Copy, VariantCount, Serialize, Deserialize)]
 pub enum SetQuantifier {
     All,
     Distinct,
 }
 
-#[derive(PartialEq, Debug, Clone, VariantCount)]
+#[derive(PartialEq, Debug, Clone, VariantCount, Serialize, Deserialize)]
 pub enum SelectBody {
     Standard(Vec<SelectExpression>),
     Values(Vec<SelectValuesExpression>),
 }
 
-#[derive(PartialEq, Debug, Clone, VariantCount)]
+#[derive(PartialEq, Debug, Clone, VariantCount, Serialize, Deserialize)]
 pub enum SelectValuesExpression {
     Expression(Expression),
     Substar(SubstarExpr),
 }
 
-#[derive(PartialEq, Debug, Clone, VariantCount)]
+#[derive(PartialEq, Debug, Clone, VariantCount, Serialize, Deserialize)]
 pub enum SelectExpression {
     Star,
     Substar(SubstarExpr),
     Expression(OptionallyAliasedExpr),
 }
 
-#[derive(PartialEq, Eq, Debug, Clone)]
+#[derive(PartialEq, Eq, Debug, Clone, Serialize, Deserialize)]
 pub struct SubstarExpr {
     pub datasource: String,
 }
@@ -108,7 +114,7 @@ where
     }
 }
 
-#[derive(PartialEq, Debug, Clone, VariantCount)]
+#[derive(PartialEq, Debug, Clone, VariantCount, Serialize, Deserialize)]
 pub enum Datasource {
     Array(ArraySource),
     Collection(CollectionSource),
@@ -137,7 +143,7 @@ impl Datasource {
             Datasource::Array(ArraySource { array: _, alias }) => {
                 self.check_alias(alias, aliases)?;
             }
-            Datasource::Collection(CollectionSource { database: _, collection: _, alias }) => {
+            Datasource::Collection(CollectionSource { database: _, collection: _, alias, span: _ }) => {
 
                 if let Some(alias) = alias {
                     self.check_alias(alias, aliases)?;
@@ -163,32 +169,36 @@ impl Datasource {
         Ok(())
     }
 }
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize, Deserialize)]
 pub struct ArraySource {
     pub array: Vec<Expression>,
     pub alias: String,
 }
 
-#[derive(PartialEq, Eq, Debug, Clone)]
+#[derive(Debug, Clone, Serialize, Deserialize)]
 pub struct CollectionSource {
     pub database: Option<String>,
     pub collection: String,
     pub alias: Option<String>,
+    // span is the location of the datasource, from its database to its alias, if it was
+    // parsed from a query. It is ignored when comparing datasources.
+    #[serde(default, skip_serializing_if = "Option::is_none")]
+    pub span: Option<Span>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize, Deserialize)]
 pub struct DerivedSource {
     pub query: Box<Query>,
     pub alias: String,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize, Deserialize)]
 pub struct AliasedExpr {
     pub expr: Expression,
     pub alias: String,
 }
 
-#[derive(PartialEq, Debug, Clone, VariantCount)]
+#[derive(PartialEq, Debug, Clone, VariantCount, Serialize, Deserialize)]
 pub enum OptionallyAliasedExpr {
     Aliased(AliasedExpr),
     Unaliased(Expression),
@@ -217,7 +227,7 @@ impl OptionallyAliasedExpr {
     }
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize, Deserialize)]
 pub struct JoinSource {
     pub join_type: JoinType,
     pub left: Box<Datasource>,
@@ -225,7 +235,7 @@ pub struct JoinSource {
     pub condition: Option<Expression>,
 }
 
-#[derive(PartialEq, Eq, Debug, Clone, Copy, VariantCount)]
+#[derive(PartialEq, Eq, Debug, Clone, Copy, VariantCount, Serialize, Deserialize)]
 pub enum JoinType {
     Left,
     Right,
@@ -233,51 +243,51 @@ pub enum JoinType {
     Inner,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize, Deserialize)]
 pub struct FlattenSource {
     pub datasource: Box<Datasource>,
     pub options: Vec<FlattenOption>,
 }
 
-#[derive(PartialEq, Eq, Debug, Clone, VariantCount)]
+#[derive(PartialEq, Eq, Debug, Clone, VariantCount, Serialize, Deserialize)]
 pub enum FlattenOption {
     Separator(String),
     Depth(u32),
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize, Deserialize)]
 pub struct UnwindSource {
     pub datasource: Box<Datasource>,
     pub options: Vec<UnwindOption>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize, Deserialize)]
 pub struct ExtendedUnwindSource {
     pub datasource: Box<Datasource>,
     pub options: Vec<ExtendedUnwindOption>,
 }
 
-#[derive(PartialEq, Debug, Clone, VariantCount)]
+#[derive(PartialEq, Debug, Clone, VariantCount, Serialize, Deserialize)]
 pub enum UnwindOption {
     Path(Expression),
     Index(String),
     Outer(bool),
 }
 
-#[derive(PartialEq, Debug, Clone, VariantCount)]
+#[derive(PartialEq, Debug, Clone, VariantCount, Serialize, Deserialize)]
 pub enum ExtendedUnwindOption {
     Paths(Vec<Vec<UnwindPathPart>>),
     Index(String),
     Outer(bool),
 }
 
-#[derive(PartialEq, Eq, Debug, Clone, VariantCount)]
+#[derive(PartialEq, Eq, Debug, Clone, VariantCount, Serialize, Deserialize)]
 pub enum UnwindPathPartOption {
     Index(String),
     Outer(bool),
 }
 
-#[derive(PartialEq, Debug, Clone, VariantCount)]
+#[derive(PartialEq, Debug, Clone, VariantCount, Serialize, Deserialize)]
 pub enum Expression {
     Binary(BinaryExpr),
     Unary(UnaryExpr),
@@ -314,13 +324,13 @@ impl Expression {
     }
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize, Deserialize)]
 pub struct DocumentPair {
     pub key: String,
     pub value: Expression,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize, Deserialize)]
 pub struct CastExpr {
     pub expr: Box<Expression>,
     pub to: Type,
@@ -328,46 +338,46 @@ pub struct CastExpr {
     pub on_error: Option<Box<Expression>>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize, Deserialize)]
 pub struct BinaryExpr {
     pub left: Box<Expression>,
     pub op: BinaryOp,
     pub right: Box<Expression>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize, Deserialize)]
 pub struct UnaryExpr {
     pub op: UnaryOp,
     pub expr: Box<Expression>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize, Deserialize)]
 pub struct BetweenExpr {
     pub arg: Box<Expression>,
     pub min: Box<Expression>,
     pub max: Box<Expression>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize, Deserialize)]
 pub struct CaseExpr {
     pub expr: Option<Box<Expression>>,
     pub when_branch: Vec<WhenBranch>,
     pub else_branch: Option<Box<Expression>>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize, Deserialize)]
 pub struct WhenBranch {
     pub when: Box<Expression>,
     pub then: Box<Expression>,
 }
 
-#[derive(PartialEq, Eq, Debug, Clone, Copy, VariantCount)]
+#[derive(PartialEq, Eq, Debug, Clone, Copy, VariantCount, Serialize, Deserialize)]
 pub enum SubqueryQuantifier {
     All,
     Any,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize, Deserialize)]
 pub struct SubqueryComparisonExpr {
     pub expr: Box<Expression>,
     pub op: ComparisonOp,
@@ -375,21 +385,21 @@ pub struct SubqueryComparisonExpr {
     pub subquery: Box<Query>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize, Deserialize)]
 pub struct FunctionExpr {
     pub function: FunctionName,
     pub args: FunctionArguments,
     pub set_quantifier: Option<SetQuantifier>,
 }
 
-#[derive(PartialEq, Eq, Debug, Clone, VariantCount)]
+#[derive(PartialEq, Eq, Debug, Clone, VariantCount, Serialize, Deserialize)]
 pub enum DateFunctionName {
     Add,
     Diff,
     Trunc,
 }
 
-#[derive(PartialEq, Eq, Debug, Clone, Copy, VariantCount)]
+#[derive(PartialEq, Eq, Debug, Clone, Copy, VariantCount, Serialize, Deserialize)]
 pub enum DatePart {
     Year,
     Quarter,
@@ -406,27 +416,27 @@ pub enum DatePart {
     IsoWeekday,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize, Deserialize)]
 pub struct DateFunctionExpr {
     pub function: DateFunctionName,
     pub date_part: DatePart,
     pub args: Vec<Expression>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize, Deserialize)]
 pub struct ExtractExpr {
     pub extract_spec: DatePart,
     pub arg: Box<Expression>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize, Deserialize)]
 pub struct TrimExpr {
     pub trim_spec: TrimSpec,
     pub trim_chars: Box<Expression>,
     pub arg: Box<Expression>,
 }
 
-#[derive(PartialEq, Eq, Debug, Clone, Copy, VariantCount)]
+#[derive(PartialEq, Eq, Debug, Clone, Copy, VariantCount, Serialize, Deserialize)]
 pub enum FunctionName {
     // Aggregation functions.
     AddToArray,
@@ -763,7 +773,7 @@ impl FunctionName {
     }
 }
 
-#[derive(PartialEq, Debug, Clone, VariantCount)]
+#[derive(PartialEq, Debug, Clone, VariantCount, Serialize, Deserialize)]
 pub enum FunctionArguments {
     Star,
     Args(Vec<Expression>),
@@ -778,66 +788,66 @@ impl FunctionArguments {
     }
 }
 
-#[derive(PartialEq, Eq, Debug, Clone, Copy, VariantCount)]
+#[derive(PartialEq, Eq, Debug, Clone, Copy, VariantCount, Serialize, Deserialize)]
 pub enum TrimSpec {
     Leading,
     Trailing,
     Both,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize, Deserialize)]
 pub struct AccessExpr {
     pub expr: Box<Expression>,
     pub subfield: Box<Expression>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize, Deserialize)]
 pub struct SubpathExpr {
     pub expr: Box<Expression>,
     pub subpath: String,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize, Deserialize)]
 pub struct UnwindPathPart {
     pub field: String,
     pub options: Vec<Vec<UnwindPathPartOption>>,
 }
 
 
-#[derive(PartialEq, Eq, Debug, Clone, Copy, VariantCount)]
+#[derive(PartialEq, Eq, Debug, Clone, Copy, VariantCount, Serialize, Deserialize)]
 pub enum TypeOrMissing {
     Type(Type),
     Number,
     Missing,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize, Deserialize)]
 pub struct IsExpr {
     pub expr: Box<Expression>,
     pub target_type: TypeOrMissing,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize, Deserialize)]
 pub struct LikeExpr {
     pub expr: Box<Expression>,
     pub pattern: Box<Expression>,
     pub escape: Option<char>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize, Deserialize)]
 pub struct TypeAssertionExpr {
     pub expr: Box<Expression>,
     pub target_type: Type,
 }
 
-#[derive(PartialEq, Eq, Debug, Clone, Copy, VariantCount)]
+#[derive(PartialEq, Eq, Debug, Clone, Copy, VariantCount, Serialize, Deserialize)]
 pub enum UnaryOp {
     Pos,
     Neg,
     Not,
 }
 
-#[derive(PartialEq, Eq, Debug, Clone, Copy, VariantCount)]
+#[derive(PartialEq, Eq, Debug, Clone, Copy, VariantCount, Serialize, Deserialize)]
 pub enum BinaryOp {
     Add,
     And,
@@ -869,7 +879,7 @@ impl BinaryOp {
     }
 }
 
-#[derive(PartialEq, Eq, Debug, Clone, Copy, VariantCount)]
+#[derive(PartialEq, Eq, Debug, Clone, Copy, VariantCount, Serialize, Deserialize)]
 pub enum ComparisonOp {
     Eq,
     Gt,
@@ -893,36 +903,36 @@ impl ComparisonOp {
     }
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize, Deserialize)]
 pub struct GroupByClause {
     pub keys: Vec<OptionallyAliasedExpr>,
     pub aggregations: Vec<AliasedExpr>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize, Deserialize)]
 pub struct OrderByClause {
     pub sort_specs: Vec<SortSpec>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize, Deserialize)]
 pub struct SortSpec {
     pub key: SortKey,
     pub direction: SortDirection,
 }
 
-#[derive(PartialEq, Debug, Clone, VariantCount)]
+#[derive(PartialEq, Debug, Clone, VariantCount, Serialize, Deserialize)]
 pub enum SortKey {
     Simple(Expression),
     Positional(u32),
 }
 
-#[derive(PartialEq, Eq, Debug, Clone, Copy, VariantCount)]
+#[derive(PartialEq, Eq, Debug, Clone, Copy, VariantCount, Serialize, Deserialize)]
 pub enum SortDirection {
     Asc,
     Desc,
 }
 
-#[derive(PartialEq, Debug, Clone, VariantCount)]
+#[derive(PartialEq, Debug, Clone, VariantCount, Serialize, Deserialize)]
 pub enum Literal {
     Null,
     Boolean(bool),
@@ -931,7 +941,7 @@ pub enum Literal {
     Double(f64),
 }
 
-#[derive(PartialEq, Eq, Debug, Clone, Copy, VariantCount)]
+#[derive(PartialEq, Eq, Debug, Clone, Copy, VariantCount, Serialize, Deserialize)]
 pub enum Type {
     Array,
     BinData,
@@ -958,39 +968,39 @@ pub enum Type {
     Undefined,
 }
 
-#[derive(PartialEq, Debug, Clone, VariantCount)]
+#[derive(PartialEq, Debug, Clone, VariantCount, Serialize, Deserialize)]
 pub enum HigherOrderFunctionExpr {
   Map(MapExpr),
   Filter(FilterExpr),
   Reduce(ReduceExpr),
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize, Deserialize)]
 pub struct MapExpr {
   pub array: Box<Expression>,
   pub f: Box<FunctionArgument>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize, Deserialize)]
 pub struct FilterExpr {
   pub array: Box<Expression>,
   pub f: Box<FunctionArgument>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize, Deserialize)]
 pub struct ReduceExpr {
   pub array: Box<Expression>,
   pub init_value: Box<Expression>,
   pub f: Box<FunctionArgument>,
 }
 
-#[derive(PartialEq, Debug, Clone, VariantCount)]
+#[derive(PartialEq, Debug, Clone, VariantCount, Serialize, Deserialize)]
 pub enum FunctionArgument {
   Expr(Expression),
   NamedFunction(NamedFunction)
 }
 
-#[derive(PartialEq, Debug, Clone, VariantCount)]
+#[derive(PartialEq, Debug, Clone, VariantCount, Serialize, Deserialize)]
 pub enum NamedFunction {
   UnaryOp(UnaryOp),
   BinaryOp(BinaryOp),
@@ -998,3 +1008,63 @@ pub enum NamedFunction {
 }
 
 } // end of generate_visitors! block
+
+/// The location of a node in the query it was parsed from.
+#[derive(PartialEq, Eq, Debug, Clone, Copy, Serialize, Deserialize)]
+pub struct Span {
+    /// The position of the first character of the node
+    pub start: Position,
+    /// The position just past the last character of the node
+    pub end: Position,
+}
+
+impl Span {
+    /// Returns the Span of the text of sql from the byte offset start to the byte offset end.
+    pub fn new(sql: &str, start: usize, end: usize) -> Self {
+        Span {
+            start: Position::new(sql, start),
+            end: Position::new(sql, end),
+        }
+    }
+}
+
+/// The locations of the clauses of a SelectQuery in the query it was parsed from. The span of a
+/// clause covers its body, without its keywords. The SELECT list and ORDER BY clause also record
+/// the span of each of their items, in order.
+#[derive(PartialEq, Eq, Debug, Clone, Serialize, Deserialize)]
+pub struct SelectQuerySpans {
+    pub select_clause: Span,
+    pub select_items: Vec<Span>,
+    pub from_clause: Option<Span>,
+    pub where_clause: Option<Span>,
+    pub group_by_clause: Option<Span>,
+    pub having_clause: Option<Span>,
+    pub sort_keys: Vec<Span>,
+}
+
+// SelectQueries are compared without their spans, so that a parsed query is equal to the same
+// query built by hand.
+impl PartialEq for SelectQuery {
+    fn eq(&self, other: &Self) -> bool {
+        self.select_clause == other.select_clause
+            && self.from_clause == other.from_clause
+            && self.where_clause == other.where_clause
+            && self.group_by_clause == other.group_by_clause
+            && self.having_clause == other.having_clause
+            && self.order_by_clause == other.order_by_clause
+            && self.limit == other.limit
+            && self.offset == other.offset
+    }
+}
+
+// CollectionSources are compared without their spans, so that a parsed datasource is equal to
+// the same datasource built by hand.
+impl PartialEq for CollectionSource {
+    fn eq(&self, other: &Self) -> bool {
+        self.database == other.database
+            && self.collection == other.collection
+            && self.alias == other.alias
+    }
+}
+
+impl Eq for CollectionSource {}
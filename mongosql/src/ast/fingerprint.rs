@@ -0,0 +1,89 @@
+use crate::ast::{
+    format::{tokenize, TokenKind},
+    pretty_print::{Error, PrettyPrint},
+    visitor::Visitor,
+    visitors::map_identifiers,
+    Expression, Literal, Query, UnaryOp,
+};
+
+// Literals are replaced by an identifier with this name before the query is pretty printed.
+// When fingerprinting, identifiers are lowercased first, so the name cannot collide with an
+// identifier in the query.
+const LITERAL_PLACEHOLDER: &str = "__MONGOSQL_FINGERPRINT_LITERAL__";
+
+/// Returns the normalized text of the provided query, in which every literal is replaced by `?`
+/// and every identifier is lowercased. Queries that differ only in their literal values, the case
+/// of their identifiers and keywords, or their whitespace have the same normalized text. The
+/// normalized text is meant for grouping queries, and is not itself a valid query.
+pub fn normalize_query(query: Query) -> Result<String, Error> {
+    let query = map_identifiers(query, |identifier| identifier.to_lowercase());
+    let query = LiteralVisitor {
+        mask_null_and_boolean: true,
+    }
+    .visit_query(query);
+    print_with_placeholders(query)
+}
+
+/// Returns the pretty printed text of the provided query with every string and numeric literal,
+/// including the strings of timestamp literals, replaced by `?`. NULL, TRUE and FALSE are kept,
+/// as are identifiers, so the structure of the query remains readable without exposing its
+/// values. Like normalized text, redacted text is not itself a valid query.
+pub fn redact_query(query: Query) -> Result<String, Error> {
+    // Identifiers keep their case here, so an identifier spelled exactly like the literal
+    // placeholder is also printed as `?`. That can only hide information, never expose it.
+    let query = LiteralVisitor {
+        mask_null_and_boolean: false,
+    }
+    .visit_query(query);
+    print_with_placeholders(query)
+}
+
+/// Pretty prints a query in which literals have been replaced by the literal placeholder,
+/// printing each placeholder as `?`.
+fn print_with_placeholders(query: Query) -> Result<String, Error> {
+    let pretty = query.pretty_print()?;
+
+    let mut printed = String::with_capacity(pretty.len());
+    for token in tokenize(&pretty) {
+        if token.space_before && !printed.is_empty() {
+            printed.push(' ');
+        }
+        if token.kind == TokenKind::Word && token.text == LITERAL_PLACEHOLDER {
+            printed.push('?');
+        } else {
+            printed.push_str(&token.text);
+        }
+    }
+    Ok(printed)
+}
+
+/// Replaces literals, including signed numeric literals, with the literal placeholder. NULL and
+/// boolean literals are only replaced if `mask_null_and_boolean` is set.
+struct LiteralVisitor {
+    mask_null_and_boolean: bool,
+}
+
+impl LiteralVisitor {
+    fn is_masked(&self, literal: &Literal) -> bool {
+        self.mask_null_and_boolean || !matches!(literal, Literal::Null | Literal::Boolean(_))
+    }
+}
+
+impl Visitor for LiteralVisitor {
+    fn visit_expression(&mut self, node: Expression) -> Expression {
+        match node {
+            Expression::Literal(ref l) if self.is_masked(l) => {
+                Expression::Identifier(LITERAL_PLACEHOLDER.to_string())
+            }
+            Expression::StringConstructor(_) => {
+                Expression::Identifier(LITERAL_PLACEHOLDER.to_string())
+            }
+            Expression::Unary(ref u)
+                if u.op != UnaryOp::Not && matches!(*u.expr, Expression::Literal(_)) =>
+            {
+                Expression::Identifier(LITERAL_PLACEHOLDER.to_string())
+            }
+            _ => node.walk(self),
+        }
+    }
+}
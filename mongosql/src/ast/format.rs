@@ -0,0 +1,307 @@
+use crate::ast::{
+    pretty_print::{identifier_to_string, Error, PrettyPrint},
+    visitors::map_identifiers,
+    Query,
+};
+
+/// The case used for keywords, function names and type names in formatted queries.
+#[derive(Debug, Clone, Copy, Default, PartialEq, Eq)]
+pub enum KeywordCase {
+    #[default]
+    Upper,
+    Lower,
+}
+
+/// Options that control the layout of formatted queries.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub struct FormatOptions {
+    pub keyword_case: KeywordCase,
+    /// The number of spaces by which subqueries and continuation lines are indented
+    pub indent: usize,
+    /// The width at which lines are wrapped when possible. Lines can only be wrapped between
+    /// tokens, so a line may exceed this width if it contains a long token.
+    pub line_width: usize,
+}
+
+impl Default for FormatOptions {
+    fn default() -> Self {
+        FormatOptions {
+            keyword_case: KeywordCase::Upper,
+            indent: 2,
+            line_width: 80,
+        }
+    }
+}
+
+// Identifiers are replaced by placeholders with this prefix before the query is pretty printed,
+// so that they can be told apart from keywords, function names and type names, which are all
+// case-insensitive and printed as bare words.
+const PLACEHOLDER_PREFIX: &str = "__mongosql_format_identifier_";
+
+/// Formats the provided query according to `options`. The formatted query is the pretty printed
+/// query with its keywords recased and its whitespace replaced by line breaks and indentation,
+/// so it parses to the same AST as the pretty printed query.
+pub fn format_query(query: Query, options: FormatOptions) -> Result<String, Error> {
+    let mut identifiers = Vec::new();
+    let query = map_identifiers(query, |identifier| {
+        identifiers.push(identifier);
+        format!("{PLACEHOLDER_PREFIX}{}", identifiers.len() - 1)
+    });
+    let pretty = query.pretty_print()?;
+
+    let tokens = tokenize(&pretty)
+        .into_iter()
+        .map(|mut token| {
+            if token.kind != TokenKind::Word {
+                return token;
+            }
+            match token.text.strip_prefix(PLACEHOLDER_PREFIX) {
+                Some(index) => {
+                    let index = index
+                        .parse::<usize>()
+                        .expect("placeholder suffix is an index");
+                    token.kind = TokenKind::Identifier;
+                    token.text = identifier_to_string(&identifiers[index]);
+                }
+                None => {
+                    token.text = match options.keyword_case {
+                        KeywordCase::Upper => token.text.to_uppercase(),
+                        KeywordCase::Lower => token.text.to_lowercase(),
+                    }
+                }
+            }
+            token
+        })
+        .collect::<Vec<_>>();
+
+    Ok(Layout::new(options).render(&tokens))
+}
+
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub(super) enum TokenKind {
+    /// A keyword, function name, type name or identifier placeholder
+    Word,
+    /// An identifier substituted for a placeholder
+    Identifier,
+    OpenParen,
+    CloseParen,
+    /// Any other token, such as a literal, a comma or an operator
+    Other,
+}
+
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub(super) struct Token {
+    pub(super) kind: TokenKind,
+    pub(super) text: String,
+    /// Whether the token was preceded by whitespace in the pretty printed query. Line breaks are
+    /// only inserted where there was whitespace, or around the parentheses of subqueries.
+    pub(super) space_before: bool,
+}
+
+/// Splits a pretty printed query into tokens. Quoted strings and delimited identifiers are kept
+/// whole, so their contents are never recased or broken across lines.
+pub(super) fn tokenize(s: &str) -> Vec<Token> {
+    let chars: Vec<char> = s.chars().collect();
+    let mut tokens = Vec::new();
+    let mut i = 0;
+    let mut space_before = false;
+    while i < chars.len() {
+        let c = chars[i];
+        let start = i;
+        let kind = match c {
+            c if c.is_whitespace() => {
+                space_before = true;
+                i += 1;
+                continue;
+            }
+            '(' | '[' | '{' => {
+                i += 1;
+                TokenKind::OpenParen
+            }
+            ')' | ']' | '}' => {
+                i += 1;
+                TokenKind::CloseParen
+            }
+            ',' => {
+                i += 1;
+                TokenKind::Other
+            }
+            '\'' | '`' | '"' => {
+                // a doubled delimiter is an escaped delimiter
+                i += 1;
+                while i < chars.len() {
+                    if chars[i] == c {
+                        if chars.get(i + 1) == Some(&c) {
+                            i += 2;
+                            continue;
+                        }
+                        i += 1;
+                        break;
+                    }
+                    i += 1;
+                }
+                TokenKind::Other
+            }
+            c if c.is_ascii_alphabetic() || c == '_' => {
+                while i < chars.len() && (chars[i].is_ascii_alphanumeric() || chars[i] == '_') {
+                    i += 1;
+                }
+                TokenKind::Word
+            }
+            c if c.is_ascii_digit() => {
+                while i < chars.len()
+                    && (chars[i].is_ascii_alphanumeric() || chars[i] == '.' || chars[i] == '_')
+                {
+                    i += 1;
+                }
+                TokenKind::Other
+            }
+            _ => {
+                while i < chars.len()
+                    && !chars[i].is_whitespace()
+                    && !chars[i].is_ascii_alphanumeric()
+                    && !"()[]{},'`\"_".contains(chars[i])
+                {
+                    i += 1;
+                }
+                TokenKind::Other
+            }
+        };
+        tokens.push(Token {
+            kind,
+            text: chars[start..i].iter().collect(),
+            space_before,
+        });
+        space_before = false;
+    }
+    tokens
+}
+
+/// Lays out tokens on lines. Each subquery is placed on its own lines, indented one level more
+/// than the enclosing query, and each clause of a query starts a new line. Lines longer than
+/// the line width are wrapped, and the wrapped lines are indented one level more than the query
+/// they belong to.
+struct Layout {
+    options: FormatOptions,
+    out: String,
+    column: usize,
+    /// Whether nothing but indentation has been written on the current line
+    line_empty: bool,
+    /// The indentation of the current query
+    indent: usize,
+    /// The number of open parentheses in the current query that do not enclose a subquery
+    nesting: usize,
+    parens: Vec<Paren>,
+}
+
+/// An open parenthesis. The layout state of the enclosing query is saved when a subquery starts
+/// and restored when it ends.
+struct Paren {
+    subquery: bool,
+    indent: usize,
+    nesting: usize,
+}
+
+impl Layout {
+    fn new(options: FormatOptions) -> Self {
+        Layout {
+            options,
+            out: String::new(),
+            column: 0,
+            line_empty: true,
+            indent: 0,
+            nesting: 0,
+            parens: Vec::new(),
+        }
+    }
+
+    fn render(mut self, tokens: &[Token]) -> String {
+        for (i, token) in tokens.iter().enumerate() {
+            match token.kind {
+                TokenKind::OpenParen => {
+                    let subquery = tokens.get(i + 1).map_or(false, |next| {
+                        next.kind == TokenKind::Word && is_query_start(&next.text)
+                    });
+                    self.push(token);
+                    self.parens.push(Paren {
+                        subquery,
+                        indent: self.indent,
+                        nesting: self.nesting,
+                    });
+                    if subquery {
+                        self.indent += self.options.indent;
+                        self.nesting = 0;
+                        self.newline(self.indent);
+                    } else {
+                        self.nesting += 1;
+                    }
+                }
+                TokenKind::CloseParen => {
+                    if let Some(paren) = self.parens.pop() {
+                        self.indent = paren.indent;
+                        self.nesting = paren.nesting;
+                        if paren.subquery {
+                            self.newline(self.indent);
+                        }
+                    }
+                    self.push(token);
+                }
+                TokenKind::Word if self.nesting == 0 && starts_clause(tokens, i) => {
+                    if !self.line_empty {
+                        self.newline(self.indent);
+                    }
+                    self.push(token);
+                }
+                _ => self.push(token),
+            }
+        }
+        self.out
+    }
+
+    fn newline(&mut self, indent: usize) {
+        self.out.push('\n');
+        self.out.push_str(&" ".repeat(indent));
+        self.column = indent;
+        self.line_empty = true;
+    }
+
+    /// Appends a token to the current line. If the token was preceded by whitespace and does
+    /// not fit on the current line, the line is wrapped first.
+    fn push(&mut self, token: &Token) {
+        let width = token.text.chars().count();
+        let continuation = self.indent + self.options.indent;
+        if !self.line_empty && token.space_before {
+            if self.column > continuation && self.column + 1 + width > self.options.line_width {
+                self.newline(continuation);
+            } else {
+                self.out.push(' ');
+                self.column += 1;
+            }
+        }
+        self.out.push_str(&token.text);
+        self.column += width;
+        self.line_empty = false;
+    }
+}
+
+/// Returns whether the word at index `i` starts a new clause of a query.
+fn starts_clause(tokens: &[Token], i: usize) -> bool {
+    let text = |i: Option<usize>| {
+        i.and_then(|i| tokens.get(i))
+            .filter(|t| t.kind == TokenKind::Word)
+            .map(|t| t.text.to_uppercase())
+            .unwrap_or_default()
+    };
+    let next = text(Some(i + 1));
+    match text(Some(i)).as_str() {
+        "FROM" | "WHERE" | "HAVING" | "LIMIT" | "OFFSET" | "UNION" => true,
+        "GROUP" | "ORDER" => next == "BY",
+        "LEFT" | "RIGHT" | "INNER" | "CROSS" => next == "JOIN",
+        "SELECT" => matches!(text(i.checked_sub(1)).as_str(), "UNION" | "ALL"),
+        _ => false,
+    }
+}
+
+fn is_query_start(word: &str) -> bool {
+    word.eq_ignore_ascii_case("SELECT") || word.eq_ignore_ascii_case("WITH")
+}
@@ -0,0 +1,137 @@
+use crate::{
+    ast::format::{format_query, FormatOptions, KeywordCase},
+    parser,
+};
+
+macro_rules! format_test {
+    ($func_name:ident, expected = $expected:expr, input = $input:expr) => {
+        format_test!(
+            $func_name,
+            expected = $expected,
+            input = $input,
+            options = FormatOptions::default()
+        );
+    };
+    ($func_name:ident, expected = $expected:expr, input = $input:expr, options = $options:expr) => {
+        #[test]
+        fn $func_name() {
+            let res = parser::parse_query($input).unwrap();
+            let out = format_query(res, $options).unwrap();
+            assert_eq!($expected, out);
+        }
+    };
+}
+
+const LOWER: FormatOptions = FormatOptions {
+    keyword_case: KeywordCase::Lower,
+    indent: 2,
+    line_width: 80,
+};
+
+mod clauses {
+    use super::*;
+    format_test!(
+        select_from_where,
+        expected = "SELECT a, b\nFROM foo\nWHERE a > 1",
+        input = "select a, b from foo where a > 1"
+    );
+    format_test!(
+        every_clause,
+        expected = "SELECT *\nFROM foo AS bar\nWHERE 1\nGROUP BY a, b AGGREGATE COUNT(*) AS agg1, SUM(a) AS agg2\nHAVING agg1 < agg2\nORDER BY agg1 ASC\nLIMIT 100\nOFFSET 10",
+        input = "SELECT * FROM foo bar WHERE 1 GROUP BY a, b AGGREGATE COUNT(*) AS agg1, SUM(a) as agg2 HAVING agg1 < agg2 ORDER BY agg1 LIMIT 100 OFFSET 10"
+    );
+    format_test!(
+        union_all,
+        expected = "SELECT a\nFROM foo\nUNION ALL\nSELECT b\nFROM bar",
+        input = "select a from foo union all select b from bar"
+    );
+    format_test!(
+        join,
+        expected = "SELECT *\nFROM foo AS f\nLEFT JOIN bar AS b ON f.a = b.a",
+        input = "select * from foo f left join bar b on f.a = b.a"
+    );
+    format_test!(
+        from_in_function_call_does_not_start_clause,
+        expected = "SELECT EXTRACT(YEAR FROM a)\nFROM foo",
+        input = "select extract(year from a) from foo"
+    );
+}
+
+mod keyword_case {
+    use super::*;
+    format_test!(
+        lower,
+        expected = "select a, b\nfrom foo\nwhere a > 1",
+        input = "SELECT a, b FROM foo WHERE a > 1",
+        options = LOWER
+    );
+    format_test!(
+        identifier_case_is_preserved,
+        expected = "select Foo, BAR\nfrom Baz",
+        input = "SELECT Foo, BAR FROM Baz",
+        options = LOWER
+    );
+    format_test!(
+        function_names,
+        expected = "select count(*), substring(a from 1 for 2)\nfrom foo",
+        input = "SELECT COUNT(*), SUBSTRING(a, 1, 2) FROM foo",
+        options = LOWER
+    );
+    format_test!(
+        keyword_identifier_stays_delimited,
+        expected = "select `select`\nfrom foo",
+        input = "SELECT `select` FROM foo",
+        options = LOWER
+    );
+    format_test!(
+        string_literal_is_unchanged,
+        expected = "select 'SELECT a FROM b'\nfrom foo",
+        input = "SELECT 'SELECT a FROM b' FROM foo",
+        options = LOWER
+    );
+}
+
+mod indentation {
+    use super::*;
+    format_test!(
+        derived_source,
+        expected = "SELECT *\nFROM (\n  SELECT a\n  FROM foo\n) AS sub",
+        input = "select * from (select a from foo) sub"
+    );
+    format_test!(
+        nested_subqueries,
+        expected = "SELECT *\nFROM (\n    SELECT *\n    FROM (\n        SELECT a\n        FROM foo\n    ) AS s1\n) AS s2",
+        input = "select * from (select * from (select a from foo) s1) s2",
+        options = FormatOptions {
+            indent: 4,
+            ..Default::default()
+        }
+    );
+    format_test!(
+        subquery_expression,
+        expected = "SELECT *\nFROM foo\nWHERE EXISTS(\n  SELECT *\n  FROM bar\n)",
+        input = "select * from foo where exists(select * from bar)"
+    );
+}
+
+mod line_width {
+    use super::*;
+    format_test!(
+        wraps_after_commas,
+        expected = "SELECT aaaa, bbbb,\n  cccc, dddd\nFROM foo",
+        input = "select aaaa, bbbb, cccc, dddd from foo",
+        options = FormatOptions {
+            line_width: 20,
+            ..Default::default()
+        }
+    );
+    format_test!(
+        long_tokens_are_not_split,
+        expected = "SELECT\n  'a long string literal'\nFROM foo",
+        input = "select 'a long string literal' from foo",
+        options = FormatOptions {
+            line_width: 10,
+            ..Default::default()
+        }
+    );
+}
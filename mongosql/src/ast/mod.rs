@@ -1,9 +1,13 @@
 mod definitions;
+pub mod fingerprint;
+pub mod format;
 pub mod pretty_print;
 pub mod rewrites;
 pub mod visitors;
 pub use definitions::*;
 
+#[cfg(test)]
+mod format_test;
 #[cfg(test)]
 mod pretty_print_fuzz_test;
 #[cfg(test)]
@@ -114,7 +114,7 @@ lazy_static! {
     .unwrap();
 }
 
-fn identifier_to_string(s: &str) -> String {
+pub(crate) fn identifier_to_string(s: &str) -> String {
     if ident_needs_delimiters(s) {
         format!("`{}`", s.replace('`', "``"))
     } else {
@@ -3,6 +3,7 @@ mod fuzz_test {
     use crate::{
         ast::{
             definitions::*,
+            format::{format_query, FormatOptions, KeywordCase},
             pretty_print::PrettyPrint,
             rewrites::{Pass, SingleTupleRewritePass},
         },
@@ -68,6 +69,63 @@ Reparsed AST:
             .rng(Gen::new(0))
             .quickcheck(pretty_print_query as fn(Query) -> TestResult);
     }
+
+    #[test]
+    // For arbitrary Query q, this test asserts the property:
+    //
+    //   q == Parse(Format(q))
+    //
+    // As in, formatting a query with any keyword case, indentation, and line
+    // width and then reparsing the formatted string results in the original query.
+    fn prop_format_parse_is_idempotent() {
+        fn format(q: Query, lower: bool, indent: u8, line_width: u8) -> TestResult {
+            let options = FormatOptions {
+                keyword_case: if lower {
+                    KeywordCase::Lower
+                } else {
+                    KeywordCase::Upper
+                },
+                indent: indent as usize % 8,
+                line_width: line_width as usize,
+            };
+            let f = match format_query(q.clone(), options) {
+                Err(_) => return TestResult::discard(),
+                Ok(f) => f,
+            };
+
+            let reparsed = match parser::parse_query(&f) {
+                Ok(parsed) => parsed,
+                Err(ref e) => {
+                    panic!("{q:?}\nError:\n{e:?}\n=========\n{f}\n\n_________\n\n");
+                }
+            };
+
+            // As above, parenthesized expressions may be reparsed as single-element tuples.
+            match SingleTupleRewritePass.apply(reparsed) {
+                Err(_) => TestResult::discard(),
+                Ok(r) if q != r => {
+                    panic!(
+                        r#"Reparsed query AST does not equal original AST
+
+Original AST:
+{q:?}
+
+Formatted query:
+{f}
+
+Reparsed AST:
+{r:?}
+"#
+                    )
+                }
+                _ => TestResult::from_bool(true),
+            }
+        }
+
+        QuickCheck::new()
+            .rng(Gen::new(0))
+            .quickcheck(format as fn(Query, bool, u8, u8) -> TestResult);
+    }
 }
 
 mod arbitrary {
@@ -211,6 +269,7 @@ mod arbitrary {
                 order_by_clause: Option::arbitrary(g),
                 limit: Option::arbitrary(g),
                 offset: Option::arbitrary(g),
+                spans: None,
             }
         }
 
@@ -450,6 +509,7 @@ mod arbitrary {
                 database: arbitrary_optional_identifier(g),
                 collection: arbitrary_identifier(g),
                 alias: arbitrary_optional_identifier(g),
+                span: None,
             }
         }
     }
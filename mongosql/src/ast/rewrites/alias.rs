@@ -100,6 +100,7 @@ impl Visitor for AddAliasRewriteVisitor {
                 database: node.database,
                 collection: coll.to_string(),
                 alias: Some(coll.to_string()),
+                span: node.span,
             },
         }
     }
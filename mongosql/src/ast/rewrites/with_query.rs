@@ -28,6 +28,7 @@ impl Visitor for CollectionReplaceVisitor<'_> {
                 ref mut database,
                 ref mut collection,
                 ref mut alias,
+                span,
             }) => {
                 // If the database is specified, this cannot be a NamedQuery from a WITH statement,
                 // this is how a user can unshadow a namespace specifically.
@@ -36,6 +37,7 @@ impl Visitor for CollectionReplaceVisitor<'_> {
                         database: std::mem::take(database),
                         collection: std::mem::take(collection),
                         alias: std::mem::take(alias),
+                        span,
                     });
                 }
                 // If the query is in theta, we replace the datasource with the query
@@ -54,6 +56,7 @@ impl Visitor for CollectionReplaceVisitor<'_> {
                     database: std::mem::take(database),
                     collection: std::mem::take(collection),
                     alias: std::mem::take(alias),
+                    span,
                 })
             }
             // a derived query could still have a use of a WITH-defined NamedQuery
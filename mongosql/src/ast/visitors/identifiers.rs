@@ -0,0 +1,114 @@
+use crate::ast::{visitor::Visitor, *};
+
+struct IdentifierVisitor<F: FnMut(String) -> String> {
+    f: F,
+}
+
+impl<F: FnMut(String) -> String> IdentifierVisitor<F> {
+    fn map(&mut self, identifier: String) -> String {
+        (self.f)(identifier)
+    }
+}
+
+impl<F: FnMut(String) -> String> Visitor for IdentifierVisitor<F> {
+    fn visit_expression(&mut self, node: Expression) -> Expression {
+        match node {
+            Expression::Identifier(i) => Expression::Identifier(self.map(i)),
+            _ => node.walk(self),
+        }
+    }
+
+    fn visit_subpath_expr(&mut self, node: SubpathExpr) -> SubpathExpr {
+        let node = node.walk(self);
+        SubpathExpr {
+            expr: node.expr,
+            subpath: self.map(node.subpath),
+        }
+    }
+
+    fn visit_substar_expr(&mut self, node: SubstarExpr) -> SubstarExpr {
+        SubstarExpr {
+            datasource: self.map(node.datasource),
+        }
+    }
+
+    fn visit_aliased_expr(&mut self, node: AliasedExpr) -> AliasedExpr {
+        let node = node.walk(self);
+        AliasedExpr {
+            expr: node.expr,
+            alias: self.map(node.alias),
+        }
+    }
+
+    fn visit_named_query(&mut self, node: NamedQuery) -> NamedQuery {
+        let node = node.walk(self);
+        NamedQuery {
+            name: self.map(node.name),
+            query: node.query,
+        }
+    }
+
+    fn visit_collection_source(&mut self, node: CollectionSource) -> CollectionSource {
+        CollectionSource {
+            database: node.database.map(|db| self.map(db)),
+            collection: self.map(node.collection),
+            alias: node.alias.map(|alias| self.map(alias)),
+            span: node.span,
+        }
+    }
+
+    fn visit_derived_source(&mut self, node: DerivedSource) -> DerivedSource {
+        let node = node.walk(self);
+        DerivedSource {
+            query: node.query,
+            alias: self.map(node.alias),
+        }
+    }
+
+    fn visit_array_source(&mut self, node: ArraySource) -> ArraySource {
+        let node = node.walk(self);
+        ArraySource {
+            array: node.array,
+            alias: self.map(node.alias),
+        }
+    }
+
+    fn visit_unwind_option(&mut self, node: UnwindOption) -> UnwindOption {
+        match node {
+            UnwindOption::Index(i) => UnwindOption::Index(self.map(i)),
+            _ => node.walk(self),
+        }
+    }
+
+    fn visit_extended_unwind_option(&mut self, node: ExtendedUnwindOption) -> ExtendedUnwindOption {
+        match node {
+            ExtendedUnwindOption::Index(i) => ExtendedUnwindOption::Index(self.map(i)),
+            _ => node.walk(self),
+        }
+    }
+
+    fn visit_unwind_path_part(&mut self, node: UnwindPathPart) -> UnwindPathPart {
+        let node = node.walk(self);
+        UnwindPathPart {
+            field: self.map(node.field),
+            options: node.options,
+        }
+    }
+
+    fn visit_unwind_path_part_option(
+        &mut self,
+        node: UnwindPathPartOption,
+    ) -> UnwindPathPartOption {
+        match node {
+            UnwindPathPartOption::Index(i) => UnwindPathPartOption::Index(self.map(i)),
+            _ => node.walk(self),
+        }
+    }
+}
+
+/// Replaces every identifier in the query, including aliases, collection and database names, and
+/// unwind indexes, with the result of applying `f` to it. Identifiers are visited in no
+/// particular order.
+pub fn map_identifiers<F: FnMut(String) -> String>(query: Query, f: F) -> Query {
+    IdentifierVisitor { f }.visit_query(query)
+}
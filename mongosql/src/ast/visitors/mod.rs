@@ -4,6 +4,9 @@ pub use literal::are_literal;
 mod collections;
 pub use collections::get_collection_sources;
 
+mod identifiers;
+pub use identifiers::map_identifiers;
+
 mod subpath_fields;
 pub use subpath_fields::get_subpath_fields;
 
@@ -138,6 +138,7 @@ macro_rules! build_select_query {
             having_clause: None,
             limit: None,
             offset: None,
+            spans: None,
         }))
     };
 }
@@ -195,11 +196,13 @@ mod subpath_field_tests {
                     database: None,
                     collection: "employees".to_string(),
                     alias: Some("e".to_string(),),
+                    span: None,
                 },)),
                 right: Box::new(Datasource::Collection(CollectionSource {
                     database: None,
                     collection: "departments".to_string(),
                     alias: Some("d".to_string(),),
+                    span: None,
                 },)),
                 condition: Some(Binary(BinaryExpr {
                     left: Box::from(Subpath(SubpathExpr {
@@ -218,7 +221,8 @@ mod subpath_field_tests {
             order_by_clause: None,
             having_clause: None,
             limit: None,
-            offset: None
+            offset: None,
+            spans: None,
         })),
     );
 }
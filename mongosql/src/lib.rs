@@ -13,6 +13,7 @@ mod codegen;
 mod internal_spec_test;
 // mir module (read as: the word "mir", or "M - I -R"; stands for "MongoSQl abstract model IR")
 mod mir;
+pub use mir::lineage::{ColumnLineage, LineageKind, SourceField};
 pub use mir::schema::SchemaCheckingMode;
 pub mod json_schema;
 mod mapping_registry;
@@ -31,13 +32,16 @@ use base64::{engine::general_purpose, Engine as _};
 
 use crate::{
     algebrizer::Algebrizer,
+    ast::pretty_print::PrettyPrint,
     catalog::Catalog,
+    mapping_registry::{MqlMappingRegistryValue, MqlReferenceType},
     mir::schema::CachedSchema,
     options::{ExcludeNamespacesOption, SqlOptions},
     result::Result,
     schema::{Schema, SchemaEnvironment},
     translator::MqlTranslator,
 };
+use mongosql_datastructures::binding_tuple::DatasourceName;
 use std::collections::{BTreeMap, BTreeSet};
 
 /// Contains all the information needed to execute the Mql translation of a Sql query.
@@ -48,19 +52,236 @@ pub struct Translation {
     pub pipeline: bson::Bson,
     pub result_set_schema: json_schema::Schema,
     pub select_order: Vec<Vec<String>>,
+    /// The optimizer passes that ran, in the order they are applied
+    pub optimizer_passes: Vec<OptimizerPassReport>,
+    /// The lineage of each column of select_order, in the same order. It is
+    /// empty unless SqlOptions::include_lineage is set.
+    pub lineage: Vec<ColumnLineage>,
+}
+
+/// Describes how an optimizer pass affected the plan during a translation.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub struct OptimizerPassReport {
+    pub pass: options::OptimizerPass,
+    /// The number of times the pass ran. The optimizer repeats its passes
+    /// until none of them changes the plan.
+    pub runs: usize,
+    /// Whether any run of the pass changed the plan
+    pub changed: bool,
+}
+
+/// A rendering of one intermediate representation produced while
+/// translating a Sql query.
+#[derive(Debug, Default, Clone, PartialEq)]
+pub struct ExplainStage {
+    /// A human-readable rendering of the representation
+    pub text: String,
+    /// The representation serialized to bson
+    pub tree: bson::Bson,
+}
+
+impl ExplainStage {
+    // new returns the ExplainStage of the provided representation, rendering
+    // its text with text.
+    fn new<T, F>(value: &T, text: F) -> Result<Self>
+    where
+        T: serde::Serialize,
+        F: FnOnce(&T) -> Result<String>,
+    {
+        let serializer = bson::Serializer::new();
+        let serializer = serde_stacker::Serializer::new(serializer);
+        let tree = value
+            .serialize(serializer)
+            .map_err(|e| result::Error::Explain(format!("failed to serialize stage: {e}")))?;
+        Ok(ExplainStage {
+            text: text(value)?,
+            tree,
+        })
+    }
+
+    // from_debug returns the ExplainStage of the provided representation,
+    // rendering its text with its Debug representation.
+    fn from_debug<T: serde::Serialize + std::fmt::Debug>(value: &T) -> Result<Self> {
+        Self::new(value, |v| Ok(format!("{v:#?}")))
+    }
+}
+
+/// Contains a rendering of every intermediate representation produced
+/// while translating a Sql query. The text of the MIR and AIR plans is
+/// their Debug representation.
+#[derive(Debug, Default, Clone, PartialEq)]
+pub struct ExplainStages {
+    /// The query after syntactic rewrites. Its text is the query printed as Sql.
+    pub rewritten_ast: ExplainStage,
+    /// The MIR plan produced by the algebrizer
+    pub mir: ExplainStage,
+    /// The MIR plan after optimization
+    pub optimized_mir: ExplainStage,
+    /// The AIR plan produced by the translator
+    pub air: ExplainStage,
+    /// The AIR plan after desugaring
+    pub desugared_air: ExplainStage,
+}
+
+/// Contains the Mql translation of a Sql query along with every
+/// intermediate representation produced while translating it.
+#[derive(Debug)]
+pub struct Explanation {
+    pub stages: ExplainStages,
+    pub translation: Translation,
 }
 
 /// Returns the Mql translation for the provided Sql query in the
-/// specified db.
+/// specified db. Translation keeps checking the query after the first
+/// error it finds wherever it can, and returns an Error::Multiple when
+/// it finds several errors.
 pub fn translate_sql(
     current_db: &str,
     sql: &str,
     catalog: &Catalog,
     sql_options: SqlOptions,
 ) -> Result<Translation> {
+    translate_sql_internal(current_db, sql, catalog, sql_options, None)
+}
+
+/// Returns the Mql translation for the provided Sql query in the
+/// specified db, along with a rendering of every intermediate
+/// representation produced along the way.
+pub fn explain_sql(
+    current_db: &str,
+    sql: &str,
+    catalog: &Catalog,
+    sql_options: SqlOptions,
+) -> Result<Explanation> {
+    let mut stages = ExplainStages::default();
+    let translation =
+        translate_sql_internal(current_db, sql, catalog, sql_options, Some(&mut stages))?;
+    Ok(Explanation {
+        stages,
+        translation,
+    })
+}
+
+/// Contains the result set information of a valid Sql query, as found by
+/// validate_sql.
+#[derive(Debug)]
+pub struct Validation {
+    pub result_set_schema: json_schema::Schema,
+    pub select_order: Vec<Vec<String>>,
+}
+
+/// Checks that the provided Sql query in the specified db can be
+/// translated, without translating it. Only the parser, the syntactic
+/// rewrites and the algebrizer run, so validation catches the same syntax,
+/// name resolution and schema checking errors as translate_sql, but not
+/// the rare errors raised while generating Mql. Instead of stopping at the
+/// first error, the algebrizer keeps checking the query wherever it can and
+/// every error found is returned. Since the optimizer does not run, the
+/// result set schema of a query with filters may be less precise than the
+/// one returned by translate_sql.
+pub fn validate_sql(
+    current_db: &str,
+    sql: &str,
+    catalog: &Catalog,
+    sql_options: SqlOptions,
+) -> std::result::Result<Validation, Vec<result::Error>> {
     // parse the query and apply syntactic rewrites
-    let ast = parser::parse_query(sql)?;
+    let ast = parser::parse_query_collecting_errors(sql).map_err(|errors| {
+        errors
+            .into_iter()
+            .map(result::Error::from)
+            .collect::<Vec<_>>()
+    })?;
+    let ast = ast::rewrites::rewrite_query(ast).map_err(|e| vec![e.into()])?;
+    let select_order = get_select_order(&ast);
+
+    // construct the algebrizer and use it to build an mir plan
+    let algebrizer = Algebrizer::new(
+        current_db,
+        catalog,
+        0u16,
+        sql_options.schema_checking_mode,
+        sql_options.allow_order_by_missing_columns,
+        crate::algebrizer::ClauseType::Unintialized,
+    );
+    let plan = algebrizer
+        .algebrize_query_collecting_errors(ast, || parse_and_rewrite(sql))
+        .map_err(|errors| {
+            errors
+                .into_iter()
+                .map(result::Error::from)
+                .collect::<Vec<_>>()
+        })?;
+
+    validation_for_plan(plan, &algebrizer, select_order, sql_options).map_err(|e| vec![e])
+}
+
+// parse_and_rewrite parses sql and applies the syntactic rewrites again. The algebrizer uses it
+// to rebuild the syntax tree of a query that failed to algebrize, so that the tree does not need
+// to be copied for every query.
+fn parse_and_rewrite(sql: &str) -> Option<ast::Query> {
+    let ast = parser::parse_query(sql).ok()?;
+    ast::rewrites::rewrite_query(ast).ok()
+}
+
+// validation_for_plan computes the result set information returned by validate_sql
+// from the mir plan of a valid query.
+fn validation_for_plan(
+    plan: mir::Stage,
+    algebrizer: &Algebrizer,
+    select_order: Option<ast::SelectBody>,
+    sql_options: SqlOptions,
+) -> Result<Validation> {
+    let schema_env = plan
+        .schema(&algebrizer.schema_inference_state())?
+        .schema_env;
+    if sql_options.exclude_namespaces == ExcludeNamespacesOption::ExcludeNamespaces {
+        schema_env.check_for_non_namespaced_collisions()?;
+    }
+
+    // The translator names each datasource in the result set after itself, and the datasource
+    // holding computed select list expressions after the empty string, so the result set schema
+    // can be produced without translating the plan.
+    let mut mapping_registry = codegen::MqlMappingRegistry::new();
+    for key in schema_env.keys() {
+        let name = match &key.datasource {
+            DatasourceName::Bottom => String::new(),
+            DatasourceName::Named(name) => name.clone(),
+        };
+        mapping_registry.insert(
+            key.clone(),
+            MqlMappingRegistryValue::new(name, MqlReferenceType::FieldRef),
+        );
+    }
+
+    let result_set_schema =
+        mql_schema_env_to_json_schema(schema_env, &mapping_registry, sql_options)?;
+    let select_order =
+        parse_select_list_order(select_order, result_set_schema.clone(), sql_options);
+    Ok(Validation {
+        result_set_schema,
+        select_order,
+    })
+}
+
+// translate_sql_internal performs the translation for both translate_sql and
+// explain_sql. When `explain` is provided, each intermediate representation is
+// rendered into it as it is produced.
+fn translate_sql_internal(
+    current_db: &str,
+    sql: &str,
+    catalog: &Catalog,
+    sql_options: SqlOptions,
+    mut explain: Option<&mut ExplainStages>,
+) -> Result<Translation> {
+    // parse the query and apply syntactic rewrites, collecting every parse error
+    let ast = parser::parse_query_collecting_errors(sql).map_err(|errors| {
+        result::Error::from_errors(errors.into_iter().map(result::Error::from).collect())
+    })?;
     let ast = ast::rewrites::rewrite_query(ast)?;
+    if let Some(stages) = explain.as_deref_mut() {
+        stages.rewritten_ast = ExplainStage::new(&ast, |ast| Ok(ast.pretty_print()?))?;
+    }
     let select_order = get_select_order(&ast);
 
     // construct the algebrizer and use it to build an mir plan
@@ -72,14 +293,29 @@ pub fn translate_sql(
         sql_options.allow_order_by_missing_columns,
         crate::algebrizer::ClauseType::Unintialized,
     );
-    let plan = algebrizer.algebrize_query(ast)?;
+    let plan = algebrizer
+        .algebrize_query_collecting_errors(ast, || parse_and_rewrite(sql))
+        .map_err(|errors| {
+            result::Error::from_errors(errors.into_iter().map(result::Error::from).collect())
+        })?;
+    if let Some(stages) = explain.as_deref_mut() {
+        stages.mir = ExplainStage::from_debug(&plan)?;
+    }
+    // lineage is computed before optimization, which may restructure the plan
+    let plan_lineage = sql_options
+        .include_lineage
+        .then(|| mir::lineage::PlanLineage::new(&plan));
 
     // optimizer runs
-    let plan = mir::optimizer::optimize_plan(
+    let (plan, optimizer_passes) = mir::optimizer::optimize_plan(
         plan,
         sql_options.schema_checking_mode,
         &algebrizer.schema_inference_state(),
+        sql_options.optimizer,
     );
+    if let Some(stages) = explain.as_deref_mut() {
+        stages.optimized_mir = ExplainStage::from_debug(&plan)?;
+    }
 
     // get the schema_env for the plan
     let schema_env = plan
@@ -94,9 +330,15 @@ pub fn translate_sql(
     // construct the translator and use it to build an air plan
     let mut translator = MqlTranslator::new(sql_options);
     let agg_plan = translator.translate_plan(plan)?;
+    if let Some(stages) = explain.as_deref_mut() {
+        stages.air = ExplainStage::from_debug(&agg_plan)?;
+    }
 
     // desugar the air plan
     let agg_plan = air::desugarer::desugar_pipeline(agg_plan)?;
+    if let Some(stages) = explain.as_deref_mut() {
+        stages.desugared_air = ExplainStage::from_debug(&agg_plan)?;
+    }
 
     // codegen the plan into Mql
     let mql_translation = codegen::generate_mql(agg_plan)?;
@@ -117,10 +359,15 @@ pub fn translate_sql(
             .collect(),
     );
 
+    let lineage_schema_env = plan_lineage.as_ref().map(|_| schema_env.clone());
     let result_set_schema =
         mql_schema_env_to_json_schema(schema_env, &translator.mapping_registry, sql_options)?;
     let select_order =
         parse_select_list_order(select_order, result_set_schema.clone(), sql_options);
+    let lineage = match (plan_lineage, lineage_schema_env) {
+        (Some(plan_lineage), Some(schema_env)) => plan_lineage.columns(&select_order, &schema_env),
+        _ => Vec::new(),
+    };
 
     Ok(Translation {
         target_db,
@@ -128,6 +375,8 @@ pub fn translate_sql(
         pipeline,
         result_set_schema,
         select_order,
+        optimizer_passes,
+        lineage,
     })
 }
 
@@ -146,6 +395,48 @@ pub fn get_namespaces(
     Ok(namespaces)
 }
 
+/// Returns the provided Sql query formatted according to `options`. The
+/// formatted query is guaranteed to parse to the same query as the input.
+pub fn format_sql(sql: &str, options: ast::format::FormatOptions) -> Result<String> {
+    use ast::rewrites::{Pass, SingleTupleRewritePass};
+
+    let query = parser::parse_query(sql)?;
+    let formatted = ast::format::format_query(query.clone(), options)?;
+
+    // Parenthesized expressions may be reparsed as single-element tuples, so both
+    // queries are rewritten before they are compared.
+    let original = SingleTupleRewritePass.apply(query)?;
+    let reparsed = parser::parse_query(&formatted)
+        .map_err(|e| result::Error::Format(format!("formatted query does not parse: {e}")))?;
+    if SingleTupleRewritePass.apply(reparsed)? != original {
+        return Err(result::Error::Format(
+            "formatted query does not parse to the original query".to_string(),
+        ));
+    }
+    Ok(formatted)
+}
+
+/// Returns the normalized text of the provided Sql query, in which every
+/// literal is replaced by `?` and every identifier is lowercased. Queries
+/// that differ only in their literal values, the case of their identifiers
+/// and keywords, or their formatting have the same normalized text.
+pub fn fingerprint_sql(sql: &str) -> Result<String> {
+    use ast::rewrites::{Pass, SingleTupleRewritePass};
+
+    let query = SingleTupleRewritePass.apply(parser::parse_query(sql)?)?;
+    Ok(ast::fingerprint::normalize_query(query)?)
+}
+
+/// Returns the provided Sql query with every string and numeric literal,
+/// including those of timestamp literals, replaced by `?`, so that the
+/// query can be logged without exposing the values it contains.
+pub fn redact_sql(sql: &str) -> Result<String> {
+    use ast::rewrites::{Pass, SingleTupleRewritePass};
+
+    let query = SingleTupleRewritePass.apply(parser::parse_query(sql)?)?;
+    Ok(ast::fingerprint::redact_query(query)?)
+}
+
 // get_select_order uses pattern matching to parse the select body from the rewritten AST.
 // Parses both distinct and non-distinct SelectQuery
 pub fn get_select_order(ast: &ast::Query) -> Option<ast::SelectBody> {
@@ -10,10 +10,11 @@ use crate::{
 use std::sync::LazyLock;
 
 use derive_new::new;
+use serde::Serialize;
 
 visitgen::generate_visitors! {
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub enum Stage {
     Filter(Filter),
     Project(Project),
@@ -44,14 +45,15 @@ impl Stage {
     }
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct Filter {
     pub source: Box<Stage>,
     pub condition: Expression,
+    #[serde(skip)]
     pub cache: SchemaCache<ResultSet>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct Project {
     pub source: Box<Stage>,
     pub expression: BindingTuple<Expression>,
@@ -59,82 +61,93 @@ pub struct Project {
     // removing any. This is currently used only to allow ordering by a column not in the select
     // list.
     pub is_add_fields: bool,
+    #[serde(skip)]
     pub cache: SchemaCache<ResultSet>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct Group {
     pub source: Box<Stage>,
     pub keys: Vec<OptionallyAliasedExpr>,
     pub aggregations: Vec<AliasedAggregation>,
+    #[serde(skip)]
     pub cache: SchemaCache<ResultSet>,
     pub scope: u16,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct Limit {
     pub source: Box<Stage>,
     pub limit: u64,
+    #[serde(skip)]
     pub cache: SchemaCache<ResultSet>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct Offset {
     pub source: Box<Stage>,
     pub offset: i64,
+    #[serde(skip)]
     pub cache: SchemaCache<ResultSet>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct Sort {
     pub source: Box<Stage>,
     pub specs: Vec<SortSpecification>,
+    #[serde(skip)]
     pub cache: SchemaCache<ResultSet>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct Collection {
     pub db: String,
     pub collection: String,
+    #[serde(skip)]
     pub cache: SchemaCache<ResultSet>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct ArraySource {
     pub array: Vec<Expression>,
     pub alias: String,
+    #[serde(skip)]
     pub cache: SchemaCache<ResultSet>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct Join {
     pub join_type: JoinType,
     pub left: Box<Stage>,
     pub right: Box<Stage>,
     pub condition: Option<Expression>,
+    #[serde(skip)]
     pub cache: SchemaCache<ResultSet>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct Set {
     pub operation: SetOperation,
     pub left: Box<Stage>,
     pub right: Box<Stage>,
+    #[serde(skip)]
     pub cache: SchemaCache<ResultSet>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct Derived {
     pub source: Box<Stage>,
+    #[serde(skip)]
     pub cache: SchemaCache<ResultSet>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct Unwind {
     pub source: Box<Stage>,
     pub path: FieldPath,
     pub index: Option<String>,
     pub outer: bool,
+    #[serde(skip)]
     pub cache: SchemaCache<ResultSet>,
 
     // This field is relevant for optimization.
@@ -143,45 +156,48 @@ pub struct Unwind {
     pub is_prefiltered: bool,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub enum MqlStage {
     EquiJoin(EquiJoin),
     LateralJoin(LateralJoin),
     MatchFilter(Box<MatchFilter>),
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct EquiJoin {
     pub join_type: JoinType,
     pub source: Box<Stage>,
     pub from: Box<Stage>,
     pub local_field: Box<FieldPath>,
     pub foreign_field: Box<FieldPath>,
+    #[serde(skip)]
     pub cache: SchemaCache<ResultSet>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct LateralJoin {
     pub join_type: JoinType,
     pub source: Box<Stage>,
     pub subquery: Box<Stage>,
+    #[serde(skip)]
     pub cache: SchemaCache<ResultSet>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct MatchFilter {
     pub source: Box<Stage>,
     pub condition: MatchQuery,
+    #[serde(skip)]
     pub cache: SchemaCache<ResultSet>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct AliasedExpr {
     pub alias: String,
     pub expr: Expression,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub enum OptionallyAliasedExpr {
     Aliased(AliasedExpr),
     Unaliased(Expression),
@@ -203,19 +219,19 @@ impl OptionallyAliasedExpr {
     }
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct AliasedAggregation {
     pub alias: String,
     pub agg_expr: AggregationExpr,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub enum AggregationExpr {
     CountStar(bool), // true = distinct, false = not distinct
     Function(AggregationFunctionApplication),
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct AggregationFunctionApplication {
     pub function: AggregationFunction,
     pub distinct: bool,
@@ -227,24 +243,24 @@ pub struct AggregationFunctionApplication {
     pub arg_is_possibly_doc: Satisfaction,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub enum SortSpecification {
     Asc(FieldPath),
     Desc(FieldPath),
 }
 
-#[derive(PartialEq, Eq, Debug, Clone, Copy)]
+#[derive(PartialEq, Eq, Debug, Clone, Copy, Serialize)]
 pub enum JoinType {
     Left,
     Inner,
 }
 
-#[derive(PartialEq, Eq, Debug, Clone, Copy)]
+#[derive(PartialEq, Eq, Debug, Clone, Copy, Serialize)]
 pub enum SetOperation {
     UnionAll,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub enum Expression {
     Array(ArrayExpr),
     Cast(CastExpr),
@@ -357,7 +373,7 @@ impl From<bson::Bson> for Expression {
     }
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub enum LiteralValue {
     Null,
     Boolean(bool),
@@ -407,7 +423,7 @@ impl LiteralValue {
     }
 }
 
-#[derive(PartialEq, Eq, Debug, Clone, Hash)]
+#[derive(PartialEq, Eq, Debug, Clone, Hash, Serialize)]
 pub struct ReferenceExpr {
     pub key: Key,
 }
@@ -420,7 +436,7 @@ impl<T: Into<Key>> From<T> for ReferenceExpr {
     }
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct ArrayExpr {
     pub array: Vec<Expression>,
 }
@@ -433,7 +449,7 @@ impl From<Vec<Expression>> for ArrayExpr {
     }
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct DocumentExpr {
     pub document: UniqueLinkedHashMap<String, Expression>,
 }
@@ -446,7 +462,7 @@ impl<T: Into<UniqueLinkedHashMap<String, Expression>>> From<T> for DocumentExpr
     }
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct ExistsExpr {
     pub stage: Box<Stage>,
 }
@@ -459,20 +475,20 @@ impl From<Box<Stage>> for ExistsExpr {
     }
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct IsExpr {
     pub expr: Box<Expression>,
     pub target_type: TypeOrMissing,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct LikeExpr {
     pub expr: Box<Expression>,
     pub pattern: Box<Expression>,
     pub escape: Option<char>,
 }
 
-#[derive(PartialEq, Debug, Clone, new)]
+#[derive(PartialEq, Debug, Clone, new, Serialize)]
 pub struct ScalarFunctionApplication {
     pub function: ScalarFunction,
     pub args: Vec<Expression>,
@@ -481,7 +497,7 @@ pub struct ScalarFunctionApplication {
 
 }
 
-#[derive(PartialEq, Debug, Clone, new)]
+#[derive(PartialEq, Debug, Clone, new, Serialize)]
 pub struct FieldAccess {
     pub expr: Box<Expression>,
     pub field: String,
@@ -489,7 +505,7 @@ pub struct FieldAccess {
     pub is_nullable: bool,
 }
 
-#[derive(PartialEq, Eq, Debug, Clone, Copy)]
+#[derive(PartialEq, Eq, Debug, Clone, Copy, Serialize)]
 pub enum AggregationFunction {
     AddToArray,
     Avg,
@@ -523,7 +539,7 @@ impl AggregationFunction {
     }
 }
 
-#[derive(PartialEq, Eq, Debug, Clone, Copy)]
+#[derive(PartialEq, Eq, Debug, Clone, Copy, Serialize)]
 pub enum ScalarFunction {
     // String operators
     Concat,
@@ -767,7 +783,7 @@ impl ScalarFunction {
     }
 }
 
-#[derive(PartialEq, Eq, Debug, Clone, Copy)]
+#[derive(PartialEq, Eq, Debug, Clone, Copy, Serialize)]
 pub enum DatePart {
     Year,
     Quarter,
@@ -780,14 +796,14 @@ pub enum DatePart {
     Millisecond,
 }
 
-#[derive(PartialEq, Eq, Debug, Clone)]
+#[derive(PartialEq, Eq, Debug, Clone, Serialize)]
 pub enum DateFunction {
     Add,
     Diff,
     Trunc,
 }
 
-#[derive(PartialEq, Debug, Clone, new)]
+#[derive(PartialEq, Debug, Clone, new, Serialize)]
 pub struct DateFunctionApplication {
     pub function: DateFunction,
     pub date_part: DatePart,
@@ -808,7 +824,7 @@ impl DateFunction {
     }
 }
 
-#[derive(PartialEq, Debug, Clone, new)]
+#[derive(PartialEq, Debug, Clone, new, Serialize)]
 pub struct SearchedCaseExpr {
     pub when_branch: Vec<WhenBranch>,
     pub else_branch: Box<Expression>,
@@ -816,7 +832,7 @@ pub struct SearchedCaseExpr {
     pub is_nullable: bool,
 }
 
-#[derive(PartialEq, Debug, Clone, new)]
+#[derive(PartialEq, Debug, Clone, new, Serialize)]
 pub struct SimpleCaseExpr {
     pub expr: Box<Expression>,
     pub when_branch: Vec<WhenBranch>,
@@ -825,7 +841,7 @@ pub struct SimpleCaseExpr {
     pub is_nullable: bool,
 }
 
-#[derive(PartialEq, Debug, Clone, new)]
+#[derive(PartialEq, Debug, Clone, new, Serialize)]
 pub struct WhenBranch {
     pub when: Box<Expression>,
     pub then: Box<Expression>,
@@ -833,7 +849,7 @@ pub struct WhenBranch {
     pub is_nullable: bool,
 }
 
-#[derive(PartialEq, Debug, Clone, new)]
+#[derive(PartialEq, Debug, Clone, new, Serialize)]
 pub struct CastExpr {
     pub expr: Box<Expression>,
     pub to: Type,
@@ -843,20 +859,20 @@ pub struct CastExpr {
     pub is_nullable: bool,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct TypeAssertionExpr {
     pub expr: Box<Expression>,
     pub target_type: Type,
 }
 
-#[derive(PartialEq, Eq, Debug, Clone, Copy)]
+#[derive(PartialEq, Eq, Debug, Clone, Copy, Serialize)]
 pub enum TypeOrMissing {
     Missing,
     Number,
     Type(Type),
 }
 
-#[derive(PartialEq, Eq, Debug, Clone, Copy)]
+#[derive(PartialEq, Eq, Debug, Clone, Copy, Serialize)]
 pub enum Type {
     Array,
     BinData,
@@ -881,7 +897,7 @@ pub enum Type {
     Undefined,
 }
 
-#[derive(PartialEq, Debug, Clone, new)]
+#[derive(PartialEq, Debug, Clone, new, Serialize)]
 pub struct SubqueryExpr {
     pub output_expr: Box<Expression>,
     pub subquery: Box<Stage>,
@@ -889,7 +905,7 @@ pub struct SubqueryExpr {
     pub is_nullable: bool,
 }
 
-#[derive(PartialEq, Debug, Clone, new)]
+#[derive(PartialEq, Debug, Clone, new, Serialize)]
 pub struct SubqueryComparison {
     pub operator: SubqueryComparisonOp,
     pub modifier: SubqueryModifier,
@@ -899,7 +915,7 @@ pub struct SubqueryComparison {
     pub is_nullable: bool,
 }
 
-#[derive(PartialEq, Eq, Debug, Clone, Copy)]
+#[derive(PartialEq, Eq, Debug, Clone, Copy, Serialize)]
 pub enum SubqueryComparisonOp {
     Lt,
     Lte,
@@ -909,20 +925,20 @@ pub enum SubqueryComparisonOp {
     Gte,
 }
 
-#[derive(PartialEq, Eq, Debug, Clone, Copy)]
+#[derive(PartialEq, Eq, Debug, Clone, Copy, Serialize)]
 pub enum SubqueryModifier {
     Any,
     All,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub enum HigherOrderFunctionApplication {
     Map(MapExpr),
     Filter(FilterExpr),
     Reduce(ReduceExpr),
 }
 
-#[derive(PartialEq, Debug, Clone, new)]
+#[derive(PartialEq, Debug, Clone, new, Serialize)]
 pub struct MapExpr {
     pub array: Box<Expression>,
     pub f: Box<Expression>,
@@ -930,7 +946,7 @@ pub struct MapExpr {
     pub is_nullable: bool,
 }
 
-#[derive(PartialEq, Debug, Clone, new)]
+#[derive(PartialEq, Debug, Clone, new, Serialize)]
 pub struct FilterExpr {
     pub array: Box<Expression>,
     pub f: Box<Expression>,
@@ -938,7 +954,7 @@ pub struct FilterExpr {
     pub is_nullable: bool,
 }
 
-#[derive(PartialEq, Debug, Clone, new)]
+#[derive(PartialEq, Debug, Clone, new, Serialize)]
 pub struct ReduceExpr {
     pub array: Box<Expression>,
     pub init_value: Box<Expression>,
@@ -947,14 +963,14 @@ pub struct ReduceExpr {
     pub is_nullable: bool,
 }
 
-#[derive(PartialEq, Debug, Clone, new)]
+#[derive(PartialEq, Debug, Clone, new, Serialize)]
 pub struct Variable {
     pub name: String,
     #[new(value = "true")]
     pub is_nullable: bool,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub enum MatchQuery {
     Logical(MatchLanguageLogical),
     Type(MatchLanguageType),
@@ -966,12 +982,13 @@ pub enum MatchQuery {
     False(MatchFalse),
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct MatchFalse {
+    #[serde(skip)]
     pub cache: SchemaCache<Schema>,
 }
 
-#[derive(Eq, Debug, Clone, new)]
+#[derive(Eq, Debug, Clone, new, Serialize)]
 pub struct FieldPath {
     pub key: Key,
     pub fields: Vec<String>,
@@ -1078,58 +1095,64 @@ impl TryFrom<&FieldAccess> for FieldPath {
     }
 }
 
-#[derive(PartialEq, Eq, Debug, Clone, Hash)]
+#[derive(PartialEq, Eq, Debug, Clone, Hash, Serialize)]
 pub struct Field {
     pub parent: Box<FieldPath>,
     pub field: String,
+    #[serde(skip)]
     pub cache: SchemaCache<Schema>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct MatchLanguageLogical {
     pub op: MatchLanguageLogicalOp,
     pub args: Vec<MatchQuery>,
+    #[serde(skip)]
     pub cache: SchemaCache<Schema>,
 }
 
-#[derive(PartialEq, Eq, Debug, Clone, Copy)]
+#[derive(PartialEq, Eq, Debug, Clone, Copy, Serialize)]
 pub enum MatchLanguageLogicalOp {
     Or,
     And,
     Not,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct MatchLanguageType {
     pub input: Option<FieldPath>,
     pub target_type: TypeOrMissing,
+    #[serde(skip)]
     pub cache: SchemaCache<Schema>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct MatchLanguageRegex {
     pub input: Option<FieldPath>,
     pub regex: String,
     pub options: String,
+    #[serde(skip)]
     pub cache: SchemaCache<Schema>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct ElemMatch {
     pub input: FieldPath,
     pub condition: Box<MatchQuery>,
+    #[serde(skip)]
     pub cache: SchemaCache<Schema>,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct MatchLanguageComparison {
     pub function: MatchLanguageComparisonOp,
     pub input: Option<FieldPath>,
     pub arg: LiteralValue,
+    #[serde(skip)]
     pub cache: SchemaCache<Schema>
 }
 
-#[derive(PartialEq, Eq, Debug, Clone, Copy)]
+#[derive(PartialEq, Eq, Debug, Clone, Copy, Serialize)]
 pub enum MatchLanguageComparisonOp {
     Lt,
     Lte,
@@ -1139,17 +1162,18 @@ pub enum MatchLanguageComparisonOp {
     Gte,
 }
 
-#[derive(PartialEq, Eq, Debug, Clone, Copy)]
+#[derive(PartialEq, Eq, Debug, Clone, Copy, Serialize)]
 pub enum MatchLanguageInOp {
     In,
     NotIn,
 }
 
-#[derive(PartialEq, Debug, Clone)]
+#[derive(PartialEq, Debug, Clone, Serialize)]
 pub struct MatchLanguageIn {
     pub op: MatchLanguageInOp,
     pub input: FieldPath,
     pub values: Vec<LiteralValue>,
+    #[serde(skip)]
     pub cache: SchemaCache<Schema>,
 }
 
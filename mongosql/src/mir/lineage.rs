@@ -0,0 +1,481 @@
+//! Column-level lineage: the collection fields that each column of a
+//! query's result set is derived from.
+//!
+//! Lineage is computed from the plan produced by the algebrizer, before
+//! optimization, because that plan still mirrors the structure of the
+//! query and every name in it has been resolved to a datasource.
+
+use crate::{
+    map,
+    mir::{
+        binding_tuple::{DatasourceName, Key},
+        AggregationExpr, Expression, FieldPath, HigherOrderFunctionApplication, MqlStage,
+        OptionallyAliasedExpr, ScalarFunction, Stage, SubqueryExpr,
+    },
+    schema::{Satisfaction, SchemaEnvironment},
+    set,
+};
+use std::collections::{BTreeMap, BTreeSet};
+
+/// Describes how a result set column is derived from its sources.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, PartialOrd, Ord)]
+pub enum LineageKind {
+    /// The column is a copy of one of its sources
+    Direct,
+    /// The column is computed by an expression over its sources
+    Transformed,
+    /// The column is computed by an aggregation function over its sources
+    Aggregate,
+}
+
+impl LineageKind {
+    /// Returns a string of the kind enum.
+    pub fn as_str(&self) -> &'static str {
+        match self {
+            LineageKind::Direct => "Direct",
+            LineageKind::Transformed => "Transformed",
+            LineageKind::Aggregate => "Aggregate",
+        }
+    }
+}
+
+/// A field of the documents of a collection.
+#[derive(Debug, Clone, PartialEq, Eq, PartialOrd, Ord, Hash)]
+pub struct SourceField {
+    pub database: String,
+    pub collection: String,
+    /// The path of the field in the documents of the collection. The
+    /// empty path is the whole document.
+    pub path: Vec<String>,
+}
+
+/// The lineage of a column of a result set.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct ColumnLineage {
+    /// The column, as it appears in the select order
+    pub column: Vec<String>,
+    pub kind: LineageKind,
+    /// The fields the column is derived from, in sorted order
+    pub sources: Vec<SourceField>,
+}
+
+/// The lineage of a value computed by a plan.
+#[derive(Debug, Clone, PartialEq)]
+enum Lineage {
+    /// A value derived from source fields. When the kind is Direct, the
+    /// value is a copy of one of the sources.
+    Fields {
+        kind: LineageKind,
+        sources: BTreeSet<SourceField>,
+    },
+    /// A document built by the plan. The listed fields have their own
+    /// lineage, and the other fields have the lineage of the same field
+    /// of rest.
+    Document {
+        fields: BTreeMap<String, Lineage>,
+        rest: Option<Box<Lineage>>,
+    },
+}
+
+impl Lineage {
+    /// The lineage of a value that is not derived from any source.
+    fn unknown() -> Self {
+        Lineage::computed(LineageKind::Direct, BTreeSet::new())
+    }
+
+    fn computed(kind: LineageKind, sources: BTreeSet<SourceField>) -> Self {
+        Lineage::Fields { kind, sources }
+    }
+
+    /// Returns the lineage of the field `name` of this value.
+    fn field(self, name: &str) -> Self {
+        match self {
+            Lineage::Fields {
+                kind: LineageKind::Direct,
+                sources,
+            } => Lineage::Fields {
+                kind: LineageKind::Direct,
+                sources: sources
+                    .into_iter()
+                    .map(|mut source| {
+                        source.path.push(name.to_string());
+                        source
+                    })
+                    .collect(),
+            },
+            // a field of a computed value is derived from the same sources
+            computed @ Lineage::Fields { .. } => computed,
+            Lineage::Document { mut fields, rest } => match fields.remove(name) {
+                Some(lineage) => lineage,
+                None => rest.map_or_else(Lineage::unknown, |rest| (*rest).field(name)),
+            },
+        }
+    }
+
+    /// Returns the kind of this value and every source it is derived from.
+    /// A document built by the plan is at least Transformed.
+    fn flatten(self) -> (LineageKind, BTreeSet<SourceField>) {
+        match self {
+            Lineage::Fields { kind, sources } => (kind, sources),
+            Lineage::Document { fields, rest } => {
+                let mut kind = LineageKind::Transformed;
+                let mut sources = BTreeSet::new();
+                for lineage in fields.into_values().chain(rest.map(|rest| *rest)) {
+                    let (k, s) = lineage.flatten();
+                    kind = kind.max(k);
+                    sources.extend(s);
+                }
+                (kind, sources)
+            }
+        }
+    }
+
+    /// Returns the lineage of a value computed from this value.
+    fn transformed(self) -> Self {
+        let (kind, sources) = self.flatten();
+        Lineage::computed(kind.max(LineageKind::Transformed), sources)
+    }
+
+    /// Returns the lineage of a value that is either this value or other,
+    /// as for the rows of a UNION ALL or the arguments of MergeObjects.
+    fn union(self, other: Lineage) -> Self {
+        match (self, other) {
+            (
+                Lineage::Fields {
+                    kind: k1,
+                    sources: mut s1,
+                },
+                Lineage::Fields {
+                    kind: k2,
+                    sources: s2,
+                },
+            ) => {
+                s1.extend(s2);
+                Lineage::computed(k1.max(k2), s1)
+            }
+            (Lineage::Document { fields, rest }, other)
+            | (other, Lineage::Document { fields, rest }) => {
+                let fields = fields
+                    .into_iter()
+                    .map(|(name, lineage)| {
+                        let other_field = other.clone().field(&name);
+                        (name, lineage.union(other_field))
+                    })
+                    .collect();
+                let rest = match rest {
+                    Some(rest) => (*rest).union(other),
+                    None => other,
+                };
+                Lineage::Document {
+                    fields,
+                    rest: Some(Box::new(rest)),
+                }
+            }
+        }
+    }
+}
+
+/// The lineage of each datasource of a binding tuple.
+type Env = BTreeMap<Key, Lineage>;
+
+fn merged(outer: &Env, inner: &Env) -> Env {
+    let mut env = outer.clone();
+    env.extend(inner.iter().map(|(k, v)| (k.clone(), v.clone())));
+    env
+}
+
+/// The lineage of the datasources returned by a plan, from which the
+/// lineage of the columns of its result set is derived.
+#[derive(Debug, Clone)]
+pub struct PlanLineage {
+    env: Env,
+}
+
+impl PlanLineage {
+    /// Computes the lineage of the datasources returned by plan, which
+    /// must be the plan produced by the algebrizer for a query at scope 0.
+    pub fn new(plan: &Stage) -> Self {
+        PlanLineage {
+            env: stage_env(plan, 0, &Env::new()),
+        }
+    }
+
+    /// Returns the lineage of each column of select_order. The columns of
+    /// a result set that includes namespaces are pairs of a datasource name
+    /// and a field name, and schema_env is not used. The columns of a
+    /// result set that excludes namespaces are field names, and the
+    /// datasources they may come from are looked up in schema_env, the
+    /// schema environment of the plan.
+    pub fn columns(
+        &self,
+        select_order: &[Vec<String>],
+        schema_env: &SchemaEnvironment,
+    ) -> Vec<ColumnLineage> {
+        select_order
+            .iter()
+            .map(|column| {
+                let lineage = match column.as_slice() {
+                    [datasource, field] => {
+                        let name = if datasource.is_empty() {
+                            DatasourceName::Bottom
+                        } else {
+                            DatasourceName::Named(datasource.clone())
+                        };
+                        self.field_of(field, |key| key.datasource == name)
+                    }
+                    [field] => self.field_of(field, |key| {
+                        schema_env
+                            .get(key)
+                            .is_some_and(|s| s.contains_field(field) != Satisfaction::Not)
+                    }),
+                    _ => Lineage::unknown(),
+                };
+                let (kind, sources) = lineage.flatten();
+                ColumnLineage {
+                    column: column.clone(),
+                    kind,
+                    sources: sources.into_iter().collect(),
+                }
+            })
+            .collect()
+    }
+
+    // field_of returns the lineage of the field of the datasources whose
+    // keys satisfy the predicate.
+    fn field_of(&self, field: &str, predicate: impl Fn(&Key) -> bool) -> Lineage {
+        self.env
+            .iter()
+            .filter(|(key, _)| predicate(key))
+            .map(|(_, lineage)| lineage.clone().field(field))
+            .fold(Lineage::unknown(), Lineage::union)
+    }
+}
+
+// stage_env returns the lineage of the datasources returned by stage, which
+// is at the provided scope and can reference the datasources in outer.
+fn stage_env(stage: &Stage, scope: u16, outer: &Env) -> Env {
+    match stage {
+        Stage::Filter(f) => stage_env(&f.source, scope, outer),
+        Stage::Limit(l) => stage_env(&l.source, scope, outer),
+        Stage::Offset(o) => stage_env(&o.source, scope, outer),
+        Stage::Sort(s) => stage_env(&s.source, scope, outer),
+        Stage::MqlIntrinsic(MqlStage::MatchFilter(m)) => stage_env(&m.source, scope, outer),
+        // the query of a derived table is one scope deeper
+        Stage::Derived(d) => stage_env(&d.source, scope + 1, outer),
+        Stage::Collection(c) => map! {
+            Key::named(&c.collection, scope) => Lineage::computed(
+                LineageKind::Direct,
+                set! {
+                    SourceField {
+                        database: c.db.clone(),
+                        collection: c.collection.clone(),
+                        path: vec![],
+                    }
+                },
+            )
+        },
+        Stage::Array(a) => map! {
+            Key::named(&a.alias, scope) => Lineage::computed(LineageKind::Transformed, BTreeSet::new())
+        },
+        Stage::Project(p) => {
+            let source = stage_env(&p.source, scope, outer);
+            let env = merged(outer, &source);
+            let mut out = if p.is_add_fields { source } else { Env::new() };
+            for (key, e) in p.expression.iter() {
+                let lineage = match (expr_lineage(e, scope, &env), out.remove(key)) {
+                    // fields added to an existing datasource
+                    (Lineage::Document { fields, rest: None }, Some(existing)) => {
+                        Lineage::Document {
+                            fields,
+                            rest: Some(Box::new(existing)),
+                        }
+                    }
+                    (lineage, _) => lineage,
+                };
+                out.insert(key.clone(), lineage);
+            }
+            out
+        }
+        Stage::Group(g) => {
+            let source = stage_env(&g.source, scope, outer);
+            let env = merged(outer, &source);
+            let mut out: BTreeMap<Key, BTreeMap<String, Lineage>> = BTreeMap::new();
+            for key in g.keys.iter() {
+                let lineage = expr_lineage(key.get_expr(), scope, &env);
+                match key {
+                    OptionallyAliasedExpr::Aliased(a) => {
+                        out.entry(Key::bot(g.scope))
+                            .or_default()
+                            .insert(a.alias.clone(), lineage);
+                    }
+                    OptionallyAliasedExpr::Unaliased(Expression::FieldAccess(f)) => {
+                        if let Expression::Reference(r) = f.expr.as_ref() {
+                            out.entry(r.key.clone())
+                                .or_default()
+                                .insert(f.field.clone(), lineage);
+                        }
+                    }
+                    OptionallyAliasedExpr::Unaliased(_) => {}
+                }
+            }
+            for aggregation in g.aggregations.iter() {
+                let sources = match &aggregation.agg_expr {
+                    AggregationExpr::CountStar(_) => BTreeSet::new(),
+                    AggregationExpr::Function(f) => expr_lineage(&f.arg, scope, &env).flatten().1,
+                };
+                out.entry(Key::bot(g.scope)).or_default().insert(
+                    aggregation.alias.clone(),
+                    Lineage::computed(LineageKind::Aggregate, sources),
+                );
+            }
+            out.into_iter()
+                .map(|(key, fields)| (key, Lineage::Document { fields, rest: None }))
+                .collect()
+        }
+        Stage::Join(j) => {
+            let mut env = stage_env(&j.left, scope, outer);
+            env.extend(stage_env(&j.right, scope, outer));
+            env
+        }
+        Stage::MqlIntrinsic(MqlStage::EquiJoin(j)) => {
+            let mut env = stage_env(&j.source, scope, outer);
+            env.extend(stage_env(&j.from, scope, outer));
+            env
+        }
+        Stage::MqlIntrinsic(MqlStage::LateralJoin(j)) => {
+            let mut env = stage_env(&j.source, scope, outer);
+            let subquery = stage_env(&j.subquery, scope, &merged(outer, &env));
+            env.extend(subquery);
+            env
+        }
+        Stage::Set(s) => {
+            let mut env = stage_env(&s.left, scope, outer);
+            for (key, lineage) in stage_env(&s.right, scope, outer) {
+                let lineage = match env.remove(&key) {
+                    Some(left) => left.union(lineage),
+                    None => lineage,
+                };
+                env.insert(key, lineage);
+            }
+            env
+        }
+        Stage::Unwind(u) => {
+            let mut env = stage_env(&u.source, scope, outer);
+            // the unwound elements are copies of the elements of the path, but
+            // the INDEX field is computed from the path
+            if let Some(index) = &u.index {
+                let index_lineage = field_path_lineage(&u.path, &merged(outer, &env)).transformed();
+                let existing = env.remove(&u.path.key).unwrap_or_else(Lineage::unknown);
+                env.insert(
+                    u.path.key.clone(),
+                    Lineage::Document {
+                        fields: map! { index.clone() => index_lineage },
+                        rest: Some(Box::new(existing)),
+                    },
+                );
+            }
+            env
+        }
+        Stage::Sentinel => Env::new(),
+    }
+}
+
+fn field_path_lineage(path: &FieldPath, env: &Env) -> Lineage {
+    path.fields.iter().fold(
+        env.get(&path.key).cloned().unwrap_or_else(Lineage::unknown),
+        |lineage, field| lineage.field(field),
+    )
+}
+
+// subquery_lineage returns the lineage of the output expression of a
+// subquery nested in a query at the provided scope.
+fn subquery_lineage(s: &SubqueryExpr, scope: u16, env: &Env) -> Lineage {
+    let subquery_env = merged(env, &stage_env(&s.subquery, scope + 1, env));
+    expr_lineage(&s.output_expr, scope + 1, &subquery_env)
+}
+
+// expr_lineage returns the lineage of the value of expr, which is at the
+// provided scope and can reference the datasources in env.
+fn expr_lineage(expr: &Expression, scope: u16, env: &Env) -> Lineage {
+    match expr {
+        Expression::Reference(r) => env.get(&r.key).cloned().unwrap_or_else(Lineage::unknown),
+        Expression::FieldAccess(f) => expr_lineage(&f.expr, scope, env).field(&f.field),
+        Expression::MqlIntrinsicFieldExistence(f) => expr_lineage(&f.expr, scope, env)
+            .field(&f.field)
+            .transformed(),
+        Expression::TypeAssertion(t) => expr_lineage(&t.expr, scope, env),
+        Expression::Document(d) => Lineage::Document {
+            fields: d
+                .document
+                .iter()
+                .map(|(name, e)| (name.clone(), expr_lineage(e, scope, env)))
+                .collect(),
+            rest: None,
+        },
+        Expression::Subquery(s) => subquery_lineage(s, scope, env),
+        Expression::SubqueryComparison(s) => expr_lineage(&s.argument, scope, env)
+            .union(subquery_lineage(&s.subquery_expr, scope, env))
+            .transformed(),
+        Expression::Literal(_) | Expression::Variable(_) | Expression::Exists(_) => {
+            Lineage::computed(LineageKind::Transformed, BTreeSet::new())
+        }
+        // a derived table that reads several datasources merges them
+        Expression::ScalarFunction(f) if f.function == ScalarFunction::MergeObjects => {
+            f.args.iter().map(|arg| expr_lineage(arg, scope, env)).fold(
+                Lineage::Document {
+                    fields: BTreeMap::new(),
+                    rest: None,
+                },
+                Lineage::union,
+            )
+        }
+        Expression::ScalarFunction(f) => computed_lineage(&f.args, scope, env),
+        Expression::DateFunction(f) => computed_lineage(&f.args, scope, env),
+        Expression::Array(a) => computed_lineage(&a.array, scope, env),
+        Expression::Cast(c) => computed_lineage([&*c.expr, &*c.on_null, &*c.on_error], scope, env),
+        Expression::Is(i) => computed_lineage([&*i.expr], scope, env),
+        Expression::Like(l) => computed_lineage([&*l.expr, &*l.pattern], scope, env),
+        Expression::SearchedCase(c) => computed_lineage(
+            c.when_branch
+                .iter()
+                .flat_map(|b| [&*b.when, &*b.then])
+                .chain([&*c.else_branch]),
+            scope,
+            env,
+        ),
+        Expression::SimpleCase(c) => computed_lineage(
+            [&*c.expr]
+                .into_iter()
+                .chain(c.when_branch.iter().flat_map(|b| [&*b.when, &*b.then]))
+                .chain([&*c.else_branch]),
+            scope,
+            env,
+        ),
+        Expression::HigherOrderFunction(HigherOrderFunctionApplication::Map(m)) => {
+            computed_lineage([&*m.array, &*m.f], scope, env)
+        }
+        Expression::HigherOrderFunction(HigherOrderFunctionApplication::Filter(f)) => {
+            computed_lineage([&*f.array, &*f.f], scope, env)
+        }
+        Expression::HigherOrderFunction(HigherOrderFunctionApplication::Reduce(r)) => {
+            computed_lineage([&*r.array, &*r.init_value, &*r.f], scope, env)
+        }
+    }
+}
+
+// computed_lineage returns the lineage of a value computed from the values
+// of exprs.
+fn computed_lineage<'a>(
+    exprs: impl IntoIterator<Item = &'a Expression>,
+    scope: u16,
+    env: &Env,
+) -> Lineage {
+    exprs
+        .into_iter()
+        .map(|e| expr_lineage(e, scope, env))
+        .fold(
+            Lineage::computed(LineageKind::Transformed, BTreeSet::new()),
+            Lineage::union,
+        )
+        .transformed()
+}
@@ -1,5 +1,6 @@
 pub mod definitions;
 pub use definitions::*;
+pub mod lineage;
 pub mod schema;
 
 pub use mongosql_datastructures::binding_tuple;
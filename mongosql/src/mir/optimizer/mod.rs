@@ -1,8 +1,9 @@
 use crate::{
     mir::{schema::SchemaInferenceState, Stage},
-    SchemaCheckingMode,
+    options::{OptimizerOptions, OptimizerPass},
+    OptimizerPassReport, SchemaCheckingMode,
 };
-use tailcall::tailcall;
+use std::collections::BTreeMap;
 
 mod constant_folding;
 mod dead_code_elimination;
@@ -27,52 +28,98 @@ pub(crate) trait Optimizer {
     ) -> (Stage, bool);
 }
 
-// Avoiding lifetime hacking by using a fn
-// Optimizers must be added to this vec in the order they should be applied
-static OPTIMIZERS: fn() -> Vec<Box<dyn Optimizer>> = || {
-    vec![
-        Box::new(flatten_variadics::FlattenVariadicFunctionsOptimizer {}),
-        Box::new(constant_folding::ConstantFoldingOptimizer {}),
-        Box::new(match_splitting::MatchSplittingOptimizer {}),
-        Box::new(rewrite_to_match_language::MatchLanguageRewriter {}),
-        Box::new(match_null_filtering::MatchNullFilteringOptimizer {}),
-        Box::new(stage_movement::StageMovementOptimizer {}),
-        Box::new(determine_join_semantics::JoinSemanticsOptimizer {}),
-        Box::new(lower_joins::LowerJoinsOptimizer {}),
-        Box::new(prefilter_unwinds::PrefilterUnwindsOptimizer {}),
-        Box::new(dead_code_elimination::DeadCodeEliminator {}),
-    ]
-};
+/// Returns the Optimizer that implements the provided pass.
+fn optimizer(pass: OptimizerPass) -> Box<dyn Optimizer> {
+    match pass {
+        OptimizerPass::FlattenVariadics => {
+            Box::new(flatten_variadics::FlattenVariadicFunctionsOptimizer {})
+        }
+        OptimizerPass::ConstantFolding => Box::new(constant_folding::ConstantFoldingOptimizer {}),
+        OptimizerPass::MatchSplitting => Box::new(match_splitting::MatchSplittingOptimizer {}),
+        OptimizerPass::RewriteToMatchLanguage => {
+            Box::new(rewrite_to_match_language::MatchLanguageRewriter {})
+        }
+        OptimizerPass::MatchNullFiltering => {
+            Box::new(match_null_filtering::MatchNullFilteringOptimizer {})
+        }
+        OptimizerPass::StageMovement => Box::new(stage_movement::StageMovementOptimizer {}),
+        OptimizerPass::DetermineJoinSemantics => {
+            Box::new(determine_join_semantics::JoinSemanticsOptimizer {})
+        }
+        OptimizerPass::LowerJoins => Box::new(lower_joins::LowerJoinsOptimizer {}),
+        OptimizerPass::PrefilterUnwinds => {
+            Box::new(prefilter_unwinds::PrefilterUnwindsOptimizer {})
+        }
+        OptimizerPass::DeadCodeElimination => {
+            Box::new(dead_code_elimination::DeadCodeEliminator {})
+        }
+        OptimizerPass::MergeNeighboringMatches => {
+            Box::new(merge_neighboring_matches::MergeNeighboringMatchesOptimizer {})
+        }
+    }
+}
+
+// The passes that are applied repeatedly until none of them changes the plan,
+// in the order they should be applied. MergeNeighboringMatches is not included
+// because it is applied once the fixed point is reached.
+const FIXED_POINT_PASSES: [OptimizerPass; 10] = [
+    OptimizerPass::FlattenVariadics,
+    OptimizerPass::ConstantFolding,
+    OptimizerPass::MatchSplitting,
+    OptimizerPass::RewriteToMatchLanguage,
+    OptimizerPass::MatchNullFiltering,
+    OptimizerPass::StageMovement,
+    OptimizerPass::DetermineJoinSemantics,
+    OptimizerPass::LowerJoins,
+    OptimizerPass::PrefilterUnwinds,
+    OptimizerPass::DeadCodeElimination,
+];
 
-/// Optimizes the provided MIR stage. Internally, Optimizers determine whether
-/// the SchemaCheckingMode is used or not.
-#[tailcall]
+/// Optimizes the provided MIR stage, running only the passes enabled in
+/// `options`. Internally, Optimizers determine whether the SchemaCheckingMode
+/// is used or not. Returns the optimized stage along with a report for every
+/// pass that ran, in the order the passes are applied.
 pub fn optimize_plan(
     st: Stage,
     schema_checking_mode: SchemaCheckingMode,
     schema_state: &SchemaInferenceState,
-) -> Stage {
-    let (new_st, changed) =
-        OPTIMIZERS()
+    options: OptimizerOptions,
+) -> (Stage, Vec<OptimizerPassReport>) {
+    let mut reports: BTreeMap<OptimizerPass, OptimizerPassReport> = BTreeMap::new();
+    let mut run = |pass: OptimizerPass, st: Stage| -> (Stage, bool) {
+        let (new_st, changed) = optimizer(pass).optimize(st, schema_checking_mode, schema_state);
+        let report = reports.entry(pass).or_insert_with(|| OptimizerPassReport {
+            pass,
+            runs: 0,
+            changed: false,
+        });
+        report.runs += 1;
+        report.changed |= changed;
+        (new_st, changed)
+    };
+
+    // If the pipeline was updated as a result of optimization, optimize again.
+    let mut plan = st;
+    loop {
+        let (new_plan, changed) = FIXED_POINT_PASSES
             .into_iter()
-            .fold((st, false), |(acc_st, acc_changed), opt| {
-                let (new_st, changed) = opt.optimize(acc_st, schema_checking_mode, schema_state);
+            .filter(|pass| options.is_enabled(*pass))
+            .fold((plan, false), |(acc_st, acc_changed), pass| {
+                let (new_st, changed) = run(pass, acc_st);
                 (new_st, acc_changed || changed)
             });
+        plan = new_plan;
+        if !changed {
+            break;
+        }
+    }
 
-    // If the pipeline was updated as a result of optimization, optimize again.
-    let plan = if changed {
-        optimize_plan(new_st, schema_checking_mode, schema_state)
-    } else {
-        new_st
-    };
     // We perform merge_neighboring_matches last because we do not want to undo this optimization during the fixed
     // point and re-merge the matches every loop of the fixed point. Most of the fixed point optimizations benefit from
     // having matches split, and it is only at the end where we can then merge them back together efficiently.
-    let (new_plan, _) = merge_neighboring_matches::MergeNeighboringMatchesOptimizer {}.optimize(
-        plan,
-        schema_checking_mode,
-        schema_state,
-    );
-    new_plan
+    if options.is_enabled(OptimizerPass::MergeNeighboringMatches) {
+        (plan, _) = run(OptimizerPass::MergeNeighboringMatches, plan);
+    }
+
+    (plan, reports.into_values().collect())
 }
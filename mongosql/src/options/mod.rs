@@ -1,4 +1,5 @@
 use crate::mir::schema::SchemaCheckingMode;
+use std::{fmt, str::FromStr};
 
 /// Options passed in for translation, used throughout the various translation components
 #[derive(Debug, Copy, Clone, Default)]
@@ -6,6 +7,10 @@ pub struct SqlOptions {
     pub exclude_namespaces: ExcludeNamespacesOption,
     pub schema_checking_mode: SchemaCheckingMode,
     pub allow_order_by_missing_columns: bool,
+    pub optimizer: OptimizerOptions,
+    /// Whether to compute the lineage of the result set columns. Lineage is
+    /// not computed by default, since most callers do not need it.
+    pub include_lineage: bool,
 }
 
 impl SqlOptions {
@@ -18,6 +23,8 @@ impl SqlOptions {
             exclude_namespaces,
             schema_checking_mode,
             allow_order_by_missing_columns: true,
+            optimizer: OptimizerOptions::default(),
+            include_lineage: false,
         }
     }
 }
@@ -30,3 +37,100 @@ pub enum ExcludeNamespacesOption {
     #[default]
     IncludeNamespaces,
 }
+
+/// Identifies one of the passes run by the MIR optimizer. Passes are listed
+/// in the order in which the optimizer applies them.
+#[derive(Debug, Copy, Clone, PartialEq, Eq, PartialOrd, Ord, Hash)]
+pub enum OptimizerPass {
+    FlattenVariadics,
+    ConstantFolding,
+    MatchSplitting,
+    RewriteToMatchLanguage,
+    MatchNullFiltering,
+    StageMovement,
+    DetermineJoinSemantics,
+    LowerJoins,
+    PrefilterUnwinds,
+    DeadCodeElimination,
+    MergeNeighboringMatches,
+}
+
+impl OptimizerPass {
+    /// All optimizer passes, in the order in which they are applied.
+    pub const ALL: [OptimizerPass; 11] = [
+        OptimizerPass::FlattenVariadics,
+        OptimizerPass::ConstantFolding,
+        OptimizerPass::MatchSplitting,
+        OptimizerPass::RewriteToMatchLanguage,
+        OptimizerPass::MatchNullFiltering,
+        OptimizerPass::StageMovement,
+        OptimizerPass::DetermineJoinSemantics,
+        OptimizerPass::LowerJoins,
+        OptimizerPass::PrefilterUnwinds,
+        OptimizerPass::DeadCodeElimination,
+        OptimizerPass::MergeNeighboringMatches,
+    ];
+
+    /// Returns the name used to refer to this pass in options and reports.
+    pub fn name(self) -> &'static str {
+        match self {
+            OptimizerPass::FlattenVariadics => "flatten_variadics",
+            OptimizerPass::ConstantFolding => "constant_folding",
+            OptimizerPass::MatchSplitting => "match_splitting",
+            OptimizerPass::RewriteToMatchLanguage => "rewrite_to_match_language",
+            OptimizerPass::MatchNullFiltering => "match_null_filtering",
+            OptimizerPass::StageMovement => "stage_movement",
+            OptimizerPass::DetermineJoinSemantics => "determine_join_semantics",
+            OptimizerPass::LowerJoins => "lower_joins",
+            OptimizerPass::PrefilterUnwinds => "prefilter_unwinds",
+            OptimizerPass::DeadCodeElimination => "dead_code_elimination",
+            OptimizerPass::MergeNeighboringMatches => "merge_neighboring_matches",
+        }
+    }
+}
+
+impl fmt::Display for OptimizerPass {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        f.write_str(self.name())
+    }
+}
+
+impl FromStr for OptimizerPass {
+    type Err = String;
+
+    fn from_str(s: &str) -> Result<Self, Self::Err> {
+        OptimizerPass::ALL
+            .into_iter()
+            .find(|pass| pass.name() == s)
+            .ok_or_else(|| format!("unknown optimizer pass '{s}'"))
+    }
+}
+
+/// Controls which passes the MIR optimizer runs. By default, every pass runs.
+#[derive(Debug, Copy, Clone, Default, PartialEq, Eq)]
+pub struct OptimizerOptions {
+    disable_optimization: bool,
+    // bit i is set when OptimizerPass::ALL[i] is disabled. A bit set keeps
+    // SqlOptions Copy.
+    disabled_passes: u16,
+}
+
+impl OptimizerOptions {
+    /// Returns options under which no optimizer pass runs.
+    pub fn disabled() -> Self {
+        OptimizerOptions {
+            disable_optimization: true,
+            disabled_passes: 0,
+        }
+    }
+
+    /// Disables the provided pass, leaving the other passes unchanged.
+    pub fn disable_pass(&mut self, pass: OptimizerPass) {
+        self.disabled_passes |= 1 << pass as u16;
+    }
+
+    /// Returns whether the provided pass should run.
+    pub fn is_enabled(&self, pass: OptimizerPass) -> bool {
+        !self.disable_optimization && self.disabled_passes & (1 << pass as u16) == 0
+    }
+}
@@ -2,7 +2,7 @@ use crate::{
     ast,
     usererror::{util::generate_suggestion, UserError, UserErrorDisplay},
 };
-use lalrpop_util::{lalrpop_mod, lexer::Token};
+use lalrpop_util::{lalrpop_mod, lexer::Token, ErrorRecovery};
 use lazy_static::lazy_static;
 use std::collections::HashMap;
 
@@ -14,24 +14,36 @@ lalrpop_mod!(
 
 type Result<T> = std::result::Result<T, Error>;
 
+/// A parse error. Each error records the byte offset in the query at which it was found, when
+/// it is known.
 #[derive(Debug, UserErrorDisplay, PartialEq, Eq)]
 pub enum Error {
-    Lalrpop(String),
-    UnexpectedToken(String, Vec<String>),
+    Lalrpop(String, Option<usize>),
+    UnexpectedToken(String, Vec<String>, usize),
+}
+
+impl Error {
+    /// Returns the byte offset in the query at which this error was found, if it is known.
+    pub fn offset(&self) -> Option<usize> {
+        match self {
+            Error::Lalrpop(_, offset) => *offset,
+            Error::UnexpectedToken(_, _, offset) => Some(*offset),
+        }
+    }
 }
 
 impl UserError for Error {
     fn code(&self) -> u32 {
         match self {
-            Error::Lalrpop(_) => 2000,
-            Error::UnexpectedToken(_, _) => 2001,
+            Error::Lalrpop(_, _) => 2000,
+            Error::UnexpectedToken(_, _, _) => 2001,
         }
     }
 
     fn user_message(&self) -> Option<String> {
         match self {
-            Error::Lalrpop(_) => None,
-            Error::UnexpectedToken(input, expected) => {
+            Error::Lalrpop(_, _) => None,
+            Error::UnexpectedToken(input, expected, _) => {
                 match generate_suggestion(
                     input,
                     &expected.iter().map(get_token).collect::<Vec<_>>(),
@@ -60,8 +72,10 @@ impl UserError for Error {
 
     fn technical_message(&self) -> String {
         match self {
-            Error::Lalrpop(string) => string.clone(),
-            Error::UnexpectedToken(t, e) => format!("Unrecognized token: `{t}`, expected: {e:?}"),
+            Error::Lalrpop(string, _) => string.clone(),
+            Error::UnexpectedToken(t, e, _) => {
+                format!("Unrecognized token: `{t}`, expected: {e:?}")
+            }
         }
     }
 }
@@ -72,19 +86,20 @@ impl From<LalrpopError<'_>> for Error {
     fn from(value: LalrpopError<'_>) -> Self {
         match value {
             lalrpop_util::ParseError::UnrecognizedToken { token, expected } => {
-                Self::UnexpectedToken(token.1.to_string(), expected.clone())
+                Self::UnexpectedToken(token.1.to_string(), expected.clone(), token.0)
             }
             lalrpop_util::ParseError::InvalidToken { location } => {
-                Self::Lalrpop(format!("InvalidToken at {location}"))
+                Self::Lalrpop(format!("InvalidToken at {location}"), Some(location))
             }
             lalrpop_util::ParseError::UnrecognizedEof { location, expected } => Self::Lalrpop(
                 format!("UnrecognizedEOF at {location} with expected {expected:?}",),
+                Some(location),
+            ),
+            lalrpop_util::ParseError::ExtraToken { token } => Self::Lalrpop(
+                format!("ExtraToken at {} with token {:?}", token.0, token.1),
+                Some(token.0),
             ),
-            lalrpop_util::ParseError::ExtraToken { token } => Self::Lalrpop(format!(
-                "ExtraToken at {} with token {:?}",
-                token.0, token.1
-            )),
-            lalrpop_util::ParseError::User { error } => Self::Lalrpop(error),
+            lalrpop_util::ParseError::User { error } => Self::Lalrpop(error, None),
         }
     }
 }
@@ -136,11 +151,39 @@ pub fn get_token<T: Into<String>>(input: T) -> String {
 }
 
 pub fn parse_query(input: &str) -> Result<ast::Query> {
-    Ok(QUERY_PARSER.parse(input)?)
+    parse_query_collecting_errors(input).map_err(|mut errors| errors.remove(0))
+}
+
+/// Parses a query like parse_query, but returns every error found in the query instead of only
+/// the first one. The grammar recovers from an error by skipping the rest of the clause that
+/// contains it, or the rest of the SELECT list item or ORDER BY key that contains it, and keeps
+/// parsing the query after it. The errors are returned in the order they appear in the query.
+pub fn parse_query_collecting_errors(input: &str) -> std::result::Result<ast::Query, Vec<Error>> {
+    let mut recovered = Vec::new();
+    let result = QUERY_PARSER.parse(input, &mut recovered, input);
+    collect_errors(result, recovered)
 }
 
 #[cfg(test)]
 pub fn parse_expression(input: &str) -> Result<ast::Expression> {
-    let expr = EXPRESSION_PARSER.parse(input)?;
-    Ok(expr)
+    let mut recovered = Vec::new();
+    let result = EXPRESSION_PARSER.parse(input, &mut recovered, input);
+    collect_errors(result, recovered).map_err(|mut errors| errors.remove(0))
+}
+
+/// Returns the value parsed by a parser that recovered from the errors in recovered, or every
+/// error it found, including the one it could not recover from, if any.
+fn collect_errors<'t, T>(
+    result: std::result::Result<T, LalrpopError<'t>>,
+    recovered: Vec<ErrorRecovery<usize, Token<'t>, String>>,
+) -> std::result::Result<T, Vec<Error>> {
+    let mut errors: Vec<Error> = recovered.into_iter().map(|r| r.error.into()).collect();
+    match result {
+        Ok(value) if errors.is_empty() => Ok(value),
+        Ok(_) => Err(errors),
+        Err(e) => {
+            errors.push(e.into());
+            Err(errors)
+        }
+    }
 }
@@ -208,6 +208,7 @@ mod select {
             order_by_clause: None,
             limit: None,
             offset: None,
+            spans: None,
         })),
         input = "SELECT foo",
     );
@@ -228,6 +229,7 @@ mod select {
             order_by_clause: None,
             limit: None,
             offset: None,
+            spans: None,
         })),
         input = r#"SELECT "foo""#,
     );
@@ -248,6 +250,7 @@ mod select {
             order_by_clause: None,
             limit: None,
             offset: None,
+            spans: None,
         })),
         input = "select `foo`",
     );
@@ -268,6 +271,7 @@ mod select {
             order_by_clause: None,
             limit: None,
             offset: None,
+            spans: None,
         })),
         input = "SELECT `1 + 2`",
     );
@@ -288,6 +292,7 @@ mod select {
             order_by_clause: None,
             limit: None,
             offset: None,
+            spans: None,
         })),
         input = "SELECT `fo``o`````",
     );
@@ -310,6 +315,7 @@ mod select {
             order_by_clause: None,
             limit: None,
             offset: None,
+            spans: None,
         })),
         input = r#"SELECT "fo""o""""""#,
     );
@@ -332,6 +338,7 @@ mod select {
             order_by_clause: None,
             limit: None,
             offset: None,
+            spans: None,
         })),
         input = r#"SELECT `fo""o`"#,
     );
@@ -352,6 +359,7 @@ mod select {
             order_by_clause: None,
             limit: None,
             offset: None,
+            spans: None,
         })),
         input = r#"SELECT "fo``o""#,
     );
@@ -411,6 +419,7 @@ mod query {
                     order_by_clause: None,
                     limit: None,
                     offset: None,
+                    spans: None,
                 }))),
                 op: SetOperator::Union,
                 right: Box::new(Query::Select(Box::new(SelectQuery {
@@ -429,6 +438,7 @@ mod query {
                     order_by_clause: None,
                     limit: None,
                     offset: None,
+                    spans: None,
                 })))
             })),
             op: SetOperator::UnionAll,
@@ -446,6 +456,7 @@ mod query {
                 order_by_clause: None,
                 limit: None,
                 offset: None,
+                spans: None,
             })))
         }),
         input = "select a union select b union all select c",
@@ -1071,6 +1082,7 @@ mod group_by {
             order_by_clause: None,
             limit: None,
             offset: None,
+            spans: None,
         })),
         input = "select * group by a, b aggregate sum(distinct b) as c",
     );
@@ -1119,6 +1131,7 @@ mod having {
             order_by_clause: None,
             limit: None,
             offset: None,
+            spans: None,
         })),
         input = "select * group by a having sum(distinct a) > 0",
     );
@@ -1176,6 +1189,7 @@ mod order_by {
             }),
             limit: None,
             offset: None,
+            spans: None,
         })),
         input = "select * order by a",
     );
@@ -1241,6 +1255,7 @@ mod limit_offset {
             order_by_clause: None,
             limit: Some(42_u32),
             offset: None,
+            spans: None,
         })),
         input = "select * limit 42",
     );
@@ -1260,6 +1275,7 @@ mod limit_offset {
             order_by_clause: None,
             limit: Some(42_u32),
             offset: Some(24_u32),
+            spans: None,
         })),
         input = "select * limit 42, 24",
     );
@@ -1279,6 +1295,7 @@ mod limit_offset {
             order_by_clause: None,
             limit: Some(42_u32),
             offset: Some(24_u32),
+            spans: None,
         })),
         input = "select * limit 42 offset 24",
     );
@@ -1357,6 +1374,7 @@ mod fetch_first {
             order_by_clause: None,
             limit: Some(42_u32),
             offset: None,
+            spans: None,
         })),
         input = "select * fetch first 42 rows only",
     );
@@ -1376,6 +1394,7 @@ mod fetch_first {
             order_by_clause: None,
             limit: Some(42_u32),
             offset: Some(24_u32),
+            spans: None,
         })),
         input = "select * fetch first 42 rows only offset 24",
     );
@@ -1395,6 +1414,7 @@ mod fetch_first {
             order_by_clause: None,
             limit: Some(42_u32),
             offset: Some(24_u32),
+            spans: None,
         })),
         input = "select * fetch next 42 row only offset 24",
     );
@@ -2201,12 +2221,14 @@ mod from {
                 left: Box::new(Datasource::Collection(CollectionSource {
                     database: None,
                     collection: "foo".to_string(),
-                    alias: None
+                    alias: None,
+                    span: None,
                 })),
                 right: Box::new(Datasource::Collection(CollectionSource {
                     database: None,
                     collection: "bar".to_string(),
-                    alias: None
+                    alias: None,
+                    span: None,
                 })),
                 condition: None
             })),
@@ -2216,6 +2238,7 @@ mod from {
             order_by_clause: None,
             limit: None,
             offset: None,
+            spans: None,
         })),
         input = "SELECT * FROM foo, bar",
     );
@@ -2232,12 +2255,14 @@ mod from {
                 left: Box::new(Datasource::Collection(CollectionSource {
                     database: None,
                     collection: "foo".to_string(),
-                    alias: None
+                    alias: None,
+                    span: None,
                 })),
                 right: Box::new(Datasource::Collection(CollectionSource {
                     database: None,
                     collection: "bar".to_string(),
-                    alias: None
+                    alias: None,
+                    span: None,
                 })),
                 condition: None
             })),
@@ -2247,6 +2272,7 @@ mod from {
             order_by_clause: None,
             limit: None,
             offset: None,
+            spans: None,
         })),
         input = "SELECT * FROM foo CROSS JOIN bar",
     );
@@ -2263,12 +2289,14 @@ mod from {
                 left: Box::new(Datasource::Collection(CollectionSource {
                     database: None,
                     collection: "foo".to_string(),
-                    alias: None
+                    alias: None,
+                    span: None,
                 })),
                 right: Box::new(Datasource::Collection(CollectionSource {
                     database: None,
                     collection: "bar".to_string(),
-                    alias: None
+                    alias: None,
+                    span: None,
                 })),
                 condition: None
             })),
@@ -2278,6 +2306,7 @@ mod from {
             order_by_clause: None,
             limit: None,
             offset: None,
+            spans: None,
         })),
         input = "SELECT * FROM foo JOIN bar",
     );
@@ -2294,12 +2323,14 @@ mod from {
                 left: Box::new(Datasource::Collection(CollectionSource {
                     database: None,
                     collection: "foo".to_string(),
-                    alias: None
+                    alias: None,
+                    span: None,
                 })),
                 right: Box::new(Datasource::Collection(CollectionSource {
                     database: None,
                     collection: "bar".to_string(),
-                    alias: None
+                    alias: None,
+                    span: None,
                 })),
                 condition: None
             })),
@@ -2309,6 +2340,7 @@ mod from {
             order_by_clause: None,
             limit: None,
             offset: None,
+            spans: None,
         })),
         input = "SELECT * FROM foo LEFT JOIN bar",
     );
@@ -2325,12 +2357,14 @@ mod from {
                 left: Box::new(Datasource::Collection(CollectionSource {
                     database: None,
                     collection: "foo".to_string(),
-                    alias: None
+                    alias: None,
+                    span: None,
                 })),
                 right: Box::new(Datasource::Collection(CollectionSource {
                     database: None,
                     collection: "bar".to_string(),
-                    alias: None
+                    alias: None,
+                    span: None,
                 })),
                 condition: None
             })),
@@ -2340,6 +2374,7 @@ mod from {
             order_by_clause: None,
             limit: None,
             offset: None,
+            spans: None,
         })),
         input = "SELECT * FROM foo RIGHT JOIN bar",
     );
@@ -2359,18 +2394,21 @@ mod from {
                         database: None,
                         collection: "foo".to_string(),
                         alias: None,
+                        span: None,
                     })),
                     right: Box::new(Datasource::Collection(CollectionSource {
                         database: None,
                         collection: "bar".to_string(),
                         alias: None,
+                        span: None,
                     })),
                     condition: None
                 })),
                 right: Box::new(Datasource::Collection(CollectionSource {
                     database: None,
                     collection: "car".to_string(),
-                    alias: None
+                    alias: None,
+                    span: None,
                 })),
                 condition: None
             })),
@@ -2380,6 +2418,7 @@ mod from {
             order_by_clause: None,
             limit: None,
             offset: None,
+            spans: None,
         })),
         input = "SELECT * FROM foo JOIN bar JOIN car",
     );
@@ -2425,6 +2464,34 @@ mod from {
         expected = false,
         input = "SELECT * FROM foo NATURAL JOIN bar"
     );
+
+    #[test]
+    fn collection_span() {
+        use crate::{parser::parse_query, result::Position};
+        let query = parse_query("SELECT * FROM\n  db.foo AS f").unwrap();
+        let from = match query {
+            Query::Select(q) => match q.from_clause {
+                Some(Datasource::Collection(c)) => c,
+                d => panic!("expected a collection datasource, got {d:?}"),
+            },
+            q => panic!("expected a select query, got {q:?}"),
+        };
+        assert_eq!(
+            Some(Span {
+                start: Position {
+                    offset: 16,
+                    line: 2,
+                    column: 3
+                },
+                end: Position {
+                    offset: 27,
+                    line: 2,
+                    column: 14
+                },
+            }),
+            from.span
+        );
+    }
 }
 
 mod where_test {
@@ -2470,6 +2537,7 @@ mod where_test {
             order_by_clause: None,
             limit: None,
             offset: None,
+            spans: None,
         })),
         input = "SELECT * WHERE a >= 2",
     );
@@ -2984,7 +3052,8 @@ mod subquery {
                 having_clause: None,
                 order_by_clause: None,
                 limit: None,
-                offset: None
+                offset: None,
+                spans: None,
             })))
         }),
         input = "x <> SOME (SELECT a)",
@@ -3012,7 +3081,8 @@ mod subquery {
                     having_clause: None,
                     order_by_clause: None,
                     limit: None,
-                    offset: None
+                    offset: None,
+                    spans: None,
                 }
             )))))
         }),
@@ -3041,7 +3111,8 @@ mod subquery {
                     having_clause: None,
                     order_by_clause: None,
                     limit: None,
-                    offset: None
+                    offset: None,
+                    spans: None,
                 }
             )))))
         }),
@@ -3216,6 +3287,7 @@ mod comments {
             order_by_clause: None,
             limit: None,
             offset: None,
+            spans: None,
         })),
         input = "SELECT foo -- This is a standard comment",
     );
@@ -3237,6 +3309,7 @@ mod comments {
             order_by_clause: None,
             limit: None,
             offset: None,
+            spans: None,
         })),
         input = "-- This is a standard single line comment
     SELECT foo",
@@ -3259,6 +3332,7 @@ mod comments {
             order_by_clause: None,
             limit: None,
             offset: None,
+            spans: None,
         })),
         input = "SELECT /* This is an inline comment */ foo",
     );
@@ -3280,6 +3354,7 @@ mod comments {
             order_by_clause: None,
             limit: None,
             offset: None,
+            spans: None,
         })),
         input = "/* This is a multiline comment
     This is a multiline comment */
@@ -3303,6 +3378,7 @@ mod comments {
             order_by_clause: None,
             limit: None,
             offset: None,
+            spans: None,
         })),
         input = "SELECT /* This is an inline
     comment */ foo",
@@ -3325,6 +3401,7 @@ mod comments {
             order_by_clause: None,
             limit: None,
             offset: None,
+            spans: None,
         })),
         input = "/* This is a multiline comment
     * with nesting: /* nested block comment */
@@ -3442,7 +3519,8 @@ mod flatten {
                 datasource: Box::new(Datasource::Collection(CollectionSource {
                     database: None,
                     collection: "foo".to_string(),
-                    alias: None
+                    alias: None,
+                    span: None,
                 })),
                 options: vec![
                     FlattenOption::Depth(1),
@@ -3456,6 +3534,7 @@ mod flatten {
             order_by_clause: None,
             limit: None,
             offset: None,
+            spans: None,
         })),
         input = "SELECT * FROM FLATTEN(foo WITH depth => 1, separator => '%', depth => 2)",
     );
@@ -3581,7 +3660,8 @@ mod unwind {
                 datasource: Box::new(Datasource::Collection(CollectionSource {
                     database: None,
                     collection: "foo".to_string(),
-                    alias: None
+                    alias: None,
+                    span: None,
                 })),
                 options: vec![
                     ExtendedUnwindOption::Paths(vec![vec![UnwindPathPart {
@@ -3603,6 +3683,7 @@ mod unwind {
             order_by_clause: None,
             limit: None,
             offset: None,
+            spans: None,
         })),
         input = "SELECT * FROM UNWIND(foo WITH PATH => arr, INDEX => i, INDEX => idx, PATH => a, OUTER => false)",
     );
@@ -3654,3 +3735,70 @@ mod unrecognized_token_suggestion {
         input = "notavalidquery"
     );
 }
+
+mod parse_query_collecting_errors {
+    use crate::parser::{parse_query, parse_query_collecting_errors};
+
+    fn error_offsets(input: &str) -> Vec<Option<usize>> {
+        parse_query_collecting_errors(input)
+            .expect_err("expected parse errors, but parsing succeeded")
+            .iter()
+            .map(|e| e.offset())
+            .collect()
+    }
+
+    #[test]
+    fn valid_query_parses_like_parse_query() {
+        let input = "SELECT a FROM foo WHERE a > 1 ORDER BY a";
+        assert_eq!(
+            parse_query(input).unwrap(),
+            parse_query_collecting_errors(input).unwrap()
+        );
+    }
+
+    #[test]
+    fn error_at_clause_keyword_is_attributed_to_previous_clause() {
+        assert_eq!(
+            vec![Some(10), Some(29)],
+            error_offsets("SELECT a, FROM foo WHERE a = = 1")
+        );
+    }
+
+    #[test]
+    fn error_in_subquery_is_recovered_from_in_subquery() {
+        assert_eq!(
+            vec![Some(43), Some(53)],
+            error_offsets("SELECT * FROM foo WHERE a IN (SELECT b FROM) ORDER BY")
+        );
+    }
+
+    #[test]
+    fn every_error_in_select_list_is_found() {
+        assert_eq!(
+            vec![Some(10), Some(16)],
+            error_offsets("SELECT a +, b + FROM foo")
+        );
+    }
+
+    #[test]
+    fn every_error_in_order_by_clause_is_found() {
+        assert_eq!(
+            vec![Some(30), Some(35)],
+            error_offsets("SELECT * FROM foo ORDER BY a +, b -")
+        );
+    }
+
+    #[test]
+    fn parse_query_returns_first_error() {
+        let input = "SELECT a, FROM foo WHERE a = = 1";
+        assert_eq!(
+            parse_query(input).unwrap_err(),
+            parse_query_collecting_errors(input).unwrap_err().remove(0)
+        );
+    }
+
+    #[test]
+    fn error_outside_of_clause_stops_recovery() {
+        assert_eq!(vec![Some(0)], error_offsets("notavalidquery"));
+    }
+}
@@ -58,8 +58,11 @@ pub fn parse_sort_key(e: Expression) -> Result<SortKey, LalrpopError<'static>> {
     }
 }
 
+/// Returns the datasource for ae, whose text spans span. Only collection datasources record
+/// their spans.
 pub fn parse_simple_datasource(
     ae: OptionallyAliasedExpr,
+    span: Span,
 ) -> Result<Datasource, LalrpopError<'static>> {
     let (expr, alias) = ae.take_fields();
     match expr {
@@ -67,6 +70,7 @@ pub fn parse_simple_datasource(
             database: None,
             collection,
             alias,
+            span: Some(span),
         })),
         Expression::Array(array) => alias.map_or(
             Err(LalrpopError::from(
@@ -87,6 +91,7 @@ pub fn parse_simple_datasource(
             database: Some(possible_db.take_identifier_name().unwrap()),
             collection,
             alias,
+            span: Some(span),
         })),
         Expression::Subpath(_) => Err(LalrpopError::from(format!(
             "collection datasources can only have database qualification, found: {}",
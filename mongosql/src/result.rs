@@ -1,4 +1,7 @@
-use crate::{air::desugarer, algebrizer, ast, codegen, mir, parser, schema, translator};
+use crate::{
+    air::desugarer, algebrizer, ast, codegen, mir, parser, schema, translator, usererror::UserError,
+};
+use serde::{Deserialize, Serialize};
 use thiserror::Error;
 
 pub type Result<T> = std::result::Result<T, Error>;
@@ -25,4 +28,95 @@ pub enum Error {
     Schema(#[from] schema::Error),
     #[error("catalog error: {0}")]
     Catalog(String),
+    #[error("pretty print error: {0}")]
+    PrettyPrint(#[from] ast::pretty_print::Error),
+    #[error("format error: {0}")]
+    Format(String),
+    #[error("explain error: {0}")]
+    Explain(String),
+    #[error("{}", display_errors(.0))]
+    Multiple(Vec<Error>),
+}
+
+fn display_errors(errors: &[Error]) -> String {
+    errors
+        .iter()
+        .map(|e| e.to_string())
+        .collect::<Vec<_>>()
+        .join("\n")
+}
+
+/// A location in a query. Lines and columns start at 1, and columns are counted in characters.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Serialize, Deserialize)]
+pub struct Position {
+    /// The byte offset of the location in the query
+    pub offset: usize,
+    pub line: usize,
+    pub column: usize,
+}
+
+impl Position {
+    /// Returns the position of the byte offset in sql. Offsets past the end of sql are treated
+    /// as the end of sql.
+    pub fn new(sql: &str, offset: usize) -> Self {
+        let mut offset = offset.min(sql.len());
+        while !sql.is_char_boundary(offset) {
+            offset -= 1;
+        }
+        let preceding = &sql[..offset];
+        let line_start = preceding.rfind('\n').map_or(0, |i| i + 1);
+        Position {
+            offset,
+            line: preceding.matches('\n').count() + 1,
+            column: preceding[line_start..].chars().count() + 1,
+        }
+    }
+}
+
+impl Error {
+    /// Returns an Error holding every error in errors, which must not be empty. A single error
+    /// is returned as is.
+    pub fn from_errors(mut errors: Vec<Error>) -> Self {
+        match errors.len() {
+            0 => panic!("cannot create an Error from no errors"),
+            1 => errors.remove(0),
+            _ => Error::Multiple(errors),
+        }
+    }
+
+    /// Returns the individual errors held by this Error.
+    pub fn into_errors(self) -> Vec<Error> {
+        match self {
+            Error::Multiple(errors) => errors,
+            e => vec![e],
+        }
+    }
+
+    /// Returns the user error code of this error, if it has one. See errors.md for the list of
+    /// codes.
+    pub fn code(&self) -> Option<u32> {
+        match self {
+            Error::Parse(e) => Some(e.code()),
+            Error::Algebrize(e) => Some(e.code()),
+            Error::SchemaInference(e) => Some(e.code()),
+            Error::JsonSchemaConversion(e) | Error::Schema(e) => match e {
+                schema::Error::FieldConflictInNonNamespacedResult(_) => Some(4000),
+                schema::Error::UnsupportedBsonType(_) => Some(1018),
+                _ => None,
+            },
+            _ => None,
+        }
+    }
+
+    /// Returns the position in sql of this error, if it is known. Parse errors have the
+    /// position at which they were found. Algebrizer errors have the position of the collection,
+    /// clause, SELECT list item or ORDER BY key they were found in, when the syntax tree records
+    /// its location. Errors found after algebrizing do not have positions.
+    pub fn position(&self, sql: &str) -> Option<Position> {
+        match self {
+            Error::Parse(e) => e.offset().map(|offset| Position::new(sql, offset)),
+            Error::Algebrize(e) => e.span().map(|span| span.start),
+            _ => None,
+        }
+    }
 }
@@ -10,6 +10,7 @@ use crate::{
 use enum_iterator::{all, Sequence};
 use itertools::{Either, Itertools};
 use lazy_static::lazy_static;
+use serde::Serialize;
 use std::collections::{HashMap, HashSet};
 use std::{
     collections::{BTreeMap, BTreeSet},
@@ -938,7 +939,7 @@ lazy_static! {
     ]);
 }
 
-#[derive(PartialEq, Eq, PartialOrd, Ord, Debug, Clone, Copy)]
+#[derive(PartialEq, Eq, PartialOrd, Ord, Debug, Clone, Copy, Serialize)]
 pub enum Satisfaction {
     Not,
     May,
@@ -1,3 +1,43 @@
+use crate::{
+    catalog::Catalog,
+    map,
+    schema::{Atomic, Document, Schema},
+    set,
+};
+use agg_ast::definitions::Namespace;
+use lazy_static::lazy_static;
+
+lazy_static! {
+    // The catalog shared by the tests of translation entry points. test.foo has an integer a and
+    // a document b holding a string c, and test.bar has an integer x.
+    static ref CATALOG: Catalog = Catalog::new(map! {
+        Namespace {database: "test".to_string(), collection: "foo".to_string()} => Schema::Document(Document {
+            keys: map! {
+                "a".to_string() => Schema::Atomic(Atomic::Integer),
+                "b".to_string() => Schema::Document(Document {
+                    keys: map! {
+                        "c".to_string() => Schema::Atomic(Atomic::String),
+                    },
+                    required: set! {"c".to_string()},
+                    additional_properties: false,
+                    ..Default::default()
+                }),
+            },
+            required: set! {"a".to_string(), "b".to_string()},
+            additional_properties: false,
+            ..Default::default()
+        }),
+        Namespace {database: "test".to_string(), collection: "bar".to_string()} => Schema::Document(Document {
+            keys: map! {
+                "x".to_string() => Schema::Atomic(Atomic::Integer),
+            },
+            required: set! {"x".to_string()},
+            additional_properties: false,
+            ..Default::default()
+        }),
+    });
+}
+
 #[allow(clippy::redundant_pattern_matching)]
 mod test_get_namespaces {
     macro_rules! test_get_namespaces {
@@ -326,7 +366,8 @@ mod test_get_select_order {
             order_by_clause: None,
             having_clause: None,
             limit: None,
-            offset: None
+            offset: None,
+            spans: None,
         }))
     );
 
@@ -344,7 +385,8 @@ mod test_get_select_order {
             order_by_clause: None,
             having_clause: None,
             limit: None,
-            offset: None
+            offset: None,
+            spans: None,
         }))
     );
 }
@@ -399,6 +441,7 @@ mod select_list_order {
                         schema_checking_mode: SchemaCheckingMode::default(),
                         exclude_namespaces: $exclude_namespaces,
                         allow_order_by_missing_columns: false,
+                        ..Default::default()
                     },
                 );
                 assert!(translation.is_ok());
@@ -575,3 +618,650 @@ mod select_list_order {
         expected = vec![vec!["a".to_string()], vec!["b".to_string()]]
     );
 }
+
+mod explain {
+    use super::CATALOG;
+    use crate::{
+        explain_sql,
+        options::{ExcludeNamespacesOption, SqlOptions},
+        translate_sql, SchemaCheckingMode,
+    };
+
+    #[test]
+    fn renders_every_stage() {
+        let sql = "select a from foo where a > 1 limit 5";
+        let options = SqlOptions::new(
+            ExcludeNamespacesOption::IncludeNamespaces,
+            SchemaCheckingMode::Strict,
+        );
+
+        let explanation = explain_sql("test", sql, &CATALOG, options).unwrap();
+        let stages = &explanation.stages;
+        assert!(stages.rewritten_ast.text.contains("LIMIT 5"));
+        assert!(stages
+            .rewritten_ast
+            .tree
+            .as_document()
+            .unwrap()
+            .contains_key("Select"));
+        for stage in [&stages.mir, &stages.optimized_mir] {
+            assert!(stage.text.contains("Limit"));
+            assert!(stage.tree.to_string().contains("Limit"));
+        }
+        for stage in [&stages.air, &stages.desugared_air] {
+            assert!(!stage.text.is_empty());
+            assert!(stage.tree.as_document().is_some());
+        }
+
+        let translation = translate_sql("test", sql, &CATALOG, options).unwrap();
+        assert_eq!(translation.pipeline, explanation.translation.pipeline);
+        assert_eq!(
+            translation.select_order,
+            explanation.translation.select_order
+        );
+    }
+
+    #[test]
+    fn error_is_returned() {
+        let options = SqlOptions::new(
+            ExcludeNamespacesOption::IncludeNamespaces,
+            SchemaCheckingMode::Strict,
+        );
+        assert!(explain_sql("test", "select a from missing", &CATALOG, options).is_err());
+    }
+}
+
+mod optimizer_options {
+    use super::CATALOG;
+    use crate::{
+        options::{ExcludeNamespacesOption, OptimizerOptions, OptimizerPass, SqlOptions},
+        translate_sql, SchemaCheckingMode,
+    };
+
+    const SQL: &str = "select a from foo where a > 1 + 2";
+
+    fn options(optimizer: OptimizerOptions) -> SqlOptions {
+        SqlOptions {
+            optimizer,
+            ..SqlOptions::new(
+                ExcludeNamespacesOption::IncludeNamespaces,
+                SchemaCheckingMode::Strict,
+            )
+        }
+    }
+
+    #[test]
+    fn every_pass_runs_by_default() {
+        let translation =
+            translate_sql("test", SQL, &CATALOG, options(OptimizerOptions::default())).unwrap();
+        let passes: Vec<OptimizerPass> = translation
+            .optimizer_passes
+            .iter()
+            .map(|report| report.pass)
+            .collect();
+        assert_eq!(OptimizerPass::ALL.to_vec(), passes);
+        assert!(translation
+            .optimizer_passes
+            .iter()
+            .any(|report| report.pass == OptimizerPass::ConstantFolding && report.changed));
+    }
+
+    #[test]
+    fn disabled_pass_does_not_run() {
+        let mut optimizer = OptimizerOptions::default();
+        optimizer.disable_pass(OptimizerPass::ConstantFolding);
+        let translation = translate_sql("test", SQL, &CATALOG, options(optimizer)).unwrap();
+        assert!(translation.optimizer_passes.len() == OptimizerPass::ALL.len() - 1);
+        assert!(!translation
+            .optimizer_passes
+            .iter()
+            .any(|report| report.pass == OptimizerPass::ConstantFolding));
+    }
+
+    #[test]
+    fn optimization_can_be_disabled() {
+        let translation =
+            translate_sql("test", SQL, &CATALOG, options(OptimizerOptions::disabled())).unwrap();
+        assert!(translation.optimizer_passes.is_empty());
+    }
+
+    #[test]
+    fn pass_names_round_trip() {
+        for pass in OptimizerPass::ALL {
+            assert_eq!(Ok(pass), pass.name().parse::<OptimizerPass>());
+        }
+        assert!("not_a_pass".parse::<OptimizerPass>().is_err());
+    }
+}
+
+mod format_sql {
+    use crate::{
+        ast::format::{FormatOptions, KeywordCase},
+        format_sql,
+    };
+
+    #[test]
+    fn formats_query() {
+        let options = FormatOptions {
+            keyword_case: KeywordCase::Lower,
+            ..Default::default()
+        };
+        assert_eq!(
+            Ok("select a\nfrom foo\nwhere a > 1".to_string()),
+            format_sql("SELECT a FROM foo WHERE a > 1", options)
+        );
+    }
+
+    #[test]
+    fn formatted_query_is_stable() {
+        let sql = "select * from (select a, b from foo) sub where a = 'x' order by b desc";
+        let formatted = format_sql(sql, FormatOptions::default()).unwrap();
+        assert_eq!(
+            Ok(formatted.clone()),
+            format_sql(&formatted, FormatOptions::default())
+        );
+    }
+
+    #[test]
+    fn parse_error_is_returned() {
+        assert!(format_sql("select from where", FormatOptions::default()).is_err());
+    }
+}
+
+mod ast_serialization {
+    use crate::{ast, parse_query};
+
+    #[test]
+    fn query_round_trips_through_bson() {
+        let query = parse_query(
+            "select a, b as c from foo f left join bar on f.x = bar.x where a > 1 and b is null order by 2 desc limit 5",
+        )
+        .unwrap();
+        let serialized = bson::to_bson(&query).unwrap();
+        let deserialized: ast::Query = bson::from_bson(serialized).unwrap();
+        assert_eq!(query, deserialized);
+    }
+
+    #[test]
+    fn variants_are_keyed_by_name() {
+        let query = parse_query("select * from foo").unwrap();
+        let serialized = bson::to_document(&query).unwrap();
+        let select = serialized.get_document("Select").unwrap();
+        let body = select
+            .get_document("select_clause")
+            .unwrap()
+            .get_document("body")
+            .unwrap();
+        assert_eq!(
+            &bson::Bson::Array(vec![bson::Bson::String("Star".to_string())]),
+            body.get("Standard").unwrap()
+        );
+    }
+}
+
+mod fingerprint_sql {
+    use crate::fingerprint_sql;
+
+    #[test]
+    fn literals_are_replaced() {
+        assert_eq!(
+            Ok("SELECT a FROM foo WHERE a > ? AND b = ? AND c = ?".to_string()),
+            fingerprint_sql("select a from foo where a > 5 and b = 'x' and c = -1.5")
+        );
+    }
+
+    #[test]
+    fn identifiers_are_lowercased() {
+        assert_eq!(
+            Ok("SELECT a AS `select` FROM db.foo".to_string()),
+            fingerprint_sql("select A as `SELECT` from DB.Foo")
+        );
+    }
+
+    #[test]
+    fn formatting_does_not_change_fingerprint() {
+        assert_eq!(
+            fingerprint_sql("select a, b from foo where (a = 1) order by b"),
+            fingerprint_sql("SELECT  a,\n  b\nFROM Foo\nWHERE a = 42\nORDER BY B ASC"),
+        );
+    }
+
+    #[test]
+    fn string_contents_are_not_kept() {
+        assert_eq!(
+            fingerprint_sql("select * from foo where a = 'x ? y'"),
+            fingerprint_sql("select * from foo where a = 'z'"),
+        );
+    }
+}
+
+mod redact_sql {
+    use crate::redact_sql;
+
+    #[test]
+    fn string_and_numeric_literals_are_replaced() {
+        assert_eq!(
+            Ok("SELECT a, ? AS b FROM foo WHERE a > ? AND c = ? AND d <> ?".to_string()),
+            redact_sql(
+                "select a, 'secret' as b from foo where a > 5 and c = -1.5 and d <> 12345678901"
+            )
+        );
+    }
+
+    #[test]
+    fn timestamp_literals_are_replaced() {
+        assert_eq!(
+            Ok("SELECT * FROM foo WHERE d = CAST(? AS BSON_DATE)".to_string()),
+            redact_sql("select * from foo where d = {ts '2020-01-01 00:00:00'}")
+        );
+    }
+
+    #[test]
+    fn null_boolean_and_identifiers_are_kept() {
+        assert_eq!(
+            Ok("SELECT Foo.A FROM Foo WHERE b = true OR c IS NULL OR d = NULL".to_string()),
+            redact_sql("select Foo.A from Foo where b = true or c is null or d = null")
+        );
+    }
+
+    #[test]
+    fn document_values_are_replaced() {
+        assert_eq!(
+            Ok("SELECT VALUE {'name': ?, 'ids': [?, ?]}".to_string()),
+            redact_sql("select value {'name': 'alice', 'ids': [1, 2]}")
+        );
+    }
+}
+
+mod validate_sql {
+    use super::CATALOG;
+    use crate::{
+        options::{ExcludeNamespacesOption, SqlOptions},
+        result::Error,
+        translate_sql, validate_sql, SchemaCheckingMode,
+    };
+
+    fn options() -> SqlOptions {
+        SqlOptions::new(
+            ExcludeNamespacesOption::IncludeNamespaces,
+            SchemaCheckingMode::Strict,
+        )
+    }
+
+    #[test]
+    fn result_set_matches_translation() {
+        let sql = "select a, a + 1 as b, foo.* from foo order by a limit 5";
+        let validation = validate_sql("test", sql, &CATALOG, options()).unwrap();
+        let translation = translate_sql("test", sql, &CATALOG, options()).unwrap();
+        assert_eq!(translation.result_set_schema, validation.result_set_schema);
+        assert_eq!(translation.select_order, validation.select_order);
+    }
+
+    #[test]
+    fn result_set_matches_translation_without_namespaces() {
+        let sql = "select a from foo union all select a from foo";
+        let options = SqlOptions::new(
+            ExcludeNamespacesOption::ExcludeNamespaces,
+            SchemaCheckingMode::Strict,
+        );
+        let validation = validate_sql("test", sql, &CATALOG, options).unwrap();
+        let translation = translate_sql("test", sql, &CATALOG, options).unwrap();
+        assert_eq!(translation.result_set_schema, validation.result_set_schema);
+        assert_eq!(translation.select_order, validation.select_order);
+    }
+
+    #[test]
+    fn parse_error() {
+        let errors =
+            validate_sql("test", "select a from foo where", &CATALOG, options()).unwrap_err();
+        assert_eq!(1, errors.len());
+        assert!(matches!(errors[0], Error::Parse(_)));
+    }
+
+    #[test]
+    fn parse_errors_in_every_clause_are_found() {
+        let errors =
+            validate_sql("test", "select a, from foo where", &CATALOG, options()).unwrap_err();
+        assert_eq!(2, errors.len());
+        assert!(errors.iter().all(|e| matches!(e, Error::Parse(_))));
+    }
+
+    #[test]
+    fn errors_in_every_select_list_item_are_found() {
+        let errors =
+            validate_sql("test", "select y, a, z from foo", &CATALOG, options()).unwrap_err();
+        assert_eq!(2, errors.len());
+        assert!(errors.iter().all(|e| matches!(e, Error::Algebrize(_))));
+    }
+
+    #[test]
+    fn errors_in_every_order_by_key_are_found() {
+        let errors = validate_sql(
+            "test",
+            "select a from foo order by y, a, z",
+            &CATALOG,
+            options(),
+        )
+        .unwrap_err();
+        assert_eq!(2, errors.len());
+        assert!(errors.iter().all(|e| matches!(e, Error::Algebrize(_))));
+    }
+
+    #[test]
+    fn errors_after_where_clause_are_found() {
+        let errors =
+            validate_sql("test", "select y from foo where z = 1", &CATALOG, options()).unwrap_err();
+        assert_eq!(2, errors.len());
+        assert!(errors.iter().all(|e| matches!(e, Error::Algebrize(_))));
+    }
+
+    #[test]
+    fn errors_in_both_sides_of_a_set_operation_are_found() {
+        let errors = validate_sql(
+            "test",
+            "select y from foo union all select a from foo where z = 1",
+            &CATALOG,
+            options(),
+        )
+        .unwrap_err();
+        assert_eq!(2, errors.len());
+    }
+
+    #[test]
+    fn errors_match_translation() {
+        let sql = "select a from missing";
+        let errors = validate_sql("test", sql, &CATALOG, options()).unwrap_err();
+        let error = translate_sql("test", sql, &CATALOG, options()).unwrap_err();
+        assert_eq!(vec![error], errors);
+    }
+
+    fn error_offsets(sql: &str) -> Vec<Option<usize>> {
+        validate_sql("test", sql, &CATALOG, options())
+            .unwrap_err()
+            .iter()
+            .map(|e| e.position(sql).map(|position| position.offset))
+            .collect()
+    }
+
+    #[test]
+    fn missing_collection_error_is_located_at_collection() {
+        assert_eq!(vec![Some(14)], error_offsets("select a from missing"));
+    }
+
+    #[test]
+    fn select_list_errors_are_located_at_their_items() {
+        assert_eq!(
+            vec![Some(7), Some(13)],
+            error_offsets("select y, a, z from foo")
+        );
+    }
+
+    #[test]
+    fn order_by_errors_are_located_at_their_keys() {
+        assert_eq!(
+            vec![Some(27), Some(33)],
+            error_offsets("select a from foo order by y, a, z")
+        );
+    }
+
+    #[test]
+    fn where_clause_error_is_located_at_where_clause() {
+        assert_eq!(
+            vec![Some(24), Some(7)],
+            error_offsets("select y from foo where z = 1")
+        );
+    }
+}
+
+mod multiple_errors {
+    use crate::{
+        catalog::Catalog,
+        options::{ExcludeNamespacesOption, SqlOptions},
+        result::{Error, Position},
+        translate_sql, SchemaCheckingMode,
+    };
+
+    fn options() -> SqlOptions {
+        SqlOptions::new(
+            ExcludeNamespacesOption::IncludeNamespaces,
+            SchemaCheckingMode::Strict,
+        )
+    }
+
+    #[test]
+    fn translation_returns_every_error() {
+        let error = translate_sql(
+            "test",
+            "select a, from foo where",
+            &Catalog::default(),
+            options(),
+        )
+        .unwrap_err();
+        assert!(matches!(error, Error::Multiple(_)));
+        let errors = error.into_errors();
+        assert_eq!(2, errors.len());
+        assert!(errors.iter().all(|e| e.code().is_some()));
+    }
+
+    #[test]
+    fn single_error_is_not_wrapped() {
+        let error = translate_sql(
+            "test",
+            "select a from foo where",
+            &Catalog::default(),
+            options(),
+        )
+        .unwrap_err();
+        assert!(matches!(error, Error::Parse(_)));
+        assert_eq!(1, error.into_errors().len());
+    }
+
+    #[test]
+    fn parse_error_has_position() {
+        let sql = "select a,\nfrom foo";
+        let error = translate_sql("test", sql, &Catalog::default(), options()).unwrap_err();
+        assert_eq!(Some(2001), error.code());
+        assert_eq!(
+            Some(Position {
+                offset: 10,
+                line: 2,
+                column: 1,
+            }),
+            error.position(sql)
+        );
+    }
+
+    #[test]
+    fn position_columns_count_characters() {
+        assert_eq!(
+            Position {
+                offset: 7,
+                line: 1,
+                column: 5,
+            },
+            Position::new("'ééé' +", 7)
+        );
+    }
+}
+
+mod column_lineage {
+    use super::CATALOG;
+    use crate::{
+        options::{ExcludeNamespacesOption, SqlOptions},
+        translate_sql, ColumnLineage, LineageKind, SchemaCheckingMode, SourceField,
+    };
+
+    fn source(collection: &str, path: &[&str]) -> SourceField {
+        SourceField {
+            database: "test".to_string(),
+            collection: collection.to_string(),
+            path: path.iter().map(|field| field.to_string()).collect(),
+        }
+    }
+
+    fn column(column: &[&str], kind: LineageKind, sources: Vec<SourceField>) -> ColumnLineage {
+        ColumnLineage {
+            column: column.iter().map(|name| name.to_string()).collect(),
+            kind,
+            sources,
+        }
+    }
+
+    macro_rules! test_lineage {
+        ($func_name:ident, sql = $sql:expr, exclude_namespaces = $exclude:expr, expected = $expected:expr,) => {
+            #[test]
+            fn $func_name() {
+                let exclude_namespaces = if $exclude {
+                    ExcludeNamespacesOption::ExcludeNamespaces
+                } else {
+                    ExcludeNamespacesOption::IncludeNamespaces
+                };
+                let translation = translate_sql(
+                    "test",
+                    $sql,
+                    &CATALOG,
+                    SqlOptions {
+                        include_lineage: true,
+                        ..SqlOptions::new(exclude_namespaces, SchemaCheckingMode::Strict)
+                    },
+                )
+                .unwrap();
+                assert_eq!($expected, translation.lineage);
+            }
+        };
+    }
+
+    test_lineage!(
+        direct_copies,
+        sql = "select a, b.c as c from foo",
+        exclude_namespaces = false,
+        expected = vec![
+            column(&["", "a"], LineageKind::Direct, vec![source("foo", &["a"])]),
+            column(
+                &["", "c"],
+                LineageKind::Direct,
+                vec![source("foo", &["b", "c"])]
+            ),
+        ],
+    );
+
+    test_lineage!(
+        transformed_expression_over_a_join,
+        sql = "select a + x as s, 'lit' as l from foo join bar",
+        exclude_namespaces = false,
+        expected = vec![
+            column(
+                &["", "s"],
+                LineageKind::Transformed,
+                vec![source("bar", &["x"]), source("foo", &["a"])]
+            ),
+            column(&["", "l"], LineageKind::Transformed, vec![]),
+        ],
+    );
+
+    test_lineage!(
+        aggregates,
+        sql = "select count(*) as n, sum(a) as total from foo",
+        exclude_namespaces = false,
+        expected = vec![
+            column(&["", "n"], LineageKind::Aggregate, vec![]),
+            column(
+                &["", "total"],
+                LineageKind::Aggregate,
+                vec![source("foo", &["a"])]
+            ),
+        ],
+    );
+
+    test_lineage!(
+        group_keys,
+        sql = "select k, total from foo group by b.c as k aggregate sum(a) as total",
+        exclude_namespaces = true,
+        expected = vec![
+            column(
+                &["k"],
+                LineageKind::Direct,
+                vec![source("foo", &["b", "c"])]
+            ),
+            column(
+                &["total"],
+                LineageKind::Aggregate,
+                vec![source("foo", &["a"])]
+            ),
+        ],
+    );
+
+    test_lineage!(
+        through_derived_table,
+        sql = "select d.s, d.a from (select a, a * 2 as s from foo) as d",
+        exclude_namespaces = false,
+        expected = vec![
+            column(
+                &["", "s"],
+                LineageKind::Transformed,
+                vec![source("foo", &["a"])]
+            ),
+            column(&["", "a"], LineageKind::Direct, vec![source("foo", &["a"])]),
+        ],
+    );
+
+    test_lineage!(
+        through_with_query,
+        sql = "with q as (select a from foo) select * from q",
+        exclude_namespaces = false,
+        expected = vec![column(
+            &["q", "a"],
+            LineageKind::Direct,
+            vec![source("foo", &["a"])]
+        )],
+    );
+
+    test_lineage!(
+        star_over_a_join,
+        sql = "select * from foo as f join bar",
+        exclude_namespaces = false,
+        expected = vec![
+            column(
+                &["bar", "x"],
+                LineageKind::Direct,
+                vec![source("bar", &["x"])]
+            ),
+            column(
+                &["f", "a"],
+                LineageKind::Direct,
+                vec![source("foo", &["a"])]
+            ),
+            column(
+                &["f", "b"],
+                LineageKind::Direct,
+                vec![source("foo", &["b"])]
+            ),
+        ],
+    );
+
+    test_lineage!(
+        union_all_arms,
+        sql = "select * from (select a as v from foo union all select x as v from bar) as u",
+        exclude_namespaces = false,
+        expected = vec![column(
+            &["u", "v"],
+            LineageKind::Direct,
+            vec![source("bar", &["x"]), source("foo", &["a"])]
+        )],
+    );
+
+    #[test]
+    fn not_computed_by_default() {
+        let translation = translate_sql(
+            "test",
+            "select a from foo",
+            &CATALOG,
+            SqlOptions::new(
+                ExcludeNamespacesOption::IncludeNamespaces,
+                SchemaCheckingMode::Strict,
+            ),
+        )
+        .unwrap();
+        assert!(translation.lineage.is_empty());
+    }
+}
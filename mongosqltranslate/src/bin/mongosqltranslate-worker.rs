@@ -0,0 +1,36 @@
+//! A worker process for the mongosqltranslate command protocol. It reads
+//! BSON-serialized Commands from stdin and writes one BSON-serialized result
+//! to stdout for each of them, exiting when stdin is closed. Running
+//! translations in a separate process isolates the caller from crashes that
+//! cannot be caught as panics, such as aborts and stack overflows.
+use std::io::{self, Read, Write};
+
+fn main() -> io::Result<()> {
+    let mut input = io::stdin().lock();
+    let mut output = io::stdout().lock();
+
+    loop {
+        // every BSON document begins with its total length as a little-endian i32
+        let mut length = [0u8; 4];
+        match input.read_exact(&mut length) {
+            Ok(()) => {}
+            Err(e) if e.kind() == io::ErrorKind::UnexpectedEof => return Ok(()),
+            Err(e) => return Err(e),
+        }
+        let length = i32::from_le_bytes(length);
+        if length < 5 {
+            return Err(io::Error::new(
+                io::ErrorKind::InvalidData,
+                format!("invalid BSON document length {length}"),
+            ));
+        }
+
+        let mut command = vec![0u8; length as usize];
+        command[..4].copy_from_slice(&length.to_le_bytes());
+        input.read_exact(&mut command[4..])?;
+
+        let result = mongosqltranslate::run_command(&command);
+        output.write_all(&result)?;
+        output.flush()?;
+    }
+}
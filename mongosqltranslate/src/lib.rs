@@ -1,12 +1,14 @@
 use command::Command;
+#[cfg(feature = "jdbc")]
 use jni::{
     objects::{JByteArray, JClass},
     sys::jbyteArray,
     JNIEnv,
 };
+use mongodb::bson::Document;
 use panic_safe::panic_safe_exec;
 use semver::VersionReq;
-use std::sync::LazyLock;
+use std::{error::Error, sync::LazyLock};
 
 mod command;
 #[cfg(test)]
@@ -39,6 +41,21 @@ pub const DEV_JDBC_VERSION_SUFFIX: &str = "-dirty";
 
 pub const SNAPSHOT_JDBC_VERSION_SUFFIX: &str = "-SNAPSHOT";
 
+/// Runs the BSON-serialized Command in `command` and returns its result.
+/// Malformed commands panic, so callers must catch panics themselves.
+pub fn execute_command(command: &[u8]) -> Result<Document, Box<dyn Error>> {
+    Command::new(command).run()
+}
+
+/// Runs the BSON-serialized Command in `command` and returns the
+/// BSON-serialized result. Panics are caught and returned as internal errors.
+///
+/// The first call installs a process-wide panic hook, so this is only meant
+/// for processes that run nothing but commands, such as the worker binary.
+pub fn run_command(command: &[u8]) -> Vec<u8> {
+    panic_safe_exec(|| execute_command(command))
+}
+
 #[repr(C)]
 pub struct OdbcCommand {
     data: *const u8,
@@ -52,6 +69,7 @@ pub struct OdbcCommand {
 /// - `env`: The JNI environment.
 /// - `_class`: The Java class, which is not used.
 /// - `command`: The command to execute as a JByteArray.
+#[cfg(feature = "jdbc")]
 #[allow(non_snake_case)]
 #[no_mangle]
 pub extern "C" fn Java_com_mongodb_jdbc_mongosql_MongoSQLTranslate_runCommand(
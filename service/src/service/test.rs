@@ -40,6 +40,32 @@ mod tests {
         assert!(response.is_ok());
     }
 
+    #[tokio::test]
+    async fn test_translate_sql_pipeline_ext_json_is_canonical() {
+        let service = TranslateSqlService;
+        let request = Request::new(TranslateSqlRequest {
+            db: "tpch".to_string(),
+            query: "SELECT VALUE {'d': {ts '2012-01-01 10:10:10'}, 'l': 9007199254740993} FROM customer"
+                .to_string(),
+            schema_catalog: get_catalog_bytes(),
+            exclude_namespaces: 0,
+            schema_checking_mode: 0,
+        });
+
+        let response = service
+            .translate_sql(request)
+            .await
+            .expect("translation failed")
+            .into_inner();
+        assert!(!response.pipeline.is_empty());
+        let pipeline = response.pipeline_ext_json;
+        let json: serde_json::Value =
+            serde_json::from_str(&pipeline).expect("pipeline_ext_json is not valid JSON");
+        bson::Bson::try_from(json).expect("pipeline_ext_json is not extended JSON");
+        assert!(pipeline.contains(r#"{"$date":{"$numberLong":"1325412610000"}}"#));
+        assert!(pipeline.contains(r#"{"$numberLong":"9007199254740993"}"#));
+    }
+
     #[tokio::test]
     async fn test_translate_sql_panic() {
         let service = PanicHandlingTranslateSqlService(TranslateSqlService);
@@ -129,6 +129,9 @@ impl TranslateSqlService {
             db: translation.target_db,
             target_collection: translation.target_collection.unwrap_or_default(),
             pipeline: translation.pipeline.to_string(),
+            // canonical extended JSON keeps the BSON type of every value,
+            // such as dates and int64s, which Bson's Display does not
+            pipeline_ext_json: translation.pipeline.into_canonical_extjson().to_string(),
             result_set_schema: serde_json::to_string(&translation.result_set_schema)
                 .unwrap_or_default(),
             select_order: translation
@@ -320,6 +323,7 @@ impl TranslateSqlService {
                 SchemaCheckingMode::Relaxed => mongosql::SchemaCheckingMode::Relaxed,
             },
             allow_order_by_missing_columns: false,
+            ..Default::default()
         })
     }
 }